@@ -0,0 +1,16 @@
+//---------------------------------------
+// Component is charge of the OpenAPI document served by the service
+//---------------------------------------
+package api
+
+import (
+	_ "embed"
+)
+
+// OpenAPI 3 document of every route registered in the http server
+//go:embed openapi.json
+var OpenAPI []byte
+
+// Reference page rendering the OpenAPI document
+//go:embed docs.html
+var Docs []byte
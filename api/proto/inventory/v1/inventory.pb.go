@@ -0,0 +1,974 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.8
+// 	protoc        (unknown)
+// source: inventory/v1/inventory.proto
+
+package inventoryv1
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type Product struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            int64                  `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	Sku           string                 `protobuf:"bytes,2,opt,name=sku,proto3" json:"sku,omitempty"`
+	Type          string                 `protobuf:"bytes,3,opt,name=type,proto3" json:"type,omitempty"`
+	Name          string                 `protobuf:"bytes,4,opt,name=name,proto3" json:"name,omitempty"`
+	Status        string                 `protobuf:"bytes,5,opt,name=status,proto3" json:"status,omitempty"`
+	LeadTime      int32                  `protobuf:"varint,6,opt,name=lead_time,json=leadTime,proto3" json:"lead_time,omitempty"`
+	Version       int32                  `protobuf:"varint,7,opt,name=version,proto3" json:"version,omitempty"`
+	CreatedAt     *timestamppb.Timestamp `protobuf:"bytes,8,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
+	UpdatedAt     *timestamppb.Timestamp `protobuf:"bytes,9,opt,name=updated_at,json=updatedAt,proto3" json:"updated_at,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Product) Reset() {
+	*x = Product{}
+	mi := &file_inventory_v1_inventory_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Product) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Product) ProtoMessage() {}
+
+func (x *Product) ProtoReflect() protoreflect.Message {
+	mi := &file_inventory_v1_inventory_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Product.ProtoReflect.Descriptor instead.
+func (*Product) Descriptor() ([]byte, []int) {
+	return file_inventory_v1_inventory_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *Product) GetId() int64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *Product) GetSku() string {
+	if x != nil {
+		return x.Sku
+	}
+	return ""
+}
+
+func (x *Product) GetType() string {
+	if x != nil {
+		return x.Type
+	}
+	return ""
+}
+
+func (x *Product) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *Product) GetStatus() string {
+	if x != nil {
+		return x.Status
+	}
+	return ""
+}
+
+func (x *Product) GetLeadTime() int32 {
+	if x != nil {
+		return x.LeadTime
+	}
+	return 0
+}
+
+func (x *Product) GetVersion() int32 {
+	if x != nil {
+		return x.Version
+	}
+	return 0
+}
+
+func (x *Product) GetCreatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.CreatedAt
+	}
+	return nil
+}
+
+func (x *Product) GetUpdatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.UpdatedAt
+	}
+	return nil
+}
+
+type Inventory struct {
+	state            protoimpl.MessageState `protogen:"open.v1"`
+	Id               int64                  `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	Product          *Product               `protobuf:"bytes,2,opt,name=product,proto3" json:"product,omitempty"`
+	Available        int32                  `protobuf:"varint,3,opt,name=available,proto3" json:"available,omitempty"`
+	Pending          int32                  `protobuf:"varint,4,opt,name=pending,proto3" json:"pending,omitempty"`
+	Reserved         int32                  `protobuf:"varint,5,opt,name=reserved,proto3" json:"reserved,omitempty"`
+	Sold             int32                  `protobuf:"varint,6,opt,name=sold,proto3" json:"sold,omitempty"`
+	Incoming         int32                  `protobuf:"varint,7,opt,name=incoming,proto3" json:"incoming,omitempty"`
+	Quarantine       int32                  `protobuf:"varint,8,opt,name=quarantine,proto3" json:"quarantine,omitempty"`
+	Damaged          int32                  `protobuf:"varint,9,opt,name=damaged,proto3" json:"damaged,omitempty"`
+	Hold             int32                  `protobuf:"varint,10,opt,name=hold,proto3" json:"hold,omitempty"`
+	Channel          string                 `protobuf:"bytes,11,opt,name=channel,proto3" json:"channel,omitempty"`
+	ChannelAvailable int32                  `protobuf:"varint,12,opt,name=channel_available,json=channelAvailable,proto3" json:"channel_available,omitempty"`
+	Version          int32                  `protobuf:"varint,13,opt,name=version,proto3" json:"version,omitempty"`
+	CreatedAt        *timestamppb.Timestamp `protobuf:"bytes,14,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
+	UpdatedAt        *timestamppb.Timestamp `protobuf:"bytes,15,opt,name=updated_at,json=updatedAt,proto3" json:"updated_at,omitempty"`
+	unknownFields    protoimpl.UnknownFields
+	sizeCache        protoimpl.SizeCache
+}
+
+func (x *Inventory) Reset() {
+	*x = Inventory{}
+	mi := &file_inventory_v1_inventory_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Inventory) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Inventory) ProtoMessage() {}
+
+func (x *Inventory) ProtoReflect() protoreflect.Message {
+	mi := &file_inventory_v1_inventory_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Inventory.ProtoReflect.Descriptor instead.
+func (*Inventory) Descriptor() ([]byte, []int) {
+	return file_inventory_v1_inventory_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *Inventory) GetId() int64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *Inventory) GetProduct() *Product {
+	if x != nil {
+		return x.Product
+	}
+	return nil
+}
+
+func (x *Inventory) GetAvailable() int32 {
+	if x != nil {
+		return x.Available
+	}
+	return 0
+}
+
+func (x *Inventory) GetPending() int32 {
+	if x != nil {
+		return x.Pending
+	}
+	return 0
+}
+
+func (x *Inventory) GetReserved() int32 {
+	if x != nil {
+		return x.Reserved
+	}
+	return 0
+}
+
+func (x *Inventory) GetSold() int32 {
+	if x != nil {
+		return x.Sold
+	}
+	return 0
+}
+
+func (x *Inventory) GetIncoming() int32 {
+	if x != nil {
+		return x.Incoming
+	}
+	return 0
+}
+
+func (x *Inventory) GetQuarantine() int32 {
+	if x != nil {
+		return x.Quarantine
+	}
+	return 0
+}
+
+func (x *Inventory) GetDamaged() int32 {
+	if x != nil {
+		return x.Damaged
+	}
+	return 0
+}
+
+func (x *Inventory) GetHold() int32 {
+	if x != nil {
+		return x.Hold
+	}
+	return 0
+}
+
+func (x *Inventory) GetChannel() string {
+	if x != nil {
+		return x.Channel
+	}
+	return ""
+}
+
+func (x *Inventory) GetChannelAvailable() int32 {
+	if x != nil {
+		return x.ChannelAvailable
+	}
+	return 0
+}
+
+func (x *Inventory) GetVersion() int32 {
+	if x != nil {
+		return x.Version
+	}
+	return 0
+}
+
+func (x *Inventory) GetCreatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.CreatedAt
+	}
+	return nil
+}
+
+func (x *Inventory) GetUpdatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.UpdatedAt
+	}
+	return nil
+}
+
+type AddProductRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Sku           string                 `protobuf:"bytes,1,opt,name=sku,proto3" json:"sku,omitempty"`
+	Type          string                 `protobuf:"bytes,2,opt,name=type,proto3" json:"type,omitempty"`
+	Name          string                 `protobuf:"bytes,3,opt,name=name,proto3" json:"name,omitempty"`
+	Status        string                 `protobuf:"bytes,4,opt,name=status,proto3" json:"status,omitempty"`
+	LeadTime      int32                  `protobuf:"varint,5,opt,name=lead_time,json=leadTime,proto3" json:"lead_time,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *AddProductRequest) Reset() {
+	*x = AddProductRequest{}
+	mi := &file_inventory_v1_inventory_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AddProductRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AddProductRequest) ProtoMessage() {}
+
+func (x *AddProductRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_inventory_v1_inventory_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AddProductRequest.ProtoReflect.Descriptor instead.
+func (*AddProductRequest) Descriptor() ([]byte, []int) {
+	return file_inventory_v1_inventory_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *AddProductRequest) GetSku() string {
+	if x != nil {
+		return x.Sku
+	}
+	return ""
+}
+
+func (x *AddProductRequest) GetType() string {
+	if x != nil {
+		return x.Type
+	}
+	return ""
+}
+
+func (x *AddProductRequest) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *AddProductRequest) GetStatus() string {
+	if x != nil {
+		return x.Status
+	}
+	return ""
+}
+
+func (x *AddProductRequest) GetLeadTime() int32 {
+	if x != nil {
+		return x.LeadTime
+	}
+	return 0
+}
+
+type GetProductRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Sku           string                 `protobuf:"bytes,1,opt,name=sku,proto3" json:"sku,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetProductRequest) Reset() {
+	*x = GetProductRequest{}
+	mi := &file_inventory_v1_inventory_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetProductRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetProductRequest) ProtoMessage() {}
+
+func (x *GetProductRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_inventory_v1_inventory_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetProductRequest.ProtoReflect.Descriptor instead.
+func (*GetProductRequest) Descriptor() ([]byte, []int) {
+	return file_inventory_v1_inventory_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *GetProductRequest) GetSku() string {
+	if x != nil {
+		return x.Sku
+	}
+	return ""
+}
+
+// Empty fields are kept, version is the optimistic lock (zero means no precondition)
+type UpdateProductRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Sku           string                 `protobuf:"bytes,1,opt,name=sku,proto3" json:"sku,omitempty"`
+	Type          string                 `protobuf:"bytes,2,opt,name=type,proto3" json:"type,omitempty"`
+	Name          string                 `protobuf:"bytes,3,opt,name=name,proto3" json:"name,omitempty"`
+	Status        string                 `protobuf:"bytes,4,opt,name=status,proto3" json:"status,omitempty"`
+	LeadTime      int32                  `protobuf:"varint,5,opt,name=lead_time,json=leadTime,proto3" json:"lead_time,omitempty"`
+	Version       int32                  `protobuf:"varint,6,opt,name=version,proto3" json:"version,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *UpdateProductRequest) Reset() {
+	*x = UpdateProductRequest{}
+	mi := &file_inventory_v1_inventory_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UpdateProductRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UpdateProductRequest) ProtoMessage() {}
+
+func (x *UpdateProductRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_inventory_v1_inventory_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UpdateProductRequest.ProtoReflect.Descriptor instead.
+func (*UpdateProductRequest) Descriptor() ([]byte, []int) {
+	return file_inventory_v1_inventory_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *UpdateProductRequest) GetSku() string {
+	if x != nil {
+		return x.Sku
+	}
+	return ""
+}
+
+func (x *UpdateProductRequest) GetType() string {
+	if x != nil {
+		return x.Type
+	}
+	return ""
+}
+
+func (x *UpdateProductRequest) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *UpdateProductRequest) GetStatus() string {
+	if x != nil {
+		return x.Status
+	}
+	return ""
+}
+
+func (x *UpdateProductRequest) GetLeadTime() int32 {
+	if x != nil {
+		return x.LeadTime
+	}
+	return 0
+}
+
+func (x *UpdateProductRequest) GetVersion() int32 {
+	if x != nil {
+		return x.Version
+	}
+	return 0
+}
+
+type GetInventoryRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Sku           string                 `protobuf:"bytes,1,opt,name=sku,proto3" json:"sku,omitempty"`
+	Channel       string                 `protobuf:"bytes,2,opt,name=channel,proto3" json:"channel,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetInventoryRequest) Reset() {
+	*x = GetInventoryRequest{}
+	mi := &file_inventory_v1_inventory_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetInventoryRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetInventoryRequest) ProtoMessage() {}
+
+func (x *GetInventoryRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_inventory_v1_inventory_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetInventoryRequest.ProtoReflect.Descriptor instead.
+func (*GetInventoryRequest) Descriptor() ([]byte, []int) {
+	return file_inventory_v1_inventory_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *GetInventoryRequest) GetSku() string {
+	if x != nil {
+		return x.Sku
+	}
+	return ""
+}
+
+func (x *GetInventoryRequest) GetChannel() string {
+	if x != nil {
+		return x.Channel
+	}
+	return ""
+}
+
+// Deltas applied to the inventory, version is the optimistic lock (zero means no precondition)
+type UpdateInventoryRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Sku           string                 `protobuf:"bytes,1,opt,name=sku,proto3" json:"sku,omitempty"`
+	Channel       string                 `protobuf:"bytes,2,opt,name=channel,proto3" json:"channel,omitempty"`
+	Available     int32                  `protobuf:"varint,3,opt,name=available,proto3" json:"available,omitempty"`
+	Pending       int32                  `protobuf:"varint,4,opt,name=pending,proto3" json:"pending,omitempty"`
+	Reserved      int32                  `protobuf:"varint,5,opt,name=reserved,proto3" json:"reserved,omitempty"`
+	Sold          int32                  `protobuf:"varint,6,opt,name=sold,proto3" json:"sold,omitempty"`
+	Version       int32                  `protobuf:"varint,7,opt,name=version,proto3" json:"version,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *UpdateInventoryRequest) Reset() {
+	*x = UpdateInventoryRequest{}
+	mi := &file_inventory_v1_inventory_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UpdateInventoryRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UpdateInventoryRequest) ProtoMessage() {}
+
+func (x *UpdateInventoryRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_inventory_v1_inventory_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UpdateInventoryRequest.ProtoReflect.Descriptor instead.
+func (*UpdateInventoryRequest) Descriptor() ([]byte, []int) {
+	return file_inventory_v1_inventory_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *UpdateInventoryRequest) GetSku() string {
+	if x != nil {
+		return x.Sku
+	}
+	return ""
+}
+
+func (x *UpdateInventoryRequest) GetChannel() string {
+	if x != nil {
+		return x.Channel
+	}
+	return ""
+}
+
+func (x *UpdateInventoryRequest) GetAvailable() int32 {
+	if x != nil {
+		return x.Available
+	}
+	return 0
+}
+
+func (x *UpdateInventoryRequest) GetPending() int32 {
+	if x != nil {
+		return x.Pending
+	}
+	return 0
+}
+
+func (x *UpdateInventoryRequest) GetReserved() int32 {
+	if x != nil {
+		return x.Reserved
+	}
+	return 0
+}
+
+func (x *UpdateInventoryRequest) GetSold() int32 {
+	if x != nil {
+		return x.Sold
+	}
+	return 0
+}
+
+func (x *UpdateInventoryRequest) GetVersion() int32 {
+	if x != nil {
+		return x.Version
+	}
+	return 0
+}
+
+// Move stock between buckets (available, quarantine, damaged, hold)
+type TransferInventoryRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Sku           string                 `protobuf:"bytes,1,opt,name=sku,proto3" json:"sku,omitempty"`
+	From          string                 `protobuf:"bytes,2,opt,name=from,proto3" json:"from,omitempty"`
+	To            string                 `protobuf:"bytes,3,opt,name=to,proto3" json:"to,omitempty"`
+	Quantity      int32                  `protobuf:"varint,4,opt,name=quantity,proto3" json:"quantity,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *TransferInventoryRequest) Reset() {
+	*x = TransferInventoryRequest{}
+	mi := &file_inventory_v1_inventory_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *TransferInventoryRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TransferInventoryRequest) ProtoMessage() {}
+
+func (x *TransferInventoryRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_inventory_v1_inventory_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TransferInventoryRequest.ProtoReflect.Descriptor instead.
+func (*TransferInventoryRequest) Descriptor() ([]byte, []int) {
+	return file_inventory_v1_inventory_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *TransferInventoryRequest) GetSku() string {
+	if x != nil {
+		return x.Sku
+	}
+	return ""
+}
+
+func (x *TransferInventoryRequest) GetFrom() string {
+	if x != nil {
+		return x.From
+	}
+	return ""
+}
+
+func (x *TransferInventoryRequest) GetTo() string {
+	if x != nil {
+		return x.To
+	}
+	return ""
+}
+
+func (x *TransferInventoryRequest) GetQuantity() int32 {
+	if x != nil {
+		return x.Quantity
+	}
+	return 0
+}
+
+type ListInventoryRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Sku           string                 `protobuf:"bytes,1,opt,name=sku,proto3" json:"sku,omitempty"`
+	Window        int32                  `protobuf:"varint,2,opt,name=window,proto3" json:"window,omitempty"`
+	Offset        int32                  `protobuf:"varint,3,opt,name=offset,proto3" json:"offset,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListInventoryRequest) Reset() {
+	*x = ListInventoryRequest{}
+	mi := &file_inventory_v1_inventory_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListInventoryRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListInventoryRequest) ProtoMessage() {}
+
+func (x *ListInventoryRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_inventory_v1_inventory_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListInventoryRequest.ProtoReflect.Descriptor instead.
+func (*ListInventoryRequest) Descriptor() ([]byte, []int) {
+	return file_inventory_v1_inventory_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *ListInventoryRequest) GetSku() string {
+	if x != nil {
+		return x.Sku
+	}
+	return ""
+}
+
+func (x *ListInventoryRequest) GetWindow() int32 {
+	if x != nil {
+		return x.Window
+	}
+	return 0
+}
+
+func (x *ListInventoryRequest) GetOffset() int32 {
+	if x != nil {
+		return x.Offset
+	}
+	return 0
+}
+
+type ListInventoryResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Inventories   []*Inventory           `protobuf:"bytes,1,rep,name=inventories,proto3" json:"inventories,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListInventoryResponse) Reset() {
+	*x = ListInventoryResponse{}
+	mi := &file_inventory_v1_inventory_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListInventoryResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListInventoryResponse) ProtoMessage() {}
+
+func (x *ListInventoryResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_inventory_v1_inventory_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListInventoryResponse.ProtoReflect.Descriptor instead.
+func (*ListInventoryResponse) Descriptor() ([]byte, []int) {
+	return file_inventory_v1_inventory_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *ListInventoryResponse) GetInventories() []*Inventory {
+	if x != nil {
+		return x.Inventories
+	}
+	return nil
+}
+
+var File_inventory_v1_inventory_proto protoreflect.FileDescriptor
+
+const file_inventory_v1_inventory_proto_rawDesc = "" +
+	"\n" +
+	"\x1cinventory/v1/inventory.proto\x12\finventory.v1\x1a\x1fgoogle/protobuf/timestamp.proto\"\x98\x02\n" +
+	"\aProduct\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x03R\x02id\x12\x10\n" +
+	"\x03sku\x18\x02 \x01(\tR\x03sku\x12\x12\n" +
+	"\x04type\x18\x03 \x01(\tR\x04type\x12\x12\n" +
+	"\x04name\x18\x04 \x01(\tR\x04name\x12\x16\n" +
+	"\x06status\x18\x05 \x01(\tR\x06status\x12\x1b\n" +
+	"\tlead_time\x18\x06 \x01(\x05R\bleadTime\x12\x18\n" +
+	"\aversion\x18\a \x01(\x05R\aversion\x129\n" +
+	"\n" +
+	"created_at\x18\b \x01(\v2\x1a.google.protobuf.TimestampR\tcreatedAt\x129\n" +
+	"\n" +
+	"updated_at\x18\t \x01(\v2\x1a.google.protobuf.TimestampR\tupdatedAt\"\xf5\x03\n" +
+	"\tInventory\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x03R\x02id\x12/\n" +
+	"\aproduct\x18\x02 \x01(\v2\x15.inventory.v1.ProductR\aproduct\x12\x1c\n" +
+	"\tavailable\x18\x03 \x01(\x05R\tavailable\x12\x18\n" +
+	"\apending\x18\x04 \x01(\x05R\apending\x12\x1a\n" +
+	"\breserved\x18\x05 \x01(\x05R\breserved\x12\x12\n" +
+	"\x04sold\x18\x06 \x01(\x05R\x04sold\x12\x1a\n" +
+	"\bincoming\x18\a \x01(\x05R\bincoming\x12\x1e\n" +
+	"\n" +
+	"quarantine\x18\b \x01(\x05R\n" +
+	"quarantine\x12\x18\n" +
+	"\adamaged\x18\t \x01(\x05R\adamaged\x12\x12\n" +
+	"\x04hold\x18\n" +
+	" \x01(\x05R\x04hold\x12\x18\n" +
+	"\achannel\x18\v \x01(\tR\achannel\x12+\n" +
+	"\x11channel_available\x18\f \x01(\x05R\x10channelAvailable\x12\x18\n" +
+	"\aversion\x18\r \x01(\x05R\aversion\x129\n" +
+	"\n" +
+	"created_at\x18\x0e \x01(\v2\x1a.google.protobuf.TimestampR\tcreatedAt\x129\n" +
+	"\n" +
+	"updated_at\x18\x0f \x01(\v2\x1a.google.protobuf.TimestampR\tupdatedAt\"\x82\x01\n" +
+	"\x11AddProductRequest\x12\x10\n" +
+	"\x03sku\x18\x01 \x01(\tR\x03sku\x12\x12\n" +
+	"\x04type\x18\x02 \x01(\tR\x04type\x12\x12\n" +
+	"\x04name\x18\x03 \x01(\tR\x04name\x12\x16\n" +
+	"\x06status\x18\x04 \x01(\tR\x06status\x12\x1b\n" +
+	"\tlead_time\x18\x05 \x01(\x05R\bleadTime\"%\n" +
+	"\x11GetProductRequest\x12\x10\n" +
+	"\x03sku\x18\x01 \x01(\tR\x03sku\"\x9f\x01\n" +
+	"\x14UpdateProductRequest\x12\x10\n" +
+	"\x03sku\x18\x01 \x01(\tR\x03sku\x12\x12\n" +
+	"\x04type\x18\x02 \x01(\tR\x04type\x12\x12\n" +
+	"\x04name\x18\x03 \x01(\tR\x04name\x12\x16\n" +
+	"\x06status\x18\x04 \x01(\tR\x06status\x12\x1b\n" +
+	"\tlead_time\x18\x05 \x01(\x05R\bleadTime\x12\x18\n" +
+	"\aversion\x18\x06 \x01(\x05R\aversion\"A\n" +
+	"\x13GetInventoryRequest\x12\x10\n" +
+	"\x03sku\x18\x01 \x01(\tR\x03sku\x12\x18\n" +
+	"\achannel\x18\x02 \x01(\tR\achannel\"\xc6\x01\n" +
+	"\x16UpdateInventoryRequest\x12\x10\n" +
+	"\x03sku\x18\x01 \x01(\tR\x03sku\x12\x18\n" +
+	"\achannel\x18\x02 \x01(\tR\achannel\x12\x1c\n" +
+	"\tavailable\x18\x03 \x01(\x05R\tavailable\x12\x18\n" +
+	"\apending\x18\x04 \x01(\x05R\apending\x12\x1a\n" +
+	"\breserved\x18\x05 \x01(\x05R\breserved\x12\x12\n" +
+	"\x04sold\x18\x06 \x01(\x05R\x04sold\x12\x18\n" +
+	"\aversion\x18\a \x01(\x05R\aversion\"l\n" +
+	"\x18TransferInventoryRequest\x12\x10\n" +
+	"\x03sku\x18\x01 \x01(\tR\x03sku\x12\x12\n" +
+	"\x04from\x18\x02 \x01(\tR\x04from\x12\x0e\n" +
+	"\x02to\x18\x03 \x01(\tR\x02to\x12\x1a\n" +
+	"\bquantity\x18\x04 \x01(\x05R\bquantity\"X\n" +
+	"\x14ListInventoryRequest\x12\x10\n" +
+	"\x03sku\x18\x01 \x01(\tR\x03sku\x12\x16\n" +
+	"\x06window\x18\x02 \x01(\x05R\x06window\x12\x16\n" +
+	"\x06offset\x18\x03 \x01(\x05R\x06offset\"R\n" +
+	"\x15ListInventoryResponse\x129\n" +
+	"\vinventories\x18\x01 \x03(\v2\x17.inventory.v1.InventoryR\vinventories2\xba\x04\n" +
+	"\x10InventoryService\x12F\n" +
+	"\n" +
+	"AddProduct\x12\x1f.inventory.v1.AddProductRequest\x1a\x17.inventory.v1.Inventory\x12D\n" +
+	"\n" +
+	"GetProduct\x12\x1f.inventory.v1.GetProductRequest\x1a\x15.inventory.v1.Product\x12J\n" +
+	"\rUpdateProduct\x12\".inventory.v1.UpdateProductRequest\x1a\x15.inventory.v1.Product\x12J\n" +
+	"\fGetInventory\x12!.inventory.v1.GetInventoryRequest\x1a\x17.inventory.v1.Inventory\x12P\n" +
+	"\x0fUpdateInventory\x12$.inventory.v1.UpdateInventoryRequest\x1a\x17.inventory.v1.Inventory\x12T\n" +
+	"\x11TransferInventory\x12&.inventory.v1.TransferInventoryRequest\x1a\x17.inventory.v1.Inventory\x12X\n" +
+	"\rListInventory\x12\".inventory.v1.ListInventoryRequest\x1a#.inventory.v1.ListInventoryResponseB<Z:github.com/go-inventory/api/proto/inventory/v1;inventoryv1b\x06proto3"
+
+var (
+	file_inventory_v1_inventory_proto_rawDescOnce sync.Once
+	file_inventory_v1_inventory_proto_rawDescData []byte
+)
+
+func file_inventory_v1_inventory_proto_rawDescGZIP() []byte {
+	file_inventory_v1_inventory_proto_rawDescOnce.Do(func() {
+		file_inventory_v1_inventory_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_inventory_v1_inventory_proto_rawDesc), len(file_inventory_v1_inventory_proto_rawDesc)))
+	})
+	return file_inventory_v1_inventory_proto_rawDescData
+}
+
+var file_inventory_v1_inventory_proto_msgTypes = make([]protoimpl.MessageInfo, 10)
+var file_inventory_v1_inventory_proto_goTypes = []any{
+	(*Product)(nil),                  // 0: inventory.v1.Product
+	(*Inventory)(nil),                // 1: inventory.v1.Inventory
+	(*AddProductRequest)(nil),        // 2: inventory.v1.AddProductRequest
+	(*GetProductRequest)(nil),        // 3: inventory.v1.GetProductRequest
+	(*UpdateProductRequest)(nil),     // 4: inventory.v1.UpdateProductRequest
+	(*GetInventoryRequest)(nil),      // 5: inventory.v1.GetInventoryRequest
+	(*UpdateInventoryRequest)(nil),   // 6: inventory.v1.UpdateInventoryRequest
+	(*TransferInventoryRequest)(nil), // 7: inventory.v1.TransferInventoryRequest
+	(*ListInventoryRequest)(nil),     // 8: inventory.v1.ListInventoryRequest
+	(*ListInventoryResponse)(nil),    // 9: inventory.v1.ListInventoryResponse
+	(*timestamppb.Timestamp)(nil),    // 10: google.protobuf.Timestamp
+}
+var file_inventory_v1_inventory_proto_depIdxs = []int32{
+	10, // 0: inventory.v1.Product.created_at:type_name -> google.protobuf.Timestamp
+	10, // 1: inventory.v1.Product.updated_at:type_name -> google.protobuf.Timestamp
+	0,  // 2: inventory.v1.Inventory.product:type_name -> inventory.v1.Product
+	10, // 3: inventory.v1.Inventory.created_at:type_name -> google.protobuf.Timestamp
+	10, // 4: inventory.v1.Inventory.updated_at:type_name -> google.protobuf.Timestamp
+	1,  // 5: inventory.v1.ListInventoryResponse.inventories:type_name -> inventory.v1.Inventory
+	2,  // 6: inventory.v1.InventoryService.AddProduct:input_type -> inventory.v1.AddProductRequest
+	3,  // 7: inventory.v1.InventoryService.GetProduct:input_type -> inventory.v1.GetProductRequest
+	4,  // 8: inventory.v1.InventoryService.UpdateProduct:input_type -> inventory.v1.UpdateProductRequest
+	5,  // 9: inventory.v1.InventoryService.GetInventory:input_type -> inventory.v1.GetInventoryRequest
+	6,  // 10: inventory.v1.InventoryService.UpdateInventory:input_type -> inventory.v1.UpdateInventoryRequest
+	7,  // 11: inventory.v1.InventoryService.TransferInventory:input_type -> inventory.v1.TransferInventoryRequest
+	8,  // 12: inventory.v1.InventoryService.ListInventory:input_type -> inventory.v1.ListInventoryRequest
+	1,  // 13: inventory.v1.InventoryService.AddProduct:output_type -> inventory.v1.Inventory
+	0,  // 14: inventory.v1.InventoryService.GetProduct:output_type -> inventory.v1.Product
+	0,  // 15: inventory.v1.InventoryService.UpdateProduct:output_type -> inventory.v1.Product
+	1,  // 16: inventory.v1.InventoryService.GetInventory:output_type -> inventory.v1.Inventory
+	1,  // 17: inventory.v1.InventoryService.UpdateInventory:output_type -> inventory.v1.Inventory
+	1,  // 18: inventory.v1.InventoryService.TransferInventory:output_type -> inventory.v1.Inventory
+	9,  // 19: inventory.v1.InventoryService.ListInventory:output_type -> inventory.v1.ListInventoryResponse
+	13, // [13:20] is the sub-list for method output_type
+	6,  // [6:13] is the sub-list for method input_type
+	6,  // [6:6] is the sub-list for extension type_name
+	6,  // [6:6] is the sub-list for extension extendee
+	0,  // [0:6] is the sub-list for field type_name
+}
+
+func init() { file_inventory_v1_inventory_proto_init() }
+func file_inventory_v1_inventory_proto_init() {
+	if File_inventory_v1_inventory_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_inventory_v1_inventory_proto_rawDesc), len(file_inventory_v1_inventory_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   10,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_inventory_v1_inventory_proto_goTypes,
+		DependencyIndexes: file_inventory_v1_inventory_proto_depIdxs,
+		MessageInfos:      file_inventory_v1_inventory_proto_msgTypes,
+	}.Build()
+	File_inventory_v1_inventory_proto = out.File
+	file_inventory_v1_inventory_proto_goTypes = nil
+	file_inventory_v1_inventory_proto_depIdxs = nil
+}
@@ -3,6 +3,7 @@ package main
 import(
 	"fmt"
 	"os"
+	"flag"
 	"io"
 	"time"
 	"context"
@@ -13,9 +14,14 @@ import(
 	"github.com/go-inventory/shared/log"
 	"github.com/go-inventory/internal/domain/model"
 	"github.com/go-inventory/internal/infrastructure/adapter/http"
+	"github.com/go-inventory/internal/infrastructure/adapter/grpc"
+	"github.com/go-inventory/internal/infrastructure/adapter/event"
+	"github.com/go-inventory/internal/infrastructure/adapter/cache"
 	"github.com/go-inventory/internal/infrastructure/server"
+	"github.com/go-inventory/internal/infrastructure/middleware"
 	"github.com/go-inventory/internal/infrastructure/config"
 	"github.com/go-inventory/internal/infrastructure/repo/database"
+	"github.com/go-inventory/internal/infrastructure/repo/memory"
 	"github.com/go-inventory/internal/domain/service"
 
 	go_core_otel_trace 	"github.com/eliezerraj/go-core/v2/otel/trace"
@@ -31,6 +37,7 @@ type AppContext struct {
 	Logger           zerolog.Logger
 	Server           *model.AppServer
 	Database         *go_core_db_pg.DatabasePGServer
+	Replica          *go_core_db_pg.DatabasePGServer
 	TracerProvider   *go_core_otel_trace.TracerProvider
 }
 
@@ -100,6 +107,17 @@ func setupAppContext(ctx context.Context) (*AppContext, error) {
 		Server:         allConfigs.Server,
 		EnvTrace:       allConfigs.OtelTrace,
 		DatabaseConfig: allConfigs.Database,
+		ReplicaConfig:  allConfigs.Replica,
+		MigrationConfig: allConfigs.Migration,
+		InventoryConfig: allConfigs.Inventory,
+		OutboxConfig:   allConfigs.Outbox,
+		WebhookConfig:  allConfigs.Webhook,
+		OrderConsumerConfig: allConfigs.OrderConsumer,
+		AuthConfig:     allConfigs.Auth,
+		TenantConfig:   allConfigs.Tenant,
+		RateLimitConfig: allConfigs.RateLimit,
+		AuditConfig:    allConfigs.Audit,
+		CacheConfig:    allConfigs.Cache,
 	}
 
 	// Setup OTEL tracer if enabled
@@ -108,16 +126,50 @@ func setupAppContext(ctx context.Context) (*AppContext, error) {
 		tracerProvider = setupTracerProvider(ctx, appServer, &logger)
 	}
 
+	// the memory storage keeps the state in the process, there is no database
+	if appServer.Application.Storage == model.StorageMemory {
+		logger.Warn().
+			Ctx(ctx).
+			Msg("memory storage, nothing is kept across restarts")
+		return &AppContext{
+			Logger:         logger,
+			Server:         appServer,
+			TracerProvider: tracerProvider,
+		}, nil
+	}
+
 	// Connect to database with retry and timeout
 	databaseServer, err := connectDatabase(ctx, *appServer.DatabaseConfig, &logger)
 	if err != nil {
 		return nil, fmt.Errorf("database connection FAILED: %w", err)
 	}
 
+	// schema of the database, the pending migrations are applied when enabled and the service refuses to run on a
+	// schema other than the one of the binary
+	if err := checkSchema(ctx, &databaseServer, appServer.MigrationConfig, &logger); err != nil {
+		databaseServer.CloseConnection()
+		return nil, fmt.Errorf("database schema check FAILED: %w", err)
+	}
+
+	// Connect to the read replica, optional. Without it the reads go to the primary
+	var replicaServer *go_core_db_pg.DatabasePGServer
+	if appServer.ReplicaConfig != nil {
+		replica, err := connectDatabase(ctx, *appServer.ReplicaConfig.Database, &logger)
+		if err != nil {
+			logger.Error().
+				Ctx(ctx).
+				Err(err).
+				Msg("replica connection FAILED, reads go to the primary")
+		} else {
+			replicaServer = &replica
+		}
+	}
+
 	return &AppContext{
 		Logger:         logger,
 		Server:         appServer,
 		Database:       &databaseServer,
+		Replica:        replicaServer,
 		TracerProvider: tracerProvider,
 	}, nil
 }
@@ -185,11 +237,58 @@ func connectDatabase(ctx context.Context, dbCfg go_core_db_pg.DatabaseConfig, lo
 	return go_core_db_pg.DatabasePGServer{}, fmt.Errorf("FAILED to connect to database after %d attempts: %w", maxRetries, lastErr)
 }
 
+// checkSchema applies the pending migrations with DB_AUTO_MIGRATE and checks the version of the schema
+func checkSchema(ctx context.Context, databaseServer *go_core_db_pg.DatabasePGServer, migrationConfig *model.MigrationConfig, logger *zerolog.Logger) error {
+	migrator, err := database.NewMigrator(databaseServer, migrationConfig, logger)
+	if err != nil {
+		return err
+	}
+
+	if migrationConfig.AutoMigrate {
+		applied, err := migrator.Up(ctx)
+		if err != nil {
+			return err
+		}
+		logger.Info().
+			Ctx(ctx).
+			Int("applied", applied).
+			Msg("schema migrations applied")
+	}
+
+	version, err := migrator.CheckVersion(ctx)
+	if err != nil {
+		return err
+	}
+	logger.Info().
+		Ctx(ctx).
+		Int("version", version).
+		Msg("schema version is compatible")
+
+	return nil
+}
+
 // main is the application entry point
 func main() {
+	// storage of the state, it overrides STORAGE
+	storage := flag.String("storage", "", "storage of the state: postgres or memory (development, nothing is kept)")
+	flag.Parse()
+	if *storage != "" {
+		os.Setenv("STORAGE", *storage)
+	}
+
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
+	// migrate up | down [steps] | status, runs against the database and exits
+	if flag.Arg(0) == "migrate" {
+		if err := runMigrate(ctx, flag.Args()[1:]); err != nil {
+			initLogger.Fatal().
+				Err(err).
+				Msg("FAILED to migrate the schema")
+		}
+		return
+	}
+
 	// Initialize all dependencies
 	appCtx, err := setupAppContext(ctx)
 	if err != nil {
@@ -211,7 +310,12 @@ func main() {
 			Msg("Shutting down application")
 
 		// Close database first (highest dependency)
-		appCtx.Database.CloseConnection()
+		if appCtx.Database != nil {
+			appCtx.Database.CloseConnection()
+		}
+		if appCtx.Replica != nil {
+			appCtx.Replica.CloseConnection()
+		}
 
 		// Shutdown tracer provider
 		if appCtx.TracerProvider != nil && appCtx.TracerProvider.TracerProvider != nil {
@@ -231,24 +335,120 @@ func main() {
 	}()
 
 	// Wire dependencies
-	repository := database.NewWorkerRepository(
-		appCtx.Database,
-		&appCtx.Logger,
-		appCtx.TracerProvider)
+	var repository service.Repository
+	var poolStat middleware.PoolStat
+	if appCtx.Server.Application.Storage == model.StorageMemory {
+		memoryRepository := memory.NewMemoryRepository(&appCtx.Logger)
+		repository, poolStat = memoryRepository, memoryRepository.AcquireStat
+	} else {
+		databaseRepository := database.NewWorkerRepository(
+			appCtx.Database,
+			appCtx.Replica,
+			appCtx.Server.ReplicaConfig,
+			&appCtx.Logger,
+			appCtx.TracerProvider)
+		repository, poolStat = databaseRepository, databaseRepository.AcquireStat
+
+		// lag and health of the read replica, the reads go to the primary until the first check passes
+		go databaseRepository.StartReplicaMonitor(ctx)
+	}
+
+	// cache of the reads, optional. Without it every read goes to the database
+	readCache, err := cache.NewCache(appCtx.Server, &appCtx.Logger)
+	if err != nil {
+		appCtx.Logger.Error().
+			Ctx(ctx).
+			Err(err).
+			Msg("FAILED to create the cache, reads go to the database")
+	} else if readCache != nil {
+		defer readCache.Close()
+	}
 
 	workerService := service.NewWorkerService(
+		appCtx.Server,
 		repository,
+		readCache,
 		&appCtx.Logger,
 		appCtx.TracerProvider)
 
+	// fan out of the inventory changes committed by any pod
+	go workerService.StartInventoryStream(ctx)
+
+	// relay of the outbox to the broker
+	publisher, err := event.NewPublisher(appCtx.Server, &appCtx.Logger)
+	if err != nil {
+		appCtx.Logger.Error().
+			Ctx(ctx).
+			Err(err).
+			Msg("FAILED to create the outbox publisher, events stay in the outbox")
+	} else if publisher != nil {
+		go workerService.StartOutboxRelay(ctx, publisher)
+	}
+
+	// alerts of low stock and stockout to the webhooks
+	go workerService.StartWebhookDispatcher(ctx, event.NewHttpWebhookSender())
+
+	// hash chain of the audit log
+	go workerService.StartAuditSealer(ctx)
+
+	// order events driving the reservations, optional
+	orderBroker, err := event.NewOrderBroker(appCtx.Server, &appCtx.Logger)
+	if err != nil {
+		appCtx.Logger.Error().
+			Ctx(ctx).
+			Err(err).
+			Msg("FAILED to create the order broker, order events are not consumed")
+	} else if orderBroker != nil {
+		go workerService.StartOrderConsumer(ctx, orderBroker)
+	}
+
 	httpRouters := http.NewHttpRouters(
 		appCtx.Server,
 		workerService,
 		&appCtx.Logger,
 		appCtx.TracerProvider)
 
+	// authentication of the callers, a invalid key configuration stops the service (fail closed)
+	authenticator, err := middleware.NewAuthenticator(appCtx.Server.AuthConfig, workerService, &appCtx.Logger)
+	if err != nil {
+		appCtx.Logger.Error().
+			Ctx(ctx).
+			Err(err).
+			Msg("FAILED to create the authenticator")
+		return
+	}
+
+	// tenant of each request, from its credential or the tenant header
+	tenantResolver := middleware.NewTenantResolver(appCtx.Server.TenantConfig, &appCtx.Logger)
+
+	// rate limit of the clients and load shedding driven by the wait of the database pool, both optional
+	rateLimiter := middleware.NewRateLimiter(appCtx.Server.RateLimitConfig, &appCtx.Logger)
+	go rateLimiter.Start(ctx)
+
+	loadShedder := middleware.NewLoadShedder(appCtx.Server.RateLimitConfig, poolStat, &appCtx.Logger)
+	go loadShedder.Start(ctx)
+
 	httpServer := server.NewHttpAppServer(
 		appCtx.Server,
+		authenticator,
+		tenantResolver,
+		rateLimiter,
+		loadShedder,
+		&appCtx.Logger)
+
+	grpcRouters := grpc.NewGrpcRouters(
+		appCtx.Server,
+		workerService,
+		&appCtx.Logger,
+		appCtx.TracerProvider)
+
+	grpcServer := server.NewGrpcAppServer(
+		appCtx.Server,
+		grpcRouters,
+		authenticator,
+		tenantResolver,
+		rateLimiter,
+		loadShedder,
 		&appCtx.Logger)
 
 	// Health check all dependencies
@@ -264,6 +464,6 @@ func main() {
 		Ctx(ctx).
 		Msg("All services health check passed")
 
-	// Start web server (blocking)
-	httpServer.StartHttpAppServer(ctx, httpRouters)
+	// Start web and grpc servers (blocking)
+	httpServer.StartHttpAppServer(ctx, httpRouters, grpcServer)
 }
\ No newline at end of file
@@ -0,0 +1,129 @@
+package main
+
+import(
+	"fmt"
+	"errors"
+	"os"
+	"strconv"
+	"context"
+	"text/tabwriter"
+
+	"github.com/rs/zerolog"
+
+	"github.com/go-inventory/internal/domain/model"
+	"github.com/go-inventory/internal/infrastructure/config"
+	"github.com/go-inventory/internal/infrastructure/repo/database"
+)
+
+// usage of the migrate subcommand
+const migrateUsage = "usage: go-inventory migrate up | down [steps] | status | baseline"
+
+// runMigrate runs the migrate subcommand against the database of the configuration
+//
+//	migrate up				apply the pending migrations
+//	migrate down [steps]	revert the last steps migrations (default 1)
+//	migrate status			list the migrations and when they were applied
+//	migrate baseline		adopt a database created before the migrations as the version 1
+func runMigrate(ctx context.Context, args []string) error {
+	logger := initLogger.With().
+				Str("package", "main").
+				Logger()
+
+	if len(args) == 0 {
+		return errors.New(migrateUsage)
+	}
+
+	steps := 1
+	switch args[0] {
+	case "up", "status", "baseline":
+		if len(args) > 1 {
+			return errors.New(migrateUsage)
+		}
+	case "down":
+		if len(args) > 2 {
+			return errors.New(migrateUsage)
+		}
+		if len(args) == 2 {
+			var err error
+			steps, err = strconv.Atoi(args[1])
+			if err != nil || steps <= 0 {
+				return fmt.Errorf("invalid steps %s: must be greater than zero", args[1])
+			}
+		}
+	default:
+		return errors.New(migrateUsage)
+	}
+
+	allConfigs, err := config.NewConfigLoader(&initLogger).LoadAll()
+	if err != nil {
+		return fmt.Errorf("configuration loading FAILED: %w", err)
+	}
+	if allConfigs.Application.Storage != model.StoragePostgres {
+		return fmt.Errorf("migrate needs STORAGE=%s", model.StoragePostgres)
+	}
+
+	databaseServer, err := connectDatabase(ctx, *allConfigs.Database, &logger)
+	if err != nil {
+		return fmt.Errorf("database connection FAILED: %w", err)
+	}
+	defer databaseServer.CloseConnection()
+
+	migrator, err := database.NewMigrator(&databaseServer, allConfigs.Migration, &logger)
+	if err != nil {
+		return err
+	}
+
+	switch args[0] {
+	case "up":
+		applied, err := migrator.Up(ctx)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("%d migration(s) applied, schema at version %d\n", applied, migrator.Latest())
+	case "down":
+		reverted, err := migrator.Down(ctx, steps)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("%d migration(s) reverted\n", reverted)
+	case "status":
+		return printMigrationStatus(ctx, migrator, &logger)
+	case "baseline":
+		if err := migrator.Baseline(ctx); err != nil {
+			return err
+		}
+		fmt.Printf("schema recorded at version 1, run migrate up to apply the %d pending migration(s)\n", migrator.Latest() - 1)
+	}
+
+	return nil
+}
+
+// printMigrationStatus writes the migrations and the version of the schema to stdout
+func printMigrationStatus(ctx context.Context, migrator *database.Migrator, logger *zerolog.Logger) error {
+	listStatus, err := migrator.Status(ctx)
+	if err != nil {
+		return err
+	}
+
+	writer := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(writer, "VERSION\tNAME\tAPPLIED AT")
+	for _, status := range *listStatus {
+		appliedAt := "pending"
+		if status.AppliedAt != nil {
+			appliedAt = status.AppliedAt.Format("2006-01-02 15:04:05 MST")
+		}
+		fmt.Fprintf(writer, "%d\t%s\t%s\n", status.Version, status.Name, appliedAt)
+	}
+	writer.Flush()
+
+	version, err := migrator.CheckVersion(ctx)
+	if err != nil {
+		logger.Warn().
+			Ctx(ctx).
+			Err(err).
+			Msg("schema is not compatible with the binary")
+	}
+	fmt.Printf("schema at version %d, binary at version %d\n", version, migrator.Latest())
+
+	return nil
+}
@@ -2,6 +2,7 @@ package model
 
 import (
 	"time"
+	"encoding/json"
 	go_core_db_pg 		"github.com/eliezerraj/go-core/v2/database/postgre"
 	go_core_otel_trace "github.com/eliezerraj/go-core/v2/otel/trace"
 )
@@ -11,6 +12,17 @@ type AppServer struct {
 	Server     		*Server     					`json:"server"`
 	EnvTrace		*go_core_otel_trace.EnvTrace	`json:"env_trace"`
 	DatabaseConfig	*go_core_db_pg.DatabaseConfig  	`json:"database_config"`
+	ReplicaConfig	*ReplicaConfig					`json:"replica_config,omitempty"`
+	MigrationConfig	*MigrationConfig				`json:"migration_config,omitempty"`
+	InventoryConfig	*InventoryConfig				`json:"inventory_config"`
+	OutboxConfig	*OutboxConfig					`json:"outbox_config"`
+	WebhookConfig	*WebhookConfig					`json:"webhook_config"`
+	OrderConsumerConfig	*OrderConsumerConfig		`json:"order_consumer_config"`
+	AuthConfig		*AuthConfig						`json:"auth_config"`
+	TenantConfig	*TenantConfig					`json:"tenant_config"`
+	RateLimitConfig	*RateLimitConfig				`json:"rate_limit_config"`
+	AuditConfig		*AuditConfig					`json:"audit_config"`
+	CacheConfig		*CacheConfig					`json:"cache_config"`
 }
 
 type MessageRouter struct {
@@ -30,16 +42,120 @@ type Application struct {
 	OtelLogs			bool   	`json:"otel_logs"`
 	StdOutLogGroup 		bool   	`json:"stdout_log_group"`
 	LogGroup			string 	`json:"log_group,omitempty"`
+	Storage				string 	`json:"storage"`
 }
 
+// Storage of the state, memory keeps nothing across restarts (development and tests)
+const (
+	StoragePostgres		= "postgres"
+	StorageMemory		= "memory"
+)
+
 type Server struct {
 	Port 			int `json:"port"`
 	ReadTimeout		int `json:"readTimeout"`
 	WriteTimeout	int `json:"writeTimeout"`
 	IdleTimeout		int `json:"idleTimeout"`
 	CtxTimeout		int `json:"ctxTimeout"`
+	GrpcPort		int `json:"grpcPort,omitempty"`
+	GraphqlMaxDepth			int `json:"graphqlMaxDepth,omitempty"`
+	GraphqlMaxComplexity	int `json:"graphqlMaxComplexity,omitempty"`
+	StreamBufferSize		int `json:"streamBufferSize,omitempty"`
+}
+
+type InventoryConfig struct {
+	CycleCountApprovalThreshold	int `json:"cycle_count_approval_threshold"`
+}
+
+const (
+	PublisherStdout	= "stdout"
+	PublisherHttp	= "http"
+	PublisherKafka	= "kafka"
+	PublisherNats	= "nats"
+	PublisherNone	= "none"
+)
+
+type OutboxConfig struct {
+	Publisher		string		`json:"publisher"`
+	HttpURL			string		`json:"http_url,omitempty"`
+	KafkaBrokers	[]string	`json:"kafka_brokers,omitempty"`
+	KafkaTopic		string		`json:"kafka_topic,omitempty"`
+	NatsURL			string		`json:"nats_url,omitempty"`
+	NatsSubject		string		`json:"nats_subject,omitempty"`
+	PollInterval	int			`json:"poll_interval_ms"`
+	BatchSize		int			`json:"batch_size"`
+	MaxAttempts		int			`json:"max_attempts"`
+}
+
+type WebhookConfig struct {
+	PollInterval	int			`json:"poll_interval_ms"`
+	BatchSize		int			`json:"batch_size"`
+	MaxAttempts		int			`json:"max_attempts"`
+	Timeout			int			`json:"timeout"`
+}
+
+const (
+	ConsumerKafka	= "kafka"
+	ConsumerNats	= "nats"
+	ConsumerFile	= "file"
+	ConsumerNone	= "none"
+)
+
+// AuthConfig holds the keys of the tokens and the scopes granting each access level
+type AuthConfig struct {
+	Enabled			bool		`json:"enabled"`
+	HmacSecret		string		`json:"-"`
+	PublicKeyFile	string		`json:"public_key_file,omitempty"`
+	JwksFile		string		`json:"jwks_file,omitempty"`
+	Issuer			string		`json:"issuer,omitempty"`
+	Audience		string		`json:"audience,omitempty"`
+	Leeway			int			`json:"leeway"`
+	ScopesRead		[]string	`json:"scopes_read"`
+	ScopesWrite		[]string	`json:"scopes_write"`
+	ScopesAdmin		[]string	`json:"scopes_admin"`
+	ApiKeyEnabled	bool		`json:"api_key_enabled"`
+	ApiKeyHeader	string		`json:"api_key_header"`
+	ApiKeyQuotaWindow int		`json:"api_key_quota_window"`
 }
 
+// TenantConfig holds how the tenant of a request is resolved when its credential does not carry one
+type TenantConfig struct {
+	Header			string		`json:"header"`
+	Default			string		`json:"default"`
+	Required		bool		`json:"required"`
+}
+
+// RateLimitConfig holds the token buckets of the clients (rate per second of each client in each route) and the
+// adaptive concurrency limit of the pod, which sheds requests when the acquire of the database pool waits too long
+type RateLimitConfig struct {
+	Rate				float64		`json:"rate"`
+	Burst				int			`json:"burst"`
+	IpRate				float64		`json:"ip_rate"`
+	IpBurst				int			`json:"ip_burst"`
+	TrustForwarded		bool		`json:"trust_forwarded"`
+	ShedEnabled			bool		`json:"shed_enabled"`
+	MinConcurrency		int			`json:"min_concurrency"`
+	MaxConcurrency		int			`json:"max_concurrency"`
+	AcquireWaitTarget	int			`json:"acquire_wait_target_ms"`
+	ShedInterval		int			`json:"shed_interval_ms"`
+}
+
+type OrderConsumerConfig struct {
+	Broker			string		`json:"broker"`
+	KafkaBrokers	[]string	`json:"kafka_brokers,omitempty"`
+	KafkaTopic		string		`json:"kafka_topic,omitempty"`
+	KafkaGroup		string		`json:"kafka_group,omitempty"`
+	NatsURL			string		`json:"nats_url,omitempty"`
+	NatsSubject		string		`json:"nats_subject,omitempty"`
+	NatsDurable		string		`json:"nats_durable,omitempty"`
+	FilePath		string		`json:"file_path,omitempty"`
+}
+
+const (
+	ProductInStock		= "IN-STOCK"
+	ProductOutOfStock	= "OUT-OF-STOCK"
+)
+
 type Product struct {
 	ID			int			`json:"id,omitempty"`
 	Sku			string		`json:"sku,omitempty"`
@@ -47,6 +163,7 @@ type Product struct {
 	Name		string 		`json:"name,omitempty"`
 	Status		string 		`json:"status,omitempty"`
 	LeadTime	int			`json:"lead_time,omitempty"`
+	Version		int			`json:"version,omitempty"`
 	CreatedAt	time.Time 	`json:"created_at,omitempty"`
 	UpdatedAt	*time.Time 	`json:"update_at,omitempty"`	
 }
@@ -59,6 +176,371 @@ type Inventory struct {
 	Reserved		int		`json:"reserved,omitempty"`
 	Sold			int		`json:"sold,omitempty"`
 	Incoming		int		`json:"incoming,omitempty"`
+	Quarantine		int		`json:"quarantine,omitempty"`
+	Damaged			int		`json:"damaged,omitempty"`
+	Hold			int		`json:"hold,omitempty"`
+	Channel			string	`json:"channel,omitempty"`
+	ChannelAvailable int	`json:"channel_available,omitempty"`
+	Version			int		`json:"version,omitempty"`
+	CreatedAt		time.Time 	`json:"created_at,omitempty"`
+	UpdatedAt		*time.Time 	`json:"update_at,omitempty"`	
+}
+
+const (
+	EventInventoryChanged	= "inventory.changed"
+	EventInventorySnapshot	= "inventory.snapshot"
+	EventProductChanged		= "product.changed"
+)
+
+// InventoryEvent is a committed change of a inventory pushed to the stream subscribers
+type InventoryEvent struct {
+	ID				string		`json:"id,omitempty"`
+	Type			string		`json:"type"`
+	Tenant			string		`json:"tenant,omitempty"`
+	Inventory		Inventory	`json:"inventory"`
+	CreatedAt		time.Time 	`json:"created_at"`
+}
+
+const (
+	OutboxPending	= "PENDING"
+	OutboxPublished	= "PUBLISHED"
+	OutboxFailed	= "FAILED"
+
+	EventTypeProductCreated		= "com.go-inventory.ProductCreated"
+	EventTypeInventoryChanged	= "com.go-inventory.InventoryChanged"
+	EventTypeStockDepleted		= "com.go-inventory.StockDepleted"
+	EventTypeOrderRejected		= "com.go-inventory.OrderRejected"
+)
+
+// OutboxEvent is a domain event written in the transaction of the change, the relay publishes it after the commit
+type OutboxEvent struct {
+	ID				int				`json:"id,omitempty"`
+	EventID			string			`json:"event_id"`
+	Type			string			`json:"type"`
+	Source			string			`json:"source"`
+	Subject			string			`json:"subject,omitempty"`
+	Actor			string			`json:"actor,omitempty"`
+	Tenant			string			`json:"tenant,omitempty"`
+	Data			json.RawMessage	`json:"data"`
+	Status			string			`json:"status,omitempty"`
+	Attempts		int				`json:"attempts"`
+	LastError		string			`json:"last_error,omitempty"`
+	NextAttemptAt	time.Time		`json:"next_attempt_at"`
+	PublishedAt		*time.Time		`json:"published_at,omitempty"`
+	CreatedAt		time.Time 		`json:"created_at"`
+}
+
+// CloudEvent is the envelope of a published event (CloudEvents 1.0, structured json mode)
+type CloudEvent struct {
+	SpecVersion		string			`json:"specversion"`
+	ID				string			`json:"id"`
+	Source			string			`json:"source"`
+	Type			string			`json:"type"`
+	Subject			string			`json:"subject,omitempty"`
+	Time			time.Time		`json:"time"`
+	Actor			string			`json:"actor,omitempty"`
+	Tenant			string			`json:"tenant,omitempty"`
+	DataContentType	string			`json:"datacontenttype"`
+	Data			json.RawMessage	`json:"data"`
+}
+
+const (
+	WebhookLowStock		= "LOW_STOCK"
+	WebhookStockout		= "STOCKOUT"
+
+	DeliveryPending		= "PENDING"
+	DeliveryDelivered	= "DELIVERED"
+	DeliveryDead		= "DEAD"
+)
+
+// Webhook is a subscription to the stock alerts of a sku or of every sku of a product type
+type Webhook struct {
+	ID				int			`json:"id,omitempty"`
+	Url				string		`json:"url"`
+	Secret			string		`json:"secret,omitempty"`
+	Sku				string		`json:"sku,omitempty"`
+	ProductType		string		`json:"product_type,omitempty"`
+	Threshold		int			`json:"threshold"`
+	Active			bool		`json:"active"`
+	CreatedAt		time.Time 	`json:"created_at,omitempty"`
+	UpdatedAt		*time.Time 	`json:"update_at,omitempty"`
+}
+
+// WebhookAlert is the payload posted to a webhook
+type WebhookAlert struct {
+	ID					string		`json:"id"`
+	Type				string		`json:"type"`
+	WebhookID			int			`json:"webhook_id"`
+	Threshold			int			`json:"threshold"`
+	PreviousAvailable	int			`json:"previous_available"`
+	Inventory			Inventory	`json:"inventory"`
+	CreatedAt			time.Time 	`json:"created_at"`
+}
+
+// WebhookDelivery is a alert to be posted to a webhook, the dead ones exhausted the attempts
+type WebhookDelivery struct {
+	ID				int				`json:"id,omitempty"`
+	WebhookID		int				`json:"webhook_id"`
+	EventID			string			`json:"event_id"`
+	Type			string			`json:"type"`
+	Sku				string			`json:"sku"`
+	Payload			json.RawMessage	`json:"payload"`
+	Status			string			`json:"status,omitempty"`
+	Attempts		int				`json:"attempts"`
+	LastStatusCode	int				`json:"last_status_code,omitempty"`
+	LastError		string			`json:"last_error,omitempty"`
+	NextAttemptAt	time.Time		`json:"next_attempt_at"`
+	DeliveredAt		*time.Time		`json:"delivered_at,omitempty"`
+	CreatedAt		time.Time 		`json:"created_at"`
+	Webhook			Webhook			`json:"-"`
+}
+
+const (
+	AdjustmentApplied			= "APPLIED"
+	AdjustmentPendingApproval	= "PENDING_APPROVAL"
+	AdjustmentRejected			= "REJECTED"
+)
+
+type InventoryAdjustment struct {
+	ID				int			`json:"id,omitempty"`
+	Product 		Product		`json:"product"`
+	Counted			int			`json:"counted"`
+	Previous		int			`json:"previous"`
+	Variance		int			`json:"variance"`
+	Status			string		`json:"status,omitempty"`
+	Reason			string		`json:"reason,omitempty"`
 	CreatedAt		time.Time 	`json:"created_at,omitempty"`
 	UpdatedAt		*time.Time 	`json:"update_at,omitempty"`	
-}
\ No newline at end of file
+}
+
+// Stock buckets, only available is sellable
+const (
+	BucketAvailable		= "available"
+	BucketQuarantine	= "quarantine"
+	BucketDamaged		= "damaged"
+	BucketHold			= "hold"
+	BucketSold			= "sold"
+	BucketReserved		= "reserved"
+)
+
+type InventoryTransfer struct {
+	Product 		Product		`json:"product"`
+	From			string		`json:"from,omitempty"`
+	To				string		`json:"to,omitempty"`
+	Quantity		int			`json:"quantity"`
+}
+
+const (
+	OrderCreated	= "order.created"
+	OrderPaid		= "order.paid"
+	OrderCancelled	= "order.cancelled"
+	OrderShipped	= "order.shipped"
+
+	ReservationReserved		= "RESERVED"
+	ReservationConfirmed	= "CONFIRMED"
+	ReservationReleased		= "RELEASED"
+
+	OrderEventApplied	= "APPLIED"
+	OrderEventRejected	= "REJECTED"
+)
+
+// OrderEvent is a step of the lifecycle of a order, consumed from the broker of the order service
+type OrderEvent struct {
+	ID				string		`json:"id"`
+	Type			string		`json:"type"`
+	OrderID			string		`json:"order_id"`
+	Tenant			string		`json:"tenant,omitempty"`
+	Items			[]OrderItem	`json:"items"`
+	CreatedAt		time.Time 	`json:"created_at"`
+}
+
+type OrderItem struct {
+	Sku				string		`json:"sku"`
+	Quantity		int			`json:"quantity"`
+}
+
+// OrderReservation is the stock held by a item of a order, its status tells what the next event can do
+type OrderReservation struct {
+	ID				int			`json:"id,omitempty"`
+	OrderID			string		`json:"order_id"`
+	Product 		Product		`json:"product"`
+	Quantity		int			`json:"quantity"`
+	Status			string		`json:"status"`
+	CreatedAt		time.Time 	`json:"created_at"`
+	UpdatedAt		*time.Time 	`json:"update_at,omitempty"`
+}
+
+// ConsumedEvent records a order event already processed, so a redelivery is skipped
+type ConsumedEvent struct {
+	EventID			string		`json:"event_id"`
+	Type			string		`json:"type"`
+	OrderID			string		`json:"order_id"`
+	Status			string		`json:"status"`
+	Error			string		`json:"error,omitempty"`
+	CreatedAt		time.Time 	`json:"created_at"`
+}
+
+const (
+	RmaCreated		= "CREATED"
+	RmaReceived		= "RECEIVED"
+	RmaInspected	= "INSPECTED"
+	RmaCompleted	= "COMPLETED"
+)
+
+type Rma struct {
+	ID				int			`json:"id,omitempty"`
+	OrderRef		string		`json:"order_ref,omitempty"`
+	Product 		Product		`json:"product"`
+	Quantity		int			`json:"quantity"`
+	Received		int			`json:"received"`
+	Restock			int			`json:"restock"`
+	Damaged			int			`json:"damaged"`
+	Status			string		`json:"status,omitempty"`
+	Reason			string		`json:"reason,omitempty"`
+	Events			[]RmaEvent	`json:"events,omitempty"`
+	CreatedAt		time.Time 	`json:"created_at,omitempty"`
+	UpdatedAt		*time.Time 	`json:"update_at,omitempty"`	
+}
+
+type RmaEvent struct {
+	ID				int			`json:"id,omitempty"`
+	Step			string		`json:"step"`
+	Quantity		int			`json:"quantity"`
+	Note			string		`json:"note,omitempty"`
+	CreatedAt		time.Time 	`json:"created_at,omitempty"`
+}
+
+const (
+	AllocationFixed			= "FIXED"
+	AllocationPercentage	= "PERCENTAGE"
+)
+
+type ChannelAllocation struct {
+	ID				int			`json:"id,omitempty"`
+	Product 		Product		`json:"product"`
+	Channel			string		`json:"channel"`
+	Type			string		`json:"type"`
+	Quantity		int			`json:"quantity,omitempty"`
+	Percentage		int			`json:"percentage,omitempty"`
+	Available		int			`json:"available"`
+	CreatedAt		time.Time 	`json:"created_at,omitempty"`
+	UpdatedAt		*time.Time 	`json:"update_at,omitempty"`	
+}
+
+const (
+	ApiKeyActive	= "ACTIVE"
+	ApiKeyRevoked	= "REVOKED"
+)
+
+// ApiKey is the credential of a partner, only the hash of the key is stored. The key is only returned
+// when issued or rotated
+type ApiKey struct {
+	ID				int			`json:"id,omitempty"`
+	Name			string		`json:"name"`
+	Prefix			string		`json:"prefix,omitempty"`
+	Key				string		`json:"key,omitempty"`
+	KeyHash			string		`json:"-"`
+	Scopes			[]string	`json:"scopes"`
+	SkuPrefixes		[]string	`json:"sku_prefixes,omitempty"`
+	Quota			int			`json:"quota"`
+	QuotaWindow		int			`json:"quota_window"`
+	Tenant			string		`json:"tenant,omitempty"`
+	Usage			*ApiKeyUsage `json:"usage,omitempty"`
+	Status			string		`json:"status,omitempty"`
+	ExpiresAt		*time.Time	`json:"expires_at,omitempty"`
+	CreatedAt		time.Time 	`json:"created_at,omitempty"`
+	UpdatedAt		*time.Time 	`json:"update_at,omitempty"`
+}
+
+// ApiKeyUsage is the count of requests of a key in a window of its quota
+type ApiKeyUsage struct {
+	WindowStart		time.Time	`json:"window_start"`
+	Requests		int			`json:"requests"`
+}
+
+const (
+	CacheMemory		= "memory"
+	CacheRedis		= "redis"
+	CacheNone		= "none"
+)
+
+// ReplicaConfig holds the read replica, nil when the reads go to the primary. The replica is used while its lag is
+// under MaxLag, and a caller reads the primary for ReadYourWrites after its last change
+type ReplicaConfig struct {
+	Database		*go_core_db_pg.DatabaseConfig	`json:"database"`
+	MaxLag			int			`json:"max_lag_ms"`
+	CheckInterval	int			`json:"check_interval_ms"`
+	ReadYourWrites	int			`json:"read_your_writes_ms"`
+}
+
+// MigrationConfig holds the schema migrations embedded in the binary. With AutoMigrate the pending ones are applied at
+// boot, one pod at a time (advisory lock, waited up to LockTimeout), otherwise they are applied by the migrate command
+type MigrationConfig struct {
+	AutoMigrate		bool		`json:"auto_migrate"`
+	LockTimeout		int			`json:"lock_timeout_ms"`
+}
+
+// MigrationStatus is a migration of the schema, AppliedAt is nil while it is pending
+type MigrationStatus struct {
+	Version			int			`json:"version"`
+	Name			string		`json:"name"`
+	Applied			bool		`json:"applied"`
+	AppliedAt		*time.Time	`json:"applied_at,omitempty"`
+}
+
+// CacheConfig holds the cache of the reads of products and inventories, in the pod (lru) or in a redis compatible server
+type CacheConfig struct {
+	Backend			string		`json:"backend"`
+	TTL				int			`json:"ttl_ms"`
+	Size			int			`json:"size"`
+	RedisAddr		string		`json:"redis_addr,omitempty"`
+	RedisPassword	string		`json:"-"`
+	RedisDB			int			`json:"redis_db"`
+	RedisPrefix		string		`json:"redis_prefix,omitempty"`
+	RedisPoolSize	int			`json:"redis_pool_size"`
+	RedisTimeout	int			`json:"redis_timeout_ms"`
+}
+
+type AuditConfig struct {
+	SealInterval	int			`json:"seal_interval_ms"`
+	BatchSize		int			`json:"batch_size"`
+}
+
+const (
+	AuditEntityProduct		= "PRODUCT"
+	AuditEntityInventory	= "INVENTORY"
+
+	AuditActionCreate		= "CREATE"
+	AuditActionUpdate		= "UPDATE"
+)
+
+// AuditRecord is a change of a product or inventory with its snapshots, written in the transaction of the change.
+// The sealer chains the records of each tenant (seq, prev_hash, hash), a record without hash is not sealed yet
+type AuditRecord struct {
+	ID				int				`json:"id,omitempty"`
+	Entity			string			`json:"entity"`
+	Sku				string			`json:"sku"`
+	Action			string			`json:"action"`
+	Actor			string			`json:"actor,omitempty"`
+	Tenant			string			`json:"tenant,omitempty"`
+	Route			string			`json:"route,omitempty"`
+	RequestID		string			`json:"request_id,omitempty"`
+	Before			json.RawMessage	`json:"before,omitempty"`
+	After			json.RawMessage	`json:"after"`
+	Seq				int64			`json:"seq,omitempty"`
+	PrevHash		string			`json:"prev_hash,omitempty"`
+	Hash			string			`json:"hash,omitempty"`
+	CreatedAt		time.Time 		`json:"created_at"`
+	From			*time.Time		`json:"-"`
+	To				*time.Time		`json:"-"`
+}
+
+// AuditVerification is the result of walking the chain of a tenant, the first broken record stops the walk
+type AuditVerification struct {
+	Valid			bool			`json:"valid"`
+	Records			int				`json:"records"`
+	Head			string			`json:"head,omitempty"`
+	BrokenAt		int64			`json:"broken_at,omitempty"`
+	Reason			string			`json:"reason,omitempty"`
+	Unsealed		int				`json:"unsealed"`
+}
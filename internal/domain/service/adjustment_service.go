@@ -0,0 +1,191 @@
+package service
+
+import (
+	"time"
+	"context"
+
+	"github.com/go-inventory/internal/domain/model"
+	"github.com/go-inventory/shared/erro"
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/codes"
+)
+
+// Helper function to know whether a variance requires approval
+func (s *WorkerService) requiresApproval(variance int) bool {
+	threshold := 0
+	if s.appServer != nil && s.appServer.InventoryConfig != nil {
+		threshold = s.appServer.InventoryConfig.CycleCountApprovalThreshold
+	}
+	if variance < 0 {
+		variance = -variance
+	}
+	return threshold > 0 && variance > threshold
+}
+
+// Helper function to set the available quantity of a locked inventory and record it in the time series
+func (s *WorkerService) setInventoryAvailable(ctx context.Context, tx Tx, inventory *model.Inventory, available int) error {
+	now := time.Now()
+	previous := *inventory
+	inventory.Available = available
+	inventory.UpdatedAt = &now
+
+	_, err := s.workerRepository.SetInventoryAvailable(ctx, tx, inventory)
+	if err != nil {
+		return err
+	}
+
+	return s.addInventorySnapshot(ctx, tx, inventory, &previous)
+}
+
+// About register a cycle count, the counted quantity becomes the available quantity
+// unless the variance exceeds the approval threshold
+func (s *WorkerService) CycleCount(ctx context.Context, adjustment *model.InventoryAdjustment) (*model.InventoryAdjustment, error){
+	s.logger.Info().
+			Ctx(ctx).
+			Str("func","CycleCount").Send()
+
+	// Trace
+	ctx, span := s.tracerProvider.SpanCtx(ctx, "service.CycleCount", trace.SpanKindServer)
+	defer span.End()
+
+	// the caller may be restricted to some skus
+	if err := authorizeSku(ctx, adjustment.Product.Sku); err != nil {
+		return nil, err
+	}
+
+	// prepare database
+	tx, err := s.workerRepository.StartTx(ctx)
+	if err != nil {
+		span.RecordError(err)
+        span.SetStatus(codes.Error, err.Error())
+		return nil, err
+	}
+
+	// handle connection and transaction
+	defer func() {
+		if err != nil {
+			tx.Rollback(ctx)
+		} else {
+			tx.Commit(ctx)
+		}
+	}()
+
+	// Get and lock the inventory counted
+	resInventory, err := s.workerRepository.GetInventoryForUpdate(ctx, tx, &model.Inventory{Product: adjustment.Product})
+	if err != nil {
+		return nil, err
+	}
+
+	// compute the variance
+	adjustment.Product = resInventory.Product
+	adjustment.Previous = resInventory.Available
+	adjustment.Variance = adjustment.Counted - resInventory.Available
+	adjustment.CreatedAt = time.Now()
+
+	if s.requiresApproval(adjustment.Variance) {
+		adjustment.Status = model.AdjustmentPendingApproval
+	} else {
+		adjustment.Status = model.AdjustmentApplied
+		err = s.setInventoryAvailable(ctx, tx, resInventory, adjustment.Counted)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	res_adjustment, err := s.workerRepository.AddInventoryAdjustment(ctx, tx, adjustment)
+	if err != nil {
+		return nil, err
+	}
+
+	return res_adjustment, nil
+}
+
+// About approve or reject a adjustment pending approval
+func (s *WorkerService) ReviewAdjustment(ctx context.Context, adjustment *model.InventoryAdjustment) (*model.InventoryAdjustment, error){
+	s.logger.Info().
+			Ctx(ctx).
+			Str("func","ReviewAdjustment").Send()
+
+	// Trace
+	ctx, span := s.tracerProvider.SpanCtx(ctx, "service.ReviewAdjustment", trace.SpanKindServer)
+	defer span.End()
+
+	// prepare database
+	tx, err := s.workerRepository.StartTx(ctx)
+	if err != nil {
+		span.RecordError(err)
+        span.SetStatus(codes.Error, err.Error())
+		return nil, err
+	}
+
+	// handle connection and transaction
+	defer func() {
+		if err != nil {
+			tx.Rollback(ctx)
+		} else {
+			tx.Commit(ctx)
+		}
+	}()
+
+	// Get and lock the adjustment
+	resAdjustment, err := s.workerRepository.GetInventoryAdjustmentForUpdate(ctx, tx, adjustment)
+	if err != nil {
+		return nil, err
+	}
+
+	err = authorizeSku(ctx, resAdjustment.Product.Sku)
+	if err != nil {
+		return nil, err
+	}
+
+	if resAdjustment.Status != model.AdjustmentPendingApproval {
+		err = erro.ErrInvalidStatus
+		return nil, err
+	}
+
+	if adjustment.Status == model.AdjustmentApplied {
+		// the variance is applied over the current available, the stock could be moved since the count
+		var resInventory *model.Inventory
+		resInventory, err = s.workerRepository.GetInventoryForUpdate(ctx, tx, &model.Inventory{Product: resAdjustment.Product})
+		if err != nil {
+			return nil, err
+		}
+
+		available := resInventory.Available + resAdjustment.Variance
+		if available < 0 {
+			available = 0
+		}
+
+		resAdjustment.Previous = resInventory.Available
+		err = s.setInventoryAvailable(ctx, tx, resInventory, available)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	now := time.Now()
+	resAdjustment.Status = adjustment.Status
+	resAdjustment.UpdatedAt = &now
+
+	_, err = s.workerRepository.UpdateInventoryAdjustmentStatus(ctx, tx, resAdjustment)
+	if err != nil {
+		return nil, err
+	}
+
+	return resAdjustment, nil
+}
+
+// About list the adjustments of a product
+func (s *WorkerService) ListInventoryAdjustment(ctx context.Context, limit int, offset int, adjustment *model.InventoryAdjustment) (*[]model.InventoryAdjustment, error){
+	result, err := s.callRepositoryRead(ctx, "ListInventoryAdjustment", func(ctx context.Context) (interface{}, error) {
+		if err := authorizeSku(ctx, adjustment.Product.Sku); err != nil {
+			return nil, err
+		}
+		return s.workerRepository.ListInventoryAdjustment(ctx, limit, offset, adjustment)
+	})
+
+	if err != nil {
+		return nil, err
+	}
+	return result.(*[]model.InventoryAdjustment), nil
+}
@@ -0,0 +1,322 @@
+package service
+
+import (
+	"time"
+	"context"
+	"strconv"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+
+	"github.com/go-inventory/internal/domain/model"
+	"github.com/go-inventory/shared/erro"
+	"github.com/go-inventory/shared/security"
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/codes"
+)
+
+// prefix of the api keys, so a leaked key is easy to recognize
+const apiKeyMark = "inv_"
+
+var errInvalidApiKey = erro.New(erro.KindUnauthorized, erro.ErrUnauthorized.Code, "invalid api key")
+
+// Helper function to hash a api key, the keys are random so a plain sha256 is enough
+func apiKeyHash(key string) string {
+	hash := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(hash[:])
+}
+
+// Helper function to generate a api key, returning the key and its public prefix
+func newApiKey() (string, string, error) {
+	random := make([]byte, 36)
+	if _, err := rand.Read(random); err != nil {
+		return "", "", err
+	}
+	prefix := hex.EncodeToString(random[:4])
+	return apiKeyMark + prefix + "_" + hex.EncodeToString(random[4:]), prefix, nil
+}
+
+// Helper function to get the start of the window of the quota of a api key
+func (s *WorkerService) apiKeyWindow(apiKey *model.ApiKey, now time.Time) (time.Time, time.Duration) {
+	window := time.Duration(apiKey.QuotaWindow) * time.Second
+	if window <= 0 {
+		window = time.Duration(s.appServer.AuthConfig.ApiKeyQuotaWindow) * time.Second
+	}
+	return now.Truncate(window), window
+}
+
+// Helper function to check the caller can access a sku, the api keys can be restricted to some prefixes
+func authorizeSku(ctx context.Context, skus ...string) error {
+	principal := security.PrincipalFrom(ctx)
+	if principal == nil {
+		return nil
+	}
+	for _, sku := range skus {
+		if !principal.AllowsSku(sku) {
+			return erro.ErrSkuForbidden.WithDetails(map[string]interface{}{"sku": sku})
+		}
+	}
+	return nil
+}
+
+// Helper function to keep the items of a list whose sku the caller can access
+func filterSku[T any](ctx context.Context, list []T, sku func(*T) string) []T {
+	principal := security.PrincipalFrom(ctx)
+	if principal == nil || len(principal.SkuPrefixes) == 0 {
+		return list
+	}
+	list_allowed := []T{}
+	for i := range list {
+		if principal.AllowsSku(sku(&list[i])) {
+			list_allowed = append(list_allowed, list[i])
+		}
+	}
+	return list_allowed
+}
+
+// About issue a api key, the key is only returned here
+func (s *WorkerService) IssueApiKey(ctx context.Context, apiKey *model.ApiKey) (*model.ApiKey, error){
+	s.logger.Info().
+			Ctx(ctx).
+			Str("func","IssueApiKey").Send()
+
+	// Trace
+	ctx, span := s.tracerProvider.SpanCtx(ctx, "service.IssueApiKey", trace.SpanKindServer)
+	defer span.End()
+
+	key, prefix, err := newApiKey()
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return nil, err
+	}
+
+	// prepare database
+	tx, err := s.workerRepository.StartTx(ctx)
+	if err != nil {
+		span.RecordError(err)
+        span.SetStatus(codes.Error, err.Error())
+		return nil, err
+	}
+
+	// handle connection and transaction
+	defer func() {
+		if err != nil {
+			tx.Rollback(ctx)
+		} else {
+			tx.Commit(ctx)
+		}
+	}()
+
+	// the key belongs to the tenant of the admin issuing it
+	apiKey.Tenant = security.TenantFrom(ctx)
+	apiKey.Prefix = prefix
+	apiKey.KeyHash = apiKeyHash(key)
+	apiKey.Status = model.ApiKeyActive
+	apiKey.CreatedAt = time.Now()
+	if apiKey.SkuPrefixes == nil {
+		apiKey.SkuPrefixes = []string{}
+	}
+	if apiKey.QuotaWindow == 0 {
+		apiKey.QuotaWindow = s.appServer.AuthConfig.ApiKeyQuotaWindow
+	}
+
+	res, err := s.workerRepository.AddApiKey(ctx, tx, apiKey)
+	if err != nil {
+		return nil, err
+	}
+
+	res.Key = key
+	return res, nil
+}
+
+// About get a api key with the usage of the current window of its quota
+func (s *WorkerService) GetApiKey(ctx context.Context, apiKey *model.ApiKey) (*model.ApiKey, error){
+	result, err := s.callRepositoryRead(ctx, "GetApiKey", func(ctx context.Context) (interface{}, error) {
+		resApiKey, err := s.workerRepository.GetApiKey(ctx, apiKey)
+		if err != nil {
+			return nil, err
+		}
+
+		windowStart, _ := s.apiKeyWindow(resApiKey, time.Now())
+		resApiKey.Usage, err = s.workerRepository.GetApiKeyUsage(ctx, resApiKey, windowStart)
+		if err != nil {
+			return nil, err
+		}
+		return resApiKey, nil
+	})
+
+	if err != nil {
+		return nil, err
+	}
+	return result.(*model.ApiKey), nil
+}
+
+// About replace the key of a api key keeping its permissions and quota, the previous key stops working
+func (s *WorkerService) RotateApiKey(ctx context.Context, apiKey *model.ApiKey) (*model.ApiKey, error){
+	s.logger.Info().
+			Ctx(ctx).
+			Str("func","RotateApiKey").Send()
+
+	// Trace
+	ctx, span := s.tracerProvider.SpanCtx(ctx, "service.RotateApiKey", trace.SpanKindServer)
+	defer span.End()
+
+	key, prefix, err := newApiKey()
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return nil, err
+	}
+
+	// prepare database
+	tx, err := s.workerRepository.StartTx(ctx)
+	if err != nil {
+		span.RecordError(err)
+        span.SetStatus(codes.Error, err.Error())
+		return nil, err
+	}
+
+	// handle connection and transaction
+	defer func() {
+		if err != nil {
+			tx.Rollback(ctx)
+		} else {
+			tx.Commit(ctx)
+		}
+	}()
+
+	now := time.Now()
+	apiKey.Prefix = prefix
+	apiKey.KeyHash = apiKeyHash(key)
+	apiKey.UpdatedAt = &now
+
+	row, err := s.workerRepository.RotateApiKey(ctx, tx, apiKey)
+	if err != nil {
+		return nil, err
+	}
+	if row == 0 {
+		// unknown or revoked
+		err = erro.ErrNotFound
+		return nil, err
+	}
+
+	res, err := s.workerRepository.GetApiKey(ctx, apiKey)
+	if err != nil {
+		return nil, err
+	}
+
+	res.Prefix = apiKey.Prefix
+	res.UpdatedAt = apiKey.UpdatedAt
+	res.Key = key
+	return res, nil
+}
+
+// About revoke a api key, its requests are refused from now on
+func (s *WorkerService) RevokeApiKey(ctx context.Context, apiKey *model.ApiKey) error{
+	s.logger.Info().
+			Ctx(ctx).
+			Str("func","RevokeApiKey").Send()
+
+	// Trace
+	ctx, span := s.tracerProvider.SpanCtx(ctx, "service.RevokeApiKey", trace.SpanKindServer)
+	defer span.End()
+
+	// prepare database
+	tx, err := s.workerRepository.StartTx(ctx)
+	if err != nil {
+		span.RecordError(err)
+        span.SetStatus(codes.Error, err.Error())
+		return err
+	}
+
+	// handle connection and transaction
+	defer func() {
+		if err != nil {
+			tx.Rollback(ctx)
+		} else {
+			tx.Commit(ctx)
+		}
+	}()
+
+	now := time.Now()
+	apiKey.Status = model.ApiKeyRevoked
+	apiKey.UpdatedAt = &now
+
+	row, err := s.workerRepository.RevokeApiKey(ctx, tx, apiKey)
+	if err != nil {
+		return err
+	}
+	if row == 0 {
+		err = erro.ErrNotFound
+		return err
+	}
+
+	return nil
+}
+
+// About authenticate a request by its api key and count it in the quota of the key, returning the principal
+// with the permissions of the key
+func (s *WorkerService) AuthenticateApiKey(ctx context.Context, key string) (*security.Principal, error){
+	s.logger.Info().
+			Ctx(ctx).
+			Str("func","AuthenticateApiKey").Send()
+
+	// Trace
+	ctx, span := s.tracerProvider.SpanCtx(ctx, "service.AuthenticateApiKey", trace.SpanKindServer)
+	defer span.End()
+
+	// the tenant is not known before the key is found, the key gives it
+	ctx = security.WithTenant(ctx, security.SystemTenant)
+
+	resApiKey, err := s.workerRepository.GetApiKeyByHash(ctx, apiKeyHash(key))
+	if err != nil {
+		if erro.KindOf(err) == erro.KindNotFound {
+			return nil, errInvalidApiKey
+		}
+		return nil, err
+	}
+
+	now := time.Now()
+	if resApiKey.Status != model.ApiKeyActive || (resApiKey.ExpiresAt != nil && now.After(*resApiKey.ExpiresAt)) {
+		return nil, errInvalidApiKey
+	}
+
+	if resApiKey.Quota > 0 {
+		windowStart, window := s.apiKeyWindow(resApiKey, now)
+
+		// prepare database
+		tx, err := s.workerRepository.StartTx(ctx)
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+			return nil, err
+		}
+
+		requests, err := s.workerRepository.AddApiKeyUsage(ctx, tx, resApiKey, windowStart)
+		if err != nil {
+			tx.Rollback(ctx)
+		} else {
+			tx.Commit(ctx)
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		if requests > resApiKey.Quota {
+			retryAfter := int(windowStart.Add(window).Sub(now).Seconds()) + 1
+			return nil, erro.ErrQuotaExceeded.WithDetails(map[string]interface{}{	"quota": resApiKey.Quota,
+																					"quota_window": int(window.Seconds()),
+																					"retry_after": retryAfter})
+		}
+	}
+
+	return &security.Principal{
+		Subject: "apikey:" + strconv.Itoa(resApiKey.ID),
+		TokenID: resApiKey.Prefix,
+		Scopes: resApiKey.Scopes,
+		Level: security.HighestLevel(resApiKey.Scopes),
+		SkuPrefixes: resApiKey.SkuPrefixes,
+		Tenant: resApiKey.Tenant,
+	}, nil
+}
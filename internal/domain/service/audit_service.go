@@ -0,0 +1,261 @@
+package service
+
+import (
+	"time"
+	"context"
+	"strconv"
+	"strings"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+
+	"github.com/go-inventory/internal/domain/model"
+	"github.com/go-inventory/shared/erro"
+	"github.com/go-inventory/shared/security"
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/codes"
+
+	go_core_midleware "github.com/eliezerraj/go-core/v2/middleware"
+)
+
+// records of the chain read at a time by the verification
+const auditVerifyBatch = 500
+
+// Helper function to write the audit record of a change of a product or inventory, inside the transaction of the
+// change. The before snapshot is nil on a creation
+func (s *WorkerService) addAuditRecord(ctx context.Context, tx Tx, entity string, action string, sku string, before interface{}, after interface{}) error {
+	record := model.AuditRecord{
+		Entity:		entity,
+		Sku:		sku,
+		Action:		action,
+		Actor:		security.SubjectFrom(ctx),
+		Route:		security.RouteFrom(ctx),
+		RequestID:	go_core_midleware.GetRequestID(ctx),
+		CreatedAt:	time.Now(),
+	}
+
+	var err error
+	if before != nil {
+		record.Before, err = json.Marshal(before)
+		if err != nil {
+			return err
+		}
+	}
+	record.After, err = json.Marshal(after)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.workerRepository.AddAuditRecord(ctx, tx, &record)
+	return err
+}
+
+// Helper function to compute the hash of a sealed record, over the previous hash and every field of the record as
+// stored (jsonb text, timestamp in microseconds), so the verification recomputes it from the table alone
+func auditHash(record *model.AuditRecord) string {
+	fields := []string{
+		record.PrevHash,
+		record.Tenant,
+		strconv.FormatInt(record.Seq, 10),
+		record.Entity,
+		record.Sku,
+		record.Action,
+		record.Actor,
+		record.Route,
+		record.RequestID,
+		string(record.Before),
+		string(record.After),
+		record.CreatedAt.UTC().Format(time.RFC3339Nano),
+	}
+
+	sum := sha256.Sum256([]byte(strings.Join(fields, "\n")))
+	return hex.EncodeToString(sum[:])
+}
+
+// About seal a batch of records, returning how many were sealed. Each record gets the next position of the chain of
+// its tenant and the hash of the previous one. The lock makes a single pod seal at a time, so each chain is linear
+func (s *WorkerService) SealAudit(ctx context.Context) (int, error){
+	s.logger.Debug().
+			Ctx(ctx).
+			Str("func","SealAudit").Send()
+
+	// Trace
+	ctx, span := s.tracerProvider.SpanCtx(ctx, "service.SealAudit", trace.SpanKindServer)
+	defer span.End()
+
+	// prepare database
+	tx, err := s.workerRepository.StartTx(ctx)
+	if err != nil {
+		span.RecordError(err)
+        span.SetStatus(codes.Error, err.Error())
+		return 0, err
+	}
+
+	// handle connection and transaction
+	defer func() {
+		if err != nil {
+			tx.Rollback(ctx)
+		} else {
+			tx.Commit(ctx)
+		}
+	}()
+
+	locked, err := s.workerRepository.TryAuditSealLock(ctx, tx)
+	if err != nil || !locked {
+		return 0, err
+	}
+
+	list_record, err := s.workerRepository.ListAuditUnsealedForUpdate(ctx, tx, s.appServer.AuditConfig.BatchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	// head of the chain of each tenant of the batch
+	type head struct {
+		seq		int64
+		hash	string
+	}
+	heads := map[string]*head{}
+
+	for i := range *list_record {
+		record := &(*list_record)[i]
+
+		h, ok := heads[record.Tenant]
+		if !ok {
+			h = &head{}
+			h.seq, h.hash, err = s.workerRepository.GetAuditHead(ctx, tx, record.Tenant)
+			if err != nil {
+				return 0, err
+			}
+			heads[record.Tenant] = h
+		}
+
+		record.Seq = h.seq + 1
+		record.PrevHash = h.hash
+		record.Hash = auditHash(record)
+
+		_, err = s.workerRepository.SealAuditRecord(ctx, tx, record)
+		if err != nil {
+			return 0, err
+		}
+
+		h.seq, h.hash = record.Seq, record.Hash
+	}
+
+	return len(*list_record), nil
+}
+
+// About seal the audit log until the context is done, a full batch is followed by the next one without waiting
+func (s *WorkerService) StartAuditSealer(ctx context.Context) {
+	s.logger.Info().
+			Ctx(ctx).
+			Str("func","StartAuditSealer").Send()
+
+	// the sealer chains the records of every tenant
+	ctx = security.WithTenant(ctx, security.SystemTenant)
+
+	interval := time.Duration(s.appServer.AuditConfig.SealInterval) * time.Millisecond
+	for {
+		sealed, err := s.SealAudit(ctx)
+		if err != nil && ctx.Err() == nil {
+			s.logger.Error().
+					Ctx(ctx).
+					Err(err).
+					Msg("audit sealer FAILED")
+		}
+
+		wait := interval
+		if err == nil && sealed >= s.appServer.AuditConfig.BatchSize {
+			wait = 0
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(wait):
+		}
+	}
+}
+
+// About list the audit records of a entity, sku, actor, request or period
+func (s *WorkerService) ListAuditRecord(ctx context.Context, limit int, offset int, record *model.AuditRecord) (*[]model.AuditRecord, error){
+	result, err := s.callRepositoryRead(ctx, "ListAuditRecord", func(ctx context.Context) (interface{}, error) {
+		if record.Sku != "" {
+			if err := authorizeSku(ctx, record.Sku); err != nil {
+				return nil, err
+			}
+		}
+		list_record, err := s.workerRepository.ListAuditRecord(ctx, limit, offset, record)
+		if err != nil {
+			return nil, err
+		}
+		filtered := filterSku(ctx, *list_record, func(r *model.AuditRecord) string { return r.Sku })
+		return &filtered, nil
+	})
+
+	if err != nil {
+		return nil, err
+	}
+	return result.(*[]model.AuditRecord), nil
+}
+
+// About walk the chain of the tenant of the request recomputing every hash. A edited record breaks its own hash,
+// a removed or reordered one breaks the sequence or the link of the next one. The system tenant sees the chains
+// of every tenant interleaved, it has no chain to verify
+func (s *WorkerService) VerifyAudit(ctx context.Context) (*model.AuditVerification, error){
+	if security.TenantFrom(ctx) == security.SystemTenant {
+		return nil, erro.ErrTenantRequired
+	}
+
+	result, err := s.callRepositoryRead(ctx, "VerifyAudit", func(ctx context.Context) (interface{}, error) {
+		verification := model.AuditVerification{Valid: true}
+
+		var seq int64
+		prevHash := ""
+		for {
+			list_record, err := s.workerRepository.ListAuditChain(ctx, seq, auditVerifyBatch)
+			if err != nil {
+				return nil, err
+			}
+
+			for i := range *list_record {
+				record := &(*list_record)[i]
+
+				switch {
+				case record.Seq != seq + 1:
+					verification.Reason = "sequence gap"
+				case record.PrevHash != prevHash:
+					verification.Reason = "previous hash mismatch"
+				case record.Hash != auditHash(record):
+					verification.Reason = "hash mismatch"
+				}
+				if verification.Reason != "" {
+					verification.Valid = false
+					verification.BrokenAt = seq + 1
+					return &verification, nil
+				}
+
+				seq, prevHash = record.Seq, record.Hash
+				verification.Records++
+				verification.Head = record.Hash
+			}
+
+			if len(*list_record) < auditVerifyBatch {
+				break
+			}
+		}
+
+		unsealed, err := s.workerRepository.CountAuditUnsealed(ctx)
+		if err != nil {
+			return nil, err
+		}
+		verification.Unsealed = unsealed
+
+		return &verification, nil
+	})
+
+	if err != nil {
+		return nil, err
+	}
+	return result.(*model.AuditVerification), nil
+}
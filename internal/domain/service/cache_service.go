@@ -0,0 +1,172 @@
+package service
+
+import (
+	"sync"
+	"time"
+	"context"
+	"strconv"
+	"encoding/json"
+
+	"github.com/go-inventory/internal/domain/model"
+	"github.com/go-inventory/shared/security"
+)
+
+// Cache keeps the reads of the repository for a while. A failure of the cache is not a failure of the read,
+// the read goes to the database
+type Cache interface {
+	Get(ctx context.Context, key string) ([]byte, bool, error)
+	Set(ctx context.Context, key string, value []byte, ttl time.Duration) error
+	Delete(ctx context.Context, keys ...string) error
+	Close() error
+}
+
+// keys of the cache, the entity comes first (the metrics are labeled by it) and the tenant before the id
+func productCacheKey(tenant string, sku string) string {
+	return "product:" + tenant + ":" + sku
+}
+
+func productIdCacheKey(tenant string, id int) string {
+	return "product-id:" + tenant + ":" + strconv.Itoa(id)
+}
+
+func inventoryCacheKey(tenant string, sku string) string {
+	return "inventory:" + tenant + ":" + sku
+}
+
+// call of a load in progress, the callers of the same key wait for it
+type flightCall struct {
+	wg		sync.WaitGroup
+	value	[]byte
+	err		error
+}
+
+// flightGroup coalesces the concurrent loads of a key, so a hot sku missing in the cache costs one query
+type flightGroup struct {
+	mu		sync.Mutex
+	calls	map[string]*flightCall
+}
+
+// About run the load of a key once for all the callers arriving while it runs
+func (g *flightGroup) Do(key string, fn func() ([]byte, error)) ([]byte, error) {
+	g.mu.Lock()
+	if g.calls == nil {
+		g.calls = map[string]*flightCall{}
+	}
+	if call, ok := g.calls[key]; ok {
+		g.mu.Unlock()
+		call.wg.Wait()
+		return call.value, call.err
+	}
+
+	call := &flightCall{}
+	call.wg.Add(1)
+	g.calls[key] = call
+	g.mu.Unlock()
+
+	call.value, call.err = fn()
+	call.wg.Done()
+
+	g.mu.Lock()
+	delete(g.calls, key)
+	g.mu.Unlock()
+
+	return call.value, call.err
+}
+
+// Helper function to read through the cache. On a miss the load runs once per key (coalesced) and its result is
+// cached, each caller gets its own copy so it can change it
+func cachedRead[T any](s *WorkerService, ctx context.Context, key string, load func(context.Context) (*T, error)) (*T, error) {
+	if s.cache == nil {
+		return load(ctx)
+	}
+
+	data, found, err := s.cache.Get(ctx, key)
+	if err != nil {
+		s.logger.Warn().
+				Ctx(ctx).
+				Err(err).
+				Str("key", key).
+				Msg("cache get FAILED")
+	}
+
+	if !found {
+		data, err = s.flight.Do(key, func() ([]byte, error) {
+			value, err := load(ctx)
+			if err != nil {
+				return nil, err
+			}
+			data, err := json.Marshal(value)
+			if err != nil {
+				return nil, err
+			}
+
+			ttl := time.Duration(s.appServer.CacheConfig.TTL) * time.Millisecond
+			if err := s.cache.Set(ctx, key, data, ttl); err != nil {
+				s.logger.Warn().
+						Ctx(ctx).
+						Err(err).
+						Str("key", key).
+						Msg("cache set FAILED")
+			}
+			return data, nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	value := new(T)
+	if err := json.Unmarshal(data, value); err != nil {
+		return nil, err
+	}
+	return value, nil
+}
+
+// Helper function to drop the cached reads of a product (by sku and id) and of its inventory
+func (s *WorkerService) invalidateProduct(ctx context.Context, tenant string, product *model.Product) {
+	s.invalidate(ctx,	productCacheKey(tenant, product.Sku),
+						productIdCacheKey(tenant, product.ID),
+						inventoryCacheKey(tenant, product.Sku))
+}
+
+// Helper function to drop the cached read of a inventory
+func (s *WorkerService) invalidateInventory(ctx context.Context, tenant string, inventory *model.Inventory) {
+	s.invalidate(ctx, inventoryCacheKey(tenant, inventory.Product.Sku))
+}
+
+// Helper function to drop keys of the cache, a failure only leaves them until their ttl
+func (s *WorkerService) invalidate(ctx context.Context, keys ...string) {
+	if s.cache == nil {
+		return
+	}
+	if err := s.cache.Delete(ctx, keys...); err != nil {
+		s.logger.Warn().
+				Ctx(ctx).
+				Err(err).
+				Strs("keys", keys).
+				Msg("cache delete FAILED")
+	}
+}
+
+// Helper function to invalidate the cache of every pod with a change committed, it arrives through the
+// notifications of the inventory stream
+func (s *WorkerService) invalidateChanged(ctx context.Context, event *model.InventoryEvent) {
+	switch event.Type {
+	case model.EventProductChanged:
+		s.invalidateProduct(ctx, event.Tenant, &event.Inventory.Product)
+	case model.EventInventoryChanged:
+		s.invalidateInventory(ctx, event.Tenant, &event.Inventory)
+	}
+}
+
+// Helper function to notify a product change, so every pod drops the product from its cache after the commit
+func (s *WorkerService) notifyProductChanged(ctx context.Context, tx Tx, product *model.Product) error {
+	event := model.InventoryEvent{
+		Type:		model.EventProductChanged,
+		Tenant:		security.TenantFrom(ctx),
+		Inventory:	model.Inventory{Product: *product},
+		CreatedAt:	time.Now(),
+	}
+
+	return s.workerRepository.NotifyInventoryChanged(ctx, tx, &event)
+}
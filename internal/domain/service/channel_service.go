@@ -0,0 +1,254 @@
+package service
+
+import (
+	"time"
+	"context"
+
+	"github.com/go-inventory/internal/domain/model"
+	"github.com/go-inventory/shared/erro"
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/codes"
+)
+
+// Helper function to fill the availability of each allocation and return the availability of the channel informed.
+// Fixed pools are ring-fenced first, percentages share what is left and channels without allocation get the remainder.
+// The fixed pools together never exceed available, when they do the ones first in the order (by channel) are served
+func computeChannelAvailability(available int, allocations []model.ChannelAllocation, channel string) (int, *model.ChannelAllocation) {
+	shared := available
+	for _, allocation := range allocations {
+		if allocation.Type == model.AllocationFixed {
+			shared -= allocation.Quantity
+		}
+	}
+	shared = max(shared, 0)
+
+	remainder := shared
+	fixedLeft := max(available, 0)
+	var channelAllocation *model.ChannelAllocation
+	for i := range allocations {
+		allocation := &allocations[i]
+		switch allocation.Type {
+		case model.AllocationFixed:
+			allocation.Available = max(min(allocation.Quantity, fixedLeft), 0)
+			fixedLeft -= allocation.Available
+		case model.AllocationPercentage:
+			allocation.Available = shared * allocation.Percentage / 100
+			remainder -= allocation.Available
+		}
+		if allocation.Channel == channel {
+			channelAllocation = allocation
+		}
+	}
+
+	if channelAllocation != nil {
+		return channelAllocation.Available, channelAllocation
+	}
+	return max(remainder, 0), nil
+}
+
+// Helper function to consume (or give back) stock of a channel pool, the inventory row and the allocations
+// are locked so the check and the decrement are atomic
+func (s *WorkerService) consumeChannel(ctx context.Context, tx Tx, inventory *model.Inventory) (*model.Inventory, error) {
+	resInventory, err := s.workerRepository.GetInventoryForUpdate(ctx, tx, inventory)
+	if err != nil {
+		return nil, err
+	}
+
+	allocations, err := s.workerRepository.ListChannelAllocationForUpdate(ctx, tx, &resInventory.Product)
+	if err != nil {
+		return nil, err
+	}
+
+	channelAvailable, allocation := computeChannelAvailability(resInventory.Available, *allocations, inventory.Channel)
+	if inventory.Available < 0 && -inventory.Available > channelAvailable {
+		return nil, erro.ErrInsufficientStock
+	}
+
+	// only fixed allocations have its own pool
+	if allocation != nil && allocation.Type == model.AllocationFixed {
+		now := time.Now()
+		allocation.UpdatedAt = &now
+
+		row, err := s.workerRepository.UpdateChannelAllocationQuantity(ctx, tx, allocation, inventory.Available)
+		if err != nil {
+			return nil, err
+		}
+		if row == 0 {
+			return nil, erro.ErrInsufficientStock
+		}
+	}
+
+	resInventory.Channel = inventory.Channel
+	resInventory.ChannelAvailable = max(channelAvailable + inventory.Available, 0)
+
+	return resInventory, nil
+}
+
+// About create or replace the allocation of a sales channel
+func (s *WorkerService) SetChannelAllocation(ctx context.Context, allocation *model.ChannelAllocation) (*model.ChannelAllocation, error){
+	s.logger.Info().
+			Ctx(ctx).
+			Str("func","SetChannelAllocation").Send()
+
+	// Trace
+	ctx, span := s.tracerProvider.SpanCtx(ctx, "service.SetChannelAllocation", trace.SpanKindServer)
+	defer span.End()
+
+	// the caller may be restricted to some skus
+	if err := authorizeSku(ctx, allocation.Product.Sku); err != nil {
+		return nil, err
+	}
+
+	switch allocation.Type {
+	case model.AllocationFixed:
+		if allocation.Quantity < 0 {
+			return nil, erro.ErrBadRequest
+		}
+		allocation.Percentage = 0
+	case model.AllocationPercentage:
+		if allocation.Percentage <= 0 || allocation.Percentage > 100 {
+			return nil, erro.ErrBadRequest
+		}
+		allocation.Quantity = 0
+	default:
+		return nil, erro.ErrBadRequest
+	}
+
+	// prepare database
+	tx, err := s.workerRepository.StartTx(ctx)
+	if err != nil {
+		span.RecordError(err)
+        span.SetStatus(codes.Error, err.Error())
+		return nil, err
+	}
+
+	// handle connection and transaction
+	defer func() {
+		if err != nil {
+			tx.Rollback(ctx)
+		} else {
+			tx.Commit(ctx)
+		}
+	}()
+
+	// Get and lock the inventory and the current allocations
+	resInventory, err := s.workerRepository.GetInventoryForUpdate(ctx, tx, &model.Inventory{Product: allocation.Product})
+	if err != nil {
+		return nil, err
+	}
+
+	allocations, err := s.workerRepository.ListChannelAllocationForUpdate(ctx, tx, &resInventory.Product)
+	if err != nil {
+		return nil, err
+	}
+
+	// the percentages of all channels can not exceed 100
+	percentage := allocation.Percentage
+	list_allocation := []model.ChannelAllocation{}
+	for _, current := range *allocations {
+		if current.Channel == allocation.Channel {
+			continue
+		}
+		percentage += current.Percentage
+		list_allocation = append(list_allocation, current)
+	}
+	if percentage > 100 {
+		err = erro.ErrBadRequest
+		return nil, err
+	}
+
+	// prepare data
+	allocation.Product = resInventory.Product
+	allocation.CreatedAt = time.Now()
+
+	res_allocation, err := s.workerRepository.UpsertChannelAllocation(ctx, tx, allocation)
+	if err != nil {
+		return nil, err
+	}
+
+	list_allocation = append(list_allocation, *res_allocation)
+	_, channelAllocation := computeChannelAvailability(resInventory.Available, list_allocation, res_allocation.Channel)
+
+	return channelAllocation, nil
+}
+
+// About delete the allocation of a sales channel, its stock goes back to the shared pool
+func (s *WorkerService) DeleteChannelAllocation(ctx context.Context, allocation *model.ChannelAllocation) error{
+	s.logger.Info().
+			Ctx(ctx).
+			Str("func","DeleteChannelAllocation").Send()
+
+	// Trace
+	ctx, span := s.tracerProvider.SpanCtx(ctx, "service.DeleteChannelAllocation", trace.SpanKindServer)
+	defer span.End()
+
+	// the caller may be restricted to some skus
+	if err := authorizeSku(ctx, allocation.Product.Sku); err != nil {
+		return err
+	}
+
+	// prepare database
+	tx, err := s.workerRepository.StartTx(ctx)
+	if err != nil {
+		span.RecordError(err)
+        span.SetStatus(codes.Error, err.Error())
+		return err
+	}
+
+	// handle connection and transaction
+	defer func() {
+		if err != nil {
+			tx.Rollback(ctx)
+		} else {
+			tx.Commit(ctx)
+		}
+	}()
+
+	resProduct, err := s.workerRepository.GetProduct(ctx, &allocation.Product)
+	if err != nil {
+		return err
+	}
+	allocation.Product = *resProduct
+
+	row, err := s.workerRepository.DeleteChannelAllocation(ctx, tx, allocation)
+	if err != nil {
+		return err
+	}
+	if row == 0 {
+		err = erro.ErrNotFound
+		return err
+	}
+
+	return nil
+}
+
+// About list the allocations of a product with the availability of each channel
+func (s *WorkerService) ListChannelAllocation(ctx context.Context, product *model.Product) (*[]model.ChannelAllocation, error){
+	result, err := s.callRepositoryRead(ctx, "ListChannelAllocation", func(ctx context.Context) (interface{}, error) {
+		if err := authorizeSku(ctx, product.Sku); err != nil {
+			return nil, err
+		}
+
+		resInventory, err := s.workerRepository.GetInventory(ctx, &model.Inventory{Product: *product})
+		if err != nil {
+			return nil, err
+		}
+
+		allocations, err := s.workerRepository.ListChannelAllocation(ctx, &resInventory.Product)
+		if err != nil {
+			return nil, err
+		}
+
+		computeChannelAvailability(resInventory.Available, *allocations, "")
+		for i := range *allocations {
+			(*allocations)[i].Product = resInventory.Product
+		}
+
+		return allocations, nil
+	})
+
+	if err != nil {
+		return nil, err
+	}
+	return result.(*[]model.ChannelAllocation), nil
+}
@@ -0,0 +1,61 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/go-inventory/internal/domain/model"
+)
+
+func TestComputeChannelAvailability(t *testing.T) {
+	tests := []struct {
+		name		string
+		available	int
+		allocations	[]model.ChannelAllocation
+		channel		string
+		want		int
+		wantPool	bool
+	}{
+		{name: "without allocations", available: 100, channel: "web", want: 100},
+		{name: "fixed pool", available: 100, allocations: []model.ChannelAllocation{
+			{Channel: "store", Type: model.AllocationFixed, Quantity: 30},
+		}, channel: "store", want: 30, wantPool: true},
+		{name: "remainder after fixed", available: 100, allocations: []model.ChannelAllocation{
+			{Channel: "store", Type: model.AllocationFixed, Quantity: 30},
+		}, channel: "web", want: 70},
+		{name: "fixed above available", available: 10, allocations: []model.ChannelAllocation{
+			{Channel: "store", Type: model.AllocationFixed, Quantity: 30},
+		}, channel: "store", want: 10, wantPool: true},
+		{name: "fixed pools above available", available: 10, allocations: []model.ChannelAllocation{
+			{Channel: "marketplace", Type: model.AllocationFixed, Quantity: 8},
+			{Channel: "store", Type: model.AllocationFixed, Quantity: 8},
+		}, channel: "marketplace", want: 8, wantPool: true},
+		{name: "fixed pools above available, the last pool", available: 10, allocations: []model.ChannelAllocation{
+			{Channel: "marketplace", Type: model.AllocationFixed, Quantity: 8},
+			{Channel: "store", Type: model.AllocationFixed, Quantity: 8},
+		}, channel: "store", want: 2, wantPool: true},
+		{name: "percentage of the shared stock", available: 100, allocations: []model.ChannelAllocation{
+			{Channel: "store", Type: model.AllocationFixed, Quantity: 20},
+			{Channel: "marketplace", Type: model.AllocationPercentage, Percentage: 25},
+		}, channel: "marketplace", want: 20, wantPool: true},
+		{name: "remainder after percentage", available: 100, allocations: []model.ChannelAllocation{
+			{Channel: "store", Type: model.AllocationFixed, Quantity: 20},
+			{Channel: "marketplace", Type: model.AllocationPercentage, Percentage: 25},
+		}, channel: "web", want: 60},
+		{name: "nothing left", available: 10, allocations: []model.ChannelAllocation{
+			{Channel: "store", Type: model.AllocationFixed, Quantity: 30},
+			{Channel: "marketplace", Type: model.AllocationPercentage, Percentage: 50},
+		}, channel: "web", want: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, pool := computeChannelAvailability(tt.available, tt.allocations, tt.channel)
+			if got != tt.want {
+				t.Errorf("got %d, want %d", got, tt.want)
+			}
+			if (pool != nil) != tt.wantPool {
+				t.Errorf("got pool %v, want pool %v", pool != nil, tt.wantPool)
+			}
+		})
+	}
+}
@@ -5,14 +5,114 @@ import (
 	"context"
 
 	"github.com/go-inventory/internal/domain/model"
+	"github.com/go-inventory/shared/erro"
+	"github.com/go-inventory/shared/security"
 	"go.opentelemetry.io/otel/trace"
 	"go.opentelemetry.io/otel/codes"
 )
 
+// Allowed moves between stock buckets, damaged stock is written off and can not leave
+var bucketTransitions = map[string]map[string]bool{
+	model.BucketAvailable:	{model.BucketQuarantine: true, model.BucketHold: true, model.BucketDamaged: true},
+	model.BucketQuarantine:	{model.BucketAvailable: true, model.BucketHold: true, model.BucketDamaged: true},
+	model.BucketHold:		{model.BucketAvailable: true, model.BucketQuarantine: true, model.BucketDamaged: true},
+}
+
+// Helper function to record the current stock buckets of a inventory in the time series, and to publish the change
+func (s *WorkerService) addInventorySnapshot(ctx context.Context, tx Tx, inventory *model.Inventory, previous *model.Inventory) error {
+	timeSeries := model.Inventory{
+		Product:	inventory.Product,
+		Available:	inventory.Available,
+		Quarantine:	inventory.Quarantine,
+		Damaged:	inventory.Damaged,
+		Hold:		inventory.Hold,
+		CreatedAt:	time.Now(),
+	}
+
+	_, err := s.workerRepository.AddInventoryTimeSeries(ctx, tx, &timeSeries)
+	if err != nil {
+		return err
+	}
+
+	err = s.addInventoryEvents(ctx, tx, inventory, previous)
+	if err != nil {
+		return err
+	}
+
+	return s.notifyInventoryChanged(ctx, tx, inventory)
+}
+
+// Helper function to move stock between buckets of a product and record it in the time series, without the checks
+// of the transitions allowed to the clients (the caller owns the move)
+func (s *WorkerService) moveStock(ctx context.Context, tx Tx, product *model.Product, transfers ...model.InventoryTransfer) (*model.Inventory, error) {
+	resInventory, err := s.workerRepository.GetInventoryForUpdate(ctx, tx, &model.Inventory{Product: *product})
+	if err != nil {
+		return nil, err
+	}
+
+	previous := *resInventory
+	buckets := map[string]*int{
+		model.BucketAvailable:	&resInventory.Available,
+		model.BucketQuarantine:	&resInventory.Quarantine,
+		model.BucketDamaged:	&resInventory.Damaged,
+		model.BucketHold:		&resInventory.Hold,
+		model.BucketSold:		&resInventory.Sold,
+		model.BucketReserved:	&resInventory.Reserved,
+	}
+
+	for _, transfer := range transfers {
+		if transfer.Quantity == 0 {
+			continue
+		}
+
+		now := time.Now()
+		resInventory.UpdatedAt = &now
+
+		row, err := s.workerRepository.TransferInventory(ctx, tx, resInventory, &transfer)
+		if err != nil {
+			return nil, err
+		}
+		if row == 0 {
+			return nil, erro.ErrInsufficientStock
+		}
+
+		*buckets[transfer.From] -= transfer.Quantity
+		*buckets[transfer.To] += transfer.Quantity
+	}
+
+	err = s.addInventorySnapshot(ctx, tx, resInventory, &previous)
+	if err != nil {
+		return nil, err
+	}
+
+	return resInventory, nil
+}
+
 // About get inventory
 func (s * WorkerService) GetInventory(ctx context.Context, inventory *model.Inventory) (*model.Inventory, error){
 	result, err := s.callRepositoryRead(ctx, "GetInventory", func(ctx context.Context) (interface{}, error) {
-		return s.workerRepository.GetInventory(ctx, inventory)
+		if err := authorizeSku(ctx, inventory.Product.Sku); err != nil {
+			return nil, err
+		}
+
+		resInventory, err := cachedRead(s, ctx, inventoryCacheKey(security.TenantFrom(ctx), inventory.Product.Sku), func(ctx context.Context) (*model.Inventory, error) {
+			return s.workerRepository.GetInventory(ctx, &model.Inventory{Product: model.Product{Sku: inventory.Product.Sku}})
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		// the availability of a sales channel depends on its allocation
+		if inventory.Channel != "" {
+			allocations, err := s.workerRepository.ListChannelAllocation(ctx, &resInventory.Product)
+			if err != nil {
+				return nil, err
+			}
+			resInventory.Channel = inventory.Channel
+			resInventory.ChannelAvailable, _ = computeChannelAvailability(resInventory.Available, *allocations, inventory.Channel)
+		}
+
+		return resInventory, nil
 	})
 	
 	if err != nil {
@@ -21,6 +121,27 @@ func (s * WorkerService) GetInventory(ctx context.Context, inventory *model.Inve
 	return result.(*model.Inventory), nil
 }
 
+// About get the inventories of several products in one query, indexed by sku
+func (s * WorkerService) GetInventoryBatch(ctx context.Context, skus []string) (map[string]*model.Inventory, error){
+	result, err := s.callRepositoryRead(ctx, "GetInventoryBatch", func(ctx context.Context) (interface{}, error) {
+		if err := authorizeSku(ctx, skus...); err != nil {
+			return nil, err
+		}
+		return s.workerRepository.ListInventoryBySku(ctx, skus)
+	})
+	
+	if err != nil {
+		return nil, err
+	}
+
+	map_inventory := map[string]*model.Inventory{}
+	list_inventory := result.(*[]model.Inventory)
+	for i := range *list_inventory {
+		map_inventory[(*list_inventory)[i].Product.Sku] = &(*list_inventory)[i]
+	}
+	return map_inventory, nil
+}
+
 // About update inventory
 func (s * WorkerService) UpdateInventory(ctx context.Context, inventory *model.Inventory) (*model.Inventory, error){
 	s.logger.Info().
@@ -30,9 +151,14 @@ func (s * WorkerService) UpdateInventory(ctx context.Context, inventory *model.I
 	// Trace
 	ctx, span := s.tracerProvider.SpanCtx(ctx, "service.UpdateInventory", trace.SpanKindServer)
 	defer span.End()
-	
+
+	// the caller may be restricted to some skus
+	if err := authorizeSku(ctx, inventory.Product.Sku); err != nil {
+		return nil, err
+	}
+
 	// prepare database
-	tx, conn, err := s.workerRepository.DatabasePG.StartTx(ctx)
+	tx, err := s.workerRepository.StartTx(ctx)
 	if err != nil {
 		span.RecordError(err) 
         span.SetStatus(codes.Error, err.Error())
@@ -46,44 +172,76 @@ func (s * WorkerService) UpdateInventory(ctx context.Context, inventory *model.I
 		} else {
 			tx.Commit(ctx)
 		}
-		s.workerRepository.DatabasePG.ReleaseTx(conn)
 	}()
 
-	// Get product info
-	resInventory, err := s.workerRepository.GetInventory(ctx, inventory)
+	// Get product info in the transaction, locking it, so the version checked, the previous values and the
+	// row inserted by the fallback are the ones of the primary
+	var resInventory *model.Inventory
+	if inventory.Channel != "" {
+		// a sales channel can only consume the stock allocated to it
+		resInventory, err = s.consumeChannel(ctx, tx, inventory)
+	} else {
+		resInventory, err = s.workerRepository.GetInventoryForUpdate(ctx, tx, inventory)
+	}
 	if err != nil {
 		return nil, err
 	}
 
+	// when a version is informed (If-Match) it must match the current one
+	if inventory.Version > 0 && inventory.Version != resInventory.Version {
+		err = erro.ErrPreconditionFailed
+		return nil, err
+	}
+
 	// set data for update
 	now := time.Now()
 	inventory.UpdatedAt = &now
 	inventory.ID = resInventory.ID
 	inventory.Product = resInventory.Product
 
-	// Call a service
-	row, err := s.workerRepository.UpdateInventory(ctx, tx, inventory)
-	if err != nil {
-		return nil, err
-	}
-	
-	// whenever zero rows was updated, due to the skip lock clause, a new row must be inserted
-	if row == 0 {
-		_, err := s.workerRepository.AddInventory(ctx, tx, resInventory)
+	var row int64
+	if inventory.Version > 0 {
+		// optimistic lock, the row must be updated exactly with the version informed
+		row, err = s.workerRepository.UpdateInventoryIfMatch(ctx, tx, inventory)
 		if err != nil {
 			return nil, err
 		}
+		if row == 0 {
+			err = erro.ErrPreconditionFailed
+			return nil, err
+		}
+	} else {
+		// Call a service
+		row, err = s.workerRepository.UpdateInventory(ctx, tx, inventory)
+		if err != nil {
+			return nil, err
+		}
+		
+		// whenever zero rows was updated, due to the skip lock clause, a new row must be inserted
+		if row == 0 {
+			_, err = s.workerRepository.AddInventory(ctx, tx, resInventory)
+			if err != nil {
+				return nil, err
+			}
+		}
 	}
 
+	previous := *resInventory
 	resInventory.Available = inventory.Available + resInventory.Available
 	resInventory.Reserved = inventory.Reserved + resInventory.Reserved
 	resInventory.Pending = inventory.Pending + resInventory.Pending
 	resInventory.Sold = inventory.Sold + resInventory.Sold
 	resInventory.UpdatedAt = inventory.UpdatedAt	
+	if row > 0 {
+		resInventory.Version = inventory.Version
+	}
 
 	// create a time series for inventory only for sold products (order checkout)
 	inventory.Available = resInventory.Available
 	inventory.CreatedAt = time.Now()
+	inventory.Quarantine = resInventory.Quarantine
+	inventory.Damaged = resInventory.Damaged
+	inventory.Hold = resInventory.Hold
 	// pending can be negative when an order is completed, but in the time series we want to keep it as zero to avoid confusion in the reports
 	if inventory.Pending < 0 {
 		inventory.Pending = 0
@@ -93,6 +251,16 @@ func (s * WorkerService) UpdateInventory(ctx context.Context, inventory *model.I
 		return nil, err
 	}
 
+	err = s.addInventoryEvents(ctx, tx, resInventory, &previous)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.notifyInventoryChanged(ctx, tx, resInventory)
+	if err != nil {
+		return nil, err
+	}
+
 	return resInventory, nil
 }
 
@@ -105,5 +273,81 @@ func (s * WorkerService) ListInventory(ctx context.Context, limit int, offset in
 	if err != nil {
 		return nil, err
 	}
-	return result.(*[]model.Inventory), nil
+
+	// the skus the caller can not access are left out of the page
+	list_inventory := filterSku(ctx, *result.(*[]model.Inventory), func(i *model.Inventory) string { return i.Product.Sku })
+	return &list_inventory, nil
+}
+
+// About move stock between buckets (available, quarantine, damaged, hold)
+func (s * WorkerService) TransferInventory(ctx context.Context, transfer *model.InventoryTransfer) (*model.Inventory, error){
+	s.logger.Info().
+			Ctx(ctx).
+			Str("func","TransferInventory").Send()
+	
+	// Trace
+	ctx, span := s.tracerProvider.SpanCtx(ctx, "service.TransferInventory", trace.SpanKindServer)
+	defer span.End()
+
+	if transfer.Quantity <= 0 || !bucketTransitions[transfer.From][transfer.To] {
+		return nil, erro.ErrBadRequest
+	}
+
+	// the caller may be restricted to some skus
+	if err := authorizeSku(ctx, transfer.Product.Sku); err != nil {
+		return nil, err
+	}
+
+	// prepare database
+	tx, err := s.workerRepository.StartTx(ctx)
+	if err != nil {
+		span.RecordError(err) 
+        span.SetStatus(codes.Error, err.Error())
+		return nil, err
+	}
+
+	// handle connection and transaction
+	defer func() {
+		if err != nil {
+			tx.Rollback(ctx)
+		} else {
+			tx.Commit(ctx)
+		}
+	}()
+
+	// Get and lock the inventory
+	resInventory, err := s.workerRepository.GetInventoryForUpdate(ctx, tx, &model.Inventory{Product: transfer.Product})
+	if err != nil {
+		return nil, err
+	}
+
+	previous := *resInventory
+	now := time.Now()
+	resInventory.UpdatedAt = &now
+
+	row, err := s.workerRepository.TransferInventory(ctx, tx, resInventory, transfer)
+	if err != nil {
+		return nil, err
+	}
+	if row == 0 {
+		err = erro.ErrInsufficientStock
+		return nil, err
+	}
+
+	// reflect the move in the buckets returned
+	buckets := map[string]*int{
+		model.BucketAvailable:	&resInventory.Available,
+		model.BucketQuarantine:	&resInventory.Quarantine,
+		model.BucketDamaged:	&resInventory.Damaged,
+		model.BucketHold:		&resInventory.Hold,
+	}
+	*buckets[transfer.From] -= transfer.Quantity
+	*buckets[transfer.To] += transfer.Quantity
+
+	err = s.addInventorySnapshot(ctx, tx, resInventory, &previous)
+	if err != nil {
+		return nil, err
+	}
+
+	return resInventory, nil
 }
@@ -0,0 +1,291 @@
+package service
+
+import (
+	"time"
+	"errors"
+	"context"
+
+	"github.com/go-inventory/internal/domain/model"
+	"github.com/go-inventory/shared/erro"
+	"github.com/go-inventory/shared/security"
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/codes"
+
+	go_core_midleware "github.com/eliezerraj/go-core/v2/middleware"
+)
+
+// OrderBroker delivers the order events to a handler, the event whose handler fails is delivered again.
+// The messages that are not a valid order event are dropped by the broker
+type OrderBroker interface {
+	Consume(ctx context.Context, handler func(context.Context, *model.OrderEvent) error) error
+	Close() error
+}
+
+// Helper function to tell whether a order event can never be applied (not found, invalid, insufficient stock,
+// invalid status), the others may be applied when delivered again
+func orderEventRejected(err error) bool {
+	if erro.IsRetryable(err) {
+		return false
+	}
+	switch erro.KindOf(err) {
+	case erro.KindInvalid, erro.KindNotFound, erro.KindConflict:
+		return true
+	}
+	return false
+}
+
+// Helper function to sum the quantities of the items of the same sku
+func mergeOrderItems(items []model.OrderItem) []model.OrderItem {
+	list_item := []model.OrderItem{}
+	index := map[string]int{}
+	for _, item := range items {
+		if i, ok := index[item.Sku]; ok {
+			list_item[i].Quantity += item.Quantity
+			continue
+		}
+		index[item.Sku] = len(list_item)
+		list_item = append(list_item, item)
+	}
+	return list_item
+}
+
+// Helper function to apply a order event to a item. The inventory is locked first, so the events of the same
+// sku are serialized and the reservation found is the current one. Each step is applied once whatever the
+// order of arrival: a late created after a cancelled or a paid is a no-op
+func (s *WorkerService) applyOrderItem(ctx context.Context, tx Tx, event *model.OrderEvent, item model.OrderItem) error {
+	resInventory, err := s.workerRepository.GetInventoryForUpdate(ctx, tx, &model.Inventory{Product: model.Product{Sku: item.Sku}})
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	reservation := model.OrderReservation{	OrderID: event.OrderID,
+											Product: resInventory.Product,
+											Quantity: item.Quantity,
+											CreatedAt: now}
+
+	resReservation, err := s.workerRepository.GetOrderReservationForUpdate(ctx, tx, &reservation)
+	if err != nil && !errors.Is(err, erro.ErrNotFound) {
+		return err
+	}
+
+	// first step of the item, the stock is taken from available
+	if resReservation == nil {
+		switch event.Type {
+		case model.OrderCreated:
+			reservation.Status = model.ReservationReserved
+			_, err = s.moveStock(ctx, tx, &reservation.Product, model.InventoryTransfer{	From: model.BucketAvailable,
+																							To: model.BucketReserved,
+																							Quantity: item.Quantity})
+		case model.OrderPaid, model.OrderShipped:
+			reservation.Status = model.ReservationConfirmed
+			_, err = s.moveStock(ctx, tx, &reservation.Product, model.InventoryTransfer{	From: model.BucketAvailable,
+																							To: model.BucketSold,
+																							Quantity: item.Quantity})
+		case model.OrderCancelled:
+			// nothing held, recorded so a late created does not reserve
+			reservation.Status = model.ReservationReleased
+		}
+		if err != nil {
+			return err
+		}
+
+		_, err = s.workerRepository.AddOrderReservation(ctx, tx, &reservation)
+		return err
+	}
+
+	// next steps move the quantity reserved
+	var transfer model.InventoryTransfer
+	switch {
+	case event.Type == model.OrderCreated:
+		return nil
+	case resReservation.Status == model.ReservationReserved && (event.Type == model.OrderPaid || event.Type == model.OrderShipped):
+		resReservation.Status = model.ReservationConfirmed
+		transfer = model.InventoryTransfer{From: model.BucketReserved, To: model.BucketSold, Quantity: resReservation.Quantity}
+	case resReservation.Status == model.ReservationReserved && event.Type == model.OrderCancelled:
+		resReservation.Status = model.ReservationReleased
+		transfer = model.InventoryTransfer{From: model.BucketReserved, To: model.BucketAvailable, Quantity: resReservation.Quantity}
+	case resReservation.Status == model.ReservationConfirmed && event.Type != model.OrderCancelled,
+		 resReservation.Status == model.ReservationReleased && event.Type == model.OrderCancelled:
+		return nil
+	default:
+		// paid after cancelled, or cancelled after sold (the stock comes back through a rma)
+		return erro.ErrInvalidStatus
+	}
+
+	_, err = s.moveStock(ctx, tx, &resReservation.Product, transfer)
+	if err != nil {
+		return err
+	}
+
+	resReservation.UpdatedAt = &now
+	_, err = s.workerRepository.UpdateOrderReservation(ctx, tx, resReservation)
+	return err
+}
+
+// About apply a order event to the inventory of its items, all or none, returning false when the event was
+// already consumed. The event is recorded as consumed in the same transaction
+func (s *WorkerService) ApplyOrderEvent(ctx context.Context, event *model.OrderEvent) (bool, error){
+	s.logger.Info().
+			Ctx(ctx).
+			Str("func","ApplyOrderEvent").Send()
+
+	// Trace
+	ctx, span := s.tracerProvider.SpanCtx(ctx, "service.ApplyOrderEvent", trace.SpanKindConsumer)
+	defer span.End()
+
+	// prepare database
+	tx, err := s.workerRepository.StartTx(ctx)
+	if err != nil {
+		span.RecordError(err)
+        span.SetStatus(codes.Error, err.Error())
+		return false, err
+	}
+
+	// handle connection and transaction
+	defer func() {
+		if err != nil {
+			tx.Rollback(ctx)
+		} else {
+			tx.Commit(ctx)
+		}
+	}()
+
+	row, err := s.workerRepository.AddConsumedEvent(ctx, tx, &model.ConsumedEvent{	EventID: event.ID,
+																					Type: event.Type,
+																					OrderID: event.OrderID,
+																					Status: model.OrderEventApplied,
+																					CreatedAt: time.Now()})
+	if err != nil {
+		return false, err
+	}
+	if row == 0 {
+		return false, nil
+	}
+
+	for _, item := range mergeOrderItems(event.Items) {
+		err = s.applyOrderItem(ctx, tx, event, item)
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+			return false, err
+		}
+	}
+
+	return true, nil
+}
+
+// About reject a order event that can never be applied, it is recorded as consumed (so a redelivery is skipped)
+// and a OrderRejected event tells the order service why
+func (s *WorkerService) RejectOrderEvent(ctx context.Context, event *model.OrderEvent, cause error) error{
+	s.logger.Info().
+			Ctx(ctx).
+			Str("func","RejectOrderEvent").Send()
+
+	// Trace
+	ctx, span := s.tracerProvider.SpanCtx(ctx, "service.RejectOrderEvent", trace.SpanKindConsumer)
+	defer span.End()
+
+	// prepare database
+	tx, err := s.workerRepository.StartTx(ctx)
+	if err != nil {
+		span.RecordError(err)
+        span.SetStatus(codes.Error, err.Error())
+		return err
+	}
+
+	// handle connection and transaction
+	defer func() {
+		if err != nil {
+			tx.Rollback(ctx)
+		} else {
+			tx.Commit(ctx)
+		}
+	}()
+
+	consumed := model.ConsumedEvent{	EventID: event.ID,
+										Type: event.Type,
+										OrderID: event.OrderID,
+										Status: model.OrderEventRejected,
+										Error: erro.As(cause).Code,
+										CreatedAt: time.Now()}
+
+	row, err := s.workerRepository.AddConsumedEvent(ctx, tx, &consumed)
+	if err != nil {
+		return err
+	}
+	if row == 0 {
+		return nil
+	}
+
+	err = s.addOutboxEvent(ctx, tx, model.EventTypeOrderRejected, event.OrderID, consumed)
+	return err
+}
+
+// About handle a order event delivered by the broker, a nil error acknowledges it. A event that can never be
+// applied is rejected and acknowledged, the others fail so the broker delivers them again. The event is applied
+// in its tenant, the default tenant when it has none. The changes are audited with the event id as request id
+func (s *WorkerService) HandleOrderEvent(ctx context.Context, event *model.OrderEvent) error {
+	tenant := event.Tenant
+	if tenant == "" {
+		tenant = s.appServer.TenantConfig.Default
+	}
+	ctx = security.WithTenant(ctx, tenant)
+	ctx = security.WithRoute(ctx, "event " + event.Type)
+	ctx = context.WithValue(ctx, go_core_midleware.RequestIDKey, event.ID)
+
+	applied, err := s.ApplyOrderEvent(ctx, event)
+	if err == nil {
+		if !applied {
+			s.logger.Info().
+					Ctx(ctx).
+					Str("event_id", event.ID).
+					Msg("order event already consumed")
+		}
+		return nil
+	}
+
+	if !orderEventRejected(err) {
+		return err
+	}
+
+	s.logger.Warn().
+			Ctx(ctx).
+			Err(err).
+			Str("event_id", event.ID).
+			Str("order_id", event.OrderID).
+			Msg("order event rejected")
+
+	return s.RejectOrderEvent(ctx, event, err)
+}
+
+// About consume the order events until the context is done, the broker is consumed again after a failure
+func (s *WorkerService) StartOrderConsumer(ctx context.Context, broker OrderBroker) {
+	s.logger.Info().
+			Ctx(ctx).
+			Str("func","StartOrderConsumer").Send()
+
+	defer broker.Close()
+
+	for attempts := 1; ; attempts++ {
+		err := broker.Consume(ctx, s.HandleOrderEvent)
+		if ctx.Err() != nil {
+			return
+		}
+		if err == nil {
+			attempts = 0
+		} else {
+			s.logger.Error().
+					Ctx(ctx).
+					Err(err).
+					Int("attempts", attempts).
+					Msg("order consumer FAILED")
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(outboxBackoff(attempts)):
+		}
+	}
+}
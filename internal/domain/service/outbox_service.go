@@ -0,0 +1,196 @@
+package service
+
+import (
+	"time"
+	"context"
+	"encoding/json"
+
+	"github.com/google/uuid"
+
+	"github.com/go-inventory/internal/domain/model"
+	"github.com/go-inventory/shared/security"
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/codes"
+)
+
+// longest wait between two attempts of a event
+const maxOutboxBackoff = 5 * time.Minute
+
+// EventPublisher delivers a event to the broker, a nil error means the broker acknowledged it
+type EventPublisher interface {
+	Publish(ctx context.Context, event *model.CloudEvent) error
+	Close() error
+}
+
+// Helper function to write a domain event in the outbox, inside the transaction of the change
+func (s *WorkerService) addOutboxEvent(ctx context.Context, tx Tx, eventType string, subject string, data interface{}) error {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+
+	event := model.OutboxEvent{
+		EventID:	uuid.New().String(),
+		Type:		eventType,
+		Source:		"/" + s.appServer.Application.Name,
+		Subject:	subject,
+		Actor:		security.SubjectFrom(ctx),
+		Data:		payload,
+		Status:		model.OutboxPending,
+		CreatedAt:	time.Now(),
+	}
+
+	_, err = s.workerRepository.AddOutboxEvent(ctx, tx, &event)
+	return err
+}
+
+// Helper function to write the events of a inventory change, StockDepleted when the change took the last available unit,
+// to queue the alerts of the webhooks and to audit the change against the inventory before it
+func (s *WorkerService) addInventoryEvents(ctx context.Context, tx Tx, inventory *model.Inventory, previous *model.Inventory) error {
+	err := s.addOutboxEvent(ctx, tx, model.EventTypeInventoryChanged, inventory.Product.Sku, inventory)
+	if err != nil {
+		return err
+	}
+
+	previousAvailable := previous.Available
+	if previousAvailable > 0 && inventory.Available <= 0 {
+		err = s.addOutboxEvent(ctx, tx, model.EventTypeStockDepleted, inventory.Product.Sku, inventory)
+		if err != nil {
+			return err
+		}
+	}
+
+	err = s.addWebhookDeliveries(ctx, tx, inventory, previousAvailable)
+	if err != nil {
+		return err
+	}
+
+	return s.addAuditRecord(ctx, tx, model.AuditEntityInventory, model.AuditActionUpdate, inventory.Product.Sku, previous, inventory)
+}
+
+// Helper function to compute the wait before the next attempt (exponential)
+func outboxBackoff(attempts int) time.Duration {
+	backoff := time.Second << min(attempts, 10)
+	return min(backoff, maxOutboxBackoff)
+}
+
+// About publish a batch of pending events, returning how many were published. The events stay locked until the
+// commit, so the pods share the outbox without publishing the same event twice. A event published whose commit
+// fails is published again (at-least-once), consumers deduplicate by the id
+func (s *WorkerService) RelayOutbox(ctx context.Context, publisher EventPublisher) (int, error){
+	s.logger.Debug().
+			Ctx(ctx).
+			Str("func","RelayOutbox").Send()
+
+	// Trace
+	ctx, span := s.tracerProvider.SpanCtx(ctx, "service.RelayOutbox", trace.SpanKindServer)
+	defer span.End()
+
+	outboxConfig := s.appServer.OutboxConfig
+
+	// prepare database
+	tx, err := s.workerRepository.StartTx(ctx)
+	if err != nil {
+		span.RecordError(err)
+        span.SetStatus(codes.Error, err.Error())
+		return 0, err
+	}
+
+	// handle connection and transaction
+	defer func() {
+		if err != nil {
+			tx.Rollback(ctx)
+		} else {
+			tx.Commit(ctx)
+		}
+	}()
+
+	list_event, err := s.workerRepository.ListOutboxPendingForUpdate(ctx, tx, outboxConfig.BatchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	published := 0
+	for i := range *list_event {
+		event := &(*list_event)[i]
+
+		cloudEvent := model.CloudEvent{
+			SpecVersion:		"1.0",
+			ID:					event.EventID,
+			Source:				event.Source,
+			Type:				event.Type,
+			Subject:			event.Subject,
+			Time:				event.CreatedAt,
+			Actor:				event.Actor,
+			Tenant:				event.Tenant,
+			DataContentType:	"application/json",
+			Data:				event.Data,
+		}
+
+		event.Attempts++
+		publishCtx, cancel := context.WithTimeout(ctx, time.Duration(s.appServer.Server.CtxTimeout) * time.Second)
+		errPublish := publisher.Publish(publishCtx, &cloudEvent)
+		cancel()
+		if errPublish == nil {
+			now := time.Now()
+			event.Status = model.OutboxPublished
+			event.PublishedAt = &now
+			event.LastError = ""
+			published++
+		} else {
+			s.logger.Warn().
+					Ctx(ctx).
+					Err(errPublish).
+					Str("event_id", event.EventID).
+					Int("attempts", event.Attempts).
+					Msg("outbox event not published")
+
+			event.LastError = errPublish.Error()
+			event.NextAttemptAt = time.Now().Add(outboxBackoff(event.Attempts))
+			if outboxConfig.MaxAttempts > 0 && event.Attempts >= outboxConfig.MaxAttempts {
+				event.Status = model.OutboxFailed
+			}
+		}
+
+		_, err = s.workerRepository.UpdateOutboxEvent(ctx, tx, event)
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	return published, nil
+}
+
+// About relay the outbox until the context is done, a full batch is followed by the next one without waiting
+func (s *WorkerService) StartOutboxRelay(ctx context.Context, publisher EventPublisher) {
+	s.logger.Info().
+			Ctx(ctx).
+			Str("func","StartOutboxRelay").Send()
+
+	// the relay publishes the events of every tenant
+	ctx = security.WithTenant(ctx, security.SystemTenant)
+
+	defer publisher.Close()
+
+	interval := time.Duration(s.appServer.OutboxConfig.PollInterval) * time.Millisecond
+	for {
+		published, err := s.RelayOutbox(ctx, publisher)
+		if err != nil && ctx.Err() == nil {
+			s.logger.Error().
+					Ctx(ctx).
+					Err(err).
+					Msg("outbox relay FAILED")
+		}
+
+		wait := interval
+		if err == nil && published >= s.appServer.OutboxConfig.BatchSize {
+			wait = 0
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(wait):
+		}
+	}
+}
@@ -8,23 +8,28 @@ import (
 
 	"github.com/go-inventory/internal/domain/model"
 	"github.com/go-inventory/shared/erro"
+	"github.com/go-inventory/shared/security"
 	"go.opentelemetry.io/otel/trace"
 	"go.opentelemetry.io/otel/codes"
-	
-	database "github.com/go-inventory/internal/infrastructure/repo/database"
 
 	go_core_db_pg "github.com/eliezerraj/go-core/v2/database/postgre"
 	go_core_otel_trace "github.com/eliezerraj/go-core/v2/otel/trace"
 )
 
 type WorkerService struct {
-	workerRepository *database.WorkerRepository
+	appServer		*model.AppServer
+	workerRepository Repository
 	logger 			*zerolog.Logger
 	tracerProvider 	*go_core_otel_trace.TracerProvider
+	inventoryHub	*InventoryHub
+	cache			Cache
+	flight			flightGroup
 }
 
-// About new worker service
-func NewWorkerService(	workerRepository *database.WorkerRepository, 
+// About new worker service, a nil cache sends every read to the database
+func NewWorkerService(	appServer		*model.AppServer,
+						workerRepository Repository, 
+						cache			Cache,
 						appLogger 		*zerolog.Logger,
 						tracerProvider 	*go_core_otel_trace.TracerProvider) *WorkerService{
 							
@@ -35,9 +40,12 @@ func NewWorkerService(	workerRepository *database.WorkerRepository,
 			Str("func","NewWorkerService").Send()
 
 	return &WorkerService{
+		appServer: appServer,
 		workerRepository: workerRepository,
 		logger: &logger,
 		tracerProvider: tracerProvider,
+		inventoryHub: NewInventoryHub(appServer.Server.StreamBufferSize, &logger),
+		cache: cache,
 	}
 }
 
@@ -73,8 +81,8 @@ func (s * WorkerService) HealthCheck(ctx context.Context) error{
 	defer span.End()
 
 	// Check database health
-	ctx, spanDB := s.tracerProvider.SpanCtx(ctx, "DatabasePG.Ping", trace.SpanKindClient)
-	err := s.workerRepository.DatabasePG.Ping()
+	ctx, spanDB := s.tracerProvider.SpanCtx(ctx, "Repository.Ping", trace.SpanKindClient)
+	err := s.workerRepository.Ping(ctx)
 	spanDB.End()
 	
 	if err != nil {
@@ -104,8 +112,13 @@ func (s *WorkerService) AddProduct(ctx context.Context,
 	ctx, span := s.tracerProvider.SpanCtx(ctx, "service.AddProduct", trace.SpanKindServer)
 	defer span.End()
 
+	// the caller may be restricted to some skus
+	if err := authorizeSku(ctx, product.Sku); err != nil {
+		return nil, err
+	}
+
 	// prepare database
-	tx, conn, err := s.workerRepository.DatabasePG.StartTx(ctx)
+	tx, err := s.workerRepository.StartTx(ctx)
 	if err != nil {
 		span.RecordError(err) 
         span.SetStatus(codes.Error, err.Error())
@@ -119,7 +132,6 @@ func (s *WorkerService) AddProduct(ctx context.Context,
 		} else {
 			tx.Commit(ctx)
 		}
-		s.workerRepository.DatabasePG.ReleaseTx(conn)
 	}()
 
 	// prepare data
@@ -149,13 +161,33 @@ func (s *WorkerService) AddProduct(ctx context.Context,
 		return nil, err
 	}
 
+	// the event is committed with the product
+	err = s.addOutboxEvent(ctx, tx, model.EventTypeProductCreated, res_product.Sku, res_inventory)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.addAuditRecord(ctx, tx, model.AuditEntityProduct, model.AuditActionCreate, res_product.Sku, nil, res_product)
+	if err != nil {
+		return nil, err
+	}
+	err = s.addAuditRecord(ctx, tx, model.AuditEntityInventory, model.AuditActionCreate, res_product.Sku, nil, res_inventory)
+	if err != nil {
+		return nil, err
+	}
+
 	return res_inventory, nil
 }
 
 // About get a product
 func (s * WorkerService) GetProduct(ctx context.Context, product *model.Product) (*model.Product, error){
 	result, err := s.callRepositoryRead(ctx, "GetProduct", func(ctx context.Context) (interface{}, error) {
-		return s.workerRepository.GetProduct(ctx, product)
+		if err := authorizeSku(ctx, product.Sku); err != nil {
+			return nil, err
+		}
+		return cachedRead(s, ctx, productCacheKey(security.TenantFrom(ctx), product.Sku), func(ctx context.Context) (*model.Product, error) {
+			return s.workerRepository.GetProduct(ctx, product)
+		})
 	})
 
 	if err != nil {
@@ -167,7 +199,17 @@ func (s * WorkerService) GetProduct(ctx context.Context, product *model.Product)
 // About get a product by ID
 func (s * WorkerService) GetProductId(ctx context.Context, product *model.Product) (*model.Product, error){
 	result, err := s.callRepositoryRead(ctx, "GetProductId", func(ctx context.Context) (interface{}, error) {
-		return s.workerRepository.GetProductId(ctx, product)
+		resProduct, err := cachedRead(s, ctx, productIdCacheKey(security.TenantFrom(ctx), product.ID), func(ctx context.Context) (*model.Product, error) {
+			return s.workerRepository.GetProductId(ctx, product)
+		})
+		if err != nil {
+			return nil, err
+		}
+		// the sku is only known after the read
+		if err := authorizeSku(ctx, resProduct.Sku); err != nil {
+			return nil, err
+		}
+		return resProduct, nil
 	})
 
 	if err != nil {
@@ -176,3 +218,91 @@ func (s * WorkerService) GetProductId(ctx context.Context, product *model.Produc
 	return result.(*model.Product), nil
 }
 
+// About update a product, the fields informed replace the current ones
+func (s *WorkerService) UpdateProduct(ctx context.Context, 
+									product *model.Product) (*model.Product, error){
+	s.logger.Info().
+			Ctx(ctx).
+			Str("func","UpdateProduct").Send()
+	// trace
+	ctx, span := s.tracerProvider.SpanCtx(ctx, "service.UpdateProduct", trace.SpanKindServer)
+	defer span.End()
+
+	// the caller may be restricted to some skus
+	if err := authorizeSku(ctx, product.Sku); err != nil {
+		return nil, err
+	}
+
+	// prepare database
+	tx, err := s.workerRepository.StartTx(ctx)
+	if err != nil {
+		span.RecordError(err) 
+        span.SetStatus(codes.Error, err.Error())
+		return nil, err
+	}
+
+	// handle connection and transaction
+	defer func() {
+		if err != nil {
+			tx.Rollback(ctx)
+		} else {
+			tx.Commit(ctx)
+		}
+	}()
+
+	// Get the current product in the transaction, locking it, so the version checked and the audit are
+	// the ones of the primary
+	res_product, err := s.workerRepository.GetProductForUpdate(ctx, tx, product)
+	if err != nil {
+		return nil, err
+	}
+
+	// when a version is informed (If-Match) it must match the current one
+	if product.Version > 0 && product.Version != res_product.Version {
+		err = erro.ErrPreconditionFailed
+		return nil, err
+	}
+
+	// prepare data, keeping the product before the change for the audit
+	previous := *res_product
+	if product.Type != "" {
+		res_product.Type = product.Type
+	}
+	if product.Name != "" {
+		res_product.Name = product.Name
+	}
+	if product.Status != "" {
+		res_product.Status = product.Status
+	}
+	if product.LeadTime > 0 {
+		res_product.LeadTime = product.LeadTime
+	}
+	now := time.Now()
+	res_product.UpdatedAt = &now
+
+	// the version informed is used as optimistic lock, zero means a blind update
+	res_product.Version = product.Version
+
+	row, err := s.workerRepository.UpdateProduct(ctx, tx, res_product)
+	if err != nil {
+		return nil, err
+	}
+	if row == 0 {
+		err = erro.ErrPreconditionFailed
+		return nil, err
+	}
+
+	err = s.addAuditRecord(ctx, tx, model.AuditEntityProduct, model.AuditActionUpdate, res_product.Sku, &previous, res_product)
+	if err != nil {
+		return nil, err
+	}
+
+	// the cache is dropped now and again by every pod when the commit is notified
+	s.invalidateProduct(ctx, security.TenantFrom(ctx), res_product)
+	err = s.notifyProductChanged(ctx, tx, res_product)
+	if err != nil {
+		return nil, err
+	}
+
+	return res_product, nil
+}
@@ -0,0 +1,114 @@
+package service
+
+import (
+	"time"
+	"context"
+
+	"github.com/go-inventory/internal/domain/model"
+
+	go_core_db_pg "github.com/eliezerraj/go-core/v2/database/postgre"
+)
+
+// Tx is a transaction of the repository, bound to the tenant of the context that started it. Commit or
+// Rollback ends it and gives back what it holds, so the caller calls exactly one of them
+type Tx interface {
+	Commit(ctx context.Context) error
+	Rollback(ctx context.Context) error
+}
+
+// Repository keeps the state of the service. The methods receiving a Tx write (or lock for a write) within it,
+// the others read what is committed. Every method sees only the rows of the tenant of the context
+type Repository interface {
+	StartTx(ctx context.Context) (Tx, error)
+	Stat(ctx context.Context) go_core_db_pg.PoolStats
+	Ping(ctx context.Context) error
+
+	// product
+	AddProduct(ctx context.Context, tx Tx, product *model.Product) (*model.Product, error)
+	GetProduct(ctx context.Context, product *model.Product) (*model.Product, error)
+	GetProductId(ctx context.Context, product *model.Product) (*model.Product, error)
+	GetProductForUpdate(ctx context.Context, tx Tx, product *model.Product) (*model.Product, error)
+	UpdateProduct(ctx context.Context, tx Tx, product *model.Product) (int64, error)
+
+	// inventory
+	AddInventory(ctx context.Context, tx Tx, inventory *model.Inventory) (*model.Inventory, error)
+	GetInventory(ctx context.Context, inventory *model.Inventory) (*model.Inventory, error)
+	ListInventoryBySku(ctx context.Context, skus []string) (*[]model.Inventory, error)
+	GetInventoryForUpdate(ctx context.Context, tx Tx, inventory *model.Inventory) (*model.Inventory, error)
+	SetInventoryAvailable(ctx context.Context, tx Tx, inventory *model.Inventory) (int64, error)
+	TransferInventory(ctx context.Context, tx Tx, inventory *model.Inventory, transfer *model.InventoryTransfer) (int64, error)
+	UpdateInventory(ctx context.Context, tx Tx, inventory *model.Inventory) (int64, error)
+	UpdateInventoryIfMatch(ctx context.Context, tx Tx, inventory *model.Inventory) (int64, error)
+	ListInventory(ctx context.Context, limit int, offset int, inventory *model.Inventory) (*[]model.Inventory, error)
+
+	// stats
+	AddInventoryTimeSeries(ctx context.Context, tx Tx, inventory *model.Inventory) (*model.Inventory, error)
+	GetInventoryTimeSeries(ctx context.Context, windowsize int, offset int, inventory *model.Inventory) (*[]model.Inventory, error)
+	ListInventoryTimeSeriesBySku(ctx context.Context, windowsize int, offset int, skus []string) (*[]model.Inventory, error)
+
+	// adjustment
+	AddInventoryAdjustment(ctx context.Context, tx Tx, adjustment *model.InventoryAdjustment) (*model.InventoryAdjustment, error)
+	GetInventoryAdjustmentForUpdate(ctx context.Context, tx Tx, adjustment *model.InventoryAdjustment) (*model.InventoryAdjustment, error)
+	UpdateInventoryAdjustmentStatus(ctx context.Context, tx Tx, adjustment *model.InventoryAdjustment) (int64, error)
+	ListInventoryAdjustment(ctx context.Context, limit int, offset int, adjustment *model.InventoryAdjustment) (*[]model.InventoryAdjustment, error)
+
+	// channel
+	UpsertChannelAllocation(ctx context.Context, tx Tx, allocation *model.ChannelAllocation) (*model.ChannelAllocation, error)
+	DeleteChannelAllocation(ctx context.Context, tx Tx, allocation *model.ChannelAllocation) (int64, error)
+	ListChannelAllocation(ctx context.Context, product *model.Product) (*[]model.ChannelAllocation, error)
+	ListChannelAllocationForUpdate(ctx context.Context, tx Tx, product *model.Product) (*[]model.ChannelAllocation, error)
+	UpdateChannelAllocationQuantity(ctx context.Context, tx Tx, allocation *model.ChannelAllocation, delta int) (int64, error)
+
+	// rma
+	AddRma(ctx context.Context, tx Tx, rma *model.Rma) (*model.Rma, error)
+	GetRma(ctx context.Context, rma *model.Rma) (*model.Rma, error)
+	GetRmaForUpdate(ctx context.Context, tx Tx, rma *model.Rma) (*model.Rma, error)
+	UpdateRma(ctx context.Context, tx Tx, rma *model.Rma) (int64, error)
+	AddRmaEvent(ctx context.Context, tx Tx, rma *model.Rma, rmaEvent *model.RmaEvent) (*model.RmaEvent, error)
+	ListRmaEvent(ctx context.Context, rma *model.Rma) (*[]model.RmaEvent, error)
+
+	// order
+	AddConsumedEvent(ctx context.Context, tx Tx, event *model.ConsumedEvent) (int64, error)
+	GetOrderReservationForUpdate(ctx context.Context, tx Tx, reservation *model.OrderReservation) (*model.OrderReservation, error)
+	AddOrderReservation(ctx context.Context, tx Tx, reservation *model.OrderReservation) (*model.OrderReservation, error)
+	UpdateOrderReservation(ctx context.Context, tx Tx, reservation *model.OrderReservation) (int64, error)
+
+	// outbox
+	AddOutboxEvent(ctx context.Context, tx Tx, event *model.OutboxEvent) (*model.OutboxEvent, error)
+	ListOutboxPendingForUpdate(ctx context.Context, tx Tx, limit int) (*[]model.OutboxEvent, error)
+	UpdateOutboxEvent(ctx context.Context, tx Tx, event *model.OutboxEvent) (int64, error)
+
+	// webhook
+	AddWebhook(ctx context.Context, tx Tx, webhook *model.Webhook) (*model.Webhook, error)
+	GetWebhook(ctx context.Context, webhook *model.Webhook) (*model.Webhook, error)
+	DeactivateWebhook(ctx context.Context, tx Tx, webhook *model.Webhook) (int64, error)
+	ListWebhookMatch(ctx context.Context, tx Tx, product *model.Product) (*[]model.Webhook, error)
+	AddWebhookDelivery(ctx context.Context, tx Tx, delivery *model.WebhookDelivery) (*model.WebhookDelivery, error)
+	ListWebhookDeliveryPendingForUpdate(ctx context.Context, tx Tx, limit int) (*[]model.WebhookDelivery, error)
+	UpdateWebhookDelivery(ctx context.Context, tx Tx, delivery *model.WebhookDelivery) (int64, error)
+	RetryWebhookDelivery(ctx context.Context, tx Tx, delivery *model.WebhookDelivery) (*model.WebhookDelivery, error)
+	ListWebhookDelivery(ctx context.Context, limit int, offset int, delivery *model.WebhookDelivery) (*[]model.WebhookDelivery, error)
+
+	// api key
+	AddApiKey(ctx context.Context, tx Tx, apiKey *model.ApiKey) (*model.ApiKey, error)
+	GetApiKey(ctx context.Context, apiKey *model.ApiKey) (*model.ApiKey, error)
+	GetApiKeyByHash(ctx context.Context, keyHash string) (*model.ApiKey, error)
+	RotateApiKey(ctx context.Context, tx Tx, apiKey *model.ApiKey) (int64, error)
+	RevokeApiKey(ctx context.Context, tx Tx, apiKey *model.ApiKey) (int64, error)
+	AddApiKeyUsage(ctx context.Context, tx Tx, apiKey *model.ApiKey, windowStart time.Time) (int, error)
+	GetApiKeyUsage(ctx context.Context, apiKey *model.ApiKey, windowStart time.Time) (*model.ApiKeyUsage, error)
+
+	// audit
+	AddAuditRecord(ctx context.Context, tx Tx, record *model.AuditRecord) (*model.AuditRecord, error)
+	ListAuditRecord(ctx context.Context, limit int, offset int, record *model.AuditRecord) (*[]model.AuditRecord, error)
+	ListAuditChain(ctx context.Context, afterSeq int64, limit int) (*[]model.AuditRecord, error)
+	CountAuditUnsealed(ctx context.Context) (int, error)
+	TryAuditSealLock(ctx context.Context, tx Tx) (bool, error)
+	ListAuditUnsealedForUpdate(ctx context.Context, tx Tx, limit int) (*[]model.AuditRecord, error)
+	GetAuditHead(ctx context.Context, tx Tx, tenant string) (int64, string, error)
+	SealAuditRecord(ctx context.Context, tx Tx, record *model.AuditRecord) (int64, error)
+
+	// stream, the notifications of a transaction are delivered when it commits
+	NotifyInventoryChanged(ctx context.Context, tx Tx, event *model.InventoryEvent) error
+	ListenInventoryChanged(ctx context.Context, handler func(*model.InventoryEvent)) error
+}
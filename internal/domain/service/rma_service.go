@@ -0,0 +1,223 @@
+package service
+
+import (
+	"time"
+	"context"
+
+	"github.com/go-inventory/internal/domain/model"
+	"github.com/go-inventory/shared/erro"
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/codes"
+)
+
+// Helper function to run a rma step inside a transaction with the rma locked in the status expected
+func (s *WorkerService) stepRma(ctx context.Context,
+								spanName string,
+								rma *model.Rma,
+								expectedStatus string,
+								step func(context.Context, Tx, *model.Rma) error) (*model.Rma, error){
+	s.logger.Info().
+			Ctx(ctx).
+			Str("func", spanName).Send()
+
+	// Trace
+	ctx, span := s.tracerProvider.SpanCtx(ctx, "service."+spanName, trace.SpanKindServer)
+	defer span.End()
+
+	// prepare database
+	tx, err := s.workerRepository.StartTx(ctx)
+	if err != nil {
+		span.RecordError(err)
+        span.SetStatus(codes.Error, err.Error())
+		return nil, err
+	}
+
+	// handle connection and transaction
+	defer func() {
+		if err != nil {
+			tx.Rollback(ctx)
+		} else {
+			tx.Commit(ctx)
+		}
+	}()
+
+	// Get and lock the rma
+	resRma, err := s.workerRepository.GetRmaForUpdate(ctx, tx, rma)
+	if err != nil {
+		return nil, err
+	}
+
+	err = authorizeSku(ctx, resRma.Product.Sku)
+	if err != nil {
+		return nil, err
+	}
+
+	if resRma.Status != expectedStatus {
+		err = erro.ErrInvalidStatus
+		return nil, err
+	}
+
+	err = step(ctx, tx, resRma)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	resRma.UpdatedAt = &now
+
+	_, err = s.workerRepository.UpdateRma(ctx, tx, resRma)
+	if err != nil {
+		return nil, err
+	}
+
+	return resRma, nil
+}
+
+// About create a rma against a order reference
+func (s *WorkerService) CreateRma(ctx context.Context, rma *model.Rma) (*model.Rma, error){
+	s.logger.Info().
+			Ctx(ctx).
+			Str("func","CreateRma").Send()
+
+	// Trace
+	ctx, span := s.tracerProvider.SpanCtx(ctx, "service.CreateRma", trace.SpanKindServer)
+	defer span.End()
+
+	// the caller may be restricted to some skus
+	if err := authorizeSku(ctx, rma.Product.Sku); err != nil {
+		return nil, err
+	}
+
+	// prepare database
+	tx, err := s.workerRepository.StartTx(ctx)
+	if err != nil {
+		span.RecordError(err)
+        span.SetStatus(codes.Error, err.Error())
+		return nil, err
+	}
+
+	// handle connection and transaction
+	defer func() {
+		if err != nil {
+			tx.Rollback(ctx)
+		} else {
+			tx.Commit(ctx)
+		}
+	}()
+
+	// Get product returned
+	resProduct, err := s.workerRepository.GetProduct(ctx, &rma.Product)
+	if err != nil {
+		return nil, err
+	}
+
+	// prepare data
+	rma.Product = *resProduct
+	rma.Status = model.RmaCreated
+	rma.CreatedAt = time.Now()
+
+	res_rma, err := s.workerRepository.AddRma(ctx, tx, rma)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = s.workerRepository.AddRmaEvent(ctx, tx, res_rma, &model.RmaEvent{	Step: model.RmaCreated,
+																				Quantity: rma.Quantity,
+																				Note: rma.Reason,
+																				CreatedAt: rma.CreatedAt})
+	if err != nil {
+		return nil, err
+	}
+
+	return res_rma, nil
+}
+
+// About receive the returned items, they stay in quarantine waiting the inspection
+func (s *WorkerService) ReceiveRma(ctx context.Context, rma *model.Rma) (*model.Rma, error){
+	return s.stepRma(ctx, "ReceiveRma", rma, model.RmaCreated, func(ctx context.Context, tx Tx, resRma *model.Rma) error {
+		if rma.Received <= 0 || rma.Received > resRma.Quantity {
+			return erro.ErrBadRequest
+		}
+
+		resRma.Received = rma.Received
+		resRma.Status = model.RmaReceived
+
+		// the items returned are no longer sold
+		_, err := s.moveStock(ctx, tx, &resRma.Product, model.InventoryTransfer{	From: model.BucketSold,
+																				To: model.BucketQuarantine,
+																				Quantity: resRma.Received})
+		if err != nil {
+			return err
+		}
+
+		_, err = s.workerRepository.AddRmaEvent(ctx, tx, resRma, &model.RmaEvent{	Step: model.RmaReceived,
+																					Quantity: resRma.Received,
+																					CreatedAt: time.Now()})
+		return err
+	})
+}
+
+// About inspect the received items, deciding how many are restocked and how many are damaged
+func (s *WorkerService) InspectRma(ctx context.Context, rma *model.Rma, note string) (*model.Rma, error){
+	return s.stepRma(ctx, "InspectRma", rma, model.RmaReceived, func(ctx context.Context, tx Tx, resRma *model.Rma) error {
+		if rma.Restock < 0 || rma.Damaged < 0 || rma.Restock + rma.Damaged != resRma.Received {
+			return erro.ErrBadRequest
+		}
+
+		resRma.Restock = rma.Restock
+		resRma.Damaged = rma.Damaged
+		resRma.Status = model.RmaInspected
+
+		_, err := s.workerRepository.AddRmaEvent(ctx, tx, resRma, &model.RmaEvent{	Step: model.RmaInspected,
+																					Quantity: resRma.Received,
+																					Note: note,
+																					CreatedAt: time.Now()})
+		return err
+	})
+}
+
+// About restock the inspected items, the good ones back to available and the others to damaged
+func (s *WorkerService) RestockRma(ctx context.Context, rma *model.Rma) (*model.Rma, error){
+	return s.stepRma(ctx, "RestockRma", rma, model.RmaInspected, func(ctx context.Context, tx Tx, resRma *model.Rma) error {
+		resRma.Status = model.RmaCompleted
+
+		_, err := s.moveStock(ctx, tx, &resRma.Product,
+							model.InventoryTransfer{From: model.BucketQuarantine, To: model.BucketAvailable, Quantity: resRma.Restock},
+							model.InventoryTransfer{From: model.BucketQuarantine, To: model.BucketDamaged, Quantity: resRma.Damaged})
+		if err != nil {
+			return err
+		}
+
+		_, err = s.workerRepository.AddRmaEvent(ctx, tx, resRma, &model.RmaEvent{	Step: model.RmaCompleted,
+																					Quantity: resRma.Restock + resRma.Damaged,
+																					CreatedAt: time.Now()})
+		return err
+	})
+}
+
+// About get a rma with its steps
+func (s *WorkerService) GetRma(ctx context.Context, rma *model.Rma) (*model.Rma, error){
+	result, err := s.callRepositoryRead(ctx, "GetRma", func(ctx context.Context) (interface{}, error) {
+		resRma, err := s.workerRepository.GetRma(ctx, rma)
+		if err != nil {
+			return nil, err
+		}
+
+		if err := authorizeSku(ctx, resRma.Product.Sku); err != nil {
+			return nil, err
+		}
+
+		resEvents, err := s.workerRepository.ListRmaEvent(ctx, resRma)
+		if err != nil {
+			return nil, err
+		}
+		resRma.Events = *resEvents
+
+		return resRma, nil
+	})
+
+	if err != nil {
+		return nil, err
+	}
+	return result.(*model.Rma), nil
+}
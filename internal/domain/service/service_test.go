@@ -0,0 +1,333 @@
+package service_test
+
+import (
+	"sync"
+	"errors"
+	"context"
+	"testing"
+
+	"github.com/rs/zerolog"
+	"go.opentelemetry.io/otel/trace/noop"
+
+	"github.com/go-inventory/internal/domain/model"
+	"github.com/go-inventory/internal/domain/service"
+	"github.com/go-inventory/internal/infrastructure/repo/memory"
+	"github.com/go-inventory/shared/erro"
+	"github.com/go-inventory/shared/security"
+
+	go_core_otel_trace "github.com/eliezerraj/go-core/v2/otel/trace"
+)
+
+// service over a empty memory repository, a cycle count above the threshold waits for approval
+func newTestService(t *testing.T, approvalThreshold int) *service.WorkerService {
+	t.Helper()
+
+	logger := zerolog.Nop()
+	appServer := model.AppServer{
+		Application:		&model.Application{Name: "go-inventory"},
+		Server:				&model.Server{CtxTimeout: 5, StreamBufferSize: 16},
+		InventoryConfig:	&model.InventoryConfig{CycleCountApprovalThreshold: approvalThreshold},
+		AuditConfig:		&model.AuditConfig{SealInterval: 1000, BatchSize: 500},
+		TenantConfig:		&model.TenantConfig{Default: "default"},
+	}
+	tracerProvider := go_core_otel_trace.TracerProvider{Tracer: noop.NewTracerProvider().Tracer("test")}
+
+	return service.NewWorkerService(&appServer, memory.NewMemoryRepository(&logger), nil, &logger, &tracerProvider)
+}
+
+// context of a request of a tenant
+func tenantCtx(tenant string) context.Context {
+	return security.WithTenant(context.Background(), tenant)
+}
+
+// Helper function to create a product, failing the test on error
+func addProduct(t *testing.T, workerService *service.WorkerService, ctx context.Context, sku string) *model.Inventory {
+	t.Helper()
+
+	inventory, err := workerService.AddProduct(ctx, &model.Product{Sku: sku, Type: "BOOK", Name: "product " + sku, Status: "IN-STOCK"})
+	if err != nil {
+		t.Fatalf("FAILED to add product %s: %v", sku, err)
+	}
+	return inventory
+}
+
+func TestAddProduct(t *testing.T) {
+	tests := []struct {
+		name		string
+		ctx			context.Context
+		sku			string
+		wantErr		error
+	}{
+		{name: "new sku", ctx: tenantCtx("acme"), sku: "sku-2"},
+		{name: "duplicate sku", ctx: tenantCtx("acme"), sku: "sku-1", wantErr: erro.ErrDuplicateKey},
+		{name: "same sku in other tenant", ctx: tenantCtx("globex"), sku: "sku-1"},
+		{name: "without tenant", ctx: context.Background(), sku: "sku-3", wantErr: erro.ErrConstraint},
+		{name: "sku not allowed", ctx: security.WithPrincipal(tenantCtx("acme"), &security.Principal{SkuPrefixes: []string{"book-"}}), sku: "sku-4", wantErr: erro.ErrSkuForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			workerService := newTestService(t, 0)
+			addProduct(t, workerService, tenantCtx("acme"), "sku-1")
+
+			inventory, err := workerService.AddProduct(tt.ctx, &model.Product{Sku: tt.sku, Name: "product"})
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("got error %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if inventory.Product.Sku != tt.sku || inventory.Available != 1000 {
+				t.Errorf("unexpected default inventory: %+v", inventory)
+			}
+		})
+	}
+}
+
+func TestGetProductIsolatesTenants(t *testing.T) {
+	workerService := newTestService(t, 0)
+	addProduct(t, workerService, tenantCtx("acme"), "sku-1")
+
+	tests := []struct {
+		name		string
+		ctx			context.Context
+		wantErr		error
+	}{
+		{name: "owner", ctx: tenantCtx("acme")},
+		{name: "other tenant", ctx: tenantCtx("globex"), wantErr: erro.ErrNotFound},
+		{name: "system tenant", ctx: tenantCtx(security.SystemTenant)},
+		{name: "without tenant", ctx: context.Background(), wantErr: erro.ErrNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			product, err := workerService.GetProduct(tt.ctx, &model.Product{Sku: "sku-1"})
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("got error %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if product.Sku != "sku-1" || product.Version != 1 {
+				t.Errorf("unexpected product: %+v", product)
+			}
+		})
+	}
+}
+
+func TestUpdateInventory(t *testing.T) {
+	tests := []struct {
+		name			string
+		update			model.Inventory
+		wantAvailable	int
+		wantSold		int
+		wantVersion		int
+		wantErr			error
+	}{
+		{name: "sale", update: model.Inventory{Available: -3, Sold: 3}, wantAvailable: 997, wantSold: 3, wantVersion: 2},
+		{name: "restock", update: model.Inventory{Available: 50}, wantAvailable: 1050, wantVersion: 2},
+		{name: "version matches", update: model.Inventory{Available: -1, Version: 1}, wantAvailable: 999, wantVersion: 2},
+		{name: "version mismatch", update: model.Inventory{Available: -1, Version: 7}, wantErr: erro.ErrPreconditionFailed},
+		{name: "unknown sku", update: model.Inventory{Product: model.Product{Sku: "missing"}}, wantErr: erro.ErrNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := tenantCtx("acme")
+			workerService := newTestService(t, 0)
+			addProduct(t, workerService, ctx, "sku-1")
+
+			update := tt.update
+			if update.Product.Sku == "" {
+				update.Product.Sku = "sku-1"
+			}
+
+			res, err := workerService.UpdateInventory(ctx, &update)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("got error %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if res.Available != tt.wantAvailable || res.Sold != tt.wantSold || res.Version != tt.wantVersion {
+				t.Errorf("got available %d sold %d version %d, want %d %d %d",
+					res.Available, res.Sold, res.Version, tt.wantAvailable, tt.wantSold, tt.wantVersion)
+			}
+
+			got, err := workerService.GetInventory(ctx, &model.Inventory{Product: model.Product{Sku: "sku-1"}})
+			if err != nil {
+				t.Fatalf("FAILED to get inventory: %v", err)
+			}
+			if got.Available != tt.wantAvailable || got.Version != tt.wantVersion {
+				t.Errorf("change not committed, got available %d version %d", got.Available, got.Version)
+			}
+		})
+	}
+}
+
+func TestTransferInventory(t *testing.T) {
+	tests := []struct {
+		name			string
+		transfer		model.InventoryTransfer
+		wantAvailable	int
+		wantQuarantine	int
+		wantErr			error
+	}{
+		{name: "to quarantine", transfer: model.InventoryTransfer{From: model.BucketAvailable, To: model.BucketQuarantine, Quantity: 10}, wantAvailable: 990, wantQuarantine: 10},
+		{name: "more than available", transfer: model.InventoryTransfer{From: model.BucketAvailable, To: model.BucketHold, Quantity: 1001}, wantErr: erro.ErrInsufficientStock},
+		{name: "damaged can not leave", transfer: model.InventoryTransfer{From: model.BucketDamaged, To: model.BucketAvailable, Quantity: 1}, wantErr: erro.ErrBadRequest},
+		{name: "zero quantity", transfer: model.InventoryTransfer{From: model.BucketAvailable, To: model.BucketHold}, wantErr: erro.ErrBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := tenantCtx("acme")
+			workerService := newTestService(t, 0)
+			addProduct(t, workerService, ctx, "sku-1")
+
+			transfer := tt.transfer
+			transfer.Product.Sku = "sku-1"
+
+			res, err := workerService.TransferInventory(ctx, &transfer)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("got error %v, want %v", err, tt.wantErr)
+				}
+				got, _ := workerService.GetInventory(ctx, &model.Inventory{Product: model.Product{Sku: "sku-1"}})
+				if got.Available != 1000 || got.Version != 1 {
+					t.Errorf("a failed transfer must roll back, got %+v", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if res.Available != tt.wantAvailable || res.Quarantine != tt.wantQuarantine {
+				t.Errorf("got available %d quarantine %d, want %d %d", res.Available, res.Quarantine, tt.wantAvailable, tt.wantQuarantine)
+			}
+		})
+	}
+}
+
+func TestCycleCount(t *testing.T) {
+	tests := []struct {
+		name			string
+		threshold		int
+		counted			int
+		approve			bool
+		wantStatus		string
+		wantAvailable	int
+	}{
+		{name: "applied without threshold", counted: 900, wantStatus: model.AdjustmentApplied, wantAvailable: 900},
+		{name: "applied within threshold", threshold: 200, counted: 900, wantStatus: model.AdjustmentApplied, wantAvailable: 900},
+		{name: "pending above threshold", threshold: 50, counted: 900, wantStatus: model.AdjustmentPendingApproval, wantAvailable: 1000},
+		{name: "approved above threshold", threshold: 50, counted: 900, approve: true, wantStatus: model.AdjustmentApplied, wantAvailable: 900},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := tenantCtx("acme")
+			workerService := newTestService(t, tt.threshold)
+			addProduct(t, workerService, ctx, "sku-1")
+
+			adjustment, err := workerService.CycleCount(ctx, &model.InventoryAdjustment{Product: model.Product{Sku: "sku-1"}, Counted: tt.counted})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if adjustment.Variance != tt.counted - 1000 {
+				t.Errorf("got variance %d, want %d", adjustment.Variance, tt.counted - 1000)
+			}
+
+			if tt.approve {
+				adjustment, err = workerService.ReviewAdjustment(ctx, &model.InventoryAdjustment{ID: adjustment.ID, Status: model.AdjustmentApplied})
+				if err != nil {
+					t.Fatalf("FAILED to approve: %v", err)
+				}
+			}
+			if adjustment.Status != tt.wantStatus {
+				t.Errorf("got status %s, want %s", adjustment.Status, tt.wantStatus)
+			}
+
+			got, err := workerService.GetInventory(ctx, &model.Inventory{Product: model.Product{Sku: "sku-1"}})
+			if err != nil {
+				t.Fatalf("FAILED to get inventory: %v", err)
+			}
+			if got.Available != tt.wantAvailable {
+				t.Errorf("got available %d, want %d", got.Available, tt.wantAvailable)
+			}
+		})
+	}
+}
+
+func TestAuditChainIsSealedAndVerified(t *testing.T) {
+	ctx := tenantCtx("acme")
+	workerService := newTestService(t, 0)
+	addProduct(t, workerService, ctx, "sku-1")
+	addProduct(t, workerService, tenantCtx("globex"), "sku-1")
+
+	sealed, err := workerService.SealAudit(tenantCtx(security.SystemTenant))
+	if err != nil {
+		t.Fatalf("FAILED to seal: %v", err)
+	}
+	if sealed != 4 {
+		t.Errorf("got %d records sealed, want 4 (product and inventory of each tenant)", sealed)
+	}
+
+	verification, err := workerService.VerifyAudit(ctx)
+	if err != nil {
+		t.Fatalf("FAILED to verify: %v", err)
+	}
+	if !verification.Valid || verification.Records != 2 || verification.Unsealed != 0 {
+		t.Errorf("unexpected verification: %+v", verification)
+	}
+
+	// the chains of the tenants are interleaved for the system tenant
+	_, err = workerService.VerifyAudit(tenantCtx(security.SystemTenant))
+	if !errors.Is(err, erro.ErrTenantRequired) {
+		t.Errorf("got error %v verifying as the system tenant, want %v", err, erro.ErrTenantRequired)
+	}
+}
+
+// the updates of a sku run concurrently, each one must be applied exactly once
+func TestUpdateInventoryConcurrent(t *testing.T) {
+	ctx := tenantCtx("acme")
+	workerService := newTestService(t, 0)
+	addProduct(t, workerService, ctx, "sku-1")
+
+	const workers = 50
+	var wg sync.WaitGroup
+	errs := make(chan error, workers)
+	for range workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := workerService.UpdateInventory(ctx, &model.Inventory{Product: model.Product{Sku: "sku-1"}, Available: -1, Sold: 1})
+			errs <- err
+		}()
+	}
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	got, err := workerService.GetInventory(ctx, &model.Inventory{Product: model.Product{Sku: "sku-1"}})
+	if err != nil {
+		t.Fatalf("FAILED to get inventory: %v", err)
+	}
+	if got.Available != 1000 - workers || got.Sold != workers || got.Version != 1 + workers {
+		t.Errorf("lost updates: available %d sold %d version %d", got.Available, got.Sold, got.Version)
+	}
+}
@@ -9,6 +9,9 @@ import (
 // About get a inventory time series for a given inventory and window size (number of days)
 func (s * WorkerService) GetInventoryTimeSeries(ctx context.Context, windowsize int, offset int, inventory *model.Inventory) (*[]model.Inventory, error){
 	result, err := s.callRepositoryRead(ctx, "GetInventoryTimeSeries", func(ctx context.Context) (interface{}, error) {
+		if err := authorizeSku(ctx, inventory.Product.Sku); err != nil {
+			return nil, err
+		}
 		return s.workerRepository.GetInventoryTimeSeries(ctx, windowsize, offset, inventory)
 	})
 
@@ -16,4 +19,23 @@ func (s * WorkerService) GetInventoryTimeSeries(ctx context.Context, windowsize
 		return nil, err
 	}
 	return result.(*[]model.Inventory), nil
-}
\ No newline at end of file
+}
+// About get the time series of several products in one query, indexed by sku
+func (s * WorkerService) GetInventoryTimeSeriesBatch(ctx context.Context, windowsize int, offset int, skus []string) (map[string][]model.Inventory, error){
+	result, err := s.callRepositoryRead(ctx, "GetInventoryTimeSeriesBatch", func(ctx context.Context) (interface{}, error) {
+		if err := authorizeSku(ctx, skus...); err != nil {
+			return nil, err
+		}
+		return s.workerRepository.ListInventoryTimeSeriesBySku(ctx, windowsize, offset, skus)
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	map_series := map[string][]model.Inventory{}
+	for _, inventory := range *result.(*[]model.Inventory) {
+		map_series[inventory.Product.Sku] = append(map_series[inventory.Product.Sku], inventory)
+	}
+	return map_series, nil
+}
@@ -0,0 +1,268 @@
+package service
+
+import (
+	"fmt"
+	"sync"
+	"time"
+	"context"
+
+	"github.com/rs/zerolog"
+
+	"github.com/go-inventory/internal/domain/model"
+	"github.com/go-inventory/shared/erro"
+	"github.com/go-inventory/shared/security"
+)
+
+// events queued for a subscriber, a subscriber slower than that is dropped and must reconnect
+const subscriptionBuffer = 64
+
+// InventorySubscription receives the events of the skus subscribed, of its tenant, until Events is closed
+type InventorySubscription struct {
+	Events		chan model.InventoryEvent
+	tenant		string
+	skus		map[string]bool
+}
+
+// Helper to know whether a event is of the subscription
+func (s *InventorySubscription) matches(event *model.InventoryEvent) bool {
+	return event.Tenant == s.tenant && s.skus[event.Inventory.Product.Sku]
+}
+
+// InventoryHub fans out the inventory notifications of the pod to its subscribers, and keeps the
+// last events in a ring buffer to replay them after a reconnection (Last-Event-ID).
+// Every pod listens the same postgres channel, so the events and its order are the same in all pods
+type InventoryHub struct {
+	mu				sync.Mutex
+	subscribers		map[*InventorySubscription]struct{}
+	buffer			[]model.InventoryEvent
+	next			int
+	full			bool
+	closed			bool
+	logger			*zerolog.Logger
+}
+
+// About create a hub
+func NewInventoryHub(size int, logger *zerolog.Logger) *InventoryHub {
+	return &InventoryHub{
+		subscribers: map[*InventorySubscription]struct{}{},
+		buffer: make([]model.InventoryEvent, max(size, 1)),
+		logger: logger,
+	}
+}
+
+// Helper to list the events of the buffer after the id informed, false when the id is no longer in the buffer
+func (h *InventoryHub) replay(lastEventID string, subscription *InventorySubscription) ([]model.InventoryEvent, bool) {
+	list_event := []model.InventoryEvent{}
+
+	start, size := 0, h.next
+	if h.full {
+		start, size = h.next, len(h.buffer)
+	}
+
+	found := false
+	for i := 0; i < size; i++ {
+		event := h.buffer[(start + i) % len(h.buffer)]
+		if found && subscription.matches(&event) {
+			list_event = append(list_event, event)
+		}
+		if event.ID == lastEventID {
+			found = true
+		}
+	}
+
+	return list_event, found
+}
+
+// About subscribe the events of the skus of a tenant, the events after lastEventID are returned to be sent first.
+// The replay and the registration happen under the same lock, so no event is lost or repeated
+func (h *InventoryHub) Subscribe(tenant string, skus []string, lastEventID string) (*InventorySubscription, []model.InventoryEvent, bool) {
+	subscription := &InventorySubscription{
+		Events: make(chan model.InventoryEvent, subscriptionBuffer),
+		tenant: tenant,
+		skus: map[string]bool{},
+	}
+	for _, sku := range skus {
+		subscription.skus[sku] = true
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.closed {
+		close(subscription.Events)
+		return subscription, nil, false
+	}
+
+	var list_event []model.InventoryEvent
+	found := false
+	if lastEventID != "" {
+		list_event, found = h.replay(lastEventID, subscription)
+	}
+
+	h.subscribers[subscription] = struct{}{}
+	return subscription, list_event, found
+}
+
+// About stop a subscription
+func (h *InventoryHub) Unsubscribe(subscription *InventorySubscription) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.remove(subscription)
+}
+
+// Helper to remove a subscription closing its channel, must be called with the lock held
+func (h *InventoryHub) remove(subscription *InventorySubscription) {
+	if _, ok := h.subscribers[subscription]; ok {
+		delete(h.subscribers, subscription)
+		close(subscription.Events)
+	}
+}
+
+// About publish a event to the subscribers of its tenant and sku
+func (h *InventoryHub) Publish(event model.InventoryEvent) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.buffer[h.next] = event
+	h.next = (h.next + 1) % len(h.buffer)
+	if h.next == 0 {
+		h.full = true
+	}
+
+	for subscription := range h.subscribers {
+		if !subscription.matches(&event) {
+			continue
+		}
+		select {
+		case subscription.Events <- event:
+		default:
+			// the subscriber is not reading, it reconnects and gets the missed events from the buffer
+			h.logger.Warn().
+					Str("sku", event.Inventory.Product.Sku).
+					Msg("slow inventory stream subscriber dropped")
+			h.remove(subscription)
+		}
+	}
+}
+
+// About close all the subscriptions, used on shutdown so the open streams do not hold the server
+func (h *InventoryHub) Close() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.closed = true
+	for subscription := range h.subscribers {
+		h.remove(subscription)
+	}
+}
+
+// Helper function to notify the subscribers of the stream about a inventory change, the notification is
+// only delivered when the transaction commits
+func (s *WorkerService) notifyInventoryChanged(ctx context.Context, tx Tx, inventory *model.Inventory) error {
+	// the cache is dropped now and again by every pod when the commit is notified
+	s.invalidateInventory(ctx, security.TenantFrom(ctx), inventory)
+
+	now := time.Now()
+	event := model.InventoryEvent{
+		ID:			fmt.Sprintf("%d-%d", now.UnixNano(), inventory.Product.ID),
+		Type:		model.EventInventoryChanged,
+		Tenant:		security.TenantFrom(ctx),
+		Inventory:	*inventory,
+		CreatedAt:	now,
+	}
+
+	return s.workerRepository.NotifyInventoryChanged(ctx, tx, &event)
+}
+
+// About listen the inventory changes of all pods and publish them to the subscribers of this pod,
+// it blocks until the context is done reconnecting whenever the connection fails
+func (s *WorkerService) StartInventoryStream(ctx context.Context) {
+	s.logger.Info().
+			Ctx(ctx).
+			Str("func","StartInventoryStream").Send()
+
+	backoff := time.Second
+	for ctx.Err() == nil {
+		start := time.Now()
+		err := s.workerRepository.ListenInventoryChanged(ctx, func(event *model.InventoryEvent) {
+			s.invalidateChanged(ctx, event)
+			if event.Type == model.EventInventoryChanged {
+				s.inventoryHub.Publish(*event)
+			}
+		})
+		if err == nil || ctx.Err() != nil {
+			break
+		}
+
+		// the notifications sent while reconnecting are lost, the subscribers still get the current state
+		if time.Since(start) > time.Minute {
+			backoff = time.Second
+		}
+		s.logger.Warn().
+				Ctx(ctx).
+				Err(err).
+				Dur("retry_in", backoff).
+				Msg("inventory stream disconnected")
+
+		select {
+		case <-ctx.Done():
+		case <-time.After(backoff):
+		}
+		backoff = min(backoff * 2, 30 * time.Second)
+	}
+
+	s.inventoryHub.Close()
+}
+
+// About stop all the open streams
+func (s *WorkerService) CloseInventoryStream() {
+	s.inventoryHub.Close()
+}
+
+// About subscribe the changes of the inventories of the skus. The events missed since lastEventID are returned
+// when they are still in the buffer of the pod, otherwise (or without lastEventID) the current state of each sku
+func (s *WorkerService) SubscribeInventory(ctx context.Context, skus []string, lastEventID string) (*InventorySubscription, []model.InventoryEvent, error){
+	s.logger.Info().
+			Ctx(ctx).
+			Str("func","SubscribeInventory").Send()
+
+	// a caller restricted to some skus must name them, the stream of every sku is not filtered
+	if principal := security.PrincipalFrom(ctx); principal != nil && len(principal.SkuPrefixes) > 0 && len(skus) == 0 {
+		return nil, nil, erro.ErrSkuForbidden
+	}
+	if err := authorizeSku(ctx, skus...); err != nil {
+		return nil, nil, err
+	}
+
+	subscription, list_event, found := s.inventoryHub.Subscribe(security.TenantFrom(ctx), skus, lastEventID)
+	if found {
+		return subscription, list_event, nil
+	}
+
+	// the current state is read after the subscription, so a change committed meanwhile is also sent as event
+	map_inventory, err := s.GetInventoryBatch(ctx, skus)
+	if err != nil {
+		s.inventoryHub.Unsubscribe(subscription)
+		return nil, nil, err
+	}
+
+	list_event = []model.InventoryEvent{}
+	for _, sku := range skus {
+		inventory, ok := map_inventory[sku]
+		if !ok {
+			continue
+		}
+		list_event = append(list_event, model.InventoryEvent{	Type: model.EventInventorySnapshot,
+																Tenant: security.TenantFrom(ctx),
+																Inventory: *inventory,
+																CreatedAt: time.Now()})
+	}
+
+	return subscription, list_event, nil
+}
+
+// About stop a subscription
+func (s *WorkerService) UnsubscribeInventory(subscription *InventorySubscription) {
+	s.inventoryHub.Unsubscribe(subscription)
+}
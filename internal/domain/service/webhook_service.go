@@ -0,0 +1,360 @@
+package service
+
+import (
+	"time"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+
+	"github.com/google/uuid"
+
+	"github.com/go-inventory/internal/domain/model"
+	"github.com/go-inventory/shared/erro"
+	"github.com/go-inventory/shared/security"
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/codes"
+)
+
+// WebhookSender posts a delivery to its webhook, returning the http status answered (zero when there is no answer).
+// A nil error means the webhook acknowledged the delivery
+type WebhookSender interface {
+	Send(ctx context.Context, delivery *model.WebhookDelivery) (int, error)
+}
+
+// Helper function to generate the secret used to sign the payloads of a webhook
+func webhookSecret() (string, error) {
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(secret), nil
+}
+
+// Helper function to tell the alert raised by a change of the available stock, if any. Hitting zero is a
+// stockout, crossing below the threshold (from at or above it) is a low stock
+func webhookAlertType(webhook *model.Webhook, previousAvailable int, available int) string {
+	if previousAvailable > 0 && available <= 0 {
+		return model.WebhookStockout
+	}
+	if previousAvailable >= webhook.Threshold && available < webhook.Threshold {
+		return model.WebhookLowStock
+	}
+	return ""
+}
+
+// Helper function to queue the alerts of a inventory change to the webhooks of the sku or of its product type,
+// inside the transaction of the change so a rollback raises no alert
+func (s *WorkerService) addWebhookDeliveries(ctx context.Context, tx Tx, inventory *model.Inventory, previousAvailable int) error {
+	if inventory.Available >= previousAvailable {
+		return nil
+	}
+
+	list_webhook, err := s.workerRepository.ListWebhookMatch(ctx, tx, &inventory.Product)
+	if err != nil {
+		return err
+	}
+
+	for i := range *list_webhook {
+		webhook := &(*list_webhook)[i]
+
+		alertType := webhookAlertType(webhook, previousAvailable, inventory.Available)
+		if alertType == "" {
+			continue
+		}
+
+		alert := model.WebhookAlert{
+			ID:					uuid.New().String(),
+			Type:				alertType,
+			WebhookID:			webhook.ID,
+			Threshold:			webhook.Threshold,
+			PreviousAvailable:	previousAvailable,
+			Inventory:			*inventory,
+			CreatedAt:			time.Now(),
+		}
+		payload, err := json.Marshal(alert)
+		if err != nil {
+			return err
+		}
+
+		delivery := model.WebhookDelivery{
+			WebhookID:	webhook.ID,
+			EventID:	alert.ID,
+			Type:		alertType,
+			Sku:		inventory.Product.Sku,
+			Payload:	payload,
+			Status:		model.DeliveryPending,
+			CreatedAt:	alert.CreatedAt,
+		}
+		_, err = s.workerRepository.AddWebhookDelivery(ctx, tx, &delivery)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// About register a webhook, the secret is generated when not informed and is only returned here
+func (s *WorkerService) AddWebhook(ctx context.Context, webhook *model.Webhook) (*model.Webhook, error){
+	s.logger.Info().
+			Ctx(ctx).
+			Str("func","AddWebhook").Send()
+
+	// Trace
+	ctx, span := s.tracerProvider.SpanCtx(ctx, "service.AddWebhook", trace.SpanKindServer)
+	defer span.End()
+
+	if webhook.Secret == "" {
+		secret, err := webhookSecret()
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+			return nil, err
+		}
+		webhook.Secret = secret
+	}
+
+	// prepare database
+	tx, err := s.workerRepository.StartTx(ctx)
+	if err != nil {
+		span.RecordError(err)
+        span.SetStatus(codes.Error, err.Error())
+		return nil, err
+	}
+
+	// handle connection and transaction
+	defer func() {
+		if err != nil {
+			tx.Rollback(ctx)
+		} else {
+			tx.Commit(ctx)
+		}
+	}()
+
+	webhook.Active = true
+	webhook.CreatedAt = time.Now()
+
+	res, err := s.workerRepository.AddWebhook(ctx, tx, webhook)
+	if err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
+
+// About get a webhook, without its secret
+func (s *WorkerService) GetWebhook(ctx context.Context, webhook *model.Webhook) (*model.Webhook, error){
+	result, err := s.callRepositoryRead(ctx, "GetWebhook", func(ctx context.Context) (interface{}, error) {
+		return s.workerRepository.GetWebhook(ctx, webhook)
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	resWebhook := result.(*model.Webhook)
+	resWebhook.Secret = ""
+	return resWebhook, nil
+}
+
+// About deactivate a webhook, its pending deliveries are no longer posted
+func (s *WorkerService) DeleteWebhook(ctx context.Context, webhook *model.Webhook) error{
+	s.logger.Info().
+			Ctx(ctx).
+			Str("func","DeleteWebhook").Send()
+
+	// Trace
+	ctx, span := s.tracerProvider.SpanCtx(ctx, "service.DeleteWebhook", trace.SpanKindServer)
+	defer span.End()
+
+	// prepare database
+	tx, err := s.workerRepository.StartTx(ctx)
+	if err != nil {
+		span.RecordError(err)
+        span.SetStatus(codes.Error, err.Error())
+		return err
+	}
+
+	// handle connection and transaction
+	defer func() {
+		if err != nil {
+			tx.Rollback(ctx)
+		} else {
+			tx.Commit(ctx)
+		}
+	}()
+
+	now := time.Now()
+	webhook.UpdatedAt = &now
+
+	row, err := s.workerRepository.DeactivateWebhook(ctx, tx, webhook)
+	if err != nil {
+		return err
+	}
+	if row == 0 {
+		err = erro.ErrNotFound
+		return err
+	}
+
+	return nil
+}
+
+// About list the delivery log of a webhook
+func (s *WorkerService) ListWebhookDelivery(ctx context.Context, limit int, offset int, delivery *model.WebhookDelivery) (*[]model.WebhookDelivery, error){
+	result, err := s.callRepositoryRead(ctx, "ListWebhookDelivery", func(ctx context.Context) (interface{}, error) {
+		// a unknown webhook is not found rather than a empty log
+		_, err := s.workerRepository.GetWebhook(ctx, &model.Webhook{ID: delivery.WebhookID})
+		if err != nil {
+			return nil, err
+		}
+		return s.workerRepository.ListWebhookDelivery(ctx, limit, offset, delivery)
+	})
+
+	if err != nil {
+		return nil, err
+	}
+	return result.(*[]model.WebhookDelivery), nil
+}
+
+// About move a dead delivery back to pending, the dispatcher posts it again on its next poll
+func (s *WorkerService) RetryWebhookDelivery(ctx context.Context, delivery *model.WebhookDelivery) (*model.WebhookDelivery, error){
+	s.logger.Info().
+			Ctx(ctx).
+			Str("func","RetryWebhookDelivery").Send()
+
+	// Trace
+	ctx, span := s.tracerProvider.SpanCtx(ctx, "service.RetryWebhookDelivery", trace.SpanKindServer)
+	defer span.End()
+
+	// prepare database
+	tx, err := s.workerRepository.StartTx(ctx)
+	if err != nil {
+		span.RecordError(err)
+        span.SetStatus(codes.Error, err.Error())
+		return nil, err
+	}
+
+	// handle connection and transaction
+	defer func() {
+		if err != nil {
+			tx.Rollback(ctx)
+		} else {
+			tx.Commit(ctx)
+		}
+	}()
+
+	res, err := s.workerRepository.RetryWebhookDelivery(ctx, tx, delivery)
+	if err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
+
+// About post a batch of pending deliveries, returning how many were delivered. A delivery that exhausts the
+// attempts is dead (dead-letter) and stays in the log until it is retried
+func (s *WorkerService) DispatchWebhooks(ctx context.Context, sender WebhookSender) (int, error){
+	s.logger.Debug().
+			Ctx(ctx).
+			Str("func","DispatchWebhooks").Send()
+
+	// Trace
+	ctx, span := s.tracerProvider.SpanCtx(ctx, "service.DispatchWebhooks", trace.SpanKindServer)
+	defer span.End()
+
+	webhookConfig := s.appServer.WebhookConfig
+
+	// prepare database
+	tx, err := s.workerRepository.StartTx(ctx)
+	if err != nil {
+		span.RecordError(err)
+        span.SetStatus(codes.Error, err.Error())
+		return 0, err
+	}
+
+	// handle connection and transaction
+	defer func() {
+		if err != nil {
+			tx.Rollback(ctx)
+		} else {
+			tx.Commit(ctx)
+		}
+	}()
+
+	list_delivery, err := s.workerRepository.ListWebhookDeliveryPendingForUpdate(ctx, tx, webhookConfig.BatchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	delivered := 0
+	for i := range *list_delivery {
+		delivery := &(*list_delivery)[i]
+
+		delivery.Attempts++
+		sendCtx, cancel := context.WithTimeout(ctx, time.Duration(webhookConfig.Timeout) * time.Second)
+		statusCode, errSend := sender.Send(sendCtx, delivery)
+		cancel()
+
+		delivery.LastStatusCode = statusCode
+		if errSend == nil {
+			now := time.Now()
+			delivery.Status = model.DeliveryDelivered
+			delivery.DeliveredAt = &now
+			delivery.LastError = ""
+			delivered++
+		} else {
+			s.logger.Warn().
+					Ctx(ctx).
+					Err(errSend).
+					Int("delivery_id", delivery.ID).
+					Int("attempts", delivery.Attempts).
+					Msg("webhook delivery not acknowledged")
+
+			delivery.LastError = errSend.Error()
+			delivery.NextAttemptAt = time.Now().Add(outboxBackoff(delivery.Attempts))
+			if delivery.Attempts >= webhookConfig.MaxAttempts {
+				delivery.Status = model.DeliveryDead
+			}
+		}
+
+		_, err = s.workerRepository.UpdateWebhookDelivery(ctx, tx, delivery)
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	return delivered, nil
+}
+
+// About dispatch the webhook deliveries until the context is done, a full batch is followed by the next one without waiting
+func (s *WorkerService) StartWebhookDispatcher(ctx context.Context, sender WebhookSender) {
+	s.logger.Info().
+			Ctx(ctx).
+			Str("func","StartWebhookDispatcher").Send()
+
+	// the dispatcher posts the deliveries of every tenant
+	ctx = security.WithTenant(ctx, security.SystemTenant)
+
+	interval := time.Duration(s.appServer.WebhookConfig.PollInterval) * time.Millisecond
+	for {
+		delivered, err := s.DispatchWebhooks(ctx, sender)
+		if err != nil && ctx.Err() == nil {
+			s.logger.Error().
+					Ctx(ctx).
+					Err(err).
+					Msg("webhook dispatcher FAILED")
+		}
+
+		wait := interval
+		if err == nil && delivered >= s.appServer.WebhookConfig.BatchSize {
+			wait = 0
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(wait):
+		}
+	}
+}
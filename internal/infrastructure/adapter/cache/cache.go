@@ -0,0 +1,116 @@
+package cache
+
+import (
+	"fmt"
+	"time"
+	"strings"
+	"context"
+
+	"github.com/rs/zerolog"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/attribute"
+
+	"github.com/go-inventory/internal/domain/model"
+	"github.com/go-inventory/internal/domain/service"
+)
+
+// Above create the cache of the backend informed in the config, nil when the cache is disabled
+func NewCache(appServer *model.AppServer, appLogger *zerolog.Logger) (service.Cache, error) {
+	logger := appLogger.With().
+				Str("package", "adapter.cache").
+				Logger()
+
+	cacheConfig := appServer.CacheConfig
+
+	logger.Info().
+			Str("func","NewCache").
+			Str("backend", cacheConfig.Backend).Send()
+
+	var backend service.Cache
+	switch cacheConfig.Backend {
+	case model.CacheMemory:
+		backend = NewMemoryCache(cacheConfig.Size)
+	case model.CacheRedis:
+		backend = NewRedisCache(cacheConfig)
+	case model.CacheNone:
+		return nil, nil
+	default:
+		return nil, fmt.Errorf("unknown cache backend: %s", cacheConfig.Backend)
+	}
+
+	metered, err := newMeteredCache(backend, appServer.Application.Name, cacheConfig.Backend)
+	if err != nil {
+		backend.Close()
+		return nil, err
+	}
+	return metered, nil
+}
+
+// meteredCache counts the hits, misses and errors of a cache by entity (the first segment of the key)
+type meteredCache struct {
+	service.Cache
+	backend			attribute.KeyValue
+	hitMetric		metric.Int64Counter
+	missMetric		metric.Int64Counter
+	errorMetric		metric.Int64Counter
+}
+
+// Helper function to wrap a cache with its counters, the meter of the global provider takes effect once it is set
+func newMeteredCache(backend service.Cache, appName string, name string) (*meteredCache, error) {
+	meter := otel.Meter(appName)
+
+	hitMetric, err := meter.Int64Counter("custom_cache_hit_count")
+	if err != nil {
+		return nil, err
+	}
+	missMetric, err := meter.Int64Counter("custom_cache_miss_count")
+	if err != nil {
+		return nil, err
+	}
+	errorMetric, err := meter.Int64Counter("custom_cache_error_count")
+	if err != nil {
+		return nil, err
+	}
+
+	return &meteredCache{
+		Cache: backend,
+		backend: attribute.String("backend", name),
+		hitMetric: hitMetric,
+		missMetric: missMetric,
+		errorMetric: errorMetric,
+	}, nil
+}
+
+// Helper function to get the entity of a key
+func entityOf(key string) string {
+	entity, _, _ := strings.Cut(key, ":")
+	return entity
+}
+
+// About get a key, counting the hit or miss
+func (c *meteredCache) Get(ctx context.Context, key string) ([]byte, bool, error) {
+	value, found, err := c.Cache.Get(ctx, key)
+
+	attrs := metric.WithAttributes(c.backend, attribute.String("entity", entityOf(key)))
+	switch {
+	case err != nil:
+		c.errorMetric.Add(ctx, 1, attrs)
+	case found:
+		c.hitMetric.Add(ctx, 1, attrs)
+	default:
+		c.missMetric.Add(ctx, 1, attrs)
+	}
+
+	return value, found, err
+}
+
+// About set a key, counting the errors
+func (c *meteredCache) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	err := c.Cache.Set(ctx, key, value, ttl)
+	if err != nil {
+		c.errorMetric.Add(ctx, 1, metric.WithAttributes(c.backend, attribute.String("entity", entityOf(key))))
+	}
+	return err
+}
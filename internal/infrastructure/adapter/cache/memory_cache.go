@@ -0,0 +1,97 @@
+package cache
+
+import (
+	"sync"
+	"time"
+	"context"
+	"container/list"
+)
+
+// entry of the memory cache
+type memoryEntry struct {
+	key			string
+	value		[]byte
+	expiresAt	time.Time
+}
+
+// MemoryCache keeps the keys in the memory of the pod, the least recently used key is evicted when the size is
+// reached. Each pod has its own copy, the invalidations of the other pods arrive by the inventory stream
+type MemoryCache struct {
+	size		int
+	mu			sync.Mutex
+	order		*list.List
+	entries		map[string]*list.Element
+}
+
+// About create a memory cache of a number of keys
+func NewMemoryCache(size int) *MemoryCache {
+	return &MemoryCache{
+		size: size,
+		order: list.New(),
+		entries: make(map[string]*list.Element),
+	}
+}
+
+// About get a key not expired
+func (c *MemoryCache) Get(ctx context.Context, key string) ([]byte, bool, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	element, ok := c.entries[key]
+	if !ok {
+		return nil, false, nil
+	}
+
+	entry := element.Value.(*memoryEntry)
+	if time.Now().After(entry.expiresAt) {
+		c.remove(element)
+		return nil, false, nil
+	}
+
+	c.order.MoveToFront(element)
+	return entry.value, true, nil
+}
+
+// About set a key for a while, evicting the least recently used keys over the size
+func (c *MemoryCache) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	expiresAt := time.Now().Add(ttl)
+	if element, ok := c.entries[key]; ok {
+		entry := element.Value.(*memoryEntry)
+		entry.value, entry.expiresAt = value, expiresAt
+		c.order.MoveToFront(element)
+		return nil
+	}
+
+	c.entries[key] = c.order.PushFront(&memoryEntry{key: key, value: value, expiresAt: expiresAt})
+	for c.size > 0 && c.order.Len() > c.size {
+		c.remove(c.order.Back())
+	}
+	return nil
+}
+
+// About delete keys
+func (c *MemoryCache) Delete(ctx context.Context, keys ...string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for _, key := range keys {
+		if element, ok := c.entries[key]; ok {
+			c.remove(element)
+		}
+	}
+	return nil
+}
+
+// About close the cache
+func (c *MemoryCache) Close() error {
+	return nil
+}
+
+// Helper function to remove a element of the list and of the map
+func (c *MemoryCache) remove(element *list.Element) {
+	c.order.Remove(element)
+	delete(c.entries, element.Value.(*memoryEntry).key)
+}
@@ -0,0 +1,202 @@
+package cache
+
+import (
+	"io"
+	"net"
+	"fmt"
+	"bufio"
+	"errors"
+	"time"
+	"context"
+	"strconv"
+
+	"github.com/go-inventory/internal/domain/model"
+)
+
+// reply of nil of the redis protocol (a key not found)
+var errRedisNil = errors.New("redis: nil")
+
+// redisConn is a connection of the pool with its buffers
+type redisConn struct {
+	conn	net.Conn
+	reader	*bufio.Reader
+	writer	*bufio.Writer
+}
+
+// RedisCache keeps the keys in a redis shared by every pod, speaking the redis protocol (RESP) over a pool of
+// connections. Every key is prefixed, so the service can share a redis with others
+type RedisCache struct {
+	addr		string
+	password	string
+	db			int
+	prefix		string
+	timeout		time.Duration
+	idle		chan *redisConn
+}
+
+// About create a redis cache, the connections are opened on demand
+func NewRedisCache(cacheConfig *model.CacheConfig) *RedisCache {
+	return &RedisCache{
+		addr: cacheConfig.RedisAddr,
+		password: cacheConfig.RedisPassword,
+		db: cacheConfig.RedisDB,
+		prefix: cacheConfig.RedisPrefix,
+		timeout: time.Duration(cacheConfig.RedisTimeout) * time.Millisecond,
+		idle: make(chan *redisConn, cacheConfig.RedisPoolSize),
+	}
+}
+
+// About get a key
+func (c *RedisCache) Get(ctx context.Context, key string) ([]byte, bool, error) {
+	reply, err := c.do(ctx, "GET", c.prefix + key)
+	if errors.Is(err, errRedisNil) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	return reply, true, nil
+}
+
+// About set a key for a while
+func (c *RedisCache) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	_, err := c.do(ctx, "SET", c.prefix + key, string(value), "PX", strconv.FormatInt(ttl.Milliseconds(), 10))
+	return err
+}
+
+// About delete keys
+func (c *RedisCache) Delete(ctx context.Context, keys ...string) error {
+	if len(keys) == 0 {
+		return nil
+	}
+
+	args := []string{"DEL"}
+	for _, key := range keys {
+		args = append(args, c.prefix + key)
+	}
+	_, err := c.do(ctx, args...)
+	return err
+}
+
+// About close the idle connections
+func (c *RedisCache) Close() error {
+	for {
+		select {
+		case rc := <-c.idle:
+			rc.conn.Close()
+		default:
+			return nil
+		}
+	}
+}
+
+// Helper function to run a command in a connection of the pool, a connection with a error is discarded
+func (c *RedisCache) do(ctx context.Context, args ...string) ([]byte, error) {
+	rc, err := c.get(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	reply, err := rc.command(ctx, c.timeout, args...)
+	if err != nil && !errors.Is(err, errRedisNil) {
+		rc.conn.Close()
+		return nil, err
+	}
+
+	c.put(rc)
+	return reply, err
+}
+
+// Helper function to take a idle connection, else dial a new one
+func (c *RedisCache) get(ctx context.Context) (*redisConn, error) {
+	select {
+	case rc := <-c.idle:
+		return rc, nil
+	default:
+	}
+
+	dialer := net.Dialer{Timeout: c.timeout}
+	conn, err := dialer.DialContext(ctx, "tcp", c.addr)
+	if err != nil {
+		return nil, err
+	}
+
+	rc := &redisConn{conn: conn, reader: bufio.NewReader(conn), writer: bufio.NewWriter(conn)}
+	if c.password != "" {
+		if _, err := rc.command(ctx, c.timeout, "AUTH", c.password); err != nil {
+			conn.Close()
+			return nil, err
+		}
+	}
+	if c.db != 0 {
+		if _, err := rc.command(ctx, c.timeout, "SELECT", strconv.Itoa(c.db)); err != nil {
+			conn.Close()
+			return nil, err
+		}
+	}
+	return rc, nil
+}
+
+// Helper function to give back a connection, it is closed when the pool is full
+func (c *RedisCache) put(rc *redisConn) {
+	select {
+	case c.idle <- rc:
+	default:
+		rc.conn.Close()
+	}
+}
+
+// Helper function to write a command and read its reply, within the timeout or the deadline of the context
+func (rc *redisConn) command(ctx context.Context, timeout time.Duration, args ...string) ([]byte, error) {
+	deadline := time.Now().Add(timeout)
+	if ctxDeadline, ok := ctx.Deadline(); ok && ctxDeadline.Before(deadline) {
+		deadline = ctxDeadline
+	}
+	if err := rc.conn.SetDeadline(deadline); err != nil {
+		return nil, err
+	}
+
+	fmt.Fprintf(rc.writer, "*%d\r\n", len(args))
+	for _, arg := range args {
+		fmt.Fprintf(rc.writer, "$%d\r\n%s\r\n", len(arg), arg)
+	}
+	if err := rc.writer.Flush(); err != nil {
+		return nil, err
+	}
+
+	return rc.reply()
+}
+
+// Helper function to read a reply, only the types answered by the commands of the cache
+func (rc *redisConn) reply() ([]byte, error) {
+	line, err := rc.reader.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	if len(line) < 3 {
+		return nil, fmt.Errorf("redis: invalid reply %q", line)
+	}
+	line = line[:len(line)-2]
+
+	switch line[0] {
+	case '+', ':':
+		return []byte(line[1:]), nil
+	case '-':
+		return nil, fmt.Errorf("redis: %s", line[1:])
+	case '$':
+		size, err := strconv.Atoi(line[1:])
+		if err != nil {
+			return nil, fmt.Errorf("redis: invalid reply %q", line)
+		}
+		if size < 0 {
+			return nil, errRedisNil
+		}
+		value := make([]byte, size + 2)
+		if _, err := io.ReadFull(rc.reader, value); err != nil {
+			return nil, err
+		}
+		return value[:size], nil
+	}
+
+	return nil, fmt.Errorf("redis: unexpected reply %q", line)
+}
@@ -0,0 +1,52 @@
+package event
+
+import (
+	"context"
+
+	"github.com/rs/zerolog"
+
+	"github.com/go-inventory/internal/domain/model"
+)
+
+// ChannelOrderBroker delivers the order events published in memory, for tests
+type ChannelOrderBroker struct {
+	messages	chan []byte
+	logger		*zerolog.Logger
+}
+
+// About create a channel broker of order events, the publish blocks when size messages are waiting
+func NewChannelOrderBroker(size int, logger *zerolog.Logger) *ChannelOrderBroker {
+	return &ChannelOrderBroker{
+		messages: make(chan []byte, size),
+		logger: logger,
+	}
+}
+
+// About publish a message
+func (b *ChannelOrderBroker) Publish(ctx context.Context, payload []byte) error {
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case b.messages <- payload:
+		return nil
+	}
+}
+
+// About consume the order events until the context is done
+func (b *ChannelOrderBroker) Consume(ctx context.Context, handler func(context.Context, *model.OrderEvent) error) error {
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case payload := <-b.messages:
+			if err := deliverOrderEvent(ctx, b.logger, handler, payload); err != nil {
+				return nil
+			}
+		}
+	}
+}
+
+// About close the broker
+func (b *ChannelOrderBroker) Close() error {
+	return nil
+}
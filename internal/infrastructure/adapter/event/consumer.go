@@ -0,0 +1,118 @@
+package event
+
+import (
+	"fmt"
+	"time"
+	"context"
+	"encoding/json"
+
+	"github.com/rs/zerolog"
+
+	"github.com/go-inventory/shared/validator"
+	"github.com/go-inventory/internal/domain/model"
+	"github.com/go-inventory/internal/domain/service"
+)
+
+// longest wait between two deliveries of a event whose handler failed
+const maxConsumerBackoff = time.Minute
+
+// Above create the broker of the order events informed in the config, nil when the consumer is disabled
+func NewOrderBroker(appServer *model.AppServer, appLogger *zerolog.Logger) (service.OrderBroker, error) {
+	logger := appLogger.With().
+				Str("package", "adapter.event").
+				Logger()
+
+	consumerConfig := appServer.OrderConsumerConfig
+
+	logger.Info().
+			Str("func","NewOrderBroker").
+			Str("broker", consumerConfig.Broker).Send()
+
+	switch consumerConfig.Broker {
+	case model.ConsumerKafka:
+		return NewKafkaOrderBroker(consumerConfig.KafkaBrokers, consumerConfig.KafkaTopic, consumerConfig.KafkaGroup, &logger), nil
+	case model.ConsumerNats:
+		return NewNatsOrderBroker(consumerConfig.NatsURL, consumerConfig.NatsSubject, consumerConfig.NatsDurable, &logger)
+	case model.ConsumerFile:
+		return NewFileOrderBroker(consumerConfig.FilePath, &logger), nil
+	case model.ConsumerNone:
+		return nil, nil
+	}
+
+	return nil, fmt.Errorf("unknown broker: %s", consumerConfig.Broker)
+}
+
+// Rules of a order event
+func orderEventRules(event *model.OrderEvent) validator.Rule {
+	return func(v *validator.Validator) {
+		v.Required("id", event.ID).
+		  MaxLength("id", event.ID, 100).
+		  Required("type", event.Type).
+		  OneOf("type", event.Type, model.OrderCreated, model.OrderPaid, model.OrderCancelled, model.OrderShipped).
+		  Required("order_id", event.OrderID).
+		  MaxLength("order_id", event.OrderID, 100).
+		  MaxLength("tenant", event.Tenant, 63).
+		  Pattern("tenant", event.Tenant, validator.TenantPattern).
+		  Check(len(event.Items) > 0, "items", "is required")
+		for _, item := range event.Items {
+			v.Required("items.sku", item.Sku).
+			  MaxLength("items.sku", item.Sku, 100).
+			  Pattern("items.sku", item.Sku, validator.SkuPattern).
+			  Min("items.quantity", item.Quantity, 1)
+		}
+	}
+}
+
+// Helper function to decode and validate a order event
+func decodeOrderEvent(payload []byte) (*model.OrderEvent, error) {
+	event := model.OrderEvent{}
+	if err := json.Unmarshal(payload, &event); err != nil {
+		return nil, err
+	}
+	if err := validator.Validate(orderEventRules(&event)); err != nil {
+		return nil, err
+	}
+	return &event, nil
+}
+
+// Helper function to compute the wait before the next delivery (exponential)
+func consumerBackoff(attempts int) time.Duration {
+	backoff := time.Second << min(attempts, 6)
+	return min(backoff, maxConsumerBackoff)
+}
+
+// Helper function to deliver a message to the handler until it succeeds or the context is done, so the next
+// message waits (the order of the partition or file is kept). A message that is not a order event is dropped
+func deliverOrderEvent(ctx context.Context,
+						logger *zerolog.Logger,
+						handler func(context.Context, *model.OrderEvent) error,
+						payload []byte) error {
+	event, err := decodeOrderEvent(payload)
+	if err != nil {
+		logger.Warn().
+				Ctx(ctx).
+				Err(err).
+				Msg("order event dropped, invalid message")
+		return nil
+	}
+
+	for attempts := 0; ; attempts++ {
+		err = handler(ctx, event)
+		if err == nil {
+			return nil
+		}
+
+		logger.Warn().
+				Ctx(ctx).
+				Err(err).
+				Str("event_id", event.ID).
+				Int("attempts", attempts + 1).
+				Msg("order event not handled")
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(consumerBackoff(attempts)):
+		}
+	}
+}
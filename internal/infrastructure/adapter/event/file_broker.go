@@ -0,0 +1,75 @@
+package event
+
+import (
+	"io"
+	"os"
+	"bufio"
+	"bytes"
+	"time"
+	"context"
+
+	"github.com/rs/zerolog"
+
+	"github.com/go-inventory/internal/domain/model"
+)
+
+// wait for new lines at the end of the file
+const filePollInterval = 500 * time.Millisecond
+
+// FileOrderBroker reads the order events of a file, one json per line, and waits for the lines appended
+// (as tail -f). The position is not kept, a restart reads the file again and the consumed events are skipped
+type FileOrderBroker struct {
+	path		string
+	logger		*zerolog.Logger
+}
+
+// About create a file broker of order events, for local development
+func NewFileOrderBroker(path string, logger *zerolog.Logger) *FileOrderBroker {
+	return &FileOrderBroker{
+		path: path,
+		logger: logger,
+	}
+}
+
+// About consume the order events until the context is done
+func (b *FileOrderBroker) Consume(ctx context.Context, handler func(context.Context, *model.OrderEvent) error) error {
+	file, err := os.Open(b.path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	reader := bufio.NewReader(file)
+	var line []byte
+	for {
+		chunk, err := reader.ReadBytes('\n')
+		line = append(line, chunk...)
+		if err == io.EOF {
+			// a line without the new line may be still written
+			select {
+			case <-ctx.Done():
+				return nil
+			case <-time.After(filePollInterval):
+			}
+			continue
+		}
+		if err != nil {
+			return err
+		}
+
+		payload := bytes.TrimSpace(line)
+		line = nil
+		if len(payload) == 0 {
+			continue
+		}
+
+		if err := deliverOrderEvent(ctx, b.logger, handler, payload); err != nil {
+			return nil
+		}
+	}
+}
+
+// About close the broker
+func (b *FileOrderBroker) Close() error {
+	return nil
+}
@@ -0,0 +1,58 @@
+package event
+
+import (
+	"io"
+	"fmt"
+	"bytes"
+	"context"
+	"net/http"
+	"encoding/json"
+
+	"github.com/go-inventory/internal/domain/model"
+)
+
+// HttpPublisher posts each event to a webhook, any 2xx is a acknowledgement
+type HttpPublisher struct {
+	url			string
+	client		*http.Client
+}
+
+// About create a http publisher
+func NewHttpPublisher(url string) *HttpPublisher {
+	return &HttpPublisher{
+		url: url,
+		client: &http.Client{},
+	}
+}
+
+// About post a event, the deadline comes from the context
+func (p *HttpPublisher) Publish(ctx context.Context, event *model.CloudEvent) error {
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.url, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", cloudEventContentType)
+
+	res, err := p.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	io.Copy(io.Discard, res.Body)
+
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		return fmt.Errorf("webhook answered http %d", res.StatusCode)
+	}
+	return nil
+}
+
+// About close the publisher
+func (p *HttpPublisher) Close() error {
+	p.client.CloseIdleConnections()
+	return nil
+}
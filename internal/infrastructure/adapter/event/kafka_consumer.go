@@ -0,0 +1,59 @@
+package event
+
+import (
+	"context"
+
+	"github.com/rs/zerolog"
+	"github.com/segmentio/kafka-go"
+
+	"github.com/go-inventory/internal/domain/model"
+)
+
+// KafkaOrderBroker reads the order events of a topic in a consumer group, the offset is committed after the
+// event is handled, so a event is delivered again when the pod stops before the commit
+type KafkaOrderBroker struct {
+	reader		*kafka.Reader
+	logger		*zerolog.Logger
+}
+
+// About create a kafka broker of order events
+func NewKafkaOrderBroker(brokers []string, topic string, group string, logger *zerolog.Logger) *KafkaOrderBroker {
+	return &KafkaOrderBroker{
+		reader: kafka.NewReader(kafka.ReaderConfig{
+			Brokers: brokers,
+			Topic: topic,
+			GroupID: group,
+			MinBytes: 1,
+			MaxBytes: 10e6,
+		}),
+		logger: logger,
+	}
+}
+
+// About consume the order events until the context is done
+func (b *KafkaOrderBroker) Consume(ctx context.Context, handler func(context.Context, *model.OrderEvent) error) error {
+	for {
+		msg, err := b.reader.FetchMessage(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return err
+		}
+
+		err = deliverOrderEvent(ctx, b.logger, handler, msg.Value)
+		if err != nil {
+			return nil
+		}
+
+		err = b.reader.CommitMessages(ctx, msg)
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// About close the broker
+func (b *KafkaOrderBroker) Close() error {
+	return b.reader.Close()
+}
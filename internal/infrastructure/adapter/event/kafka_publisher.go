@@ -0,0 +1,52 @@
+package event
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/segmentio/kafka-go"
+
+	"github.com/go-inventory/internal/domain/model"
+)
+
+// KafkaPublisher writes each event to a topic keyed by the subject (sku), so the events of a
+// product keep its order in the partition. The write waits the acknowledgement of all replicas
+type KafkaPublisher struct {
+	writer		*kafka.Writer
+}
+
+// About create a kafka publisher
+func NewKafkaPublisher(brokers []string, topic string) *KafkaPublisher {
+	return &KafkaPublisher{
+		writer: &kafka.Writer{
+			Addr: kafka.TCP(brokers...),
+			Topic: topic,
+			Balancer: &kafka.Hash{},
+			RequiredAcks: kafka.RequireAll,
+			AllowAutoTopicCreation: false,
+		},
+	}
+}
+
+// About write a event
+func (p *KafkaPublisher) Publish(ctx context.Context, event *model.CloudEvent) error {
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	return p.writer.WriteMessages(ctx, kafka.Message{
+		Key: []byte(event.Subject),
+		Value: payload,
+		Headers: []kafka.Header{
+			{Key: "content-type", Value: []byte(cloudEventContentType)},
+			{Key: "ce_id", Value: []byte(event.ID)},
+			{Key: "ce_type", Value: []byte(event.Type)},
+		},
+	})
+}
+
+// About close the publisher
+func (p *KafkaPublisher) Close() error {
+	return p.writer.Close()
+}
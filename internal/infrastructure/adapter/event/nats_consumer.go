@@ -0,0 +1,111 @@
+package event
+
+import (
+	"time"
+	"errors"
+	"context"
+
+	"github.com/rs/zerolog"
+	"github.com/nats-io/nats.go"
+
+	"github.com/go-inventory/internal/domain/model"
+)
+
+// wait of a fetch without messages
+const natsFetchWait = 5 * time.Second
+
+// NatsOrderBroker pulls the order events of a JetStream durable consumer, a event is acknowledged after it is
+// handled and a event whose handler failed is delivered again after a backoff
+type NatsOrderBroker struct {
+	conn		*nats.Conn
+	jetStream	nats.JetStreamContext
+	subject		string
+	durable		string
+	logger		*zerolog.Logger
+}
+
+// About create a nats broker of order events, a stream must capture the subject
+func NewNatsOrderBroker(url string, subject string, durable string, logger *zerolog.Logger) (*NatsOrderBroker, error) {
+	conn, err := nats.Connect(url,
+							nats.Name("go-inventory-order-consumer"),
+							nats.MaxReconnects(-1),
+							nats.RetryOnFailedConnect(true),
+							nats.DisconnectErrHandler(func(_ *nats.Conn, err error) {
+								logger.Warn().
+										Err(err).
+										Msg("nats disconnected")
+							}))
+	if err != nil {
+		return nil, err
+	}
+
+	jetStream, err := conn.JetStream()
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	return &NatsOrderBroker{
+		conn: conn,
+		jetStream: jetStream,
+		subject: subject,
+		durable: durable,
+		logger: logger,
+	}, nil
+}
+
+// About consume the order events until the context is done
+func (b *NatsOrderBroker) Consume(ctx context.Context, handler func(context.Context, *model.OrderEvent) error) error {
+	sub, err := b.jetStream.PullSubscribe(b.subject, b.durable, nats.ManualAck())
+	if err != nil {
+		return err
+	}
+	defer sub.Unsubscribe()
+
+	for {
+		fetchCtx, cancel := context.WithTimeout(ctx, natsFetchWait)
+		list_msg, err := sub.Fetch(10, nats.Context(fetchCtx))
+		cancel()
+		if ctx.Err() != nil {
+			return nil
+		}
+		if err != nil && !errors.Is(err, nats.ErrTimeout) && !errors.Is(err, context.DeadlineExceeded) {
+			return err
+		}
+
+		for _, msg := range list_msg {
+			event, err := decodeOrderEvent(msg.Data)
+			if err != nil {
+				b.logger.Warn().
+						Ctx(ctx).
+						Err(err).
+						Msg("order event dropped, invalid message")
+				msg.Term()
+				continue
+			}
+
+			err = handler(ctx, event)
+			if err == nil {
+				msg.Ack()
+				continue
+			}
+
+			attempts := 0
+			if metadata, errMetadata := msg.Metadata(); errMetadata == nil {
+				attempts = int(metadata.NumDelivered)
+			}
+			b.logger.Warn().
+					Ctx(ctx).
+					Err(err).
+					Str("event_id", event.ID).
+					Int("attempts", attempts).
+					Msg("order event not handled")
+			msg.NakWithDelay(consumerBackoff(attempts))
+		}
+	}
+}
+
+// About close the broker
+func (b *NatsOrderBroker) Close() error {
+	return b.conn.Drain()
+}
@@ -0,0 +1,67 @@
+package event
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/rs/zerolog"
+	"github.com/nats-io/nats.go"
+
+	"github.com/go-inventory/internal/domain/model"
+)
+
+// NatsPublisher publishes each event to a JetStream subject, the publish waits the acknowledgement of the
+// stream and the event id is the message id, so a event published twice is discarded by the stream
+type NatsPublisher struct {
+	conn		*nats.Conn
+	jetStream	nats.JetStreamContext
+	subject		string
+}
+
+// About create a nats publisher, a stream must capture the subject
+func NewNatsPublisher(url string, subject string, logger *zerolog.Logger) (*NatsPublisher, error) {
+	conn, err := nats.Connect(url,
+							nats.Name("go-inventory-outbox"),
+							nats.MaxReconnects(-1),
+							nats.RetryOnFailedConnect(true),
+							nats.DisconnectErrHandler(func(_ *nats.Conn, err error) {
+								logger.Warn().
+										Err(err).
+										Msg("nats disconnected")
+							}))
+	if err != nil {
+		return nil, err
+	}
+
+	jetStream, err := conn.JetStream()
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	return &NatsPublisher{
+		conn: conn,
+		jetStream: jetStream,
+		subject: subject,
+	}, nil
+}
+
+// About publish a event
+func (p *NatsPublisher) Publish(ctx context.Context, event *model.CloudEvent) error {
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	msg := nats.NewMsg(p.subject)
+	msg.Data = payload
+	msg.Header.Set("Content-Type", cloudEventContentType)
+
+	_, err = p.jetStream.PublishMsg(msg, nats.MsgId(event.ID), nats.Context(ctx))
+	return err
+}
+
+// About close the publisher
+func (p *NatsPublisher) Close() error {
+	return p.conn.Drain()
+}
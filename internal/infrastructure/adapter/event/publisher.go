@@ -0,0 +1,69 @@
+package event
+
+import (
+	"os"
+	"fmt"
+	"sync"
+	"context"
+	"encoding/json"
+
+	"github.com/rs/zerolog"
+
+	"github.com/go-inventory/internal/domain/model"
+	"github.com/go-inventory/internal/domain/service"
+)
+
+// content type of a event in structured mode
+const cloudEventContentType = "application/cloudevents+json"
+
+// Above create the publisher of the outbox relay informed in the config, nil when the relay is disabled
+func NewPublisher(appServer *model.AppServer, appLogger *zerolog.Logger) (service.EventPublisher, error) {
+	logger := appLogger.With().
+				Str("package", "adapter.event").
+				Logger()
+
+	outboxConfig := appServer.OutboxConfig
+
+	logger.Info().
+			Str("func","NewPublisher").
+			Str("publisher", outboxConfig.Publisher).Send()
+
+	switch outboxConfig.Publisher {
+	case model.PublisherStdout:
+		return NewStdoutPublisher(), nil
+	case model.PublisherHttp:
+		return NewHttpPublisher(outboxConfig.HttpURL), nil
+	case model.PublisherKafka:
+		return NewKafkaPublisher(outboxConfig.KafkaBrokers, outboxConfig.KafkaTopic), nil
+	case model.PublisherNats:
+		return NewNatsPublisher(outboxConfig.NatsURL, outboxConfig.NatsSubject, &logger)
+	case model.PublisherNone:
+		return nil, nil
+	}
+
+	return nil, fmt.Errorf("unknown publisher: %s", outboxConfig.Publisher)
+}
+
+// StdoutPublisher writes each event as a json line, for local development
+type StdoutPublisher struct {
+	mu			sync.Mutex
+	encoder		*json.Encoder
+}
+
+// About create a stdout publisher
+func NewStdoutPublisher() *StdoutPublisher {
+	return &StdoutPublisher{encoder: json.NewEncoder(os.Stdout)}
+}
+
+// About write a event
+func (p *StdoutPublisher) Publish(ctx context.Context, event *model.CloudEvent) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return p.encoder.Encode(event)
+}
+
+// About close the publisher
+func (p *StdoutPublisher) Close() error {
+	return nil
+}
@@ -0,0 +1,99 @@
+package event
+
+import (
+	"io"
+	"fmt"
+	"net"
+	"bytes"
+	"errors"
+	"context"
+	"strconv"
+	"syscall"
+	"time"
+	"net/http"
+	"net/netip"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+
+	"github.com/go-inventory/internal/domain/model"
+	"github.com/go-inventory/shared/security"
+)
+
+// HttpWebhookSender posts the alerts to the webhooks, any 2xx is a acknowledgement
+type HttpWebhookSender struct {
+	client		*http.Client
+}
+
+// About create a webhook sender. The urls are informed by the tenants, so the address is checked
+// when dialing (after the name is resolved, a name can not point to a internal address later) and
+// the redirects are refused, a redirect could lead to a internal address as well
+func NewHttpWebhookSender() *HttpWebhookSender {
+	dialer := &net.Dialer{
+		Timeout: 30 * time.Second,
+		KeepAlive: 30 * time.Second,
+		Control: dialPublic,
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil // through a proxy the dialed address would be the one of the proxy
+	transport.DialContext = dialer.DialContext
+
+	return &HttpWebhookSender{
+		client: &http.Client{
+			Transport: transport,
+			CheckRedirect: func(req *http.Request, via []*http.Request) error {
+				return errors.New("webhook answered a redirect, redirects are not followed")
+			},
+		},
+	}
+}
+
+// Helper function to refuse a connection to a address internal to the deployment
+func dialPublic(network string, address string, c syscall.RawConn) error {
+	addrPort, err := netip.ParseAddrPort(address)
+	if err != nil {
+		return fmt.Errorf("webhook address %s: %w", address, err)
+	}
+	if !security.PublicAddress(addrPort.Addr()) {
+		return fmt.Errorf("webhook address %s is not public", addrPort.Addr())
+	}
+	return nil
+}
+
+// Helper function to sign a payload, the timestamp is signed along so a captured request can not be replayed later
+func SignWebhook(secret string, timestamp string, payload []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(payload)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// About post a delivery signed with the secret of its webhook, the deadline comes from the context
+func (p *HttpWebhookSender) Send(ctx context.Context, delivery *model.WebhookDelivery) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, delivery.Webhook.Url, bytes.NewReader(delivery.Payload))
+	if err != nil {
+		return 0, err
+	}
+
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Webhook-Id", strconv.Itoa(delivery.WebhookID))
+	req.Header.Set("X-Webhook-Event", delivery.Type)
+	req.Header.Set("X-Webhook-Delivery", delivery.EventID)
+	req.Header.Set("X-Webhook-Timestamp", timestamp)
+	req.Header.Set("X-Webhook-Signature", SignWebhook(delivery.Webhook.Secret, timestamp, delivery.Payload))
+
+	res, err := p.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer res.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(res.Body, 64 << 10))
+
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		return res.StatusCode, fmt.Errorf("webhook answered http %d", res.StatusCode)
+	}
+	return res.StatusCode, nil
+}
@@ -0,0 +1,144 @@
+package graphql
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/graphql-go/graphql/language/ast"
+
+	"github.com/go-inventory/shared/erro"
+)
+
+// list argument of each list field, the cost of its selection is multiplied by the size of the list
+var listArguments = map[string]struct{
+	argument	string
+	fallback	int
+}{
+	"products":		{argument: "skus", fallback: 1},
+	"timeSeries":	{argument: "window", fallback: defaultWindow},
+}
+
+// limits is the walk of a query document measuring its depth and complexity
+type limits struct {
+	fragments	map[string]*ast.FragmentDefinition
+	visiting	map[string]bool
+	variables	map[string]interface{}
+	maxDepth	int
+}
+
+// About check the depth and the complexity of a query before executing it
+func checkLimits(document *ast.Document, variables map[string]interface{}, maxDepth int, maxComplexity int) error {
+	l := limits{
+		fragments: map[string]*ast.FragmentDefinition{},
+		visiting: map[string]bool{},
+		variables: variables,
+		maxDepth: maxDepth,
+	}
+
+	for _, definition := range document.Definitions {
+		if fragment, ok := definition.(*ast.FragmentDefinition); ok {
+			l.fragments[fragment.Name.Value] = fragment
+		}
+	}
+
+	for _, definition := range document.Definitions {
+		operation, ok := definition.(*ast.OperationDefinition)
+		if !ok {
+			continue
+		}
+
+		depth, complexity := l.walk(operation.SelectionSet, 1)
+		if maxDepth > 0 && depth > maxDepth {
+			return erro.ErrValidation.WithViolations(erro.Violation{ Field: "query",
+																	 Message: fmt.Sprintf("depth %d exceeds the limit of %d", depth, maxDepth)})
+		}
+		if maxComplexity > 0 && complexity > maxComplexity {
+			return erro.ErrValidation.WithViolations(erro.Violation{ Field: "query",
+																	 Message: fmt.Sprintf("complexity %d exceeds the limit of %d", complexity, maxComplexity)})
+		}
+	}
+
+	return nil
+}
+
+// Helper to measure a selection set, fragments are expanded in place
+func (l *limits) walk(selectionSet *ast.SelectionSet, depth int) (int, int) {
+	if selectionSet == nil {
+		return depth - 1, 0
+	}
+	// stops the walk early
+	if l.maxDepth > 0 && depth > l.maxDepth {
+		return depth, 0
+	}
+
+	maxDepth, complexity := depth, 0
+	for _, selection := range selectionSet.Selections {
+		var childDepth, childComplexity int
+
+		switch selection := selection.(type) {
+		case *ast.Field:
+			childDepth, childComplexity = l.walk(selection.SelectionSet, depth + 1)
+			childComplexity = 1 + l.multiplier(selection) * childComplexity
+		case *ast.InlineFragment:
+			childDepth, childComplexity = l.walk(selection.SelectionSet, depth)
+		case *ast.FragmentSpread:
+			// a fragment spreading itself is rejected later by the schema validation
+			fragment, ok := l.fragments[selection.Name.Value]
+			if !ok || l.visiting[fragment.Name.Value] {
+				continue
+			}
+			l.visiting[fragment.Name.Value] = true
+			childDepth, childComplexity = l.walk(fragment.SelectionSet, depth)
+			l.visiting[fragment.Name.Value] = false
+		}
+		maxDepth = max(maxDepth, childDepth)
+		complexity += childComplexity
+	}
+
+	return maxDepth, complexity
+}
+
+// Helper to get how many items a field returns
+func (l *limits) multiplier(field *ast.Field) int {
+	list, ok := listArguments[field.Name.Value]
+	if !ok {
+		return 1
+	}
+
+	for _, argument := range field.Arguments {
+		if argument.Name.Value != list.argument {
+			continue
+		}
+		value := l.value(argument.Value)
+		switch value := value.(type) {
+		case []interface{}:
+			return max(len(value), 1)
+		case []ast.Value:
+			return max(len(value), 1)
+		case float64:
+			return max(int(value), 1)
+		case int:
+			return max(value, 1)
+		case string:
+			size, err := strconv.Atoi(value)
+			if err == nil {
+				return max(size, 1)
+			}
+		}
+	}
+
+	return list.fallback
+}
+
+// Helper to get the value of a argument, variables are replaced by the values informed
+func (l *limits) value(value ast.Value) interface{} {
+	switch value := value.(type) {
+	case *ast.Variable:
+		return l.variables[value.Name.Value]
+	case *ast.ListValue:
+		return value.Values
+	case *ast.IntValue:
+		return value.Value
+	}
+	return nil
+}
@@ -0,0 +1,188 @@
+package graphql
+
+import (
+	"sync"
+	"slices"
+	"context"
+
+	"github.com/go-inventory/internal/domain/model"
+)
+
+type loaderKey struct{}
+
+// batchFunc loads several inventories at once, indexed by sku
+type batchFunc func(ctx context.Context, skus []string) (map[string]*model.Inventory, error)
+
+// inventoryLoader collects the skus requested while a level of the query is resolved and
+// loads all of them in a single call when the first value is needed (dataloader).
+// A loader lives only during one request, so it is also a per-request cache
+type inventoryLoader struct {
+	mu			sync.Mutex
+	batch		batchFunc
+	pending		[]string
+	results		map[string]*model.Inventory
+	errors		map[string]error
+}
+
+// About create a loader
+func newInventoryLoader(batch batchFunc) *inventoryLoader {
+	return &inventoryLoader{
+		batch: batch,
+		results: map[string]*model.Inventory{},
+		errors: map[string]error{},
+	}
+}
+
+// Helper to put a loader into the context of the request
+func withLoader(ctx context.Context, loader *inventoryLoader) context.Context {
+	return context.WithValue(ctx, loaderKey{}, loader)
+}
+
+// Helper to get the loader of the request
+func loaderFrom(ctx context.Context) *inventoryLoader {
+	loader, _ := ctx.Value(loaderKey{}).(*inventoryLoader)
+	return loader
+}
+
+// About register a sku and return a thunk, the executor only calls the thunks after
+// every field of the level was resolved so all skus are loaded in the same batch
+func (l *inventoryLoader) Load(ctx context.Context, sku string) func() (interface{}, error) {
+	l.mu.Lock()
+	if _, ok := l.results[sku]; !ok && !slices.Contains(l.pending, sku) {
+		l.pending = append(l.pending, sku)
+	}
+	l.mu.Unlock()
+
+	return func() (interface{}, error) {
+		l.mu.Lock()
+		defer l.mu.Unlock()
+
+		if _, ok := l.results[sku]; !ok && len(l.pending) > 0 {
+			l.dispatch(ctx)
+		}
+
+		if err := l.errors[sku]; err != nil {
+			return nil, resolveError(err)
+		}
+		if inventory := l.results[sku]; inventory != nil {
+			return inventory, nil
+		}
+		return nil, nil
+	}
+}
+
+// Helper to load the pending skus, must be called with the lock held
+func (l *inventoryLoader) dispatch(ctx context.Context) {
+	skus := l.pending
+	l.pending = nil
+
+	map_inventory, err := l.batch(ctx, skus)
+	for _, sku := range skus {
+		if err != nil {
+			l.errors[sku] = err
+		}
+		// a missing sku is cached as nil, so it is not loaded again
+		l.results[sku] = map_inventory[sku]
+	}
+}
+
+type timeSeriesLoaderKey struct{}
+
+// seriesBatchFunc loads the time series of several products at once, indexed by sku
+type seriesBatchFunc func(ctx context.Context, windowsize int, offset int, skus []string) (map[string][]model.Inventory, error)
+
+// timeSeriesKey is a time series of a sku, the same sku can be asked with different windows
+type timeSeriesKey struct {
+	sku			string
+	window		int
+	offset		int
+}
+
+// timeSeriesLoader is the dataloader of the time series, the pending skus are loaded
+// in one call for each window and offset asked in the level
+type timeSeriesLoader struct {
+	mu			sync.Mutex
+	batch		seriesBatchFunc
+	pending		[]timeSeriesKey
+	results		map[timeSeriesKey][]model.Inventory
+	errors		map[timeSeriesKey]error
+}
+
+// About create a time series loader
+func newTimeSeriesLoader(batch seriesBatchFunc) *timeSeriesLoader {
+	return &timeSeriesLoader{
+		batch: batch,
+		results: map[timeSeriesKey][]model.Inventory{},
+		errors: map[timeSeriesKey]error{},
+	}
+}
+
+// Helper to put a time series loader into the context of the request
+func withTimeSeriesLoader(ctx context.Context, loader *timeSeriesLoader) context.Context {
+	return context.WithValue(ctx, timeSeriesLoaderKey{}, loader)
+}
+
+// Helper to get the time series loader of the request
+func timeSeriesLoaderFrom(ctx context.Context) *timeSeriesLoader {
+	loader, _ := ctx.Value(timeSeriesLoaderKey{}).(*timeSeriesLoader)
+	return loader
+}
+
+// About register the time series of a sku and return a thunk, as the inventory loader does
+func (l *timeSeriesLoader) Load(ctx context.Context, sku string, window int, offset int) func() (interface{}, error) {
+	key := timeSeriesKey{sku: sku, window: window, offset: offset}
+
+	l.mu.Lock()
+	if _, ok := l.results[key]; !ok && !slices.Contains(l.pending, key) {
+		l.pending = append(l.pending, key)
+	}
+	l.mu.Unlock()
+
+	return func() (interface{}, error) {
+		l.mu.Lock()
+		defer l.mu.Unlock()
+
+		if _, ok := l.results[key]; !ok && len(l.pending) > 0 {
+			l.dispatch(ctx)
+		}
+
+		if err := l.errors[key]; err != nil {
+			return nil, err
+		}
+		return l.results[key], nil
+	}
+}
+
+// Helper to load the pending time series with a call for each window and offset, must be called with the lock held
+func (l *timeSeriesLoader) dispatch(ctx context.Context) {
+	pending := l.pending
+	l.pending = nil
+
+	for len(pending) > 0 {
+		window, offset := pending[0].window, pending[0].offset
+
+		var skus []string
+		var keys []timeSeriesKey
+		pending = slices.DeleteFunc(pending, func(key timeSeriesKey) bool {
+			if key.window != window || key.offset != offset {
+				return false
+			}
+			skus = append(skus, key.sku)
+			keys = append(keys, key)
+			return true
+		})
+
+		map_series, err := l.batch(ctx, window, offset, skus)
+		for _, key := range keys {
+			if err != nil {
+				l.errors[key] = err
+			}
+			// a sku without snapshots is cached as a empty series
+			series := map_series[key.sku]
+			if series == nil {
+				series = []model.Inventory{}
+			}
+			l.results[key] = series
+		}
+	}
+}
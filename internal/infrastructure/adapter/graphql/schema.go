@@ -0,0 +1,286 @@
+package graphql
+
+import (
+	"context"
+
+	"github.com/rs/zerolog"
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/codes"
+
+	gql "github.com/graphql-go/graphql"
+	gql_parser "github.com/graphql-go/graphql/language/parser"
+
+	"github.com/go-inventory/shared/erro"
+	"github.com/go-inventory/internal/domain/model"
+	"github.com/go-inventory/internal/domain/service"
+
+	go_core_otel_trace "github.com/eliezerraj/go-core/v2/otel/trace"
+)
+
+// default number of days of a time series, the same of the rest endpoint
+const defaultWindow = 14
+
+// Request is the body of a graphql request
+type Request struct {
+	Query			string					`json:"query"`
+	OperationName	string					`json:"operationName,omitempty"`
+	Variables		map[string]interface{}	`json:"variables,omitempty"`
+}
+
+// fieldError exposes the code of a domain error in the extensions of a graphql error
+type fieldError struct {
+	err		*erro.Error
+}
+
+// only the stable message is answered, the wrapped cause could expose the database
+func (e fieldError) Error() string {
+	return e.err.PublicMessage()
+}
+
+func (e fieldError) Extensions() map[string]interface{} {
+	extensions := map[string]interface{}{
+		"code": e.err.Code,
+		"retryable": e.err.Retryable,
+	}
+	if len(e.err.Violations) > 0 {
+		extensions["errors"] = e.err.Violations
+	}
+	return extensions
+}
+
+type Executor struct {
+	schema			gql.Schema
+	workerService 	*service.WorkerService
+	appServer		*model.AppServer
+	logger			*zerolog.Logger
+	tracerProvider 	*go_core_otel_trace.TracerProvider
+}
+
+// Above create the graphql executor and its schema
+func NewExecutor(appServer *model.AppServer,
+				workerService *service.WorkerService,
+				appLogger *zerolog.Logger,
+				tracerProvider *go_core_otel_trace.TracerProvider) (*Executor, error) {
+
+	logger := appLogger.With().
+				Str("package", "adapter.graphql").
+				Logger()
+
+	logger.Info().
+			Str("func","NewExecutor").Send()
+
+	executor := &Executor{
+		workerService: workerService,
+		appServer: appServer,
+		logger: &logger,
+		tracerProvider: tracerProvider,
+	}
+
+	schema, err := executor.newSchema()
+	if err != nil {
+		return nil, err
+	}
+	executor.schema = schema
+
+	return executor, nil
+}
+
+// About execute a query, the limits are checked before any field is resolved
+func (e *Executor) Execute(ctx context.Context, request *Request) (*gql.Result, error) {
+	e.logger.Info().
+			Ctx(ctx).
+			Str("func","Execute").Send()
+
+	// Trace
+	ctx, span := e.tracerProvider.SpanCtx(ctx, "adapter.graphql.Execute", trace.SpanKindInternal)
+	defer span.End()
+
+	// syntax errors are reported by the executor, as graphql errors
+	document, err := gql_parser.Parse(gql_parser.ParseParams{Source: request.Query})
+	if err == nil {
+		err = checkLimits(document, request.Variables, e.appServer.Server.GraphqlMaxDepth, e.appServer.Server.GraphqlMaxComplexity)
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+			return nil, err
+		}
+	}
+
+	// a new loader for each request, so nothing is cached between requests
+	ctx = withLoader(ctx, newInventoryLoader(e.workerService.GetInventoryBatch))
+	ctx = withTimeSeriesLoader(ctx, newTimeSeriesLoader(e.workerService.GetInventoryTimeSeriesBatch))
+
+	return gql.Do(gql.Params{
+		Schema: e.schema,
+		RequestString: request.Query,
+		VariableValues: request.Variables,
+		OperationName: request.OperationName,
+		Context: ctx,
+	}), nil
+}
+
+// Helper to convert a error into a graphql error with the code of the domain
+func resolveError(err error) error {
+	return fieldError{erro.As(err)}
+}
+
+// Helper to get the inventory of the source of a field, lists have values and not pointers
+func sourceInventory(source interface{}) *model.Inventory {
+	switch source := source.(type) {
+	case *model.Inventory:
+		return source
+	case model.Inventory:
+		return &source
+	}
+	return nil
+}
+
+// Helper to create a field reading a attribute of the inventory
+func inventoryField(fieldType gql.Output, get func(*model.Inventory) interface{}) *gql.Field {
+	return &gql.Field{
+		Type: fieldType,
+		Resolve: func(p gql.ResolveParams) (interface{}, error) {
+			inventory := sourceInventory(p.Source)
+			if inventory == nil {
+				return nil, nil
+			}
+			return get(inventory), nil
+		},
+	}
+}
+
+// About create the schema, a Product is resolved from the inventory row because both are
+// loaded by the same query
+func (e *Executor) newSchema() (gql.Schema, error) {
+	inventoryType := gql.NewObject(gql.ObjectConfig{
+		Name: "Inventory",
+		Description: "Stock of a product by bucket, on a time series createdAt is the date of the snapshot",
+		Fields: gql.Fields{
+			"id":				inventoryField(gql.Int, func(i *model.Inventory) interface{} { return i.ID }),
+			"available":		inventoryField(gql.Int, func(i *model.Inventory) interface{} { return i.Available }),
+			"pending":			inventoryField(gql.Int, func(i *model.Inventory) interface{} { return i.Pending }),
+			"reserved":			inventoryField(gql.Int, func(i *model.Inventory) interface{} { return i.Reserved }),
+			"sold":				inventoryField(gql.Int, func(i *model.Inventory) interface{} { return i.Sold }),
+			"incoming":			inventoryField(gql.Int, func(i *model.Inventory) interface{} { return i.Incoming }),
+			"quarantine":		inventoryField(gql.Int, func(i *model.Inventory) interface{} { return i.Quarantine }),
+			"damaged":			inventoryField(gql.Int, func(i *model.Inventory) interface{} { return i.Damaged }),
+			"hold":				inventoryField(gql.Int, func(i *model.Inventory) interface{} { return i.Hold }),
+			"channel":			inventoryField(gql.String, func(i *model.Inventory) interface{} { return i.Channel }),
+			"channelAvailable":	inventoryField(gql.Int, func(i *model.Inventory) interface{} { return i.ChannelAvailable }),
+			"version":			inventoryField(gql.Int, func(i *model.Inventory) interface{} { return i.Version }),
+			"createdAt":		inventoryField(gql.DateTime, func(i *model.Inventory) interface{} { return i.CreatedAt }),
+			"updatedAt":		inventoryField(gql.DateTime, func(i *model.Inventory) interface{} { return i.UpdatedAt }),
+		},
+	})
+
+	productType := gql.NewObject(gql.ObjectConfig{
+		Name: "Product",
+		Fields: gql.Fields{
+			"id":			inventoryField(gql.Int, func(i *model.Inventory) interface{} { return i.Product.ID }),
+			"sku":			inventoryField(gql.String, func(i *model.Inventory) interface{} { return i.Product.Sku }),
+			"type":			inventoryField(gql.String, func(i *model.Inventory) interface{} { return i.Product.Type }),
+			"name":			inventoryField(gql.String, func(i *model.Inventory) interface{} { return i.Product.Name }),
+			"status":		inventoryField(gql.String, func(i *model.Inventory) interface{} { return i.Product.Status }),
+			"leadTime":		inventoryField(gql.Int, func(i *model.Inventory) interface{} { return i.Product.LeadTime }),
+			"version":		inventoryField(gql.Int, func(i *model.Inventory) interface{} { return i.Product.Version }),
+			"createdAt":	inventoryField(gql.DateTime, func(i *model.Inventory) interface{} { return i.Product.CreatedAt }),
+			"updatedAt":	inventoryField(gql.DateTime, func(i *model.Inventory) interface{} { return i.Product.UpdatedAt }),
+			"inventory": &gql.Field{
+				Type: inventoryType,
+				Description: "Inventory of the product, with a channel the availability of the channel is computed",
+				Args: gql.FieldConfigArgument{
+					"channel": &gql.ArgumentConfig{Type: gql.String},
+				},
+				Resolve: e.resolveInventory,
+			},
+			"timeSeries": &gql.Field{
+				Type: gql.NewList(inventoryType),
+				Description: "Daily snapshots with sales of the product, oldest first",
+				Args: gql.FieldConfigArgument{
+					"window": &gql.ArgumentConfig{Type: gql.Int, DefaultValue: defaultWindow},
+					"offset": &gql.ArgumentConfig{Type: gql.Int, DefaultValue: 0},
+				},
+				Resolve: e.resolveTimeSeries,
+			},
+		},
+	})
+
+	queryType := gql.NewObject(gql.ObjectConfig{
+		Name: "Query",
+		Fields: gql.Fields{
+			"product": &gql.Field{
+				Type: productType,
+				Args: gql.FieldConfigArgument{
+					"sku": &gql.ArgumentConfig{Type: gql.NewNonNull(gql.String)},
+				},
+				Resolve: e.resolveProduct,
+			},
+			"products": &gql.Field{
+				Type: gql.NewNonNull(gql.NewList(productType)),
+				Description: "Products in the order of the skus, null when a sku does not exist",
+				Args: gql.FieldConfigArgument{
+					"skus": &gql.ArgumentConfig{Type: gql.NewNonNull(gql.NewList(gql.NewNonNull(gql.String)))},
+				},
+				Resolve: e.resolveProducts,
+			},
+		},
+	})
+
+	return gql.NewSchema(gql.SchemaConfig{
+		Query: queryType,
+	})
+}
+
+// About resolve a product, the load is deferred so the products of the same level are loaded together
+func (e *Executor) resolveProduct(p gql.ResolveParams) (interface{}, error) {
+	sku, _ := p.Args["sku"].(string)
+	return loaderFrom(p.Context).Load(p.Context, sku), nil
+}
+
+// About resolve a list of products with a single query
+func (e *Executor) resolveProducts(p gql.ResolveParams) (interface{}, error) {
+	skus, _ := p.Args["skus"].([]interface{})
+
+	loader := loaderFrom(p.Context)
+	list_product := make([]interface{}, 0, len(skus))
+	for _, sku := range skus {
+		list_product = append(list_product, loader.Load(p.Context, sku.(string)))
+	}
+	return list_product, nil
+}
+
+// About resolve the inventory of a product, only the availability of a channel needs a new query
+func (e *Executor) resolveInventory(p gql.ResolveParams) (interface{}, error) {
+	inventory := sourceInventory(p.Source)
+	channel, _ := p.Args["channel"].(string)
+	if inventory == nil || channel == "" {
+		return inventory, nil
+	}
+
+	res, err := e.workerService.GetInventory(p.Context, &model.Inventory{Product: inventory.Product, Channel: channel})
+	if err != nil {
+		return nil, resolveError(err)
+	}
+	return res, nil
+}
+
+// About resolve the time series of a product, loaded together with the time series of the other products
+func (e *Executor) resolveTimeSeries(p gql.ResolveParams) (interface{}, error) {
+	inventory := sourceInventory(p.Source)
+	window, _ := p.Args["window"].(int)
+	offset, _ := p.Args["offset"].(int)
+	if window <= 0 || offset < 0 {
+		return nil, resolveError(erro.ErrValidation.WithViolations(erro.Violation{	Field: "window",
+																					Message: "window must be greater than 0 and offset not negative"}))
+	}
+
+	// deferred like the products, so the time series of a list of products is a single query
+	thunk := timeSeriesLoaderFrom(p.Context).Load(p.Context, inventory.Product.Sku, window, offset)
+	return func() (interface{}, error) {
+		res, err := thunk()
+		if err != nil {
+			return nil, resolveError(err)
+		}
+		return res, nil
+	}, nil
+}
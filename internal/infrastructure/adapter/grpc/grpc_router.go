@@ -0,0 +1,323 @@
+package grpc
+
+import (
+	"time"
+	"context"
+
+	"github.com/rs/zerolog"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	grpc_codes "google.golang.org/grpc/codes"
+	grpc_status "google.golang.org/grpc/status"
+
+	"github.com/go-inventory/shared/erro"
+	"github.com/go-inventory/shared/validator"
+	"github.com/go-inventory/internal/domain/model"
+	"github.com/go-inventory/internal/domain/service"
+	"github.com/go-inventory/internal/infrastructure/adapter/rules"
+	inventoryv1 "github.com/go-inventory/api/proto/inventory/v1"
+
+	go_core_otel_trace "github.com/eliezerraj/go-core/v2/otel/trace"
+)
+
+// grpc code of each kind of error
+var kindCode = map[erro.Kind]grpc_codes.Code{
+	erro.KindInvalid:		grpc_codes.InvalidArgument,
+	erro.KindNotFound:		grpc_codes.NotFound,
+	erro.KindConflict:		grpc_codes.Aborted,
+	erro.KindPrecondition:	grpc_codes.FailedPrecondition,
+	erro.KindUnauthorized:	grpc_codes.Unauthenticated,
+	erro.KindForbidden:		grpc_codes.PermissionDenied,
+	erro.KindRateLimited:	grpc_codes.ResourceExhausted,
+	erro.KindTimeout:		grpc_codes.DeadlineExceeded,
+	erro.KindUnavailable:	grpc_codes.Unavailable,
+	erro.KindInternal:		grpc_codes.Internal,
+}
+
+type GrpcRouters struct {
+	inventoryv1.UnimplementedInventoryServiceServer
+	workerService 	*service.WorkerService
+	appServer		*model.AppServer
+	logger			*zerolog.Logger
+	tracerProvider 	*go_core_otel_trace.TracerProvider
+}
+
+// Above create grpc routers
+func NewGrpcRouters(appServer *model.AppServer,
+					workerService *service.WorkerService,
+					appLogger *zerolog.Logger,
+					tracerProvider *go_core_otel_trace.TracerProvider) *GrpcRouters {
+
+	logger := appLogger.With().
+				Str("package", "adapter.grpc").
+				Logger()
+
+	logger.Info().
+			Str("func","NewGrpcRouters").Send()
+
+	return &GrpcRouters{
+		workerService: workerService,
+		appServer: appServer,
+		logger: &logger,
+		tracerProvider: tracerProvider,
+	}
+}
+
+// Helper to extract context with timeout and setup span
+func (g *GrpcRouters) withContext(ctx context.Context, spanName string) (context.Context, context.CancelFunc, trace.Span) {
+	ctx, cancel := context.WithTimeout(ctx,
+		time.Duration(g.appServer.Server.CtxTimeout) * time.Second)
+
+	g.logger.Info().
+			Ctx(ctx).
+			Str("func", spanName).Send()
+
+	ctx, span := g.tracerProvider.SpanCtx(ctx, "adapter.grpc."+spanName, trace.SpanKindInternal)
+	return ctx, cancel, span
+}
+
+// ErrorHandler converts a error into a grpc status with the code of the error kind
+// the status has the stable message of the typed error, the wrapped cause is only logged
+func (g *GrpcRouters) ErrorHandler(err error) error {
+	typed := erro.As(err)
+
+	msg := typed.PublicMessage()
+	code, ok := kindCode[typed.Kind]
+	if !ok {
+		code = grpc_codes.Internal
+		msg = "internal server error"
+	}
+
+	g.logger.Warn().
+			Err(err).
+			Str("code", typed.Code).
+			Msg("grpc error")
+
+	return grpc_status.Error(code, typed.Code + ": " + msg)
+}
+
+// Helper to convert a time to protobuf
+func timestamp(t *time.Time) *timestamppb.Timestamp {
+	if t == nil || t.IsZero() {
+		return nil
+	}
+	return timestamppb.New(*t)
+}
+
+// Helper to convert a product to protobuf
+func toProduct(product *model.Product) *inventoryv1.Product {
+	return &inventoryv1.Product{
+		Id: int64(product.ID),
+		Sku: product.Sku,
+		Type: product.Type,
+		Name: product.Name,
+		Status: product.Status,
+		LeadTime: int32(product.LeadTime),
+		Version: int32(product.Version),
+		CreatedAt: timestamp(&product.CreatedAt),
+		UpdatedAt: timestamp(product.UpdatedAt),
+	}
+}
+
+// Helper to convert a inventory to protobuf
+func toInventory(inventory *model.Inventory) *inventoryv1.Inventory {
+	return &inventoryv1.Inventory{
+		Id: int64(inventory.ID),
+		Product: toProduct(&inventory.Product),
+		Available: int32(inventory.Available),
+		Pending: int32(inventory.Pending),
+		Reserved: int32(inventory.Reserved),
+		Sold: int32(inventory.Sold),
+		Incoming: int32(inventory.Incoming),
+		Quarantine: int32(inventory.Quarantine),
+		Damaged: int32(inventory.Damaged),
+		Hold: int32(inventory.Hold),
+		Channel: inventory.Channel,
+		ChannelAvailable: int32(inventory.ChannelAvailable),
+		Version: int32(inventory.Version),
+		CreatedAt: timestamp(&inventory.CreatedAt),
+		UpdatedAt: timestamp(inventory.UpdatedAt),
+	}
+}
+
+// About add product
+func (g *GrpcRouters) AddProduct(ctx context.Context, req *inventoryv1.AddProductRequest) (*inventoryv1.Inventory, error) {
+	ctx, cancel, span := g.withContext(ctx, "AddProduct")
+	defer cancel()
+	defer span.End()
+
+	product := model.Product{	Sku: req.GetSku(),
+								Type: req.GetType(),
+								Name: req.GetName(),
+								Status: req.GetStatus(),
+								LeadTime: int(req.GetLeadTime())}
+
+	err := validator.Validate(rules.Product(&product, true))
+	if err != nil {
+		return nil, g.ErrorHandler(err)
+	}
+
+	res, err := g.workerService.AddProduct(ctx, &product)
+	if err != nil {
+		return nil, g.ErrorHandler(err)
+	}
+
+	return toInventory(res), nil
+}
+
+// About get product
+func (g *GrpcRouters) GetProduct(ctx context.Context, req *inventoryv1.GetProductRequest) (*inventoryv1.Product, error) {
+	ctx, cancel, span := g.withContext(ctx, "GetProduct")
+	defer cancel()
+	defer span.End()
+
+	err := validator.Validate(rules.Sku(req.GetSku()))
+	if err != nil {
+		return nil, g.ErrorHandler(err)
+	}
+
+	res, err := g.workerService.GetProduct(ctx, &model.Product{Sku: req.GetSku()})
+	if err != nil {
+		return nil, g.ErrorHandler(err)
+	}
+
+	return toProduct(res), nil
+}
+
+// About update product
+func (g *GrpcRouters) UpdateProduct(ctx context.Context, req *inventoryv1.UpdateProductRequest) (*inventoryv1.Product, error) {
+	ctx, cancel, span := g.withContext(ctx, "UpdateProduct")
+	defer cancel()
+	defer span.End()
+
+	product := model.Product{	Sku: req.GetSku(),
+								Type: req.GetType(),
+								Name: req.GetName(),
+								Status: req.GetStatus(),
+								LeadTime: int(req.GetLeadTime()),
+								Version: int(req.GetVersion())}
+
+	err := validator.Validate(rules.Sku(product.Sku), rules.Product(&product, false), func(v *validator.Validator) {
+		v.Min("version", product.Version, 0)
+	})
+	if err != nil {
+		return nil, g.ErrorHandler(err)
+	}
+
+	res, err := g.workerService.UpdateProduct(ctx, &product)
+	if err != nil {
+		return nil, g.ErrorHandler(err)
+	}
+
+	return toProduct(res), nil
+}
+
+// About get inventory
+func (g *GrpcRouters) GetInventory(ctx context.Context, req *inventoryv1.GetInventoryRequest) (*inventoryv1.Inventory, error) {
+	ctx, cancel, span := g.withContext(ctx, "GetInventory")
+	defer cancel()
+	defer span.End()
+
+	err := validator.Validate(rules.Sku(req.GetSku()))
+	if err != nil {
+		return nil, g.ErrorHandler(err)
+	}
+
+	res, err := g.workerService.GetInventory(ctx, &model.Inventory{	Product: model.Product{Sku: req.GetSku()},
+																	Channel: req.GetChannel()})
+	if err != nil {
+		return nil, g.ErrorHandler(err)
+	}
+
+	return toInventory(res), nil
+}
+
+// About update inventory
+func (g *GrpcRouters) UpdateInventory(ctx context.Context, req *inventoryv1.UpdateInventoryRequest) (*inventoryv1.Inventory, error) {
+	ctx, cancel, span := g.withContext(ctx, "UpdateInventory")
+	defer cancel()
+	defer span.End()
+
+	inventory := model.Inventory{	Product: model.Product{Sku: req.GetSku()},
+									Channel: req.GetChannel(),
+									Available: int(req.GetAvailable()),
+									Pending: int(req.GetPending()),
+									Reserved: int(req.GetReserved()),
+									Sold: int(req.GetSold()),
+									Version: int(req.GetVersion())}
+
+	err := validator.Validate(rules.Sku(inventory.Product.Sku), rules.Inventory(&inventory), func(v *validator.Validator) {
+		v.Min("version", inventory.Version, 0)
+	})
+	if err != nil {
+		return nil, g.ErrorHandler(err)
+	}
+
+	res, err := g.workerService.UpdateInventory(ctx, &inventory)
+	if err != nil {
+		return nil, g.ErrorHandler(err)
+	}
+
+	return toInventory(res), nil
+}
+
+// About move stock between buckets
+func (g *GrpcRouters) TransferInventory(ctx context.Context, req *inventoryv1.TransferInventoryRequest) (*inventoryv1.Inventory, error) {
+	ctx, cancel, span := g.withContext(ctx, "TransferInventory")
+	defer cancel()
+	defer span.End()
+
+	transfer := model.InventoryTransfer{Product: model.Product{Sku: req.GetSku()},
+										From: req.GetFrom(),
+										To: req.GetTo(),
+										Quantity: int(req.GetQuantity())}
+
+	err := validator.Validate(rules.Sku(transfer.Product.Sku), rules.Transfer(&transfer), func(v *validator.Validator) {
+		v.Required("from", transfer.From).
+		  Required("to", transfer.To)
+	})
+	if err != nil {
+		return nil, g.ErrorHandler(err)
+	}
+
+	res, err := g.workerService.TransferInventory(ctx, &transfer)
+	if err != nil {
+		return nil, g.ErrorHandler(err)
+	}
+
+	return toInventory(res), nil
+}
+
+// About list the inventory snapshots of a product
+func (g *GrpcRouters) ListInventory(ctx context.Context, req *inventoryv1.ListInventoryRequest) (*inventoryv1.ListInventoryResponse, error) {
+	ctx, cancel, span := g.withContext(ctx, "ListInventory")
+	defer cancel()
+	defer span.End()
+
+	// default window is 14, as the http router
+	window := int(req.GetWindow())
+	if window == 0 {
+		window = 14
+	}
+
+	err := validator.Validate(rules.Sku(req.GetSku()), func(v *validator.Validator) {
+		v.Min("window", window, 1).
+		  Min("offset", int(req.GetOffset()), 0)
+	})
+	if err != nil {
+		return nil, g.ErrorHandler(err)
+	}
+
+	res, err := g.workerService.ListInventory(ctx, window, int(req.GetOffset()), &model.Inventory{Product: model.Product{Sku: req.GetSku()}})
+	if err != nil {
+		return nil, g.ErrorHandler(err)
+	}
+
+	list_inventory := &inventoryv1.ListInventoryResponse{}
+	for i := range *res {
+		list_inventory.Inventories = append(list_inventory.Inventories, toInventory(&(*res)[i]))
+	}
+
+	return list_inventory, nil
+}
@@ -0,0 +1,134 @@
+package http
+
+import (
+	"net/http"
+	"strconv"
+
+	"go.opentelemetry.io/otel/codes"
+	"github.com/gorilla/mux"
+	"github.com/go-inventory/internal/domain/model"
+	"github.com/go-inventory/shared/erro"
+	"github.com/go-inventory/shared/validator"
+	"github.com/go-inventory/internal/infrastructure/adapter/rules"
+)
+
+// cycle count payload, the previous quantity, the variance and the status are computed by the service
+type adjustmentPayload struct {
+	Counted			int			`json:"counted"`
+	Reason			string		`json:"reason,omitempty"`
+}
+
+// About register a cycle count of a product
+func (h *HttpRouters) CycleCount(rw http.ResponseWriter, req *http.Request) error {
+	ctx, cancel, span := h.withContext(req, "CycleCount")
+	defer cancel()
+	defer span.End()
+
+	// decode payload
+	payload := adjustmentPayload{}
+	defer req.Body.Close()
+
+	err := h.decodeJSON(req, &payload)
+	if err != nil {
+		span.RecordError(err)
+        span.SetStatus(codes.Error, err.Error())
+		return h.ErrorHandler(h.getTraceID(ctx), err)
+	}
+	adjustment := model.InventoryAdjustment{Counted: payload.Counted, Reason: payload.Reason}
+
+	// get post parameter
+	vars := mux.Vars(req)
+	adjustment.Product = model.Product{Sku: vars["id"]}
+
+	// validate payload
+	err = validator.Validate(rules.Sku(adjustment.Product.Sku), adjustmentRules(&adjustment))
+	if err != nil {
+		return h.ErrorHandler(h.getTraceID(ctx), err)
+	}
+
+	// call service
+	res, err := h.workerService.CycleCount(ctx, &adjustment)
+	if err != nil {
+		return h.ErrorHandler(h.getTraceID(ctx), err)
+	}
+
+	return h.writeJSON(rw, http.StatusOK, res)
+}
+
+// About approve a adjustment pending approval
+func (h *HttpRouters) ApproveAdjustment(rw http.ResponseWriter, req *http.Request) error {
+	return h.reviewAdjustment(rw, req, "ApproveAdjustment", model.AdjustmentApplied)
+}
+
+// About reject a adjustment pending approval
+func (h *HttpRouters) RejectAdjustment(rw http.ResponseWriter, req *http.Request) error {
+	return h.reviewAdjustment(rw, req, "RejectAdjustment", model.AdjustmentRejected)
+}
+
+// Helper to approve or reject a adjustment
+func (h *HttpRouters) reviewAdjustment(rw http.ResponseWriter, req *http.Request, spanName string, status string) error {
+	ctx, cancel, span := h.withContext(req, spanName)
+	defer cancel()
+	defer span.End()
+
+	// get put parameter
+	vars := mux.Vars(req)
+	varID, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		span.RecordError(err)
+        span.SetStatus(codes.Error, err.Error())
+		return h.ErrorHandler(h.getTraceID(ctx), erro.ErrBadRequest)
+	}
+
+	adjustment := model.InventoryAdjustment{ID: varID, Status: status}
+
+	// call service
+	res, err := h.workerService.ReviewAdjustment(ctx, &adjustment)
+	if err != nil {
+		return h.ErrorHandler(h.getTraceID(ctx), err)
+	}
+
+	return h.writeJSON(rw, http.StatusOK, res)
+}
+
+// About list the adjustments of a product
+func (h *HttpRouters) ListInventoryAdjustment(rw http.ResponseWriter, req *http.Request) error {
+	ctx, cancel, span := h.withContext(req, "ListInventoryAdjustment")
+	defer cancel()
+	defer span.End()
+
+	vars := mux.Vars(req)
+	query := req.URL.Query()
+
+	// default window is 14, can be override by query parameter
+	window := 14
+	windowParam := query.Get("window")
+	if windowParam != "" {
+		parsedWindow, err := strconv.Atoi(windowParam)
+		if err != nil || parsedWindow <= 0 {
+			return h.ErrorHandler(h.getTraceID(ctx), erro.ErrBadRequest)
+		}
+		window = parsedWindow
+	}
+
+	offset := 0
+	offsetParam := query.Get("offset")
+	if offsetParam != "" {
+		parsedOffset, err := strconv.Atoi(offsetParam)
+		if err != nil || parsedOffset < 0 {
+			return h.ErrorHandler(h.getTraceID(ctx), erro.ErrBadRequest)
+		}
+		offset = parsedOffset
+	}
+
+	adjustment := model.InventoryAdjustment{	Product: model.Product{Sku: vars["id"]},
+												Status: query.Get("status")}
+
+	// call service
+	res, err := h.workerService.ListInventoryAdjustment(ctx, window, offset, &adjustment)
+	if err != nil {
+		return h.ErrorHandler(h.getTraceID(ctx), err)
+	}
+
+	return h.writeJSON(rw, http.StatusOK, res)
+}
@@ -0,0 +1,132 @@
+package http
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+	"go.opentelemetry.io/otel/codes"
+	"github.com/gorilla/mux"
+	"github.com/go-inventory/internal/domain/model"
+	"github.com/go-inventory/shared/erro"
+	"github.com/go-inventory/shared/validator"
+)
+
+// Helper to get the api key id from the path
+func (h *HttpRouters) apiKeyID(req *http.Request) (int, error) {
+	vars := mux.Vars(req)
+	varID, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		return 0, erro.ErrBadRequest
+	}
+	return varID, nil
+}
+
+// api key payload, the key, its prefix, the tenant and the status are set by the service
+type apiKeyPayload struct {
+	Name			string		`json:"name"`
+	Scopes			[]string	`json:"scopes"`
+	SkuPrefixes		[]string	`json:"sku_prefixes,omitempty"`
+	Quota			int			`json:"quota"`
+	QuotaWindow		int			`json:"quota_window"`
+	ExpiresAt		*time.Time	`json:"expires_at,omitempty"`
+}
+
+// About issue a api key, the key is only returned here
+func (h *HttpRouters) IssueApiKey(rw http.ResponseWriter, req *http.Request) error {
+	ctx, cancel, span := h.withContext(req, "IssueApiKey")
+	defer cancel()
+	defer span.End()
+
+	// decode payload
+	payload := apiKeyPayload{}
+	defer req.Body.Close()
+
+	err := h.decodeJSON(req, &payload)
+	if err != nil {
+		span.RecordError(err)
+        span.SetStatus(codes.Error, err.Error())
+		return h.ErrorHandler(h.getTraceID(ctx), err)
+	}
+	apiKey := model.ApiKey{	Name: payload.Name,
+							Scopes: payload.Scopes,
+							SkuPrefixes: payload.SkuPrefixes,
+							Quota: payload.Quota,
+							QuotaWindow: payload.QuotaWindow,
+							ExpiresAt: payload.ExpiresAt}
+
+	// validate payload
+	err = validator.Validate(apiKeyRules(&apiKey))
+	if err != nil {
+		return h.ErrorHandler(h.getTraceID(ctx), err)
+	}
+
+	// call service
+	res, err := h.workerService.IssueApiKey(ctx, &apiKey)
+	if err != nil {
+		return h.ErrorHandler(h.getTraceID(ctx), err)
+	}
+
+	return h.writeJSON(rw, http.StatusOK, res)
+}
+
+// About get a api key and the usage of its quota
+func (h *HttpRouters) GetApiKey(rw http.ResponseWriter, req *http.Request) error {
+	ctx, cancel, span := h.withContext(req, "GetApiKey")
+	defer cancel()
+	defer span.End()
+
+	varID, err := h.apiKeyID(req)
+	if err != nil {
+		return h.ErrorHandler(h.getTraceID(ctx), err)
+	}
+
+	// call service
+	res, err := h.workerService.GetApiKey(ctx, &model.ApiKey{ID: varID})
+	if err != nil {
+		return h.ErrorHandler(h.getTraceID(ctx), err)
+	}
+
+	return h.writeJSON(rw, http.StatusOK, res)
+}
+
+// About rotate a api key, the new key is only returned here
+func (h *HttpRouters) RotateApiKey(rw http.ResponseWriter, req *http.Request) error {
+	ctx, cancel, span := h.withContext(req, "RotateApiKey")
+	defer cancel()
+	defer span.End()
+
+	varID, err := h.apiKeyID(req)
+	if err != nil {
+		return h.ErrorHandler(h.getTraceID(ctx), err)
+	}
+
+	// call service
+	res, err := h.workerService.RotateApiKey(ctx, &model.ApiKey{ID: varID})
+	if err != nil {
+		return h.ErrorHandler(h.getTraceID(ctx), err)
+	}
+
+	return h.writeJSON(rw, http.StatusOK, res)
+}
+
+// About revoke a api key
+func (h *HttpRouters) RevokeApiKey(rw http.ResponseWriter, req *http.Request) error {
+	ctx, cancel, span := h.withContext(req, "RevokeApiKey")
+	defer cancel()
+	defer span.End()
+
+	varID, err := h.apiKeyID(req)
+	if err != nil {
+		return h.ErrorHandler(h.getTraceID(ctx), err)
+	}
+
+	apiKey := model.ApiKey{ID: varID}
+
+	// call service
+	err = h.workerService.RevokeApiKey(ctx, &apiKey)
+	if err != nil {
+		return h.ErrorHandler(h.getTraceID(ctx), err)
+	}
+
+	return h.writeJSON(rw, http.StatusOK, apiKey)
+}
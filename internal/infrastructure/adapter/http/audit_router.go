@@ -0,0 +1,93 @@
+package http
+
+import (
+	"time"
+	"strconv"
+	"net/http"
+
+	"github.com/go-inventory/internal/domain/model"
+	"github.com/go-inventory/shared/erro"
+	"github.com/go-inventory/shared/validator"
+)
+
+// Helper to parse a optional RFC 3339 time of the query, recording a violation when it is invalid
+func parseQueryTime(v *validator.Validator, field string, value string) *time.Time {
+	if value == "" {
+		return nil
+	}
+	parsed, err := time.Parse(time.RFC3339, value)
+	v.Check(err == nil, field, "must be a RFC 3339 time")
+	if err != nil {
+		return nil
+	}
+	return &parsed
+}
+
+// About list the audit log, filtered by entity, sku, actor, request_id and period (from inclusive, to exclusive)
+func (h *HttpRouters) ListAuditRecord(rw http.ResponseWriter, req *http.Request) error {
+	ctx, cancel, span := h.withContext(req, "ListAuditRecord")
+	defer cancel()
+	defer span.End()
+
+	query := req.URL.Query()
+
+	// default window is 14, can be override by query parameter
+	window := 14
+	windowParam := query.Get("window")
+	if windowParam != "" {
+		parsedWindow, err := strconv.Atoi(windowParam)
+		if err != nil || parsedWindow <= 0 {
+			return h.ErrorHandler(h.getTraceID(ctx), erro.ErrBadRequest)
+		}
+		window = parsedWindow
+	}
+
+	offset := 0
+	offsetParam := query.Get("offset")
+	if offsetParam != "" {
+		parsedOffset, err := strconv.Atoi(offsetParam)
+		if err != nil || parsedOffset < 0 {
+			return h.ErrorHandler(h.getTraceID(ctx), erro.ErrBadRequest)
+		}
+		offset = parsedOffset
+	}
+
+	record := model.AuditRecord{	Entity: query.Get("entity"),
+									Sku: query.Get("sku"),
+									Actor: query.Get("actor"),
+									RequestID: query.Get("request_id")}
+
+	err := validator.Validate(func(v *validator.Validator) {
+		v.OneOf("entity", record.Entity, model.AuditEntityProduct, model.AuditEntityInventory).
+		  MaxLength("sku", record.Sku, 100).
+		  Pattern("sku", record.Sku, validator.SkuPattern)
+		record.From = parseQueryTime(v, "from", query.Get("from"))
+		record.To = parseQueryTime(v, "to", query.Get("to"))
+	})
+	if err != nil {
+		return h.ErrorHandler(h.getTraceID(ctx), err)
+	}
+
+	// call service
+	res, err := h.workerService.ListAuditRecord(ctx, window, offset, &record)
+	if err != nil {
+		return h.ErrorHandler(h.getTraceID(ctx), err)
+	}
+
+	return h.writeJSON(rw, http.StatusOK, res)
+}
+
+// About verify the hash chain of the audit log of the tenant
+func (h *HttpRouters) VerifyAudit(rw http.ResponseWriter, req *http.Request) error {
+	ctx, cancel, span := h.withContext(req, "VerifyAudit")
+	defer cancel()
+	defer span.End()
+
+	// call service
+	res, err := h.workerService.VerifyAudit(ctx)
+	if err != nil {
+		return h.ErrorHandler(h.getTraceID(ctx), err)
+	}
+
+	return h.writeJSON(rw, http.StatusOK, res)
+}
@@ -0,0 +1,96 @@
+package http
+
+import (
+	"net/http"
+
+	"go.opentelemetry.io/otel/codes"
+	"github.com/gorilla/mux"
+	"github.com/go-inventory/internal/domain/model"
+	"github.com/go-inventory/shared/validator"
+	"github.com/go-inventory/internal/infrastructure/adapter/rules"
+)
+
+// allocation payload, the product comes from the path and the available is computed by the service
+type allocationPayload struct {
+	Channel			string		`json:"channel"`
+	Type			string		`json:"type"`
+	Quantity		int			`json:"quantity,omitempty"`
+	Percentage		int			`json:"percentage,omitempty"`
+}
+
+// About create or replace the allocation of a sales channel
+func (h *HttpRouters) SetChannelAllocation(rw http.ResponseWriter, req *http.Request) error {
+	ctx, cancel, span := h.withContext(req, "SetChannelAllocation")
+	defer cancel()
+	defer span.End()
+
+	// decode payload
+	payload := allocationPayload{}
+	defer req.Body.Close()
+
+	err := h.decodeJSON(req, &payload)
+	if err != nil {
+		span.RecordError(err)
+        span.SetStatus(codes.Error, err.Error())
+		return h.ErrorHandler(h.getTraceID(ctx), err)
+	}
+	allocation := model.ChannelAllocation{	Channel: payload.Channel,
+											Type: payload.Type,
+											Quantity: payload.Quantity,
+											Percentage: payload.Percentage}
+
+	// get put parameter
+	vars := mux.Vars(req)
+	allocation.Product = model.Product{Sku: vars["id"]}
+
+	// validate payload
+	err = validator.Validate(rules.Sku(allocation.Product.Sku), allocationRules(&allocation))
+	if err != nil {
+		return h.ErrorHandler(h.getTraceID(ctx), err)
+	}
+
+	// call service
+	res, err := h.workerService.SetChannelAllocation(ctx, &allocation)
+	if err != nil {
+		return h.ErrorHandler(h.getTraceID(ctx), err)
+	}
+
+	return h.writeJSON(rw, http.StatusOK, res)
+}
+
+// About list the allocations of a product
+func (h *HttpRouters) ListChannelAllocation(rw http.ResponseWriter, req *http.Request) error {
+	ctx, cancel, span := h.withContext(req, "ListChannelAllocation")
+	defer cancel()
+	defer span.End()
+
+	vars := mux.Vars(req)
+	product := model.Product{Sku: vars["id"]}
+
+	// call service
+	res, err := h.workerService.ListChannelAllocation(ctx, &product)
+	if err != nil {
+		return h.ErrorHandler(h.getTraceID(ctx), err)
+	}
+
+	return h.writeJSON(rw, http.StatusOK, res)
+}
+
+// About delete the allocation of a sales channel
+func (h *HttpRouters) DeleteChannelAllocation(rw http.ResponseWriter, req *http.Request) error {
+	ctx, cancel, span := h.withContext(req, "DeleteChannelAllocation")
+	defer cancel()
+	defer span.End()
+
+	vars := mux.Vars(req)
+	allocation := model.ChannelAllocation{	Product: model.Product{Sku: vars["id"]},
+											Channel: vars["channel"]}
+
+	// call service
+	err := h.workerService.DeleteChannelAllocation(ctx, &allocation)
+	if err != nil {
+		return h.ErrorHandler(h.getTraceID(ctx), err)
+	}
+
+	return h.writeJSON(rw, http.StatusOK, allocation)
+}
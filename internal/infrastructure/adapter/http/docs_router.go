@@ -0,0 +1,27 @@
+package http
+
+import (
+	"net/http"
+
+	"github.com/go-inventory/api"
+)
+
+// the reference page loads redoc from its cdn, so it needs a policy wider than the api one
+const docsContentSecurityPolicy = "default-src 'none'; script-src https://cdn.redoc.ly; style-src 'unsafe-inline' https://fonts.googleapis.com; font-src https://fonts.gstatic.com; img-src 'self' data: https://cdn.redoc.ly; worker-src blob:; connect-src 'self'"
+
+// About return the OpenAPI document
+func (h *HttpRouters) OpenAPI(rw http.ResponseWriter, req *http.Request) {
+	rw.Header().Set("Content-Type", "application/json")
+	rw.WriteHeader(http.StatusOK)
+
+	rw.Write(api.OpenAPI)
+}
+
+// About return the API reference page
+func (h *HttpRouters) Docs(rw http.ResponseWriter, req *http.Request) {
+	rw.Header().Set("Content-Security-Policy", docsContentSecurityPolicy)
+	rw.Header().Set("Content-Type", "text/html; charset=utf-8")
+	rw.WriteHeader(http.StatusOK)
+
+	rw.Write(api.Docs)
+}
@@ -0,0 +1,166 @@
+package http
+
+import (
+	"fmt"
+	"errors"
+	"strings"
+	"strconv"
+	"net/http"
+	"encoding/json"
+
+	"github.com/go-inventory/shared/erro"
+
+	go_core_midleware "github.com/eliezerraj/go-core/v2/middleware"
+)
+
+// http status of each kind of error
+var kindStatus = map[erro.Kind]int{
+	erro.KindInvalid:		http.StatusBadRequest,
+	erro.KindNotFound:		http.StatusNotFound,
+	erro.KindConflict:		http.StatusConflict,
+	erro.KindPrecondition:	http.StatusPreconditionFailed,
+	erro.KindUnauthorized:	http.StatusUnauthorized,
+	erro.KindForbidden:		http.StatusForbidden,
+	erro.KindRateLimited:	http.StatusTooManyRequests,
+	erro.KindTimeout:		http.StatusGatewayTimeout,
+	erro.KindUnavailable:	http.StatusServiceUnavailable,
+	erro.KindInternal:		http.StatusInternalServerError,
+}
+
+// APIError is the error response, code is stable and can be used by clients
+type APIError struct {
+	StatusCode	int						`json:"status_code"`
+	Code		string					`json:"code"`
+	Msg			string					`json:"message"`
+	Retryable	bool					`json:"retryable,omitempty"`
+	Details		map[string]interface{}	`json:"details,omitempty"`
+	Violations	[]erro.Violation		`json:"violations,omitempty"`
+	RequestID	string					`json:"request_id,omitempty"`
+	cause		error
+}
+
+func (e *APIError) Error() string {
+	return e.Msg
+}
+
+// Problem is the RFC 7807 error response (application/problem+json)
+type Problem struct {
+	Type		string					`json:"type"`
+	Title		string					`json:"title"`
+	Status		int						`json:"status"`
+	Detail		string					`json:"detail,omitempty"`
+	Instance	string					`json:"instance,omitempty"`
+	Code		string					`json:"code,omitempty"`
+	Retryable	bool					`json:"retryable,omitempty"`
+	RequestID	string					`json:"request_id,omitempty"`
+	Details		map[string]interface{}	`json:"details,omitempty"`
+	Errors		[]erro.Violation		`json:"errors,omitempty"`
+}
+
+const problemContentType = "application/problem+json"
+
+// Helper to know whether the client accepts problem+json, otherwise the APIError format is kept
+func acceptsProblem(req *http.Request) bool {
+	for _, accept := range strings.Split(req.Header.Get("Accept"), ",") {
+		mediaType := strings.TrimSpace(strings.Split(accept, ";")[0])
+		if strings.EqualFold(mediaType, problemContentType) {
+			return true
+		}
+	}
+	return false
+}
+
+// Helper to convert a APIError into a problem
+func (e *APIError) problem(req *http.Request) Problem {
+	return Problem{
+		Type: "/problems/" + strings.ReplaceAll(strings.ToLower(e.Code), "_", "-"),
+		Title: http.StatusText(e.StatusCode),
+		Status: e.StatusCode,
+		Detail: e.Msg,
+		Instance: req.URL.Path,
+		Code: e.Code,
+		Retryable: e.Retryable,
+		RequestID: e.RequestID,
+		Details: e.Details,
+		Errors: e.Violations,
+	}
+}
+
+// Helper to write a problem+json response
+func (h *HttpRouters) writeProblem(w http.ResponseWriter, problem Problem) error {
+	w.Header().Set("Content-Type", problemContentType)
+	w.WriteHeader(problem.Status)
+
+	return json.NewEncoder(w).Encode(problem)
+}
+
+// apiFunc is a handler function that can return an error
+type apiFunc func(w http.ResponseWriter, r *http.Request) error
+
+// ErrorHandler creates an APIError with the http status of the error kind
+// the message is the stable one of the typed error, the wrapped cause is kept only to be logged
+func (h *HttpRouters) ErrorHandler(traceID string, err error) *APIError {
+	typed := erro.As(err)
+
+	msg := typed.PublicMessage()
+	httpStatusCode, ok := kindStatus[typed.Kind]
+	if !ok {
+		httpStatusCode = http.StatusInternalServerError
+		msg = "internal server error"
+	}
+
+	return &APIError{
+		StatusCode: httpStatusCode,
+		Code: typed.Code,
+		Msg: msg,
+		Retryable: typed.Retryable,
+		Details: typed.Details,
+		Violations: typed.Violations,
+		RequestID: traceID,
+		cause: err,
+	}
+}
+
+// MiddleWareErrorHandler wraps handler functions and writes their errors
+func (h *HttpRouters) MiddleWareErrorHandler(next apiFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		err := next(w, r)
+		if err == nil {
+			return
+		}
+
+		requestID := go_core_midleware.GetRequestID(r.Context())
+
+		var apiErr *APIError
+		if !errors.As(err, &apiErr) {
+			h.logger.Error().
+					Err(err).
+					Str("request_id", requestID).
+					Msg("Internal server error")
+			apiErr = h.ErrorHandler(requestID, erro.Wrap(fmt.Errorf("internal server error"), erro.KindInternal, "INTERNAL_ERROR", ""))
+		} else {
+			h.logger.Warn().
+					Err(apiErr.cause).
+					Str("request_id", requestID).
+					Int("status_code", apiErr.StatusCode).
+					Str("code", apiErr.Code).
+					Msg("API error")
+		}
+
+		// the seconds to wait are in the details when the error knows them
+		if apiErr.Retryable {
+			retryAfter := "1"
+			if seconds, ok := apiErr.Details["retry_after"].(int); ok && seconds > 0 {
+				retryAfter = strconv.Itoa(seconds)
+			}
+			w.Header().Set("Retry-After", retryAfter)
+		}
+
+		// the format is negotiated by the Accept header
+		if acceptsProblem(r) {
+			h.writeProblem(w, apiErr.problem(r))
+			return
+		}
+		h.writeJSON(w, apiErr.StatusCode, apiErr)
+	}
+}
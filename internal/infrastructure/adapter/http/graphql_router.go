@@ -0,0 +1,49 @@
+package http
+
+import (
+	"net/http"
+
+	"go.opentelemetry.io/otel/codes"
+
+	"github.com/go-inventory/shared/erro"
+	"github.com/go-inventory/shared/validator"
+	"github.com/go-inventory/internal/infrastructure/adapter/graphql"
+)
+
+// About execute a graphql query, errors of the fields are returned with status 200 in the errors of the result
+func (h *HttpRouters) Graphql(rw http.ResponseWriter, req *http.Request) error {
+	ctx, cancel, span := h.withContext(req, "Graphql")
+	defer cancel()
+	defer span.End()
+
+	if h.graphqlExecutor == nil {
+		return h.ErrorHandler(h.getTraceID(ctx), erro.ErrServer)
+	}
+
+	// decode payload
+	request := graphql.Request{}
+	defer req.Body.Close()
+
+	err := h.decodeJSON(req, &request)
+	if err != nil {
+		span.RecordError(err) 
+        span.SetStatus(codes.Error, err.Error())		
+		return h.ErrorHandler(h.getTraceID(ctx), err)
+	}
+
+	// validate payload
+	err = validator.Validate(func(v *validator.Validator) {
+		v.Required("query", request.Query)
+	})
+	if err != nil {
+		return h.ErrorHandler(h.getTraceID(ctx), err)
+	}
+
+	// call executor
+	res, err := h.graphqlExecutor.Execute(ctx, &request)
+	if err != nil {
+		return h.ErrorHandler(h.getTraceID(ctx), err)
+	}
+
+	return h.writeJSON(rw, http.StatusOK, res)
+}
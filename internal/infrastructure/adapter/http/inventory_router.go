@@ -3,14 +3,30 @@ package http
 import (
 	"net/http"
 	"strconv"
-	"encoding/json"	
 
 	"go.opentelemetry.io/otel/codes"	
 	"github.com/gorilla/mux"
 	"github.com/go-inventory/internal/domain/model"
 	"github.com/go-inventory/shared/erro"
+	"github.com/go-inventory/shared/validator"
+	"github.com/go-inventory/internal/infrastructure/adapter/rules"
 )
 
+// inventory update payload, the deltas of the buckets the client moves and the sales channel consuming the stock
+type inventoryPayload struct {
+	Available		int		`json:"available,omitempty"`
+	Pending			int		`json:"pending,omitempty"`
+	Reserved		int		`json:"reserved,omitempty"`
+	Sold			int		`json:"sold,omitempty"`
+	Channel			string	`json:"channel,omitempty"`
+}
+
+// move between buckets payload, the source is available unless informed
+type transferPayload struct {
+	From			string	`json:"from,omitempty"`
+	Quantity		int		`json:"quantity"`
+}
+
 // About get inventory
 func (h *HttpRouters) GetInventory(rw http.ResponseWriter, req *http.Request) error {			
 	ctx, cancel, span := h.withContext(req, "GetInventory")
@@ -22,12 +38,16 @@ func (h *HttpRouters) GetInventory(rw http.ResponseWriter, req *http.Request) er
 	varID := vars["id"]
 	inventory := model.Inventory{Product: model.Product{Sku: varID}}
 
+	// the availability can be scoped by a sales channel
+	inventory.Channel = req.URL.Query().Get("channel")
+
 	// call service	
 	res, err := h.workerService.GetInventory(ctx, &inventory)
 	if err != nil {
 		return h.ErrorHandler(h.getTraceID(ctx), err)
 	}
 	
+	h.setETag(rw, res.Version)
 	return h.writeJSON(rw, http.StatusOK, res)
 }
 
@@ -38,27 +58,50 @@ func (h *HttpRouters) UpdateInventory(rw http.ResponseWriter, req *http.Request)
 	defer span.End()
 
 	// decode payload	
-	inventory := model.Inventory{}
+	payload := inventoryPayload{}
 	defer req.Body.Close()
 	
-	err := json.NewDecoder(req.Body).Decode(&inventory)
+	err := h.decodeJSON(req, &payload)
 	if err != nil {
 		span.RecordError(err) 
         span.SetStatus(codes.Error, err.Error())
-		return h.ErrorHandler(h.getTraceID(ctx), erro.ErrBadRequest)
+		return h.ErrorHandler(h.getTraceID(ctx), err)
 	}
+	inventory := model.Inventory{	Available: payload.Available,
+									Pending: payload.Pending,
+									Reserved: payload.Reserved,
+									Sold: payload.Sold,
+									Channel: payload.Channel}
 
 	// get put parameter		
 	vars := mux.Vars(req)
 	varSku := vars["id"]
 	inventory.Product.Sku = varSku
 
+	// the sales channel consuming the stock can be informed in the query
+	if channel := req.URL.Query().Get("channel"); channel != "" {
+		inventory.Channel = channel
+	}
+
+	// the version expected only comes from If-Match
+	inventory.Version, err = h.parseIfMatch(req)
+	if err != nil {
+		return h.ErrorHandler(h.getTraceID(ctx), err)
+	}
+
+	// validate payload
+	err = validator.Validate(rules.Sku(inventory.Product.Sku), rules.Inventory(&inventory))
+	if err != nil {
+		return h.ErrorHandler(h.getTraceID(ctx), err)
+	}
+
 	// call service	
 	res, err := h.workerService.UpdateInventory(ctx, &inventory)
 	if err != nil {
 		return h.ErrorHandler(h.getTraceID(ctx), err)
 	}
 	
+	h.setETag(rw, res.Version)
 	return h.writeJSON(rw, http.StatusOK, res)
 }
 
@@ -105,4 +148,71 @@ func (h *HttpRouters) ListInventory(rw http.ResponseWriter, req *http.Request) e
 	}
 	
 	return h.writeJSON(rw, http.StatusOK, res)
-}
\ No newline at end of file
+}
+
+// About move available (or the bucket informed) stock to quarantine
+func (h *HttpRouters) QuarantineInventory(rw http.ResponseWriter, req *http.Request) error {
+	return h.transferInventory(rw, req, "QuarantineInventory", model.BucketQuarantine)
+}
+
+// About move available (or the bucket informed) stock to damaged
+func (h *HttpRouters) DamageInventory(rw http.ResponseWriter, req *http.Request) error {
+	return h.transferInventory(rw, req, "DamageInventory", model.BucketDamaged)
+}
+
+// About move available (or the bucket informed) stock to hold
+func (h *HttpRouters) HoldInventory(rw http.ResponseWriter, req *http.Request) error {
+	return h.transferInventory(rw, req, "HoldInventory", model.BucketHold)
+}
+
+// About move quarantine or hold stock back to available
+func (h *HttpRouters) ReleaseInventory(rw http.ResponseWriter, req *http.Request) error {
+	return h.transferInventory(rw, req, "ReleaseInventory", model.BucketAvailable)
+}
+
+// Helper to move stock between buckets
+func (h *HttpRouters) transferInventory(rw http.ResponseWriter, req *http.Request, spanName string, to string) error {
+	ctx, cancel, span := h.withContext(req, spanName)
+	defer cancel()
+	defer span.End()
+
+	// decode payload	
+	payload := transferPayload{}
+	defer req.Body.Close()
+	
+	err := h.decodeJSON(req, &payload)
+	if err != nil {
+		span.RecordError(err) 
+        span.SetStatus(codes.Error, err.Error())
+		return h.ErrorHandler(h.getTraceID(ctx), err)
+	}
+	transfer := model.InventoryTransfer{From: payload.From, Quantity: payload.Quantity}
+
+	// the source is available unless informed, a release must inform it
+	if transfer.From == "" {
+		if to == model.BucketAvailable {
+			return h.ErrorHandler(h.getTraceID(ctx), erro.ErrValidation.WithViolations(erro.Violation{Field: "from", Message: "is required"}))
+		}
+		transfer.From = model.BucketAvailable
+	}
+	transfer.To = to
+
+	// get put parameter		
+	vars := mux.Vars(req)
+	transfer.Product = model.Product{Sku: vars["id"]}
+
+	// validate payload
+	err = validator.Validate(rules.Sku(transfer.Product.Sku), rules.Transfer(&transfer))
+	if err != nil {
+		return h.ErrorHandler(h.getTraceID(ctx), err)
+	}
+
+	// call service	
+	res, err := h.workerService.TransferInventory(ctx, &transfer)
+	if err != nil {
+		return h.ErrorHandler(h.getTraceID(ctx), err)
+	}
+	
+	h.setETag(rw, res.Version)
+	return h.writeJSON(rw, http.StatusOK, res)
+}
@@ -12,8 +12,11 @@ import (
 	"github.com/gorilla/mux"
 
 	"github.com/go-inventory/shared/erro"
+	"github.com/go-inventory/shared/validator"
+	"github.com/go-inventory/internal/infrastructure/adapter/rules"
 	"github.com/go-inventory/internal/domain/model"
 	"github.com/go-inventory/internal/domain/service"
+	"github.com/go-inventory/internal/infrastructure/adapter/graphql"
 	"go.opentelemetry.io/otel/trace"
 	"go.opentelemetry.io/otel/codes"
 
@@ -31,6 +34,7 @@ type HttpRouters struct {
 	appServer		*model.AppServer
 	logger			*zerolog.Logger
 	tracerProvider 	*go_core_otel_trace.TracerProvider
+	graphqlExecutor	*graphql.Executor
 }
 
 // Helper to extract context with timeout and setup span
@@ -64,11 +68,20 @@ func NewHttpRouters(appServer *model.AppServer,
 	logger.Info().
 			Str("func","NewHttpRouters").Send()
 
+	// the schema is static, a error here is a bug and the endpoint answers unavailable
+	graphqlExecutor, err := graphql.NewExecutor(appServer, workerService, appLogger, tracerProvider)
+	if err != nil {
+		logger.Error().
+				Err(err).
+				Msg("FAILED to create the graphql schema")
+	}
+
 	return HttpRouters{
 		workerService: workerService,
 		appServer: appServer,
 		logger: &logger,
 		tracerProvider: tracerProvider,
+		graphqlExecutor: graphqlExecutor,
 	}
 }
 
@@ -80,28 +93,30 @@ func (h *HttpRouters) writeJSON(w http.ResponseWriter, code int, data interface{
 	return json.NewEncoder(w).Encode(data)
 }
 
-// ErrorHandler creates an APIError with appropriate HTTP status based on error type
-func (h *HttpRouters) ErrorHandler(traceID string, err error) *go_core_midleware.APIError {
-	var httpStatusCode int = http.StatusInternalServerError
-
-	if strings.Contains(err.Error(), "context deadline exceeded") {
-		httpStatusCode = http.StatusGatewayTimeout
+// Helper to set the ETag header with the resource version
+func (h *HttpRouters) setETag(w http.ResponseWriter, version int) {
+	if version > 0 {
+		w.Header().Set("ETag", strconv.Quote(strconv.Itoa(version)))
 	}
+}
 
-	if strings.Contains(err.Error(), "check parameters") {
-		httpStatusCode = http.StatusBadRequest
+// Helper to get the version informed in the If-Match header, zero means no precondition. If-Match uses the
+// strong comparison (RFC 7232), a weak validator never matches
+func (h *HttpRouters) parseIfMatch(req *http.Request) (int, error) {
+	ifMatch := strings.TrimSpace(req.Header.Get("If-Match"))
+	if ifMatch == "" || ifMatch == "*" {
+		return 0, nil
 	}
 
-	if strings.Contains(err.Error(), "not found") {
-		httpStatusCode = http.StatusNotFound
+	if strings.HasPrefix(ifMatch, "W/") {
+		return 0, erro.ErrPreconditionFailed
 	}
-
-	if strings.Contains(err.Error(), "duplicate key") || 
-	   strings.Contains(err.Error(), "unique constraint") {
-		httpStatusCode = http.StatusBadRequest
+	version, err := strconv.Atoi(strings.Trim(ifMatch, `"`))
+	if err != nil || version <= 0 {
+		return 0, erro.ErrBadRequest
 	}
 
-	return go_core_midleware.NewAPIError(err, traceID, httpStatusCode)
+	return version, nil
 }
 
 // About return a health, without log and trace to avoid flush then in K8 
@@ -148,6 +163,26 @@ func (h *HttpRouters) Info(rw http.ResponseWriter, req *http.Request) {
 	json.NewEncoder(rw).Encode(h.appServer)
 }
 
+// product payload, the id, the version and the dates belong to the service
+type productPayload struct {
+	Sku			string		`json:"sku,omitempty"`
+	Type		string 		`json:"type,omitempty"`
+	Name		string 		`json:"name,omitempty"`
+	Status		string 		`json:"status,omitempty"`
+	LeadTime	int			`json:"lead_time,omitempty"`
+}
+
+// Helper to map the payload to a product
+func (p productPayload) toProduct() model.Product {
+	return model.Product{
+		Sku:		p.Sku,
+		Type:		p.Type,
+		Name:		p.Name,
+		Status:		p.Status,
+		LeadTime:	p.LeadTime,
+	}
+}
+
 // About add product
 func (h *HttpRouters) AddProduct(rw http.ResponseWriter, req *http.Request) error {
 	ctx, cancel, span := h.withContext(req, "AddProduct")
@@ -155,14 +190,21 @@ func (h *HttpRouters) AddProduct(rw http.ResponseWriter, req *http.Request) erro
 	defer span.End()
 	
 	// decode payload		
-	product := model.Product{}
+	payload := productPayload{}
 	defer req.Body.Close()
 	
-	err := json.NewDecoder(req.Body).Decode(&product)
+	err := h.decodeJSON(req, &payload)
 	if err != nil {
 		span.RecordError(err) 
         span.SetStatus(codes.Error, err.Error())		
-		return h.ErrorHandler(h.getTraceID(ctx), erro.ErrBadRequest)
+		return h.ErrorHandler(h.getTraceID(ctx), err)
+	}
+	product := payload.toProduct()
+
+	// validate payload
+	err = validator.Validate(rules.Product(&product, true))
+	if err != nil {
+		return h.ErrorHandler(h.getTraceID(ctx), err)
 	}
 
 	// call service
@@ -200,6 +242,50 @@ func (h *HttpRouters) GetProduct(rw http.ResponseWriter, req *http.Request) erro
 		return h.ErrorHandler(h.getTraceID(ctx), err)
 	}
 	
+	h.setETag(rw, res.Version)
+	return h.writeJSON(rw, http.StatusOK, res)
+}
+
+// About update product
+func (h *HttpRouters) UpdateProduct(rw http.ResponseWriter, req *http.Request) error {
+	ctx, cancel, span := h.withContext(req, "UpdateProduct")
+	defer cancel()
+	defer span.End()
+
+	// decode payload		
+	payload := productPayload{}
+	defer req.Body.Close()
+	
+	err := h.decodeJSON(req, &payload)
+	if err != nil {
+		span.RecordError(err) 
+        span.SetStatus(codes.Error, err.Error())		
+		return h.ErrorHandler(h.getTraceID(ctx), err)
+	}
+	product := payload.toProduct()
+
+	// get put parameter and the version expected
+	vars := mux.Vars(req)
+	product.Sku = vars["id"]
+
+	product.Version, err = h.parseIfMatch(req)
+	if err != nil {
+		return h.ErrorHandler(h.getTraceID(ctx), err)
+	}
+
+	// validate payload
+	err = validator.Validate(rules.Sku(product.Sku), rules.Product(&product, false))
+	if err != nil {
+		return h.ErrorHandler(h.getTraceID(ctx), err)
+	}
+
+	// call service
+	res, err := h.workerService.UpdateProduct(ctx, &product)
+	if err != nil {
+		return h.ErrorHandler(h.getTraceID(ctx), err)
+	}
+	
+	h.setETag(rw, res.Version)
 	return h.writeJSON(rw, http.StatusOK, res)
 }
 
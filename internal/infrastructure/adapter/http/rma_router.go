@@ -0,0 +1,196 @@
+package http
+
+import (
+	"net/http"
+	"strconv"
+
+	"go.opentelemetry.io/otel/codes"
+	"github.com/gorilla/mux"
+	"github.com/go-inventory/internal/domain/model"
+	"github.com/go-inventory/shared/erro"
+	"github.com/go-inventory/shared/validator"
+)
+
+// rma creation payload, the product is informed by its sku
+type rmaPayload struct {
+	OrderRef		string		`json:"order_ref,omitempty"`
+	Product			struct {
+		Sku			string		`json:"sku,omitempty"`
+	}							`json:"product"`
+	Quantity		int			`json:"quantity"`
+	Reason			string		`json:"reason,omitempty"`
+}
+
+// receive payload
+type rmaReceive struct {
+	Quantity		int		`json:"quantity"`
+}
+
+// inspection payload
+type rmaInspection struct {
+	Restock		int		`json:"restock"`
+	Damaged		int		`json:"damaged"`
+	Note		string	`json:"note,omitempty"`
+}
+
+// Helper to get the rma id from the path
+func (h *HttpRouters) rmaID(req *http.Request) (int, error) {
+	vars := mux.Vars(req)
+	varID, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		return 0, erro.ErrBadRequest
+	}
+	return varID, nil
+}
+
+// About create a rma
+func (h *HttpRouters) CreateRma(rw http.ResponseWriter, req *http.Request) error {
+	ctx, cancel, span := h.withContext(req, "CreateRma")
+	defer cancel()
+	defer span.End()
+
+	// decode payload
+	payload := rmaPayload{}
+	defer req.Body.Close()
+
+	err := h.decodeJSON(req, &payload)
+	if err != nil {
+		span.RecordError(err)
+        span.SetStatus(codes.Error, err.Error())
+		return h.ErrorHandler(h.getTraceID(ctx), err)
+	}
+	rma := model.Rma{	OrderRef: payload.OrderRef,
+						Product: model.Product{Sku: payload.Product.Sku},
+						Quantity: payload.Quantity,
+						Reason: payload.Reason}
+
+	// validate payload
+	err = validator.Validate(rmaRules(&rma))
+	if err != nil {
+		return h.ErrorHandler(h.getTraceID(ctx), err)
+	}
+
+	// call service
+	res, err := h.workerService.CreateRma(ctx, &rma)
+	if err != nil {
+		return h.ErrorHandler(h.getTraceID(ctx), err)
+	}
+
+	return h.writeJSON(rw, http.StatusOK, res)
+}
+
+// About get a rma
+func (h *HttpRouters) GetRma(rw http.ResponseWriter, req *http.Request) error {
+	ctx, cancel, span := h.withContext(req, "GetRma")
+	defer cancel()
+	defer span.End()
+
+	varID, err := h.rmaID(req)
+	if err != nil {
+		return h.ErrorHandler(h.getTraceID(ctx), err)
+	}
+
+	// call service
+	res, err := h.workerService.GetRma(ctx, &model.Rma{ID: varID})
+	if err != nil {
+		return h.ErrorHandler(h.getTraceID(ctx), err)
+	}
+
+	return h.writeJSON(rw, http.StatusOK, res)
+}
+
+// About receive the items of a rma
+func (h *HttpRouters) ReceiveRma(rw http.ResponseWriter, req *http.Request) error {
+	ctx, cancel, span := h.withContext(req, "ReceiveRma")
+	defer cancel()
+	defer span.End()
+
+	varID, err := h.rmaID(req)
+	if err != nil {
+		return h.ErrorHandler(h.getTraceID(ctx), err)
+	}
+
+	// decode payload
+	received := rmaReceive{}
+	defer req.Body.Close()
+
+	err = h.decodeJSON(req, &received)
+	if err != nil {
+		span.RecordError(err)
+        span.SetStatus(codes.Error, err.Error())
+		return h.ErrorHandler(h.getTraceID(ctx), err)
+	}
+
+	// validate payload
+	err = validator.Validate(receiveRules(&received))
+	if err != nil {
+		return h.ErrorHandler(h.getTraceID(ctx), err)
+	}
+
+	// call service
+	res, err := h.workerService.ReceiveRma(ctx, &model.Rma{ID: varID, Received: received.Quantity})
+	if err != nil {
+		return h.ErrorHandler(h.getTraceID(ctx), err)
+	}
+
+	return h.writeJSON(rw, http.StatusOK, res)
+}
+
+// About inspect the items received of a rma
+func (h *HttpRouters) InspectRma(rw http.ResponseWriter, req *http.Request) error {
+	ctx, cancel, span := h.withContext(req, "InspectRma")
+	defer cancel()
+	defer span.End()
+
+	varID, err := h.rmaID(req)
+	if err != nil {
+		return h.ErrorHandler(h.getTraceID(ctx), err)
+	}
+
+	// decode payload
+	inspection := rmaInspection{}
+	defer req.Body.Close()
+
+	err = h.decodeJSON(req, &inspection)
+	if err != nil {
+		span.RecordError(err)
+        span.SetStatus(codes.Error, err.Error())
+		return h.ErrorHandler(h.getTraceID(ctx), err)
+	}
+
+	// validate payload
+	err = validator.Validate(inspectionRules(&inspection))
+	if err != nil {
+		return h.ErrorHandler(h.getTraceID(ctx), err)
+	}
+
+	// call service
+	res, err := h.workerService.InspectRma(ctx, &model.Rma{	ID: varID,
+															Restock: inspection.Restock,
+															Damaged: inspection.Damaged}, inspection.Note)
+	if err != nil {
+		return h.ErrorHandler(h.getTraceID(ctx), err)
+	}
+
+	return h.writeJSON(rw, http.StatusOK, res)
+}
+
+// About restock the items inspected of a rma
+func (h *HttpRouters) RestockRma(rw http.ResponseWriter, req *http.Request) error {
+	ctx, cancel, span := h.withContext(req, "RestockRma")
+	defer cancel()
+	defer span.End()
+
+	varID, err := h.rmaID(req)
+	if err != nil {
+		return h.ErrorHandler(h.getTraceID(ctx), err)
+	}
+
+	// call service
+	res, err := h.workerService.RestockRma(ctx, &model.Rma{ID: varID})
+	if err != nil {
+		return h.ErrorHandler(h.getTraceID(ctx), err)
+	}
+
+	return h.writeJSON(rw, http.StatusOK, res)
+}
@@ -0,0 +1,222 @@
+package http
+
+import (
+	"fmt"
+	"time"
+	"strings"
+	"context"
+	"net/http"
+	"encoding/json"
+
+	"github.com/gorilla/websocket"
+
+	"github.com/go-inventory/shared/validator"
+	"github.com/go-inventory/internal/infrastructure/adapter/rules"
+	"github.com/go-inventory/internal/domain/model"
+	"github.com/go-inventory/internal/domain/service"
+)
+
+const (
+	// skus of a single stream
+	maxStreamSkus = 100
+	// interval of the keep alive, shorter than the idle timeout of the usual proxies
+	streamHeartbeat = 15 * time.Second
+)
+
+// stock levels are public and the stream is read only, so any origin can open a websocket
+var upgrader = websocket.Upgrader{
+	ReadBufferSize: 1024,
+	WriteBufferSize: 1024,
+	CheckOrigin: func(req *http.Request) bool { return true },
+}
+
+// Helper to get the skus of a stream, informed as sku=a,b or sku=a&sku=b
+func streamSkus(req *http.Request) []string {
+	list_sku := []string{}
+	for _, param := range req.URL.Query()["sku"] {
+		for _, sku := range strings.Split(param, ",") {
+			if sku = strings.TrimSpace(sku); sku != "" {
+				list_sku = append(list_sku, sku)
+			}
+		}
+	}
+	return list_sku
+}
+
+// Rules of the skus of a stream
+func streamRules(skus []string) validator.Rule {
+	return func(v *validator.Validator) {
+		v.Check(len(skus) > 0, "sku", "is required").
+		  Check(len(skus) <= maxStreamSkus, "sku", fmt.Sprintf("must have at most %d skus", maxStreamSkus))
+		for _, sku := range skus {
+			v.MaxLength("sku", sku, rules.MaxLength).
+			  Pattern("sku", sku, validator.SkuPattern)
+		}
+	}
+}
+
+// About stream the changes of the inventories of the skus, over websocket when the connection is upgraded
+// and over server-sent events otherwise. The stream lasts beyond the context timeout of the other routers
+func (h *HttpRouters) InventoryStream(rw http.ResponseWriter, req *http.Request) error {
+	ctx := req.Context()
+
+	h.logger.Info().
+			Ctx(ctx).
+			Str("func","InventoryStream").Send()
+
+	skus := streamSkus(req)
+	err := validator.Validate(streamRules(skus))
+	if err != nil {
+		return h.ErrorHandler(h.getTraceID(ctx), err)
+	}
+
+	// EventSource sends the header on reconnection, websocket clients can only use the query
+	lastEventID := req.Header.Get("Last-Event-ID")
+	if lastEventID == "" {
+		lastEventID = req.URL.Query().Get("last_event_id")
+	}
+
+	// the subscription only needs the timeout to read the current state
+	subscribeCtx, cancel := context.WithTimeout(ctx, time.Duration(h.appServer.Server.CtxTimeout) * time.Second)
+	subscription, list_event, err := h.workerService.SubscribeInventory(subscribeCtx, skus, lastEventID)
+	cancel()
+	if err != nil {
+		return h.ErrorHandler(h.getTraceID(ctx), err)
+	}
+	defer h.workerService.UnsubscribeInventory(subscription)
+
+	if websocket.IsWebSocketUpgrade(req) {
+		return h.streamWebsocket(rw, req, subscription, list_event)
+	}
+	return h.streamEvents(rw, req, subscription, list_event)
+}
+
+// Helper to stream as server-sent events
+func (h *HttpRouters) streamEvents(rw http.ResponseWriter,
+								req *http.Request,
+								subscription *service.InventorySubscription,
+								list_event []model.InventoryEvent) error {
+	controller := http.NewResponseController(rw)
+	// the write timeout of the server would close the stream
+	controller.SetWriteDeadline(time.Time{})
+
+	rw.Header().Set("Content-Type", "text/event-stream")
+	rw.Header().Set("Cache-Control", "no-cache")
+	rw.Header().Set("Connection", "keep-alive")
+	rw.Header().Set("X-Accel-Buffering", "no")
+	rw.WriteHeader(http.StatusOK)
+
+	fmt.Fprintf(rw, "retry: %d\n\n", 3000)
+	for _, event := range list_event {
+		if err := writeEvent(rw, event); err != nil {
+			return nil
+		}
+	}
+	if err := controller.Flush(); err != nil {
+		return nil
+	}
+
+	heartbeat := time.NewTicker(streamHeartbeat)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-req.Context().Done():
+			return nil
+		case event, ok := <-subscription.Events:
+			if !ok {
+				// dropped or shutdown, the client reconnects with Last-Event-ID
+				return nil
+			}
+			if err := writeEvent(rw, event); err != nil {
+				return nil
+			}
+		case <-heartbeat.C:
+			if _, err := fmt.Fprint(rw, ": heartbeat\n\n"); err != nil {
+				return nil
+			}
+		}
+		if err := controller.Flush(); err != nil {
+			return nil
+		}
+	}
+}
+
+// Helper to write a server-sent event, snapshots have no id so the Last-Event-ID of the client is kept
+func writeEvent(rw http.ResponseWriter, event model.InventoryEvent) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	if event.ID != "" {
+		if _, err := fmt.Fprintf(rw, "id: %s\n", event.ID); err != nil {
+			return err
+		}
+	}
+	_, err = fmt.Fprintf(rw, "event: %s\ndata: %s\n\n", event.Type, data)
+	return err
+}
+
+// Helper to stream over a websocket, each event is a text message with the event as json
+func (h *HttpRouters) streamWebsocket(rw http.ResponseWriter,
+									req *http.Request,
+									subscription *service.InventorySubscription,
+									list_event []model.InventoryEvent) error {
+	conn, err := upgrader.Upgrade(rw, req, nil)
+	if err != nil {
+		// the upgrader already answered the request
+		h.logger.Warn().
+				Ctx(req.Context()).
+				Err(err).
+				Msg("websocket upgrade FAILED")
+		return nil
+	}
+	defer conn.Close()
+
+	// the client messages are discarded, reading is needed to process the close and the pong
+	closed := make(chan struct{})
+	go func() {
+		defer close(closed)
+		for {
+			if _, _, err := conn.NextReader(); err != nil {
+				return
+			}
+		}
+	}()
+
+	for _, event := range list_event {
+		if err := conn.WriteJSON(event); err != nil {
+			return nil
+		}
+	}
+
+	heartbeat := time.NewTicker(streamHeartbeat)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-closed:
+			return nil
+		case event, ok := <-subscription.Events:
+			if !ok {
+				conn.WriteControl(websocket.CloseMessage,
+								websocket.FormatCloseMessage(websocket.CloseGoingAway, "reconnect with last_event_id"),
+								time.Now().Add(time.Second))
+				return nil
+			}
+			conn.SetWriteDeadline(time.Now().Add(streamHeartbeat))
+			if err := conn.WriteJSON(event); err != nil {
+				return nil
+			}
+		case <-heartbeat.C:
+			if err := conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(time.Second)); err != nil {
+				return nil
+			}
+		}
+	}
+}
+
+// About close the open streams, the server waits for them on shutdown otherwise
+func (h *HttpRouters) CloseStreams() {
+	h.workerService.CloseInventoryStream()
+}
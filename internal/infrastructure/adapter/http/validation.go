@@ -0,0 +1,147 @@
+package http
+
+import (
+	"io"
+	"errors"
+	"time"
+	"strings"
+	"net/url"
+	"net/http"
+	"net/netip"
+	"encoding/json"
+
+	"github.com/go-inventory/internal/domain/model"
+	"github.com/go-inventory/shared/erro"
+	"github.com/go-inventory/shared/security"
+	"github.com/go-inventory/shared/validator"
+	"github.com/go-inventory/internal/infrastructure/adapter/rules"
+)
+
+// Helper to decode a payload rejecting unknown fields
+func (h *HttpRouters) decodeJSON(req *http.Request, data interface{}) error {
+	decoder := json.NewDecoder(req.Body)
+	decoder.DisallowUnknownFields()
+
+	err := decoder.Decode(data)
+	if err == nil {
+		return nil
+	}
+
+	violation := erro.Violation{Field: "body", Message: err.Error()}
+
+	var typeErr *json.UnmarshalTypeError
+	switch {
+	case errors.Is(err, io.EOF):
+		violation.Message = "is required"
+	case errors.As(err, &typeErr):
+		violation = erro.Violation{Field: typeErr.Field, Message: "must be " + typeErr.Type.String()}
+	case strings.HasPrefix(err.Error(), "json: unknown field "):
+		violation = erro.Violation{	Field: strings.Trim(strings.TrimPrefix(err.Error(), "json: unknown field "), `"`),
+									Message: "is unknown"}
+	}
+
+	return erro.ErrValidation.WithViolations(violation)
+}
+
+// Rules of a cycle count
+func adjustmentRules(adjustment *model.InventoryAdjustment) validator.Rule {
+	return func(v *validator.Validator) {
+		v.Min("counted", adjustment.Counted, 0).
+		  MaxLength("reason", adjustment.Reason, rules.MaxLength)
+	}
+}
+
+// Rules of a rma creation
+func rmaRules(rma *model.Rma) validator.Rule {
+	return func(v *validator.Validator) {
+		v.Required("order_ref", rma.OrderRef).
+		  MaxLength("order_ref", rma.OrderRef, rules.MaxLength).
+		  Required("product.sku", rma.Product.Sku).
+		  Pattern("product.sku", rma.Product.Sku, validator.SkuPattern).
+		  Min("quantity", rma.Quantity, 1).
+		  MaxLength("reason", rma.Reason, rules.MaxLength)
+	}
+}
+
+// Rules of the items received of a rma
+func receiveRules(received *rmaReceive) validator.Rule {
+	return func(v *validator.Validator) {
+		v.Min("quantity", received.Quantity, 1)
+	}
+}
+
+// Rules of a rma inspection
+func inspectionRules(inspection *rmaInspection) validator.Rule {
+	return func(v *validator.Validator) {
+		v.Min("restock", inspection.Restock, 0).
+		  Min("damaged", inspection.Damaged, 0).
+		  MaxLength("note", inspection.Note, rules.MaxLength)
+	}
+}
+
+// Rules of a channel allocation
+func allocationRules(allocation *model.ChannelAllocation) validator.Rule {
+	return func(v *validator.Validator) {
+		v.Required("channel", allocation.Channel).
+		  MaxLength("channel", allocation.Channel, rules.MaxLength).
+		  Required("type", allocation.Type).
+		  OneOf("type", allocation.Type, model.AllocationFixed, model.AllocationPercentage)
+		if allocation.Type == model.AllocationFixed {
+			v.Min("quantity", allocation.Quantity, 0)
+		}
+		if allocation.Type == model.AllocationPercentage {
+			v.Range("percentage", allocation.Percentage, 1, 100)
+		}
+	}
+}
+
+// Helper to tell whether the host of a webhook may be public, a name is only resolved when
+// the webhook is posted (the sender checks the address again)
+func publicHost(host string) bool {
+	if strings.EqualFold(host, "localhost") || strings.HasSuffix(strings.ToLower(host), ".localhost") {
+		return false
+	}
+	if addr, err := netip.ParseAddr(host); err == nil {
+		return security.PublicAddress(addr)
+	}
+	return true
+}
+
+// Rules of a webhook registration, a webhook watches either a sku or a product type
+func webhookRules(webhook *model.Webhook) validator.Rule {
+	return func(v *validator.Validator) {
+		target, err := url.Parse(webhook.Url)
+		v.Required("url", webhook.Url).
+		  MaxLength("url", webhook.Url, 2048).
+		  Check(err == nil && (target.Scheme == "http" || target.Scheme == "https") && target.Host != "",
+				"url", "must be a absolute http or https url").
+		  Check(err != nil || publicHost(target.Hostname()), "url", "must not be a loopback, private or link-local address").
+		  Check((webhook.Sku == "") != (webhook.ProductType == ""), "sku", "exactly one of sku or product_type must be informed").
+		  MaxLength("sku", webhook.Sku, rules.MaxLength).
+		  Pattern("sku", webhook.Sku, validator.SkuPattern).
+		  MaxLength("product_type", webhook.ProductType, rules.MaxLength).
+		  Min("threshold", webhook.Threshold, 0).
+		  MaxLength("secret", webhook.Secret, 256)
+	}
+}
+
+// Rules of a api key, at least one level and the sku prefixes as the beginning of a sku
+func apiKeyRules(apiKey *model.ApiKey) validator.Rule {
+	return func(v *validator.Validator) {
+		v.Required("name", apiKey.Name).
+		  MaxLength("name", apiKey.Name, rules.MaxLength).
+		  Check(len(apiKey.Scopes) > 0, "scopes", "at least one scope is required").
+		  Min("quota", apiKey.Quota, 0).
+		  Min("quota_window", apiKey.QuotaWindow, 0).
+		  Check(apiKey.ExpiresAt == nil || apiKey.ExpiresAt.After(time.Now()), "expires_at", "must be in the future")
+		for _, scope := range apiKey.Scopes {
+			v.Check(scope != "", "scopes", "must not be empty").
+			  OneOf("scopes", scope, security.ScopeRead, security.ScopeWrite, security.ScopeAdmin)
+		}
+		for _, prefix := range apiKey.SkuPrefixes {
+			v.Required("sku_prefixes", prefix).
+			  MaxLength("sku_prefixes", prefix, rules.MaxLength).
+			  Pattern("sku_prefixes", prefix, validator.SkuPattern)
+		}
+	}
+}
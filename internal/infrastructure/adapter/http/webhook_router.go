@@ -0,0 +1,182 @@
+package http
+
+import (
+	"net/http"
+	"strconv"
+	"go.opentelemetry.io/otel/codes"
+	"github.com/gorilla/mux"
+	"github.com/go-inventory/internal/domain/model"
+	"github.com/go-inventory/shared/erro"
+	"github.com/go-inventory/shared/validator"
+)
+
+// Helper to get the webhook (or delivery) id from the path
+func (h *HttpRouters) webhookID(req *http.Request) (int, error) {
+	vars := mux.Vars(req)
+	varID, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		return 0, erro.ErrBadRequest
+	}
+	return varID, nil
+}
+
+// webhook registration payload, a webhook is active once registered
+type webhookPayload struct {
+	Url				string		`json:"url"`
+	Secret			string		`json:"secret,omitempty"`
+	Sku				string		`json:"sku,omitempty"`
+	ProductType		string		`json:"product_type,omitempty"`
+	Threshold		int			`json:"threshold"`
+}
+
+// About register a webhook, the secret to verify the signatures is only returned here
+func (h *HttpRouters) AddWebhook(rw http.ResponseWriter, req *http.Request) error {
+	ctx, cancel, span := h.withContext(req, "AddWebhook")
+	defer cancel()
+	defer span.End()
+
+	// decode payload
+	payload := webhookPayload{}
+	defer req.Body.Close()
+
+	err := h.decodeJSON(req, &payload)
+	if err != nil {
+		span.RecordError(err)
+        span.SetStatus(codes.Error, err.Error())
+		return h.ErrorHandler(h.getTraceID(ctx), err)
+	}
+	webhook := model.Webhook{	Url: payload.Url,
+								Secret: payload.Secret,
+								Sku: payload.Sku,
+								ProductType: payload.ProductType,
+								Threshold: payload.Threshold}
+
+	// validate payload
+	err = validator.Validate(webhookRules(&webhook))
+	if err != nil {
+		return h.ErrorHandler(h.getTraceID(ctx), err)
+	}
+
+	// call service
+	res, err := h.workerService.AddWebhook(ctx, &webhook)
+	if err != nil {
+		return h.ErrorHandler(h.getTraceID(ctx), err)
+	}
+
+	return h.writeJSON(rw, http.StatusOK, res)
+}
+
+// About get a webhook
+func (h *HttpRouters) GetWebhook(rw http.ResponseWriter, req *http.Request) error {
+	ctx, cancel, span := h.withContext(req, "GetWebhook")
+	defer cancel()
+	defer span.End()
+
+	varID, err := h.webhookID(req)
+	if err != nil {
+		return h.ErrorHandler(h.getTraceID(ctx), err)
+	}
+
+	// call service
+	res, err := h.workerService.GetWebhook(ctx, &model.Webhook{ID: varID})
+	if err != nil {
+		return h.ErrorHandler(h.getTraceID(ctx), err)
+	}
+
+	return h.writeJSON(rw, http.StatusOK, res)
+}
+
+// About delete (deactivate) a webhook
+func (h *HttpRouters) DeleteWebhook(rw http.ResponseWriter, req *http.Request) error {
+	ctx, cancel, span := h.withContext(req, "DeleteWebhook")
+	defer cancel()
+	defer span.End()
+
+	varID, err := h.webhookID(req)
+	if err != nil {
+		return h.ErrorHandler(h.getTraceID(ctx), err)
+	}
+
+	webhook := model.Webhook{ID: varID}
+
+	// call service
+	err = h.workerService.DeleteWebhook(ctx, &webhook)
+	if err != nil {
+		return h.ErrorHandler(h.getTraceID(ctx), err)
+	}
+
+	return h.writeJSON(rw, http.StatusOK, webhook)
+}
+
+// About list the delivery log of a webhook
+func (h *HttpRouters) ListWebhookDelivery(rw http.ResponseWriter, req *http.Request) error {
+	ctx, cancel, span := h.withContext(req, "ListWebhookDelivery")
+	defer cancel()
+	defer span.End()
+
+	varID, err := h.webhookID(req)
+	if err != nil {
+		return h.ErrorHandler(h.getTraceID(ctx), err)
+	}
+
+	query := req.URL.Query()
+
+	// default window is 14, can be override by query parameter
+	window := 14
+	windowParam := query.Get("window")
+	if windowParam != "" {
+		parsedWindow, err := strconv.Atoi(windowParam)
+		if err != nil || parsedWindow <= 0 {
+			return h.ErrorHandler(h.getTraceID(ctx), erro.ErrBadRequest)
+		}
+		window = parsedWindow
+	}
+
+	offset := 0
+	offsetParam := query.Get("offset")
+	if offsetParam != "" {
+		parsedOffset, err := strconv.Atoi(offsetParam)
+		if err != nil || parsedOffset < 0 {
+			return h.ErrorHandler(h.getTraceID(ctx), erro.ErrBadRequest)
+		}
+		offset = parsedOffset
+	}
+
+	delivery := model.WebhookDelivery{	WebhookID: varID,
+										Status: query.Get("status")}
+
+	err = validator.Validate(func(v *validator.Validator) {
+		v.OneOf("status", delivery.Status, model.DeliveryPending, model.DeliveryDelivered, model.DeliveryDead)
+	})
+	if err != nil {
+		return h.ErrorHandler(h.getTraceID(ctx), err)
+	}
+
+	// call service
+	res, err := h.workerService.ListWebhookDelivery(ctx, window, offset, &delivery)
+	if err != nil {
+		return h.ErrorHandler(h.getTraceID(ctx), err)
+	}
+
+	return h.writeJSON(rw, http.StatusOK, res)
+}
+
+// About retry a dead delivery
+func (h *HttpRouters) RetryWebhookDelivery(rw http.ResponseWriter, req *http.Request) error {
+	ctx, cancel, span := h.withContext(req, "RetryWebhookDelivery")
+	defer cancel()
+	defer span.End()
+
+	varID, err := h.webhookID(req)
+	if err != nil {
+		return h.ErrorHandler(h.getTraceID(ctx), err)
+	}
+
+	// call service
+	res, err := h.workerService.RetryWebhookDelivery(ctx, &model.WebhookDelivery{ID: varID})
+	if err != nil {
+		return h.ErrorHandler(h.getTraceID(ctx), err)
+	}
+
+	return h.writeJSON(rw, http.StatusOK, res)
+}
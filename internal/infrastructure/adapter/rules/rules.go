@@ -0,0 +1,57 @@
+//---------------------------------------
+// Component is charge of the validation rules shared by the http and grpc adapters, so both reject the same payloads
+//---------------------------------------
+package rules
+
+import (
+	"github.com/go-inventory/internal/domain/model"
+	"github.com/go-inventory/shared/validator"
+)
+
+// length of the VARCHAR columns
+const MaxLength = 100
+
+// Rules of the product attributes, the sku is required only on creation
+func Product(product *model.Product, create bool) validator.Rule {
+	return func(v *validator.Validator) {
+		if create {
+			v.Required("sku", product.Sku).
+			  Required("type", product.Type).
+			  Required("name", product.Name).
+			  Required("status", product.Status)
+		}
+		v.MaxLength("sku", product.Sku, MaxLength).
+		  Pattern("sku", product.Sku, validator.SkuPattern).
+		  MaxLength("type", product.Type, MaxLength).
+		  MaxLength("name", product.Name, MaxLength).
+		  OneOf("status", product.Status, model.ProductInStock, model.ProductOutOfStock).
+		  Min("lead_time", product.LeadTime, 0)
+	}
+}
+
+// Rules of the sku informed in the path or in the request
+func Sku(sku string) validator.Rule {
+	return func(v *validator.Validator) {
+		v.Required("sku", sku).
+		  MaxLength("sku", sku, MaxLength).
+		  Pattern("sku", sku, validator.SkuPattern)
+	}
+}
+
+// Rules of a inventory update, at least one delta must be informed
+func Inventory(inventory *model.Inventory) validator.Rule {
+	return func(v *validator.Validator) {
+		v.Check(inventory.Available != 0 || inventory.Pending != 0 || inventory.Reserved != 0 || inventory.Sold != 0,
+				"available", "at least one of available, pending, reserved or sold must be non-zero").
+		  MaxLength("channel", inventory.Channel, MaxLength)
+	}
+}
+
+// Rules of a move between buckets
+func Transfer(transfer *model.InventoryTransfer) validator.Rule {
+	return func(v *validator.Validator) {
+		v.Min("quantity", transfer.Quantity, 1).
+		  OneOf("from", transfer.From, model.BucketAvailable, model.BucketQuarantine, model.BucketHold).
+		  OneOf("to", transfer.To, model.BucketAvailable, model.BucketQuarantine, model.BucketDamaged, model.BucketHold)
+	}
+}
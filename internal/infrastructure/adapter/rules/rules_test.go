@@ -0,0 +1,58 @@
+package rules
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/go-inventory/internal/domain/model"
+	"github.com/go-inventory/shared/validator"
+)
+
+func TestProduct(t *testing.T) {
+	long := strings.Repeat("x", MaxLength + 1)
+
+	tests := []struct {
+		name		string
+		product		model.Product
+		create		bool
+		wantErr		bool
+	}{
+		{name: "valid", product: model.Product{Sku: "sku-1", Type: "BOOK", Name: "Go", Status: model.ProductInStock}, create: true},
+		{name: "status required on creation", product: model.Product{Sku: "sku-1", Type: "BOOK", Name: "Go"}, create: true, wantErr: true},
+		{name: "status optional on update", product: model.Product{Sku: "sku-1", Name: "Go"}},
+		{name: "type too long", product: model.Product{Sku: "sku-1", Type: long, Name: "Go", Status: model.ProductInStock}, create: true, wantErr: true},
+		{name: "name too long", product: model.Product{Sku: "sku-1", Name: long}, wantErr: true},
+		{name: "invalid sku", product: model.Product{Sku: "sku 1", Type: "BOOK", Name: "Go", Status: model.ProductInStock}, create: true, wantErr: true},
+		{name: "negative lead time", product: model.Product{Sku: "sku-1", LeadTime: -1}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validator.Validate(Product(&tt.product, tt.create))
+			if (err != nil) != tt.wantErr {
+				t.Errorf("got error %v, want error %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestInventory(t *testing.T) {
+	tests := []struct {
+		name		string
+		inventory	model.Inventory
+		wantErr		bool
+	}{
+		{name: "delta informed", inventory: model.Inventory{Available: -1}},
+		{name: "without delta", inventory: model.Inventory{Channel: "web"}, wantErr: true},
+		{name: "channel too long", inventory: model.Inventory{Sold: 1, Channel: strings.Repeat("x", MaxLength + 1)}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validator.Validate(Inventory(&tt.inventory))
+			if (err != nil) != tt.wantErr {
+				t.Errorf("got error %v, want error %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -12,6 +12,7 @@ import (
 	"github.com/joho/godotenv"
 
 	"github.com/go-inventory/internal/domain/model"
+	"github.com/go-inventory/shared/validator"
 	go_core_db_pg "github.com/eliezerraj/go-core/v2/database/postgre"
 	go_core_otel_trace "github.com/eliezerraj/go-core/v2/otel/trace"
 )
@@ -26,7 +27,18 @@ type AllConfig struct {
 	Application *model.Application
 	Server      *model.Server
 	Database    *go_core_db_pg.DatabaseConfig
+	Replica     *model.ReplicaConfig
+	Migration   *model.MigrationConfig
 	OtelTrace   *go_core_otel_trace.EnvTrace
+	Inventory   *model.InventoryConfig
+	Outbox      *model.OutboxConfig
+	Webhook     *model.WebhookConfig
+	OrderConsumer *model.OrderConsumerConfig
+	Auth        *model.AuthConfig
+	Tenant      *model.TenantConfig
+	RateLimit   *model.RateLimitConfig
+	Audit       *model.AuditConfig
+	Cache       *model.CacheConfig
 }
 
 // ConfigLoader handles loading and validating all configurations
@@ -65,9 +77,20 @@ func (cl *ConfigLoader) LoadAll() (*AllConfig, error) {
 		return nil, fmt.Errorf("FAILED to load server config: %w", err)
 	}
 
-	database, err := cl.loadDatabase()
-	if err != nil {
-		return nil, fmt.Errorf("FAILED to load database config: %w", err)
+	// the memory storage needs no database
+	var database *go_core_db_pg.DatabaseConfig
+	var replica *model.ReplicaConfig
+	var migration *model.MigrationConfig
+	if app.Storage == model.StoragePostgres {
+		database, replica, err = cl.loadDatabase()
+		if err != nil {
+			return nil, fmt.Errorf("FAILED to load database config: %w", err)
+		}
+
+		migration, err = cl.loadMigration()
+		if err != nil {
+			return nil, fmt.Errorf("FAILED to load migration config: %w", err)
+		}
 	}
 
 	otel, err := cl.loadOtel()
@@ -75,11 +98,67 @@ func (cl *ConfigLoader) LoadAll() (*AllConfig, error) {
 		return nil, fmt.Errorf("FAILED to load OTEL config: %w", err)
 	}
 
+	inventory, err := cl.loadInventory()
+	if err != nil {
+		return nil, fmt.Errorf("FAILED to load inventory config: %w", err)
+	}
+
+	outbox, err := cl.loadOutbox()
+	if err != nil {
+		return nil, fmt.Errorf("FAILED to load outbox config: %w", err)
+	}
+
+	webhook, err := cl.loadWebhook()
+	if err != nil {
+		return nil, fmt.Errorf("FAILED to load webhook config: %w", err)
+	}
+
+	orderConsumer, err := cl.loadOrderConsumer()
+	if err != nil {
+		return nil, fmt.Errorf("FAILED to load order consumer config: %w", err)
+	}
+
+	auth, err := cl.loadAuth()
+	if err != nil {
+		return nil, fmt.Errorf("FAILED to load auth config: %w", err)
+	}
+
+	tenant, err := cl.loadTenant()
+	if err != nil {
+		return nil, fmt.Errorf("FAILED to load tenant config: %w", err)
+	}
+
+	rateLimit, err := cl.loadRateLimit()
+	if err != nil {
+		return nil, fmt.Errorf("FAILED to load rate limit config: %w", err)
+	}
+
+	audit, err := cl.loadAudit()
+	if err != nil {
+		return nil, fmt.Errorf("FAILED to load audit config: %w", err)
+	}
+
+	cache, err := cl.loadCache()
+	if err != nil {
+		return nil, fmt.Errorf("FAILED to load cache config: %w", err)
+	}
+
 	return &AllConfig{
 		Application: app,
 		Server:      server,
 		Database:    database,
+		Replica:     replica,
+		Migration:   migration,
 		OtelTrace:   otel,
+		Inventory:   inventory,
+		Outbox:      outbox,
+		Webhook:     webhook,
+		OrderConsumer: orderConsumer,
+		Auth:        auth,
+		Tenant:      tenant,
+		RateLimit:   rateLimit,
+		Audit:       audit,
+		Cache:       cache,
 	}, nil
 }
 
@@ -98,6 +177,11 @@ func (cl *ConfigLoader) loadApplication() (*model.Application, error) {
 		OtelTraces:    getEnvBool("OTEL_TRACES", false),
 		OtelLogs:      getEnvBool("OTEL_LOGS", false),
 		OtelMetrics:   getEnvBool("OTEL_METRICS", false),
+		Storage:       getEnvString("STORAGE", model.StoragePostgres),
+	}
+
+	if app.Storage != model.StoragePostgres && app.Storage != model.StorageMemory {
+		return nil, fmt.Errorf("invalid STORAGE: %s (postgres or memory)", app.Storage)
 	}
 
 	addrs, err := net.InterfaceAddrs()
@@ -151,12 +235,45 @@ func (cl *ConfigLoader) loadServer() (*model.Server, error) {
 		return nil, fmt.Errorf("invalid CTX_TIMEOUT: %w", err)
 	}
 
+	// zero disables the gRPC server
+	grpcPort, err := getEnvInt("GRPC_PORT", 0)
+	if err != nil {
+		return nil, fmt.Errorf("invalid GRPC_PORT: %w", err)
+	}
+	if grpcPort < 0 {
+		return nil, fmt.Errorf("invalid GRPC_PORT: must not be negative")
+	}
+
+	// limits of a graphql query, zero disables the limit
+	graphqlMaxDepth, err := getEnvInt("GRAPHQL_MAX_DEPTH", 6)
+	if err != nil {
+		return nil, fmt.Errorf("invalid GRAPHQL_MAX_DEPTH: %w", err)
+	}
+
+	graphqlMaxComplexity, err := getEnvInt("GRAPHQL_MAX_COMPLEXITY", 500)
+	if err != nil {
+		return nil, fmt.Errorf("invalid GRAPHQL_MAX_COMPLEXITY: %w", err)
+	}
+
+	// events kept to replay a stream reconnected with Last-Event-ID
+	streamBufferSize, err := getEnvInt("STREAM_BUFFER_SIZE", 1024)
+	if err != nil {
+		return nil, fmt.Errorf("invalid STREAM_BUFFER_SIZE: %w", err)
+	}
+	if streamBufferSize <= 0 {
+		return nil, fmt.Errorf("invalid STREAM_BUFFER_SIZE: must be greater than zero")
+	}
+
 	server := &model.Server{
 		Port:         port,
 		ReadTimeout:  readTimeout,
 		WriteTimeout: writeTimeout,
 		IdleTimeout:  idleTimeout,
 		CtxTimeout:   ctxTimeout,
+		GrpcPort:     grpcPort,
+		GraphqlMaxDepth:      graphqlMaxDepth,
+		GraphqlMaxComplexity: graphqlMaxComplexity,
+		StreamBufferSize:     streamBufferSize,
 	}
 
 	cl.logger.Info().
@@ -166,19 +283,19 @@ func (cl *ConfigLoader) loadServer() (*model.Server, error) {
 	return server, nil
 }
 
-// loadDatabase loads database configuration
-func (cl *ConfigLoader) loadDatabase() (*go_core_db_pg.DatabaseConfig, error) {
+// loadDatabase loads database configuration, with the read replica when DB_REPLICA_HOST is informed
+func (cl *ConfigLoader) loadDatabase() (*go_core_db_pg.DatabaseConfig, *model.ReplicaConfig, error) {
 	cl.logger.Debug().Msg("Loading database configuration")
 
 	maxConn, err := getEnvInt("DB_MAX_CONNECTION", 10)
 	if err != nil {
-		return nil, fmt.Errorf("invalid DB_MAX_CONNECTION: %w", err)
+		return nil, nil, fmt.Errorf("invalid DB_MAX_CONNECTION: %w", err)
 	}
 
 	// Get credentials with fallbacks
 	user, pass, err := getDatabaseCredentials(cl.logger)
 	if err != nil {
-		return nil, fmt.Errorf("FAILED to load database credentials: %w", err)
+		return nil, nil, fmt.Errorf("FAILED to load database credentials: %w", err)
 	}
 
 	dbCfg := &go_core_db_pg.DatabaseConfig{
@@ -194,7 +311,85 @@ func (cl *ConfigLoader) loadDatabase() (*go_core_db_pg.DatabaseConfig, error) {
 		Interface("dbCfg", dbCfg).
 		Msg("Database configuration loaded SUCCESSFULLY")
 
-	return dbCfg, nil
+	replicaHost := getEnvString("DB_REPLICA_HOST", "")
+	if replicaHost == "" {
+		return dbCfg, nil, nil
+	}
+
+	replicaMaxConn, err := getEnvInt("DB_REPLICA_MAX_CONNECTION", maxConn)
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid DB_REPLICA_MAX_CONNECTION: %w", err)
+	}
+
+	maxLag, err := getEnvInt("DB_REPLICA_MAX_LAG_MS", 1000)
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid DB_REPLICA_MAX_LAG_MS: %w", err)
+	}
+	if maxLag <= 0 {
+		return nil, nil, fmt.Errorf("invalid DB_REPLICA_MAX_LAG_MS: must be greater than zero")
+	}
+
+	checkInterval, err := getEnvInt("DB_REPLICA_CHECK_INTERVAL_MS", 1000)
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid DB_REPLICA_CHECK_INTERVAL_MS: %w", err)
+	}
+	if checkInterval <= 0 {
+		return nil, nil, fmt.Errorf("invalid DB_REPLICA_CHECK_INTERVAL_MS: must be greater than zero")
+	}
+
+	readYourWrites, err := getEnvInt("DB_READ_YOUR_WRITES_MS", 5000)
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid DB_READ_YOUR_WRITES_MS: %w", err)
+	}
+	if readYourWrites < 0 {
+		return nil, nil, fmt.Errorf("invalid DB_READ_YOUR_WRITES_MS: must not be negative")
+	}
+
+	// the replica has the database and the credentials of the primary
+	replica := &model.ReplicaConfig{
+		Database: &go_core_db_pg.DatabaseConfig{
+			Host:            replicaHost,
+			Port:            getEnvString("DB_REPLICA_PORT", dbCfg.Port),
+			DatabaseName:    dbCfg.DatabaseName,
+			User:            dbCfg.User,
+			Password:        dbCfg.Password,
+			DBMaxConnection: replicaMaxConn,
+		},
+		MaxLag:         maxLag,
+		CheckInterval:  checkInterval,
+		ReadYourWrites: readYourWrites,
+	}
+
+	cl.logger.Info().
+		Str("host", replica.Database.Host).
+		Int("max_lag_ms", replica.MaxLag).
+		Msg("Database replica configuration loaded SUCCESSFULLY")
+
+	return dbCfg, replica, nil
+}
+
+// loadMigration loads the schema migration configuration
+func (cl *ConfigLoader) loadMigration() (*model.MigrationConfig, error) {
+	cl.logger.Debug().Msg("Loading migration configuration")
+
+	lockTimeout, err := getEnvInt("DB_MIGRATE_LOCK_TIMEOUT_MS", 60000)
+	if err != nil {
+		return nil, fmt.Errorf("invalid DB_MIGRATE_LOCK_TIMEOUT_MS: %w", err)
+	}
+	if lockTimeout <= 0 {
+		return nil, fmt.Errorf("invalid DB_MIGRATE_LOCK_TIMEOUT_MS: must be greater than zero")
+	}
+
+	migration := &model.MigrationConfig{
+		AutoMigrate: getEnvBool("DB_AUTO_MIGRATE", false),
+		LockTimeout: lockTimeout,
+	}
+
+	cl.logger.Info().
+		Interface("migration", migration).
+		Msg("Migration configuration loaded SUCCESSFULLY")
+
+	return migration, nil
 }
 
 // loadOtel loads OTEL configuration
@@ -225,6 +420,439 @@ func (cl *ConfigLoader) loadOtel() (*go_core_otel_trace.EnvTrace, error) {
 	return otel, nil
 }
 
+// loadInventory loads inventory business rules configuration
+func (cl *ConfigLoader) loadInventory() (*model.InventoryConfig, error) {
+	cl.logger.Debug().Msg("Loading inventory configuration")
+
+	threshold, err := getEnvInt("CYCLE_COUNT_APPROVAL_THRESHOLD", 0)
+	if err != nil {
+		return nil, fmt.Errorf("invalid CYCLE_COUNT_APPROVAL_THRESHOLD: %w", err)
+	}
+	if threshold < 0 {
+		return nil, fmt.Errorf("invalid CYCLE_COUNT_APPROVAL_THRESHOLD: must not be negative")
+	}
+
+	inventory := &model.InventoryConfig{
+		CycleCountApprovalThreshold: threshold,
+	}
+
+	cl.logger.Info().
+		Interface("inventory", inventory).
+		Msg("Inventory configuration loaded SUCCESSFULLY")
+
+	return inventory, nil
+}
+
+// loadOutbox loads the publisher of the outbox relay
+func (cl *ConfigLoader) loadOutbox() (*model.OutboxConfig, error) {
+	cl.logger.Debug().Msg("Loading outbox configuration")
+
+	pollInterval, err := getEnvInt("OUTBOX_POLL_INTERVAL_MS", 1000)
+	if err != nil {
+		return nil, fmt.Errorf("invalid OUTBOX_POLL_INTERVAL_MS: %w", err)
+	}
+	if pollInterval <= 0 {
+		return nil, fmt.Errorf("invalid OUTBOX_POLL_INTERVAL_MS: must be greater than zero")
+	}
+
+	batchSize, err := getEnvInt("OUTBOX_BATCH_SIZE", 100)
+	if err != nil {
+		return nil, fmt.Errorf("invalid OUTBOX_BATCH_SIZE: %w", err)
+	}
+	if batchSize <= 0 {
+		return nil, fmt.Errorf("invalid OUTBOX_BATCH_SIZE: must be greater than zero")
+	}
+
+	// zero retries forever
+	maxAttempts, err := getEnvInt("OUTBOX_MAX_ATTEMPTS", 10)
+	if err != nil {
+		return nil, fmt.Errorf("invalid OUTBOX_MAX_ATTEMPTS: %w", err)
+	}
+	if maxAttempts < 0 {
+		return nil, fmt.Errorf("invalid OUTBOX_MAX_ATTEMPTS: must not be negative")
+	}
+
+	outbox := &model.OutboxConfig{
+		Publisher:    strings.ToLower(getEnvString("OUTBOX_PUBLISHER", model.PublisherStdout)),
+		HttpURL:      getEnvString("OUTBOX_HTTP_URL", ""),
+		KafkaTopic:   getEnvString("OUTBOX_KAFKA_TOPIC", "inventory.events"),
+		NatsURL:      getEnvString("OUTBOX_NATS_URL", "nats://127.0.0.1:4222"),
+		NatsSubject:  getEnvString("OUTBOX_NATS_SUBJECT", "inventory.events"),
+		PollInterval: pollInterval,
+		BatchSize:    batchSize,
+		MaxAttempts:  maxAttempts,
+	}
+	for _, broker := range strings.Split(getEnvString("OUTBOX_KAFKA_BROKERS", "127.0.0.1:9092"), ",") {
+		if broker = strings.TrimSpace(broker); broker != "" {
+			outbox.KafkaBrokers = append(outbox.KafkaBrokers, broker)
+		}
+	}
+
+	switch outbox.Publisher {
+	case model.PublisherStdout, model.PublisherKafka, model.PublisherNats, model.PublisherNone:
+	case model.PublisherHttp:
+		if outbox.HttpURL == "" {
+			return nil, fmt.Errorf("invalid OUTBOX_HTTP_URL: required by the http publisher")
+		}
+	default:
+		return nil, fmt.Errorf("invalid OUTBOX_PUBLISHER: %s", outbox.Publisher)
+	}
+
+	cl.logger.Info().
+		Interface("outbox", outbox).
+		Msg("Outbox configuration loaded SUCCESSFULLY")
+
+	return outbox, nil
+}
+
+// loadWebhook loads the dispatcher of the webhook alerts
+func (cl *ConfigLoader) loadWebhook() (*model.WebhookConfig, error) {
+	cl.logger.Debug().Msg("Loading webhook configuration")
+
+	pollInterval, err := getEnvInt("WEBHOOK_POLL_INTERVAL_MS", 1000)
+	if err != nil {
+		return nil, fmt.Errorf("invalid WEBHOOK_POLL_INTERVAL_MS: %w", err)
+	}
+	if pollInterval <= 0 {
+		return nil, fmt.Errorf("invalid WEBHOOK_POLL_INTERVAL_MS: must be greater than zero")
+	}
+
+	batchSize, err := getEnvInt("WEBHOOK_BATCH_SIZE", 50)
+	if err != nil {
+		return nil, fmt.Errorf("invalid WEBHOOK_BATCH_SIZE: %w", err)
+	}
+	if batchSize <= 0 {
+		return nil, fmt.Errorf("invalid WEBHOOK_BATCH_SIZE: must be greater than zero")
+	}
+
+	maxAttempts, err := getEnvInt("WEBHOOK_MAX_ATTEMPTS", 8)
+	if err != nil {
+		return nil, fmt.Errorf("invalid WEBHOOK_MAX_ATTEMPTS: %w", err)
+	}
+	if maxAttempts <= 0 {
+		return nil, fmt.Errorf("invalid WEBHOOK_MAX_ATTEMPTS: must be greater than zero")
+	}
+
+	timeout, err := getEnvInt("WEBHOOK_TIMEOUT", 5)
+	if err != nil {
+		return nil, fmt.Errorf("invalid WEBHOOK_TIMEOUT: %w", err)
+	}
+	if timeout <= 0 {
+		return nil, fmt.Errorf("invalid WEBHOOK_TIMEOUT: must be greater than zero")
+	}
+
+	webhook := &model.WebhookConfig{
+		PollInterval: pollInterval,
+		BatchSize:    batchSize,
+		MaxAttempts:  maxAttempts,
+		Timeout:      timeout,
+	}
+
+	cl.logger.Info().
+		Interface("webhook", webhook).
+		Msg("Webhook configuration loaded SUCCESSFULLY")
+
+	return webhook, nil
+}
+
+// loadOrderConsumer loads the broker of the order events, the consumer is optional
+func (cl *ConfigLoader) loadOrderConsumer() (*model.OrderConsumerConfig, error) {
+	cl.logger.Debug().Msg("Loading order consumer configuration")
+
+	orderConsumer := &model.OrderConsumerConfig{
+		Broker:      strings.ToLower(getEnvString("ORDER_CONSUMER", model.ConsumerNone)),
+		KafkaTopic:  getEnvString("ORDER_KAFKA_TOPIC", "order.events"),
+		KafkaGroup:  getEnvString("ORDER_KAFKA_GROUP", "go-inventory"),
+		NatsURL:     getEnvString("ORDER_NATS_URL", "nats://127.0.0.1:4222"),
+		NatsSubject: getEnvString("ORDER_NATS_SUBJECT", "order.events"),
+		NatsDurable: getEnvString("ORDER_NATS_DURABLE", "go-inventory"),
+		FilePath:    getEnvString("ORDER_FILE_PATH", ""),
+	}
+	for _, broker := range strings.Split(getEnvString("ORDER_KAFKA_BROKERS", "127.0.0.1:9092"), ",") {
+		if broker = strings.TrimSpace(broker); broker != "" {
+			orderConsumer.KafkaBrokers = append(orderConsumer.KafkaBrokers, broker)
+		}
+	}
+
+	switch orderConsumer.Broker {
+	case model.ConsumerKafka, model.ConsumerNats, model.ConsumerNone:
+	case model.ConsumerFile:
+		if orderConsumer.FilePath == "" {
+			return nil, fmt.Errorf("invalid ORDER_FILE_PATH: required by the file broker")
+		}
+	default:
+		return nil, fmt.Errorf("invalid ORDER_CONSUMER: %s", orderConsumer.Broker)
+	}
+
+	cl.logger.Info().
+		Interface("order_consumer", orderConsumer).
+		Msg("Order consumer configuration loaded SUCCESSFULLY")
+
+	return orderConsumer, nil
+}
+
+// loadAuth loads the validation of the tokens and of the api keys, at least one key is required when the tokens are enabled
+func (cl *ConfigLoader) loadAuth() (*model.AuthConfig, error) {
+	cl.logger.Debug().Msg("Loading auth configuration")
+
+	leeway, err := getEnvInt("JWT_LEEWAY", 30)
+	if err != nil {
+		return nil, fmt.Errorf("invalid JWT_LEEWAY: %w", err)
+	}
+	if leeway < 0 {
+		return nil, fmt.Errorf("invalid JWT_LEEWAY: must not be negative")
+	}
+
+	apiKeyQuotaWindow, err := getEnvInt("API_KEY_QUOTA_WINDOW", 60)
+	if err != nil {
+		return nil, fmt.Errorf("invalid API_KEY_QUOTA_WINDOW: %w", err)
+	}
+	if apiKeyQuotaWindow <= 0 {
+		return nil, fmt.Errorf("invalid API_KEY_QUOTA_WINDOW: must be positive")
+	}
+
+	auth := &model.AuthConfig{
+		Enabled:       getEnvBool("JWT_ENABLED", false),
+		HmacSecret:    getEnvString("JWT_HMAC_SECRET", ""),
+		PublicKeyFile: getEnvString("JWT_PUBLIC_KEY_FILE", ""),
+		JwksFile:      getEnvString("JWT_JWKS_FILE", ""),
+		Issuer:        getEnvString("JWT_ISSUER", ""),
+		Audience:      getEnvString("JWT_AUDIENCE", ""),
+		Leeway:        leeway,
+		ScopesRead:    getEnvList("JWT_SCOPES_READ", "inventory:read,tool:get_product"),
+		ScopesWrite:   getEnvList("JWT_SCOPES_WRITE", "inventory:write"),
+		ScopesAdmin:   getEnvList("JWT_SCOPES_ADMIN", "admin"),
+		ApiKeyEnabled: getEnvBool("API_KEY_ENABLED", false),
+		ApiKeyHeader:  getEnvString("API_KEY_HEADER", "X-API-Key"),
+		ApiKeyQuotaWindow: apiKeyQuotaWindow,
+	}
+
+	if auth.Enabled && auth.HmacSecret == "" && auth.PublicKeyFile == "" && auth.JwksFile == "" {
+		return nil, fmt.Errorf("invalid JWT_ENABLED: one of JWT_HMAC_SECRET, JWT_PUBLIC_KEY_FILE or JWT_JWKS_FILE is required")
+	}
+	if !auth.Enabled && !auth.ApiKeyEnabled {
+		cl.logger.Warn().Msg("JWT and api key authentication disabled, any caller can change the stock")
+	}
+
+	cl.logger.Info().
+		Interface("auth", auth).
+		Msg("Auth configuration loaded SUCCESSFULLY")
+
+	return auth, nil
+}
+
+// loadTenant loads the resolution of the tenant of the requests without a tenant in their credential
+func (cl *ConfigLoader) loadTenant() (*model.TenantConfig, error) {
+	cl.logger.Debug().Msg("Loading tenant configuration")
+
+	tenant := &model.TenantConfig{
+		Header:   getEnvString("TENANT_HEADER", "X-Tenant-ID"),
+		Default:  strings.TrimSpace(getEnvString("TENANT_DEFAULT", "default")),
+		Required: getEnvBool("TENANT_REQUIRED", false),
+	}
+
+	if len(tenant.Default) > 63 || !validator.TenantPattern.MatchString(tenant.Default) {
+		return nil, fmt.Errorf("invalid TENANT_DEFAULT: must match %s with at most 63 characters", validator.TenantPattern.String())
+	}
+
+	cl.logger.Info().
+		Interface("tenant", tenant).
+		Msg("Tenant configuration loaded SUCCESSFULLY")
+
+	return tenant, nil
+}
+
+// loadAudit loads the sealer of the audit log
+func (cl *ConfigLoader) loadAudit() (*model.AuditConfig, error) {
+	cl.logger.Debug().Msg("Loading audit configuration")
+
+	sealInterval, err := getEnvInt("AUDIT_SEAL_INTERVAL_MS", 1000)
+	if err != nil {
+		return nil, fmt.Errorf("invalid AUDIT_SEAL_INTERVAL_MS: %w", err)
+	}
+	if sealInterval <= 0 {
+		return nil, fmt.Errorf("invalid AUDIT_SEAL_INTERVAL_MS: must be greater than zero")
+	}
+
+	batchSize, err := getEnvInt("AUDIT_BATCH_SIZE", 500)
+	if err != nil {
+		return nil, fmt.Errorf("invalid AUDIT_BATCH_SIZE: %w", err)
+	}
+	if batchSize <= 0 {
+		return nil, fmt.Errorf("invalid AUDIT_BATCH_SIZE: must be greater than zero")
+	}
+
+	audit := &model.AuditConfig{
+		SealInterval: sealInterval,
+		BatchSize:    batchSize,
+	}
+
+	cl.logger.Info().
+		Interface("audit", audit).
+		Msg("Audit configuration loaded SUCCESSFULLY")
+
+	return audit, nil
+}
+
+// loadCache loads the cache of the reads, disabled by default
+func (cl *ConfigLoader) loadCache() (*model.CacheConfig, error) {
+	cl.logger.Debug().Msg("Loading cache configuration")
+
+	ttl, err := getEnvInt("CACHE_TTL_MS", 2000)
+	if err != nil {
+		return nil, fmt.Errorf("invalid CACHE_TTL_MS: %w", err)
+	}
+	if ttl <= 0 {
+		return nil, fmt.Errorf("invalid CACHE_TTL_MS: must be greater than zero")
+	}
+
+	size, err := getEnvInt("CACHE_SIZE", 10000)
+	if err != nil {
+		return nil, fmt.Errorf("invalid CACHE_SIZE: %w", err)
+	}
+	if size <= 0 {
+		return nil, fmt.Errorf("invalid CACHE_SIZE: must be greater than zero")
+	}
+
+	redisDB, err := getEnvInt("CACHE_REDIS_DB", 0)
+	if err != nil {
+		return nil, fmt.Errorf("invalid CACHE_REDIS_DB: %w", err)
+	}
+	if redisDB < 0 {
+		return nil, fmt.Errorf("invalid CACHE_REDIS_DB: must not be negative")
+	}
+
+	redisPoolSize, err := getEnvInt("CACHE_REDIS_POOL_SIZE", 10)
+	if err != nil {
+		return nil, fmt.Errorf("invalid CACHE_REDIS_POOL_SIZE: %w", err)
+	}
+	if redisPoolSize <= 0 {
+		return nil, fmt.Errorf("invalid CACHE_REDIS_POOL_SIZE: must be greater than zero")
+	}
+
+	redisTimeout, err := getEnvInt("CACHE_REDIS_TIMEOUT_MS", 200)
+	if err != nil {
+		return nil, fmt.Errorf("invalid CACHE_REDIS_TIMEOUT_MS: %w", err)
+	}
+	if redisTimeout <= 0 {
+		return nil, fmt.Errorf("invalid CACHE_REDIS_TIMEOUT_MS: must be greater than zero")
+	}
+
+	cache := &model.CacheConfig{
+		Backend:       strings.ToLower(getEnvString("CACHE_BACKEND", model.CacheNone)),
+		TTL:           ttl,
+		Size:          size,
+		RedisAddr:     getEnvString("CACHE_REDIS_ADDR", "127.0.0.1:6379"),
+		RedisPassword: getEnvString("CACHE_REDIS_PASSWORD", ""),
+		RedisDB:       redisDB,
+		RedisPrefix:   getEnvString("CACHE_REDIS_PREFIX", "go-inventory:"),
+		RedisPoolSize: redisPoolSize,
+		RedisTimeout:  redisTimeout,
+	}
+
+	switch cache.Backend {
+	case model.CacheMemory, model.CacheRedis, model.CacheNone:
+	default:
+		return nil, fmt.Errorf("invalid CACHE_BACKEND: %s", cache.Backend)
+	}
+
+	cl.logger.Info().
+		Interface("cache", cache).
+		Msg("Cache configuration loaded SUCCESSFULLY")
+
+	return cache, nil
+}
+
+// loadRateLimit loads the rate limit of the clients and the load shedding of the pod, both are optional
+func (cl *ConfigLoader) loadRateLimit() (*model.RateLimitConfig, error) {
+	cl.logger.Debug().Msg("Loading rate limit configuration")
+
+	// zero disables the rate limit
+	rate, err := getEnvFloat("RATE_LIMIT_RPS", 0)
+	if err != nil {
+		return nil, fmt.Errorf("invalid RATE_LIMIT_RPS: %w", err)
+	}
+	if rate < 0 {
+		return nil, fmt.Errorf("invalid RATE_LIMIT_RPS: must not be negative")
+	}
+
+	burst, err := getEnvInt("RATE_LIMIT_BURST", 20)
+	if err != nil {
+		return nil, fmt.Errorf("invalid RATE_LIMIT_BURST: %w", err)
+	}
+	if burst <= 0 {
+		return nil, fmt.Errorf("invalid RATE_LIMIT_BURST: must be greater than zero")
+	}
+
+	// the bucket of the ip is taken before the authentication, by default as the one of the subject
+	ipRate, err := getEnvFloat("RATE_LIMIT_IP_RPS", rate)
+	if err != nil {
+		return nil, fmt.Errorf("invalid RATE_LIMIT_IP_RPS: %w", err)
+	}
+	if ipRate < 0 {
+		return nil, fmt.Errorf("invalid RATE_LIMIT_IP_RPS: must not be negative")
+	}
+
+	ipBurst, err := getEnvInt("RATE_LIMIT_IP_BURST", burst)
+	if err != nil {
+		return nil, fmt.Errorf("invalid RATE_LIMIT_IP_BURST: %w", err)
+	}
+	if ipBurst <= 0 {
+		return nil, fmt.Errorf("invalid RATE_LIMIT_IP_BURST: must be greater than zero")
+	}
+
+	minConcurrency, err := getEnvInt("SHED_MIN_CONCURRENCY", 10)
+	if err != nil {
+		return nil, fmt.Errorf("invalid SHED_MIN_CONCURRENCY: %w", err)
+	}
+	if minConcurrency <= 0 {
+		return nil, fmt.Errorf("invalid SHED_MIN_CONCURRENCY: must be greater than zero")
+	}
+
+	maxConcurrency, err := getEnvInt("SHED_MAX_CONCURRENCY", 200)
+	if err != nil {
+		return nil, fmt.Errorf("invalid SHED_MAX_CONCURRENCY: %w", err)
+	}
+	if maxConcurrency < minConcurrency {
+		return nil, fmt.Errorf("invalid SHED_MAX_CONCURRENCY: must not be lower than SHED_MIN_CONCURRENCY")
+	}
+
+	acquireWaitTarget, err := getEnvInt("SHED_ACQUIRE_WAIT_MS", 50)
+	if err != nil {
+		return nil, fmt.Errorf("invalid SHED_ACQUIRE_WAIT_MS: %w", err)
+	}
+	if acquireWaitTarget <= 0 {
+		return nil, fmt.Errorf("invalid SHED_ACQUIRE_WAIT_MS: must be greater than zero")
+	}
+
+	shedInterval, err := getEnvInt("SHED_INTERVAL_MS", 1000)
+	if err != nil {
+		return nil, fmt.Errorf("invalid SHED_INTERVAL_MS: %w", err)
+	}
+	if shedInterval <= 0 {
+		return nil, fmt.Errorf("invalid SHED_INTERVAL_MS: must be greater than zero")
+	}
+
+	rateLimit := &model.RateLimitConfig{
+		Rate:              rate,
+		Burst:             burst,
+		IpRate:            ipRate,
+		IpBurst:           ipBurst,
+		TrustForwarded:    getEnvBool("RATE_LIMIT_TRUST_FORWARDED", false),
+		ShedEnabled:       getEnvBool("SHED_ENABLED", false),
+		MinConcurrency:    minConcurrency,
+		MaxConcurrency:    maxConcurrency,
+		AcquireWaitTarget: acquireWaitTarget,
+		ShedInterval:      shedInterval,
+	}
+
+	cl.logger.Info().
+		Interface("rate_limit", rateLimit).
+		Msg("Rate limit configuration loaded SUCCESSFULLY")
+
+	return rateLimit, nil
+}
+
 // Helper functions
 // getEnvString retrieves environment variable as string with default
 func getEnvString(key, defaultVal string) string {
@@ -234,6 +862,17 @@ func getEnvString(key, defaultVal string) string {
 	return defaultVal
 }
 
+// getEnvList retrieves environment variable as a comma separated list with default
+func getEnvList(key, defaultVal string) []string {
+	list := []string{}
+	for _, val := range strings.Split(getEnvString(key, defaultVal), ",") {
+		if val = strings.TrimSpace(val); val != "" {
+			list = append(list, val)
+		}
+	}
+	return list
+}
+
 // getEnvBool retrieves environment variable as boolean with default
 func getEnvBool(key string, defaultVal bool) bool {
 	val := os.Getenv(key)
@@ -258,6 +897,21 @@ func getEnvInt(key string, defaultVal int) (int, error) {
 	return intVal, nil
 }
 
+// getEnvFloat retrieves environment variable as float with error handling
+func getEnvFloat(key string, defaultVal float64) (float64, error) {
+	val := os.Getenv(key)
+	if val == "" {
+		return defaultVal, nil
+	}
+
+	floatVal, err := strconv.ParseFloat(val, 64)
+	if err != nil {
+		return 0, fmt.Errorf("FAILED to parse %s as float: %w", key, err)
+	}
+
+	return floatVal, nil
+}
+
 // getDatabaseCredentials retrieves database credentials with fallbacks
 func getDatabaseCredentials(logger *zerolog.Logger) (user, pass string, err error) {
 	// Try reading from Kubernetes secret volume
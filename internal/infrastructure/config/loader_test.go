@@ -0,0 +1,417 @@
+package config
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/rs/zerolog"
+
+	"github.com/go-inventory/internal/domain/model"
+)
+
+// loader without the .env, the tests only see the variables they set
+func newTestLoader() *ConfigLoader {
+	logger := zerolog.Nop()
+	return &ConfigLoader{logger: &logger}
+}
+
+func TestGetEnvInt(t *testing.T) {
+	tests := []struct {
+		name		string
+		value		string
+		want		int
+		wantErr		bool
+	}{
+		{name: "unset uses the default", value: "", want: 42},
+		{name: "integer", value: "7", want: 7},
+		{name: "negative", value: "-3", want: -3},
+		{name: "not a integer", value: "seven", wantErr: true},
+		{name: "float", value: "1.5", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("TEST_ENV_INT", tt.value)
+
+			got, err := getEnvInt("TEST_ENV_INT", 42)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("expected a error, got %d", got)
+				}
+				if !strings.Contains(err.Error(), "TEST_ENV_INT") {
+					t.Errorf("error must name the variable, got %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("got %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGetEnvFloat(t *testing.T) {
+	tests := []struct {
+		name		string
+		value		string
+		want		float64
+		wantErr		bool
+	}{
+		{name: "unset uses the default", value: "", want: 0.5},
+		{name: "float", value: "2.25", want: 2.25},
+		{name: "integer", value: "3", want: 3},
+		{name: "not a float", value: "fast", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("TEST_ENV_FLOAT", tt.value)
+
+			got, err := getEnvFloat("TEST_ENV_FLOAT", 0.5)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("got error %v, want error %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && got != tt.want {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGetEnvBool(t *testing.T) {
+	tests := []struct {
+		name		string
+		value		string
+		defaultVal	bool
+		want		bool
+	}{
+		{name: "unset uses the default true", value: "", defaultVal: true, want: true},
+		{name: "unset uses the default false", value: "", defaultVal: false, want: false},
+		{name: "true", value: "true", want: true},
+		{name: "upper case", value: "TRUE", want: true},
+		{name: "anything else is false", value: "yes", defaultVal: true, want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("TEST_ENV_BOOL", tt.value)
+
+			if got := getEnvBool("TEST_ENV_BOOL", tt.defaultVal); got != tt.want {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGetEnvList(t *testing.T) {
+	tests := []struct {
+		name		string
+		value		string
+		want		[]string
+	}{
+		{name: "unset uses the default", value: "", want: []string{"a", "b"}},
+		{name: "single", value: "admin", want: []string{"admin"}},
+		{name: "trims and drops the empty items", value: " x , ,y,", want: []string{"x", "y"}},
+		{name: "only separators", value: ",,", want: []string{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("TEST_ENV_LIST", tt.value)
+
+			if got := getEnvList("TEST_ENV_LIST", "a,b"); !slices.Equal(got, tt.want) {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadApplicationStorage(t *testing.T) {
+	tests := []struct {
+		name		string
+		value		string
+		want		string
+		wantErr		bool
+	}{
+		{name: "postgres by default", value: "", want: model.StoragePostgres},
+		{name: "postgres", value: "postgres", want: model.StoragePostgres},
+		{name: "memory", value: "memory", want: model.StorageMemory},
+		{name: "unknown", value: "sqlite", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("STORAGE", tt.value)
+
+			app, err := newTestLoader().loadApplication()
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("expected a error, got storage %q", app.Storage)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if app.Storage != tt.want {
+				t.Errorf("got storage %q, want %q", app.Storage, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadServer(t *testing.T) {
+	tests := []struct {
+		name		string
+		env			map[string]string
+		wantErr		string
+	}{
+		{name: "defaults"},
+		{name: "invalid port", env: map[string]string{"PORT": "http"}, wantErr: "PORT"},
+		{name: "negative grpc port", env: map[string]string{"GRPC_PORT": "-1"}, wantErr: "GRPC_PORT"},
+		{name: "zero stream buffer", env: map[string]string{"STREAM_BUFFER_SIZE": "0"}, wantErr: "STREAM_BUFFER_SIZE"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for key, value := range tt.env {
+				t.Setenv(key, value)
+			}
+
+			server, err := newTestLoader().loadServer()
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("got error %v, want a error about %s", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if server.Port != 8080 || server.CtxTimeout != 5 || server.StreamBufferSize != 1024 {
+				t.Errorf("unexpected defaults: %+v", server)
+			}
+		})
+	}
+}
+
+func TestLoadDatabase(t *testing.T) {
+	tests := []struct {
+		name		string
+		env			map[string]string
+		wantReplica	bool
+		wantErr		string
+	}{
+		{name: "without user", env: map[string]string{"DB_USER": "", "DB_PASS": "secret"}, wantErr: "credentials"},
+		{name: "without replica", env: map[string]string{"DB_USER": "app", "DB_PASS": "secret"}},
+		{name: "with replica", env: map[string]string{"DB_USER": "app", "DB_PASS": "secret", "DB_REPLICA_HOST": "replica"}, wantReplica: true},
+		{name: "replica with invalid lag", env: map[string]string{"DB_USER": "app", "DB_PASS": "secret", "DB_REPLICA_HOST": "replica", "DB_REPLICA_MAX_LAG_MS": "0"}, wantErr: "DB_REPLICA_MAX_LAG_MS"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for key, value := range tt.env {
+				t.Setenv(key, value)
+			}
+
+			database, replica, err := newTestLoader().loadDatabase()
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("got error %v, want a error about %s", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if database.User != "app" || database.Password != "secret" {
+				t.Errorf("unexpected credentials: %s", database.User)
+			}
+			if (replica != nil) != tt.wantReplica {
+				t.Fatalf("got replica %v, want replica %v", replica != nil, tt.wantReplica)
+			}
+			if replica != nil && (replica.Database.Port != database.Port || replica.Database.DatabaseName != database.DatabaseName) {
+				t.Errorf("replica must default to the port and the database of the primary, got %+v", replica.Database)
+			}
+		})
+	}
+}
+
+func TestLoadOutboxPublisher(t *testing.T) {
+	tests := []struct {
+		name		string
+		env			map[string]string
+		want		string
+		wantErr		bool
+	}{
+		{name: "stdout by default", want: model.PublisherStdout},
+		{name: "case insensitive", env: map[string]string{"OUTBOX_PUBLISHER": "NATS"}, want: model.PublisherNats},
+		{name: "http without url", env: map[string]string{"OUTBOX_PUBLISHER": "http"}, wantErr: true},
+		{name: "http with url", env: map[string]string{"OUTBOX_PUBLISHER": "http", "OUTBOX_HTTP_URL": "http://localhost"}, want: model.PublisherHttp},
+		{name: "unknown", env: map[string]string{"OUTBOX_PUBLISHER": "smtp"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for key, value := range tt.env {
+				t.Setenv(key, value)
+			}
+
+			outbox, err := newTestLoader().loadOutbox()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("got error %v, want error %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && outbox.Publisher != tt.want {
+				t.Errorf("got publisher %q, want %q", outbox.Publisher, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadAuth(t *testing.T) {
+	tests := []struct {
+		name		string
+		env			map[string]string
+		wantErr		bool
+	}{
+		{name: "disabled"},
+		{name: "enabled without key", env: map[string]string{"JWT_ENABLED": "true"}, wantErr: true},
+		{name: "enabled with secret", env: map[string]string{"JWT_ENABLED": "true", "JWT_HMAC_SECRET": "s3cret"}},
+		{name: "zero quota window", env: map[string]string{"API_KEY_QUOTA_WINDOW": "0"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for key, value := range tt.env {
+				t.Setenv(key, value)
+			}
+
+			_, err := newTestLoader().loadAuth()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("got error %v, want error %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestLoadTenant(t *testing.T) {
+	tests := []struct {
+		name		string
+		value		string
+		want		string
+		wantErr		bool
+	}{
+		{name: "default", value: "", want: "default"},
+		{name: "trimmed", value: " acme ", want: "acme"},
+		{name: "upper case", value: "ACME", wantErr: true},
+		{name: "too long", value: strings.Repeat("a", 64), wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("TENANT_DEFAULT", tt.value)
+
+			tenant, err := newTestLoader().loadTenant()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("got error %v, want error %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && tenant.Default != tt.want {
+				t.Errorf("got default %q, want %q", tenant.Default, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadRateLimit(t *testing.T) {
+	tests := []struct {
+		name		string
+		env			map[string]string
+		wantErr		string
+	}{
+		{name: "defaults"},
+		{name: "negative rate", env: map[string]string{"RATE_LIMIT_RPS": "-1"}, wantErr: "RATE_LIMIT_RPS"},
+		{name: "negative ip rate", env: map[string]string{"RATE_LIMIT_IP_RPS": "-1"}, wantErr: "RATE_LIMIT_IP_RPS"},
+		{name: "zero ip burst", env: map[string]string{"RATE_LIMIT_IP_BURST": "0"}, wantErr: "RATE_LIMIT_IP_BURST"},
+		{name: "max below min", env: map[string]string{"SHED_MIN_CONCURRENCY": "20", "SHED_MAX_CONCURRENCY": "10"}, wantErr: "SHED_MAX_CONCURRENCY"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for key, value := range tt.env {
+				t.Setenv(key, value)
+			}
+
+			_, err := newTestLoader().loadRateLimit()
+			if tt.wantErr == "" && err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)) {
+				t.Fatalf("got error %v, want a error about %s", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestLoadMigration(t *testing.T) {
+	tests := []struct {
+		name		string
+		env			map[string]string
+		wantAuto	bool
+		wantErr		string
+	}{
+		{name: "defaults"},
+		{name: "auto migrate", env: map[string]string{"DB_AUTO_MIGRATE": "true"}, wantAuto: true},
+		{name: "zero lock timeout", env: map[string]string{"DB_MIGRATE_LOCK_TIMEOUT_MS": "0"}, wantErr: "DB_MIGRATE_LOCK_TIMEOUT_MS"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for key, value := range tt.env {
+				t.Setenv(key, value)
+			}
+
+			migration, err := newTestLoader().loadMigration()
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("got error %v, want a error about %s", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if migration.AutoMigrate != tt.wantAuto {
+				t.Errorf("got auto migrate %v, want %v", migration.AutoMigrate, tt.wantAuto)
+			}
+		})
+	}
+}
+
+func TestLoadAllMemoryStorageSkipsDatabase(t *testing.T) {
+	t.Setenv("STORAGE", model.StorageMemory)
+	t.Setenv("DB_USER", "")
+	t.Setenv("DB_PASS", "")
+
+	all, err := newTestLoader().LoadAll()
+	if err != nil {
+		t.Fatalf("memory storage must load without database credentials: %v", err)
+	}
+	if all.Database != nil || all.Replica != nil || all.Migration != nil {
+		t.Errorf("memory storage must not load the database config")
+	}
+}
+
+func TestLoadAllPostgresRequiresCredentials(t *testing.T) {
+	t.Setenv("STORAGE", model.StoragePostgres)
+	t.Setenv("DB_USER", "")
+	t.Setenv("DB_PASS", "")
+
+	_, err := newTestLoader().LoadAll()
+	if err == nil || !strings.Contains(err.Error(), "database") {
+		t.Fatalf("got error %v, want a error about the database", err)
+	}
+}
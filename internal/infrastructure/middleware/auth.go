@@ -0,0 +1,257 @@
+package middleware
+
+import (
+	"time"
+	"strings"
+	"context"
+	"net/http"
+	"encoding/json"
+
+	"github.com/rs/zerolog"
+	"github.com/golang-jwt/jwt/v5"
+
+	"google.golang.org/grpc/metadata"
+
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/attribute"
+
+	"github.com/go-inventory/shared/erro"
+	"github.com/go-inventory/shared/security"
+	"github.com/go-inventory/internal/domain/model"
+)
+
+// algorithms accepted, the alg of the token must also match the kind of its key
+var validMethods = []string{jwt.SigningMethodRS256.Alg(), jwt.SigningMethodHS256.Alg()}
+
+var (
+	errMissingToken		= erro.New(erro.KindUnauthorized, erro.ErrUnauthorized.Code, "missing bearer token or api key")
+	errInsufficientScope = erro.New(erro.KindForbidden, "INSUFFICIENT_SCOPE", "the token has no scope for this operation")
+)
+
+// scopeClaim accepts the scopes as a array or as a space separated string (RFC 8693)
+type scopeClaim []string
+
+func (s *scopeClaim) UnmarshalJSON(data []byte) error {
+	var list []string
+	if err := json.Unmarshal(data, &list); err == nil {
+		*s = list
+		return nil
+	}
+
+	var value string
+	if err := json.Unmarshal(data, &value); err != nil {
+		return err
+	}
+	*s = strings.Fields(value)
+	return nil
+}
+
+// claims of the tokens, the subject falls back to the username and the client id of the identity providers.
+// A token with tenant_id is bound to that tenant
+type tokenClaims struct {
+	jwt.RegisteredClaims
+	Username	string		`json:"username,omitempty"`
+	ClientID	string		`json:"client_id,omitempty"`
+	TenantID	string		`json:"tenant_id,omitempty"`
+	Scope		scopeClaim	`json:"scope,omitempty"`
+	Scp			scopeClaim	`json:"scp,omitempty"`
+}
+
+// ApiKeyVerifier finds the principal of a api key, counting the request in the quota of the key
+type ApiKeyVerifier interface {
+	AuthenticateApiKey(ctx context.Context, key string) (*security.Principal, error)
+}
+
+// Authenticator validates the bearer tokens or the api keys and the access level of the operations. A nil
+// authenticator (authentication disabled) allows everything
+type Authenticator struct {
+	authConfig		*model.AuthConfig
+	keys			*keySet
+	parser			*jwt.Parser
+	apiKeys			ApiKeyVerifier
+	logger			*zerolog.Logger
+}
+
+// About create the authenticator, nil when both the tokens and the api keys are disabled
+func NewAuthenticator(	authConfig *model.AuthConfig,
+						apiKeys ApiKeyVerifier,
+						appLogger *zerolog.Logger) (*Authenticator, error) {
+	logger := appLogger.With().
+				Str("package", "infrastructure.middleware").
+				Logger()
+
+	logger.Info().
+			Str("func","NewAuthenticator").Send()
+
+	if authConfig == nil || (!authConfig.Enabled && !authConfig.ApiKeyEnabled) {
+		return nil, nil
+	}
+
+	authenticator := &Authenticator{
+		authConfig: authConfig,
+		logger: &logger,
+	}
+	if authConfig.ApiKeyEnabled {
+		authenticator.apiKeys = apiKeys
+	}
+	if !authConfig.Enabled {
+		return authenticator, nil
+	}
+
+	keys, err := loadKeySet(authConfig.HmacSecret, authConfig.PublicKeyFile, authConfig.JwksFile)
+	if err != nil {
+		return nil, err
+	}
+
+	options := []jwt.ParserOption{
+		jwt.WithValidMethods(validMethods),
+		jwt.WithExpirationRequired(),
+		jwt.WithLeeway(time.Duration(authConfig.Leeway) * time.Second),
+	}
+	if authConfig.Issuer != "" {
+		options = append(options, jwt.WithIssuer(authConfig.Issuer))
+	}
+	if authConfig.Audience != "" {
+		options = append(options, jwt.WithAudience(authConfig.Audience))
+	}
+
+	authenticator.keys = keys
+	authenticator.parser = jwt.NewParser(options...)
+
+	return authenticator, nil
+}
+
+// Helper function to get the access level granted by the scopes, the highest one
+func (a *Authenticator) level(scopes []string) string {
+	for _, level := range []struct{
+		name	string
+		scopes	[]string
+	}{
+		{security.ScopeAdmin, a.authConfig.ScopesAdmin},
+		{security.ScopeWrite, a.authConfig.ScopesWrite},
+		{security.ScopeRead, a.authConfig.ScopesRead},
+	} {
+		for _, scope := range level.scopes {
+			for _, granted := range scopes {
+				if scope == granted {
+					return level.name
+				}
+			}
+		}
+	}
+	return ""
+}
+
+// About validate a token (signature, expiration, issuer and audience) and get its principal
+func (a *Authenticator) Authenticate(tokenString string) (*security.Principal, error) {
+	if tokenString == "" || a.parser == nil {
+		return nil, errMissingToken
+	}
+
+	claims := tokenClaims{}
+	_, err := a.parser.ParseWithClaims(tokenString, &claims, a.keys.lookup)
+	if err != nil {
+		return nil, erro.Wrap(err, erro.KindUnauthorized, erro.ErrUnauthorized.Code, "invalid token")
+	}
+
+	subject := claims.Subject
+	if subject == "" {
+		subject = claims.Username
+	}
+	if subject == "" {
+		subject = claims.ClientID
+	}
+
+	scopes := append(append([]string{}, claims.Scope...), claims.Scp...)
+	return &security.Principal{
+		Subject: subject,
+		Issuer: claims.Issuer,
+		TokenID: claims.ID,
+		Scopes: scopes,
+		Level: a.level(scopes),
+		Tenant: claims.TenantID,
+	}, nil
+}
+
+// About authenticate a caller by its api key, when informed, or else by its token and check its level,
+// returning the context with the principal. The subject is also recorded in the current span
+func (a *Authenticator) Authorize(ctx context.Context, tokenString string, apiKey string, level string) (context.Context, error) {
+	if a == nil {
+		return ctx, nil
+	}
+
+	var principal *security.Principal
+	var err error
+	if apiKey != "" && a.apiKeys != nil {
+		principal, err = a.apiKeys.AuthenticateApiKey(ctx, apiKey)
+	} else {
+		principal, err = a.Authenticate(tokenString)
+	}
+	if err != nil {
+		a.logger.Warn().
+				Ctx(ctx).
+				Err(err).
+				Msg("request not authenticated")
+		return ctx, err
+	}
+
+	span := trace.SpanFromContext(ctx)
+	span.SetAttributes(	attribute.String("enduser.id", principal.Subject),
+						attribute.String("enduser.scope", strings.Join(principal.Scopes, " ")))
+
+	ctx = security.WithPrincipal(ctx, principal)
+	if !principal.Allows(level) {
+		a.logger.Warn().
+				Ctx(ctx).
+				Str("level", level).
+				Msg("request not authorized")
+		return ctx, errInsufficientScope
+	}
+
+	return ctx, nil
+}
+
+// Helper function to get the token of the Authorization header, with or without the Bearer prefix
+func bearerToken(authorization string) string {
+	authorization = strings.TrimSpace(authorization)
+	if len(authorization) > 7 && strings.EqualFold(authorization[:7], "bearer ") {
+		return strings.TrimSpace(authorization[7:])
+	}
+	return authorization
+}
+
+// About authorize a http request, the request returned carries the principal
+func (a *Authenticator) AuthorizeRequest(req *http.Request, level string) (*http.Request, error) {
+	if a == nil {
+		return req, nil
+	}
+
+	ctx, err := a.Authorize(req.Context(),
+							bearerToken(req.Header.Get("Authorization")),
+							req.Header.Get(a.authConfig.ApiKeyHeader),
+							level)
+	if err != nil {
+		return req, err
+	}
+	return req.WithContext(ctx), nil
+}
+
+// About authorize a grpc call, the token and the api key are taken from the metadata (authorization and the
+// api key header)
+func (a *Authenticator) AuthorizeMetadata(ctx context.Context, level string) (context.Context, error) {
+	if a == nil {
+		return ctx, nil
+	}
+
+	tokenString, apiKey := "", ""
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if values := md.Get("authorization"); len(values) > 0 {
+			tokenString = bearerToken(values[0])
+		}
+		if values := md.Get(a.authConfig.ApiKeyHeader); len(values) > 0 {
+			apiKey = values[0]
+		}
+	}
+
+	return a.Authorize(ctx, tokenString, apiKey, level)
+}
@@ -0,0 +1,128 @@
+package middleware
+
+import (
+	"os"
+	"fmt"
+	"math/big"
+	"crypto/rsa"
+	"encoding/json"
+	"encoding/base64"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// keySet holds the keys of each algorithm by key id, the empty id is the key of the tokens without kid
+type keySet struct {
+	hmac	map[string][]byte
+	rsa		map[string]*rsa.PublicKey
+}
+
+// a key of a jwks document (RFC 7517), only the fields of the RSA and symmetric keys
+type jsonWebKey struct {
+	Kty		string	`json:"kty"`
+	Kid		string	`json:"kid"`
+	Use		string	`json:"use,omitempty"`
+	Alg		string	`json:"alg,omitempty"`
+	N		string	`json:"n,omitempty"`
+	E		string	`json:"e,omitempty"`
+	K		string	`json:"k,omitempty"`
+}
+
+// Helper function to load the keys: the hmac secret, a PEM public key and the keys of a jwks file
+func loadKeySet(hmacSecret string, publicKeyFile string, jwksFile string) (*keySet, error) {
+	keys := &keySet{
+		hmac: map[string][]byte{},
+		rsa: map[string]*rsa.PublicKey{},
+	}
+
+	if hmacSecret != "" {
+		keys.hmac[""] = []byte(hmacSecret)
+	}
+
+	if publicKeyFile != "" {
+		pem, err := os.ReadFile(publicKeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("FAILED to read the public key: %w", err)
+		}
+		publicKey, err := jwt.ParseRSAPublicKeyFromPEM(pem)
+		if err != nil {
+			return nil, fmt.Errorf("FAILED to parse the public key: %w", err)
+		}
+		keys.rsa[""] = publicKey
+	}
+
+	if jwksFile != "" {
+		if err := keys.loadJwks(jwksFile); err != nil {
+			return nil, err
+		}
+	}
+
+	return keys, nil
+}
+
+// Helper function to load the keys of a jwks file, the keys not meant for signatures are skipped
+func (k *keySet) loadJwks(jwksFile string) error {
+	data, err := os.ReadFile(jwksFile)
+	if err != nil {
+		return fmt.Errorf("FAILED to read the jwks: %w", err)
+	}
+
+	jwks := struct {
+		Keys	[]jsonWebKey	`json:"keys"`
+	}{}
+	if err := json.Unmarshal(data, &jwks); err != nil {
+		return fmt.Errorf("FAILED to parse the jwks: %w", err)
+	}
+
+	for _, key := range jwks.Keys {
+		if key.Use != "" && key.Use != "sig" {
+			continue
+		}
+
+		switch key.Kty {
+		case "RSA":
+			n, errN := base64.RawURLEncoding.DecodeString(key.N)
+			e, errE := base64.RawURLEncoding.DecodeString(key.E)
+			if errN != nil || errE != nil || len(n) == 0 || len(e) == 0 {
+				return fmt.Errorf("FAILED to parse the jwks: invalid RSA key %s", key.Kid)
+			}
+			k.rsa[key.Kid] = &rsa.PublicKey{
+				N: new(big.Int).SetBytes(n),
+				E: int(new(big.Int).SetBytes(e).Int64()),
+			}
+		case "oct":
+			secret, err := base64.RawURLEncoding.DecodeString(key.K)
+			if err != nil || len(secret) == 0 {
+				return fmt.Errorf("FAILED to parse the jwks: invalid symmetric key %s", key.Kid)
+			}
+			k.hmac[key.Kid] = secret
+		}
+	}
+
+	return nil
+}
+
+// Helper function to find the key of a token by its algorithm, the key of its kid or else the key without kid.
+// A token signed with HS256 is only checked against the secrets, so a public key is never used as a secret
+func (k *keySet) lookup(token *jwt.Token) (interface{}, error) {
+	kid, _ := token.Header["kid"].(string)
+
+	switch token.Method.Alg() {
+	case jwt.SigningMethodHS256.Alg():
+		if key, ok := k.hmac[kid]; ok {
+			return key, nil
+		}
+		if key, ok := k.hmac[""]; ok {
+			return key, nil
+		}
+	case jwt.SigningMethodRS256.Alg():
+		if key, ok := k.rsa[kid]; ok {
+			return key, nil
+		}
+		if key, ok := k.rsa[""]; ok {
+			return key, nil
+		}
+	}
+
+	return nil, fmt.Errorf("no key for the token (alg %s, kid %q)", token.Method.Alg(), kid)
+}
@@ -0,0 +1,441 @@
+package middleware
+
+import (
+	"net"
+	"math"
+	"sync"
+	"time"
+	"strings"
+	"context"
+	"net/http"
+
+	"github.com/rs/zerolog"
+
+	"google.golang.org/grpc/peer"
+
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/attribute"
+
+	"github.com/go-inventory/shared/erro"
+	"github.com/go-inventory/shared/security"
+	"github.com/go-inventory/internal/domain/model"
+)
+
+// idle time after which the buckets are swept, a bucket idle for longer than its refill is full anyway
+const sweepInterval = time.Minute
+
+// token bucket of a client in a route
+type tokenBucket struct {
+	tokens	float64
+	last	time.Time
+}
+
+// RateLimiter limits the requests of each client in each route with token buckets: the bucket of the ip is taken
+// before the authentication (a failed authentication costs a token as well) and the bucket of the subject of the
+// credential after it. A nil limiter lets every request pass
+type RateLimiter struct {
+	rate			float64
+	burst			float64
+	ipRate			float64
+	ipBurst			float64
+	trustForwarded	bool
+	logger			*zerolog.Logger
+	mu				sync.Mutex
+	buckets			map[string]*tokenBucket
+	limitedMetric	metric.Int64Counter
+}
+
+// About create the rate limiter, nil when the rate is not configured
+func NewRateLimiter(rateLimitConfig *model.RateLimitConfig,
+					appLogger *zerolog.Logger) *RateLimiter {
+	logger := appLogger.With().
+				Str("package", "infrastructure.middleware").
+				Logger()
+
+	logger.Info().
+			Str("func","NewRateLimiter").Send()
+
+	if rateLimitConfig == nil || rateLimitConfig.Rate <= 0 {
+		logger.Info().Msg("rate limit disabled")
+		return nil
+	}
+
+	return &RateLimiter{
+		rate: rateLimitConfig.Rate,
+		burst: float64(rateLimitConfig.Burst),
+		ipRate: rateLimitConfig.IpRate,
+		ipBurst: float64(rateLimitConfig.IpBurst),
+		trustForwarded: rateLimitConfig.TrustForwarded,
+		logger: &logger,
+		buckets: make(map[string]*tokenBucket),
+	}
+}
+
+// About register the counter of the requests limited
+func (l *RateLimiter) RegisterMetrics(meter metric.Meter) error {
+	if l == nil {
+		return nil
+	}
+
+	limitedMetric, err := meter.Int64Counter("custom_rate_limited_count")
+	if err != nil {
+		return err
+	}
+	l.limitedMetric = limitedMetric
+	return nil
+}
+
+// About sweep the idle buckets until the context is done
+func (l *RateLimiter) Start(ctx context.Context) {
+	if l == nil {
+		return
+	}
+
+	ticker := time.NewTicker(sweepInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			l.sweep(time.Now())
+		}
+	}
+}
+
+// Helper function to remove the buckets already full again
+func (l *RateLimiter) sweep(now time.Time) {
+	refill := time.Duration(l.burst / l.rate * float64(time.Second))
+	if l.ipRate > 0 {
+		refill = max(refill, time.Duration(l.ipBurst / l.ipRate * float64(time.Second)))
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	for key, bucket := range l.buckets {
+		if now.Sub(bucket.last) >= refill {
+			delete(l.buckets, key)
+		}
+	}
+}
+
+// Helper function to take a token of the bucket, when empty it returns the time until the next token
+func (l *RateLimiter) take(key string, rate float64, burst float64, now time.Time) (bool, time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	bucket, ok := l.buckets[key]
+	if !ok {
+		bucket = &tokenBucket{tokens: burst, last: now}
+		l.buckets[key] = bucket
+	}
+
+	bucket.tokens = math.Min(burst, bucket.tokens + now.Sub(bucket.last).Seconds() * rate)
+	bucket.last = now
+
+	if bucket.tokens < 1 {
+		return false, time.Duration((1 - bucket.tokens) / rate * float64(time.Second))
+	}
+	bucket.tokens--
+	return true, 0
+}
+
+// About take a token of the client in the route (the subject of the credential, else the ip), the error carries
+// the seconds to wait
+func (l *RateLimiter) Allow(ctx context.Context, client string, route string) error {
+	if l == nil {
+		return nil
+	}
+
+	if principal := security.PrincipalFrom(ctx); principal != nil && principal.Subject != "" {
+		client = principal.Subject
+	}
+
+	allowed, wait := l.take(client + " " + route, l.rate, l.burst, time.Now())
+	if allowed {
+		return nil
+	}
+	return l.limited(ctx, client, route, wait)
+}
+
+// About take a token of the ip in the route, before the authentication so a flood of invalid credentials
+// is rejected without reaching the database
+func (l *RateLimiter) AllowAddress(ctx context.Context, ip string, route string) error {
+	if l == nil || l.ipRate <= 0 {
+		return nil
+	}
+
+	allowed, wait := l.take("ip " + ip + " " + route, l.ipRate, l.ipBurst, time.Now())
+	if allowed {
+		return nil
+	}
+	return l.limited(ctx, ip, route, wait)
+}
+
+// Helper function to count and log a request limited, the error carries the seconds to wait
+func (l *RateLimiter) limited(ctx context.Context, client string, route string, wait time.Duration) error {
+	if l.limitedMetric != nil {
+		l.limitedMetric.Add(ctx, 1, metric.WithAttributes(attribute.String("route", route)))
+	}
+	l.logger.Warn().
+			Ctx(ctx).
+			Str("client", client).
+			Str("route", route).
+			Msg("rate limit exceeded")
+
+	return erro.ErrRateLimited.WithDetails(map[string]interface{}{	"route": route,
+																	"retry_after": int(math.Ceil(wait.Seconds()))})
+}
+
+// About take a token of the caller of a http request
+func (l *RateLimiter) AllowRequest(req *http.Request, route string) error {
+	if l == nil {
+		return nil
+	}
+	return l.Allow(req.Context(), l.clientIP(req), route)
+}
+
+// About take a token of the ip of a http request, before the authentication
+func (l *RateLimiter) AllowRequestAddress(req *http.Request, route string) error {
+	if l == nil {
+		return nil
+	}
+	return l.AllowAddress(req.Context(), l.clientIP(req), route)
+}
+
+// About take a token of the caller of a grpc call, the client is the address of the peer
+func (l *RateLimiter) AllowMetadata(ctx context.Context, method string) error {
+	if l == nil {
+		return nil
+	}
+	return l.Allow(ctx, peerIP(ctx), method)
+}
+
+// About take a token of the address of the peer of a grpc call, before the authentication
+func (l *RateLimiter) AllowMetadataAddress(ctx context.Context, method string) error {
+	if l == nil {
+		return nil
+	}
+	return l.AllowAddress(ctx, peerIP(ctx), method)
+}
+
+// Helper function to get the ip of the peer of a grpc call
+func peerIP(ctx context.Context) string {
+	if p, ok := peer.FromContext(ctx); ok && p.Addr != nil {
+		return hostOf(p.Addr.String())
+	}
+	return ""
+}
+
+// Helper function to get the ip of the caller, behind a trusted proxy the last address of X-Forwarded-For,
+// the one the proxy appended. The addresses before it are sent by the client and can be anything
+func (l *RateLimiter) clientIP(req *http.Request) string {
+	if l.trustForwarded {
+		if values := req.Header.Values("X-Forwarded-For"); len(values) > 0 {
+			addresses := strings.Split(values[len(values)-1], ",")
+			if last := strings.TrimSpace(addresses[len(addresses)-1]); last != "" {
+				return last
+			}
+		}
+	}
+	return hostOf(req.RemoteAddr)
+}
+
+// Helper function to strip the port of an address
+func hostOf(addr string) string {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return addr
+	}
+	return host
+}
+
+// PoolStat returns the cumulative acquires of the database pool, the canceled ones and the total time waited
+type PoolStat func() (acquireCount int64, canceledCount int64, acquireDuration time.Duration)
+
+// LoadShedder limits the requests in flight of the pod. At each interval the limit is cut when the average wait to
+// acquire a connection of the pool passes the target (or acquires were canceled), and grows by one when the limit
+// was reached without waits (AIMD). A nil shedder lets every request pass
+type LoadShedder struct {
+	minLimit		int
+	maxLimit		int
+	waitTarget		time.Duration
+	interval		time.Duration
+	poolStat		PoolStat
+	logger			*zerolog.Logger
+	mu				sync.Mutex
+	limit			int
+	inFlight		int
+	peak			int
+	avgWait			time.Duration
+	lastCount		int64
+	lastCanceled	int64
+	lastDuration	time.Duration
+	shedMetric		metric.Int64Counter
+}
+
+// About create the load shedder, nil when it is not enabled
+func NewLoadShedder(rateLimitConfig *model.RateLimitConfig,
+					poolStat PoolStat,
+					appLogger *zerolog.Logger) *LoadShedder {
+	logger := appLogger.With().
+				Str("package", "infrastructure.middleware").
+				Logger()
+
+	logger.Info().
+			Str("func","NewLoadShedder").Send()
+
+	if rateLimitConfig == nil || !rateLimitConfig.ShedEnabled {
+		logger.Info().Msg("load shedding disabled")
+		return nil
+	}
+
+	s := &LoadShedder{
+		minLimit: rateLimitConfig.MinConcurrency,
+		maxLimit: rateLimitConfig.MaxConcurrency,
+		waitTarget: time.Duration(rateLimitConfig.AcquireWaitTarget) * time.Millisecond,
+		interval: time.Duration(rateLimitConfig.ShedInterval) * time.Millisecond,
+		poolStat: poolStat,
+		logger: &logger,
+		limit: rateLimitConfig.MaxConcurrency,
+	}
+	s.lastCount, s.lastCanceled, s.lastDuration = poolStat()
+
+	return s
+}
+
+// About register the counter of the requests shed and the gauges of the limit, the requests in flight and the wait
+func (s *LoadShedder) RegisterMetrics(meter metric.Meter) error {
+	if s == nil {
+		return nil
+	}
+
+	shedMetric, err := meter.Int64Counter("custom_shed_count")
+	if err != nil {
+		return err
+	}
+	s.shedMetric = shedMetric
+
+	limitGauge, err := meter.Int64ObservableGauge("custom_concurrency_limit")
+	if err != nil {
+		return err
+	}
+	inFlightGauge, err := meter.Int64ObservableGauge("custom_concurrency_in_flight")
+	if err != nil {
+		return err
+	}
+	waitGauge, err := meter.Float64ObservableGauge("custom_pool_acquire_wait")
+	if err != nil {
+		return err
+	}
+
+	_, err = meter.RegisterCallback(func(ctx context.Context, o metric.Observer) error {
+		limit, inFlight, avgWait := s.Stats()
+		o.ObserveInt64(limitGauge, int64(limit))
+		o.ObserveInt64(inFlightGauge, int64(inFlight))
+		o.ObserveFloat64(waitGauge, avgWait.Seconds())
+		return nil
+	}, limitGauge, inFlightGauge, waitGauge)
+
+	return err
+}
+
+// About get the current limit, the requests in flight and the average acquire wait of the last interval
+func (s *LoadShedder) Stats() (int, int, time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.limit, s.inFlight, s.avgWait
+}
+
+// About admit a request, the release must be called when it finishes
+func (s *LoadShedder) Acquire(ctx context.Context, route string) (func(), error) {
+	if s == nil {
+		return func() {}, nil
+	}
+
+	s.mu.Lock()
+	if s.inFlight >= s.limit {
+		limit := s.limit
+		s.mu.Unlock()
+
+		if s.shedMetric != nil {
+			s.shedMetric.Add(ctx, 1, metric.WithAttributes(attribute.String("route", route)))
+		}
+		s.logger.Warn().
+				Ctx(ctx).
+				Str("route", route).
+				Int("limit", limit).
+				Msg("request shed")
+
+		return nil, erro.ErrOverloaded.WithDetails(map[string]interface{}{"retry_after": int(math.Ceil(s.interval.Seconds()))})
+	}
+	s.inFlight++
+	if s.inFlight > s.peak {
+		s.peak = s.inFlight
+	}
+	s.mu.Unlock()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			s.mu.Lock()
+			s.inFlight--
+			s.mu.Unlock()
+		})
+	}, nil
+}
+
+// About adjust the limit at each interval until the context is done
+func (s *LoadShedder) Start(ctx context.Context) {
+	if s == nil {
+		return
+	}
+
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.adjust(ctx)
+		}
+	}
+}
+
+// Helper function to compute the wait of the interval and move the limit
+func (s *LoadShedder) adjust(ctx context.Context) {
+	count, canceled, duration := s.poolStat()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	acquires := count - s.lastCount
+	canceledAcquires := canceled - s.lastCanceled
+	s.avgWait = 0
+	if acquires > 0 {
+		s.avgWait = (duration - s.lastDuration) / time.Duration(acquires)
+	}
+	s.lastCount, s.lastCanceled, s.lastDuration = count, canceled, duration
+
+	previous := s.limit
+	switch {
+	case s.avgWait > s.waitTarget || canceledAcquires > 0:
+		s.limit = max(s.minLimit, s.limit * 3 / 4)
+	case s.peak >= s.limit:
+		s.limit = min(s.maxLimit, s.limit + 1)
+	}
+	s.peak = s.inFlight
+
+	if s.limit != previous {
+		s.logger.Info().
+				Ctx(ctx).
+				Int("limit", s.limit).
+				Dur("acquire_wait", s.avgWait).
+				Int64("canceled_acquires", canceledAcquires).
+				Msg("concurrency limit adjusted")
+	}
+}
@@ -0,0 +1,133 @@
+package middleware
+
+import (
+	"strings"
+	"context"
+	"net/http"
+
+	"github.com/rs/zerolog"
+
+	"google.golang.org/grpc/metadata"
+
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/attribute"
+
+	"github.com/go-inventory/shared/erro"
+	"github.com/go-inventory/shared/security"
+	"github.com/go-inventory/shared/validator"
+	"github.com/go-inventory/internal/domain/model"
+)
+
+// TenantResolver puts the tenant of a request in its context: the tenant of the credential (claim tenant_id of the
+// token or the tenant of the api key), else the default tenant. The tenant header is only honored for a admin
+// without tenant or when the authentication is disabled. A nil resolver leaves the context as it is
+type TenantResolver struct {
+	tenantConfig	*model.TenantConfig
+	logger			*zerolog.Logger
+}
+
+// About create the tenant resolver
+func NewTenantResolver(	tenantConfig *model.TenantConfig,
+						appLogger *zerolog.Logger) *TenantResolver {
+	logger := appLogger.With().
+				Str("package", "infrastructure.middleware").
+				Logger()
+
+	logger.Info().
+			Str("func","NewTenantResolver").Send()
+
+	return &TenantResolver{
+		tenantConfig: tenantConfig,
+		logger: &logger,
+	}
+}
+
+// Rules of a tenant, the same of the sku but lowercase
+func tenantRules(tenant string) validator.Rule {
+	return func(v *validator.Validator) {
+		v.MaxLength("tenant", tenant, 63).
+		  Pattern("tenant", tenant, validator.TenantPattern)
+	}
+}
+
+// Helper function to get the tenant a caller is bound to: the tenant of its credential, or the default tenant
+// for a credential without tenant. Only a admin without tenant, or a request not authenticated (authentication
+// disabled), can choose the tenant by the header
+func (t *TenantResolver) boundTenant(ctx context.Context) (string, bool) {
+	principal := security.PrincipalFrom(ctx)
+	switch {
+	case principal == nil:
+		return "", false
+	case principal.Tenant != "":
+		return principal.Tenant, true
+	case principal.Allows(security.ScopeAdmin):
+		return "", false
+	default:
+		return t.tenantConfig.Default, true
+	}
+}
+
+// About resolve the tenant of a caller already authenticated, the header can only repeat the tenant the caller
+// is bound to. The tenant is also recorded in the current span
+func (t *TenantResolver) Resolve(ctx context.Context, header string) (context.Context, error) {
+	if t == nil {
+		return ctx, nil
+	}
+
+	tenant := strings.TrimSpace(header)
+	if bound, ok := t.boundTenant(ctx); ok {
+		if bound == "" || (tenant != "" && tenant != bound) {
+			t.logger.Warn().
+					Ctx(ctx).
+					Str("tenant", tenant).
+					Msg("tenant not allowed for the caller")
+			return ctx, erro.ErrTenantForbidden.WithDetails(map[string]interface{}{"tenant": tenant})
+		}
+		tenant = bound
+	}
+
+	if tenant == "" {
+		if t.tenantConfig.Required {
+			return ctx, erro.ErrTenantRequired
+		}
+		tenant = t.tenantConfig.Default
+	}
+
+	if err := validator.Validate(tenantRules(tenant)); err != nil {
+		return ctx, err
+	}
+
+	span := trace.SpanFromContext(ctx)
+	span.SetAttributes(attribute.String("tenant.id", tenant))
+
+	return security.WithTenant(ctx, tenant), nil
+}
+
+// About resolve the tenant of a http request, the request returned carries the tenant
+func (t *TenantResolver) ResolveRequest(req *http.Request) (*http.Request, error) {
+	if t == nil {
+		return req, nil
+	}
+
+	ctx, err := t.Resolve(req.Context(), req.Header.Get(t.tenantConfig.Header))
+	if err != nil {
+		return req, err
+	}
+	return req.WithContext(ctx), nil
+}
+
+// About resolve the tenant of a grpc call, the header is taken from the metadata
+func (t *TenantResolver) ResolveMetadata(ctx context.Context) (context.Context, error) {
+	if t == nil {
+		return ctx, nil
+	}
+
+	header := ""
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if values := md.Get(t.tenantConfig.Header); len(values) > 0 {
+			header = values[0]
+		}
+	}
+
+	return t.Resolve(ctx, header)
+}
@@ -0,0 +1,267 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"database/sql"
+
+	"github.com/jackc/pgx/v5"
+
+	"github.com/go-inventory/shared/erro"
+	"github.com/go-inventory/internal/domain/model"
+	"github.com/go-inventory/internal/domain/service"
+
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/codes"
+)
+
+// Helper function to scan a adjustment joined with its product from rows iterator
+func (w *WorkerRepository) scanAdjustmentFromRows(rows pgx.Rows) (*model.InventoryAdjustment, error) {
+	adjustment := model.InventoryAdjustment{}
+	var nullUpdatedAt sql.NullTime
+
+	err := rows.Scan(&adjustment.ID,
+					&adjustment.Product.ID,
+					&adjustment.Product.Sku,
+					&adjustment.Counted,
+					&adjustment.Previous,
+					&adjustment.Variance,
+					&adjustment.Status,
+					&adjustment.Reason,
+					&adjustment.CreatedAt,
+					&nullUpdatedAt,
+				)
+	if err != nil {
+		return nil, fmt.Errorf("FAILED to scan adjustment from rows: %w", dbError(err))
+	}
+
+	adjustment.UpdatedAt = w.pointerTime(nullUpdatedAt)
+	return &adjustment, nil
+}
+
+// About create a inventory adjustment (cycle count)
+func (w* WorkerRepository) AddInventoryAdjustment(ctx context.Context,
+												tx service.Tx,
+												adjustment *model.InventoryAdjustment) (*model.InventoryAdjustment, error){
+	w.logger.Info().
+			Ctx(ctx).
+			Str("func","AddInventoryAdjustment").Send()
+
+	// trace
+	ctx, span := w.tracerProvider.SpanCtx(ctx, "database.AddInventoryAdjustment", trace.SpanKindInternal)
+	defer span.End()
+
+	//Prepare
+	var id int
+
+	// Query Execute
+	query := `INSERT INTO inventory_adjustment ( fk_product_id,
+												counted,
+												previous,
+												variance,
+												status,
+												reason,
+												created_at)
+				VALUES($1, $2, $3, $4, $5, $6, $7) RETURNING id`
+
+	row := pgxTx(tx).QueryRow(	ctx,
+						query,
+						adjustment.Product.ID,
+						adjustment.Counted,
+						adjustment.Previous,
+						adjustment.Variance,
+						adjustment.Status,
+						adjustment.Reason,
+						adjustment.CreatedAt)
+
+	if err := row.Scan(&id); err != nil {
+		span.RecordError(err)
+        span.SetStatus(codes.Error, err.Error())
+		w.logger.Error().
+				Ctx(ctx).
+				Err(err).Send()
+		return nil, fmt.Errorf("FAILED to insert inventory_adjustment: %w", dbError(err))
+	}
+
+	// Set PK
+	adjustment.ID = id
+
+	return adjustment , nil
+}
+
+// About get a inventory adjustment locking its row until the end of the transaction
+func (w *WorkerRepository) GetInventoryAdjustmentForUpdate(ctx context.Context,
+															tx service.Tx,
+															adjustment *model.InventoryAdjustment) (*model.InventoryAdjustment, error){
+	w.logger.Info().
+			Ctx(ctx).
+			Str("func","GetInventoryAdjustmentForUpdate").Send()
+
+	// Trace
+	ctx, span := w.tracerProvider.SpanCtx(ctx, "database.GetInventoryAdjustmentForUpdate", trace.SpanKindInternal)
+	defer span.End()
+
+	// Query and Execute
+	query := `SELECT ia.id,
+					 p.id,
+					 p.sku,
+					 ia.counted,
+					 ia.previous,
+					 ia.variance,
+					 ia.status,
+					 ia.reason,
+					 ia.created_at,
+					 ia.updated_at
+				FROM inventory_adjustment as ia,
+					 product as p
+				WHERE ia.id = $1
+				and p.id = ia.fk_product_id
+				FOR UPDATE OF ia`
+
+	rows, err := pgxTx(tx).Query(ctx,
+						query,
+						adjustment.ID)
+	if err != nil {
+		span.RecordError(err)
+        span.SetStatus(codes.Error, err.Error())
+		w.logger.Error().
+				Ctx(ctx).
+				Err(err).Send()
+		return nil, fmt.Errorf("FAILED to query inventory_adjustment: %w", dbError(err))
+	}
+	defer rows.Close()
+
+	if rows.Next() {
+		res_adjustment, err := w.scanAdjustmentFromRows(rows)
+		if err != nil {
+			span.RecordError(err)
+        	span.SetStatus(codes.Error, err.Error())
+			w.logger.Error().
+					Ctx(ctx).
+					Err(err).Send()
+			return nil, fmt.Errorf("FAILED to scanAdjustmentFromRows inventory_adjustment: %w", dbError(err))
+		}
+		return res_adjustment, nil
+	}
+
+	w.logger.Warn().
+			Ctx(ctx).
+			Err(erro.ErrNotFound).Send()
+
+	return nil, erro.ErrNotFound
+}
+
+// About update the status of a inventory adjustment
+func (w* WorkerRepository) UpdateInventoryAdjustmentStatus(ctx context.Context,
+															tx service.Tx,
+															adjustment *model.InventoryAdjustment) (int64, error){
+	w.logger.Info().
+			Ctx(ctx).
+			Str("func","UpdateInventoryAdjustmentStatus").Send()
+
+	// trace
+	ctx, span := w.tracerProvider.SpanCtx(ctx, "database.UpdateInventoryAdjustmentStatus", trace.SpanKindInternal)
+	defer span.End()
+
+	// Query Execute
+	query := `UPDATE inventory_adjustment
+				SET status = $2,
+					previous = $3,
+					updated_at = $4
+				WHERE id = $1`
+
+	row, err := pgxTx(tx).Exec(ctx,
+						query,
+						adjustment.ID,
+						adjustment.Status,
+						adjustment.Previous,
+						adjustment.UpdatedAt,
+					)
+	if err != nil {
+		span.RecordError(err)
+        span.SetStatus(codes.Error, err.Error())
+		w.logger.Error().
+				Ctx(ctx).
+				Err(err).Send()
+		return 0, fmt.Errorf("FAILED to update inventory_adjustment: %w", dbError(err))
+	}
+
+	return row.RowsAffected(), nil
+}
+
+// About list the inventory adjustments of a product
+func (w *WorkerRepository) ListInventoryAdjustment(ctx context.Context,
+													limit int,
+													offset int,
+													adjustment *model.InventoryAdjustment) (*[]model.InventoryAdjustment, error){
+	w.logger.Info().
+			Ctx(ctx).
+			Str("func","ListInventoryAdjustment").Send()
+
+	// Trace
+	ctx, span := w.tracerProvider.SpanCtx(ctx, "database.ListInventoryAdjustment", trace.SpanKindInternal)
+	defer span.End()
+
+	// db connection
+	conn, err := w.acquireRead(ctx)
+	if err != nil {
+		span.RecordError(err)
+        span.SetStatus(codes.Error, err.Error())
+		w.logger.Error().
+			  	 Ctx(ctx).
+				 Err(err).Send()
+		return nil, fmt.Errorf("FAILED to acquire connection: %w", dbError(err))
+	}
+	defer w.DatabasePG.Release(conn)
+
+	// Query and Execute
+	query := `SELECT ia.id,
+					 p.id,
+					 p.sku,
+					 ia.counted,
+					 ia.previous,
+					 ia.variance,
+					 ia.status,
+					 ia.reason,
+					 ia.created_at,
+					 ia.updated_at
+				FROM inventory_adjustment as ia,
+					 product as p
+				WHERE p.sku = $1
+				and p.id = ia.fk_product_id
+				and ($2 = '' or ia.status = $2)
+				order by ia.id desc
+				limit $3 offset $4`
+
+	rows, err := conn.Query(ctx,
+							query,
+							adjustment.Product.Sku,
+							adjustment.Status,
+							limit,
+							offset)
+	if err != nil {
+		span.RecordError(err)
+        span.SetStatus(codes.Error, err.Error())
+		w.logger.Error().
+				Ctx(ctx).
+				Err(err).Send()
+		return nil, fmt.Errorf("FAILED to query inventory_adjustment: %w", dbError(err))
+	}
+	defer rows.Close()
+
+	list_adjustment := []model.InventoryAdjustment{}
+	for rows.Next() {
+		res_adjustment, err := w.scanAdjustmentFromRows(rows)
+		if err != nil {
+			span.RecordError(err)
+        	span.SetStatus(codes.Error, err.Error())
+			w.logger.Error().
+					Ctx(ctx).
+					Err(err).Send()
+			return nil, fmt.Errorf("FAILED to scanAdjustmentFromRows inventory_adjustment: %w", dbError(err))
+		}
+		list_adjustment = append(list_adjustment, *res_adjustment)
+	}
+
+	return &list_adjustment, nil
+}
@@ -0,0 +1,354 @@
+package database
+
+import (
+	"fmt"
+	"time"
+	"errors"
+	"context"
+	"database/sql"
+
+	"github.com/jackc/pgx/v5"
+
+	"github.com/go-inventory/shared/erro"
+	"github.com/go-inventory/internal/domain/model"
+	"github.com/go-inventory/internal/domain/service"
+
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/codes"
+)
+
+// columns of a api key, in the order of scanApiKeyFromRows
+const apiKeyColumns = `id,
+					 name,
+					 prefix,
+					 key_hash,
+					 scopes,
+					 sku_prefixes,
+					 quota,
+					 quota_window,
+					 tenant_id,
+					 status,
+					 expires_at,
+					 created_at,
+					 updated_at`
+
+// Helper function to scan a api key from rows iterator
+func (w *WorkerRepository) scanApiKeyFromRows(rows pgx.Rows) (*model.ApiKey, error) {
+	apiKey := model.ApiKey{}
+	var nullExpiresAt, nullUpdatedAt sql.NullTime
+
+	err := rows.Scan(&apiKey.ID,
+					&apiKey.Name,
+					&apiKey.Prefix,
+					&apiKey.KeyHash,
+					&apiKey.Scopes,
+					&apiKey.SkuPrefixes,
+					&apiKey.Quota,
+					&apiKey.QuotaWindow,
+					&apiKey.Tenant,
+					&apiKey.Status,
+					&nullExpiresAt,
+					&apiKey.CreatedAt,
+					&nullUpdatedAt,
+				)
+	if err != nil {
+		return nil, fmt.Errorf("FAILED to scan api_key from rows: %w", dbError(err))
+	}
+
+	apiKey.ExpiresAt = w.pointerTime(nullExpiresAt)
+	apiKey.UpdatedAt = w.pointerTime(nullUpdatedAt)
+	return &apiKey, nil
+}
+
+// Helper function to query a single api key
+func (w *WorkerRepository) queryApiKey(ctx context.Context, spanName string, where string, arg interface{}) (*model.ApiKey, error){
+	w.logger.Info().
+			Ctx(ctx).
+			Str("func",spanName).Send()
+
+	// Trace
+	ctx, span := w.tracerProvider.SpanCtx(ctx, "database."+spanName, trace.SpanKindInternal)
+	defer span.End()
+
+	// db connection
+	conn, err := w.acquire(ctx)
+	if err != nil {
+		span.RecordError(err)
+        span.SetStatus(codes.Error, err.Error())
+		w.logger.Error().
+				Ctx(ctx).
+				Err(err).Send()
+		return nil, fmt.Errorf("FAILED to acquire connection: %w", dbError(err))
+	}
+	defer w.DatabasePG.Release(conn)
+
+	// Query and Execute
+	query := `SELECT ` + apiKeyColumns + `
+				FROM api_key
+				WHERE ` + where
+
+	rows, err := conn.Query(ctx,
+							query,
+							arg)
+	if err != nil {
+		span.RecordError(err)
+        span.SetStatus(codes.Error, err.Error())
+		w.logger.Error().
+				Ctx(ctx).
+				Err(err).Send()
+		return nil, fmt.Errorf("FAILED to query api_key: %w", dbError(err))
+	}
+	defer rows.Close()
+
+	if rows.Next() {
+		res_api_key, err := w.scanApiKeyFromRows(rows)
+		if err != nil {
+			span.RecordError(err)
+        	span.SetStatus(codes.Error, err.Error())
+			w.logger.Error().
+					Ctx(ctx).
+					Err(err).Send()
+			return nil, err
+		}
+		return res_api_key, nil
+	}
+
+	return nil, erro.ErrNotFound
+}
+
+// About issue a api key, only its hash is stored
+func (w *WorkerRepository) AddApiKey(ctx context.Context,
+									tx service.Tx,
+									apiKey *model.ApiKey) (*model.ApiKey, error){
+	w.logger.Info().
+			Ctx(ctx).
+			Str("func","AddApiKey").Send()
+
+	// trace
+	ctx, span := w.tracerProvider.SpanCtx(ctx, "database.AddApiKey", trace.SpanKindInternal)
+	defer span.End()
+
+	//Prepare
+	var id int
+
+	// Query Execute
+	query := `INSERT INTO api_key (	name,
+									prefix,
+									key_hash,
+									scopes,
+									sku_prefixes,
+									quota,
+									quota_window,
+									status,
+									expires_at,
+									created_at)
+				VALUES($1, $2, $3, $4, $5, $6, $7, $8, $9, $10) RETURNING id`
+
+	row := pgxTx(tx).QueryRow(	ctx,
+						query,
+						apiKey.Name,
+						apiKey.Prefix,
+						apiKey.KeyHash,
+						apiKey.Scopes,
+						apiKey.SkuPrefixes,
+						apiKey.Quota,
+						apiKey.QuotaWindow,
+						apiKey.Status,
+						apiKey.ExpiresAt,
+						apiKey.CreatedAt)
+
+	if err := row.Scan(&id); err != nil {
+		span.RecordError(err)
+        span.SetStatus(codes.Error, err.Error())
+		w.logger.Error().
+				Ctx(ctx).
+				Err(err).Send()
+		return nil, fmt.Errorf("FAILED to insert api_key: %w", dbError(err))
+	}
+
+	// Set PK
+	apiKey.ID = id
+
+	return apiKey, nil
+}
+
+// About get a api key
+func (w *WorkerRepository) GetApiKey(ctx context.Context,
+									apiKey *model.ApiKey) (*model.ApiKey, error){
+	return w.queryApiKey(ctx, "GetApiKey", "id = $1", apiKey.ID)
+}
+
+// About get the api key of a hash, used to authenticate the requests
+func (w *WorkerRepository) GetApiKeyByHash(ctx context.Context,
+										keyHash string) (*model.ApiKey, error){
+	return w.queryApiKey(ctx, "GetApiKeyByHash", "key_hash = $1", keyHash)
+}
+
+// About replace the key of a active api key, the previous key stops working
+func (w *WorkerRepository) RotateApiKey(ctx context.Context,
+										tx service.Tx,
+										apiKey *model.ApiKey) (int64, error){
+	w.logger.Info().
+			Ctx(ctx).
+			Str("func","RotateApiKey").Send()
+
+	// trace
+	ctx, span := w.tracerProvider.SpanCtx(ctx, "database.RotateApiKey", trace.SpanKindInternal)
+	defer span.End()
+
+	// Query Execute
+	query := `UPDATE api_key
+				SET prefix = $2,
+					key_hash = $3,
+					updated_at = $4
+				WHERE id = $1
+				and status = $5`
+
+	row, err := pgxTx(tx).Exec(ctx,
+						query,
+						apiKey.ID,
+						apiKey.Prefix,
+						apiKey.KeyHash,
+						apiKey.UpdatedAt,
+						model.ApiKeyActive)
+	if err != nil {
+		span.RecordError(err)
+        span.SetStatus(codes.Error, err.Error())
+		w.logger.Error().
+				Ctx(ctx).
+				Err(err).Send()
+		return 0, fmt.Errorf("FAILED to update api_key: %w", dbError(err))
+	}
+
+	return row.RowsAffected(), nil
+}
+
+// About revoke a api key, it is kept for the audit
+func (w *WorkerRepository) RevokeApiKey(ctx context.Context,
+										tx service.Tx,
+										apiKey *model.ApiKey) (int64, error){
+	w.logger.Info().
+			Ctx(ctx).
+			Str("func","RevokeApiKey").Send()
+
+	// trace
+	ctx, span := w.tracerProvider.SpanCtx(ctx, "database.RevokeApiKey", trace.SpanKindInternal)
+	defer span.End()
+
+	// Query Execute
+	query := `UPDATE api_key
+				SET status = $2,
+					updated_at = $3
+				WHERE id = $1
+				and status = $4`
+
+	row, err := pgxTx(tx).Exec(ctx,
+						query,
+						apiKey.ID,
+						model.ApiKeyRevoked,
+						apiKey.UpdatedAt,
+						model.ApiKeyActive)
+	if err != nil {
+		span.RecordError(err)
+        span.SetStatus(codes.Error, err.Error())
+		w.logger.Error().
+				Ctx(ctx).
+				Err(err).Send()
+		return 0, fmt.Errorf("FAILED to update api_key: %w", dbError(err))
+	}
+
+	return row.RowsAffected(), nil
+}
+
+// About count a request of a api key in its window, returning the requests of the window. The key keeps a
+// single row, a newer window starts the count again (a older one, from a pod with clock skew, is counted
+// in the current window)
+func (w *WorkerRepository) AddApiKeyUsage(ctx context.Context,
+										tx service.Tx,
+										apiKey *model.ApiKey,
+										windowStart time.Time) (int, error){
+	w.logger.Info().
+			Ctx(ctx).
+			Str("func","AddApiKeyUsage").Send()
+
+	// trace
+	ctx, span := w.tracerProvider.SpanCtx(ctx, "database.AddApiKeyUsage", trace.SpanKindInternal)
+	defer span.End()
+
+	//Prepare
+	var requests int
+
+	// Query Execute
+	query := `INSERT INTO api_key_usage (	fk_api_key_id,
+											window_start,
+											requests)
+				VALUES($1, $2, 1)
+				ON CONFLICT (fk_api_key_id) DO UPDATE
+				SET requests = CASE WHEN excluded.window_start > api_key_usage.window_start THEN 1
+									ELSE api_key_usage.requests + 1 END,
+					window_start = greatest(excluded.window_start, api_key_usage.window_start)
+				RETURNING requests`
+
+	row := pgxTx(tx).QueryRow(	ctx,
+						query,
+						apiKey.ID,
+						windowStart)
+
+	if err := row.Scan(&requests); err != nil {
+		span.RecordError(err)
+        span.SetStatus(codes.Error, err.Error())
+		w.logger.Error().
+				Ctx(ctx).
+				Err(err).Send()
+		return 0, fmt.Errorf("FAILED to upsert api_key_usage: %w", dbError(err))
+	}
+
+	return requests, nil
+}
+
+// About get the usage of a api key, zero requests when it was not used in the window
+func (w *WorkerRepository) GetApiKeyUsage(ctx context.Context,
+										apiKey *model.ApiKey,
+										windowStart time.Time) (*model.ApiKeyUsage, error){
+	w.logger.Info().
+			Ctx(ctx).
+			Str("func","GetApiKeyUsage").Send()
+
+	// Trace
+	ctx, span := w.tracerProvider.SpanCtx(ctx, "database.GetApiKeyUsage", trace.SpanKindInternal)
+	defer span.End()
+
+	// db connection
+	conn, err := w.acquire(ctx)
+	if err != nil {
+		span.RecordError(err)
+        span.SetStatus(codes.Error, err.Error())
+		w.logger.Error().
+				Ctx(ctx).
+				Err(err).Send()
+		return nil, fmt.Errorf("FAILED to acquire connection: %w", dbError(err))
+	}
+	defer w.DatabasePG.Release(conn)
+
+	// Query and Execute
+	query := `SELECT requests
+				FROM api_key_usage
+				WHERE fk_api_key_id = $1
+				and window_start = $2`
+
+	usage := model.ApiKeyUsage{WindowStart: windowStart}
+	err = conn.QueryRow(ctx,
+						query,
+						apiKey.ID,
+						windowStart).Scan(&usage.Requests)
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		span.RecordError(err)
+        span.SetStatus(codes.Error, err.Error())
+		w.logger.Error().
+				Ctx(ctx).
+				Err(err).Send()
+		return nil, fmt.Errorf("FAILED to query api_key_usage: %w", dbError(err))
+	}
+
+	return &usage, nil
+}
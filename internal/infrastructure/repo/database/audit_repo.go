@@ -0,0 +1,392 @@
+package database
+
+import (
+	"fmt"
+	"errors"
+	"context"
+
+	"github.com/jackc/pgx/v5"
+
+	"github.com/go-inventory/internal/domain/model"
+	"github.com/go-inventory/internal/domain/service"
+
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/codes"
+)
+
+// key of the advisory lock of the sealer, only one pod chains the records at a time
+const auditSealLock = 7402
+
+// columns of a audit record, the chain fields are empty until the record is sealed
+const auditColumns = `id,
+					entity,
+					sku,
+					action,
+					actor,
+					tenant_id,
+					route,
+					request_id,
+					before,
+					after,
+					COALESCE(seq, 0),
+					COALESCE(prev_hash, ''),
+					COALESCE(hash, ''),
+					created_at`
+
+// Helper function to scan a audit record from rows iterator
+func (w *WorkerRepository) scanAuditRecordFromRows(rows pgx.Rows) (*model.AuditRecord, error) {
+	record := model.AuditRecord{}
+
+	err := rows.Scan(&record.ID,
+					&record.Entity,
+					&record.Sku,
+					&record.Action,
+					&record.Actor,
+					&record.Tenant,
+					&record.Route,
+					&record.RequestID,
+					&record.Before,
+					&record.After,
+					&record.Seq,
+					&record.PrevHash,
+					&record.Hash,
+					&record.CreatedAt,
+				)
+	if err != nil {
+		return nil, fmt.Errorf("FAILED to scan audit record from rows: %w", dbError(err))
+	}
+
+	return &record, nil
+}
+
+// Helper function to scan all audit records from rows iterator
+func (w *WorkerRepository) scanAuditRecordList(rows pgx.Rows) (*[]model.AuditRecord, error) {
+	list_record := []model.AuditRecord{}
+	for rows.Next() {
+		record, err := w.scanAuditRecordFromRows(rows)
+		if err != nil {
+			return nil, err
+		}
+		list_record = append(list_record, *record)
+	}
+	return &list_record, nil
+}
+
+// About add a audit record, in the transaction of the change it describes
+func (w *WorkerRepository) AddAuditRecord(ctx context.Context,
+										tx service.Tx,
+										record *model.AuditRecord) (*model.AuditRecord, error){
+	w.logger.Info().
+			Ctx(ctx).
+			Str("func","AddAuditRecord").Send()
+
+	// trace
+	ctx, span := w.tracerProvider.SpanCtx(ctx, "database.AddAuditRecord", trace.SpanKindInternal)
+	defer span.End()
+
+	//Prepare
+	var id int
+
+	// Query Execute
+	query := `INSERT INTO audit_log (	entity,
+										sku,
+										action,
+										actor,
+										route,
+										request_id,
+										before,
+										after,
+										created_at)
+				VALUES($1, $2, $3, $4, $5, $6, $7, $8, $9) RETURNING id`
+
+	row := pgxTx(tx).QueryRow(	ctx,
+						query,
+						record.Entity,
+						record.Sku,
+						record.Action,
+						record.Actor,
+						record.Route,
+						record.RequestID,
+						record.Before,
+						record.After,
+						record.CreatedAt)
+
+	if err := row.Scan(&id); err != nil {
+		span.RecordError(err)
+        span.SetStatus(codes.Error, err.Error())
+		w.logger.Error().
+				Ctx(ctx).
+				Err(err).Send()
+		return nil, fmt.Errorf("FAILED to insert audit_log: %w", dbError(err))
+	}
+
+	// Set PK
+	record.ID = id
+
+	return record, nil
+}
+
+// About list the audit records of the filters informed (entity, sku, actor, request id and period), newest first
+func (w *WorkerRepository) ListAuditRecord(ctx context.Context,
+										limit int,
+										offset int,
+										record *model.AuditRecord) (*[]model.AuditRecord, error){
+	w.logger.Info().
+			Ctx(ctx).
+			Str("func","ListAuditRecord").Send()
+
+	// Trace
+	ctx, span := w.tracerProvider.SpanCtx(ctx, "database.ListAuditRecord", trace.SpanKindInternal)
+	defer span.End()
+
+	// db connection
+	conn, err := w.acquireRead(ctx)
+	if err != nil {
+		span.RecordError(err)
+        span.SetStatus(codes.Error, err.Error())
+		w.logger.Error().
+				Ctx(ctx).
+				Err(err).Send()
+		return nil, fmt.Errorf("FAILED to acquire connection: %w", dbError(err))
+	}
+	defer w.DatabasePG.Release(conn)
+
+	// Query and Execute
+	query := `SELECT ` + auditColumns + `
+				FROM audit_log
+				WHERE ($1 = '' or entity = $1)
+				and ($2 = '' or sku = $2)
+				and ($3 = '' or actor = $3)
+				and ($4 = '' or request_id = $4)
+				and ($5::timestamptz is null or created_at >= $5)
+				and ($6::timestamptz is null or created_at < $6)
+				order by id desc
+				limit $7
+				offset $8`
+
+	rows, err := conn.Query(ctx,
+							query,
+							record.Entity,
+							record.Sku,
+							record.Actor,
+							record.RequestID,
+							record.From,
+							record.To,
+							limit,
+							offset)
+	if err != nil {
+		span.RecordError(err)
+        span.SetStatus(codes.Error, err.Error())
+		w.logger.Error().
+				Ctx(ctx).
+				Err(err).Send()
+		return nil, fmt.Errorf("FAILED to query audit_log: %w", dbError(err))
+	}
+	defer rows.Close()
+
+	list_record, err := w.scanAuditRecordList(rows)
+	if err != nil {
+		span.RecordError(err)
+        span.SetStatus(codes.Error, err.Error())
+		return nil, err
+	}
+
+	return list_record, nil
+}
+
+// About list the sealed records of the tenant of the context after a position of its chain, in the chain order
+func (w *WorkerRepository) ListAuditChain(ctx context.Context,
+										afterSeq int64,
+										limit int) (*[]model.AuditRecord, error){
+	w.logger.Info().
+			Ctx(ctx).
+			Str("func","ListAuditChain").Send()
+
+	// Trace
+	ctx, span := w.tracerProvider.SpanCtx(ctx, "database.ListAuditChain", trace.SpanKindInternal)
+	defer span.End()
+
+	// db connection
+	conn, err := w.acquire(ctx)
+	if err != nil {
+		span.RecordError(err)
+        span.SetStatus(codes.Error, err.Error())
+		w.logger.Error().
+				Ctx(ctx).
+				Err(err).Send()
+		return nil, fmt.Errorf("FAILED to acquire connection: %w", dbError(err))
+	}
+	defer w.DatabasePG.Release(conn)
+
+	// Query and Execute
+	query := `SELECT ` + auditColumns + `
+				FROM audit_log
+				WHERE seq > $1
+				order by seq
+				limit $2`
+
+	rows, err := conn.Query(ctx, query, afterSeq, limit)
+	if err != nil {
+		span.RecordError(err)
+        span.SetStatus(codes.Error, err.Error())
+		w.logger.Error().
+				Ctx(ctx).
+				Err(err).Send()
+		return nil, fmt.Errorf("FAILED to query audit_log: %w", dbError(err))
+	}
+	defer rows.Close()
+
+	list_record, err := w.scanAuditRecordList(rows)
+	if err != nil {
+		span.RecordError(err)
+        span.SetStatus(codes.Error, err.Error())
+		return nil, err
+	}
+
+	return list_record, nil
+}
+
+// About count the records of the tenant of the context not sealed yet
+func (w *WorkerRepository) CountAuditUnsealed(ctx context.Context) (int, error){
+	w.logger.Info().
+			Ctx(ctx).
+			Str("func","CountAuditUnsealed").Send()
+
+	// Trace
+	ctx, span := w.tracerProvider.SpanCtx(ctx, "database.CountAuditUnsealed", trace.SpanKindInternal)
+	defer span.End()
+
+	// db connection
+	conn, err := w.acquire(ctx)
+	if err != nil {
+		span.RecordError(err)
+        span.SetStatus(codes.Error, err.Error())
+		return 0, fmt.Errorf("FAILED to acquire connection: %w", dbError(err))
+	}
+	defer w.DatabasePG.Release(conn)
+
+	var count int
+	err = conn.QueryRow(ctx, `SELECT count(*) FROM audit_log WHERE hash IS NULL`).Scan(&count)
+	if err != nil {
+		span.RecordError(err)
+        span.SetStatus(codes.Error, err.Error())
+		w.logger.Error().
+				Ctx(ctx).
+				Err(err).Send()
+		return 0, fmt.Errorf("FAILED to count audit_log: %w", dbError(err))
+	}
+
+	return count, nil
+}
+
+// About take the lock of the sealer until the end of the transaction, false when another pod holds it
+func (w *WorkerRepository) TryAuditSealLock(ctx context.Context, tx service.Tx) (bool, error){
+	w.logger.Debug().
+			Ctx(ctx).
+			Str("func","TryAuditSealLock").Send()
+
+	var locked bool
+	err := pgxTx(tx).QueryRow(ctx, `SELECT pg_try_advisory_xact_lock($1)`, auditSealLock).Scan(&locked)
+	if err != nil {
+		return false, fmt.Errorf("FAILED to lock audit_log: %w", dbError(err))
+	}
+
+	return locked, nil
+}
+
+// About list the records not sealed yet, in the order they were written
+func (w *WorkerRepository) ListAuditUnsealedForUpdate(ctx context.Context,
+													tx service.Tx,
+													limit int) (*[]model.AuditRecord, error){
+	w.logger.Debug().
+			Ctx(ctx).
+			Str("func","ListAuditUnsealedForUpdate").Send()
+
+	// trace
+	ctx, span := w.tracerProvider.SpanCtx(ctx, "database.ListAuditUnsealedForUpdate", trace.SpanKindInternal)
+	defer span.End()
+
+	// Query and Execute
+	query := `SELECT ` + auditColumns + `
+				FROM audit_log
+				WHERE hash IS NULL
+				order by id
+				limit $1
+				FOR UPDATE`
+
+	rows, err := pgxTx(tx).Query(ctx, query, limit)
+	if err != nil {
+		span.RecordError(err)
+        span.SetStatus(codes.Error, err.Error())
+		w.logger.Error().
+				Ctx(ctx).
+				Err(err).Send()
+		return nil, fmt.Errorf("FAILED to query audit_log: %w", dbError(err))
+	}
+	defer rows.Close()
+
+	list_record, err := w.scanAuditRecordList(rows)
+	if err != nil {
+		span.RecordError(err)
+        span.SetStatus(codes.Error, err.Error())
+		return nil, err
+	}
+
+	return list_record, nil
+}
+
+// About get the last sealed record of a tenant, the head of its chain. A tenant without records has a empty head
+func (w *WorkerRepository) GetAuditHead(ctx context.Context,
+										tx service.Tx,
+										tenant string) (int64, string, error){
+	w.logger.Debug().
+			Ctx(ctx).
+			Str("func","GetAuditHead").Send()
+
+	query := `SELECT seq, hash
+				FROM audit_log
+				WHERE tenant_id = $1
+				and seq IS NOT NULL
+				order by seq desc
+				limit 1`
+
+	var seq int64
+	var hash string
+	err := pgxTx(tx).QueryRow(ctx, query, tenant).Scan(&seq, &hash)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return 0, "", nil
+	}
+	if err != nil {
+		return 0, "", fmt.Errorf("FAILED to get head of audit_log: %w", dbError(err))
+	}
+
+	return seq, hash, nil
+}
+
+// About seal a record with its position in the chain of its tenant
+func (w *WorkerRepository) SealAuditRecord(ctx context.Context,
+										tx service.Tx,
+										record *model.AuditRecord) (int64, error){
+	w.logger.Debug().
+			Ctx(ctx).
+			Str("func","SealAuditRecord").Send()
+
+	query := `UPDATE audit_log
+				SET seq = $2,
+					prev_hash = $3,
+					hash = $4
+				WHERE id = $1
+				and hash IS NULL`
+
+	row, err := pgxTx(tx).Exec(ctx,
+						query,
+						record.ID,
+						record.Seq,
+						record.PrevHash,
+						record.Hash)
+	if err != nil {
+		return 0, fmt.Errorf("FAILED to seal audit_log: %w", dbError(err))
+	}
+
+	return row.RowsAffected(), nil
+}
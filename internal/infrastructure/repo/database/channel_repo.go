@@ -0,0 +1,288 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"database/sql"
+
+	"github.com/jackc/pgx/v5"
+
+	"github.com/go-inventory/internal/domain/model"
+	"github.com/go-inventory/internal/domain/service"
+
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/codes"
+)
+
+// Helper function to scan a channel allocation from rows iterator
+func (w *WorkerRepository) scanChannelAllocationFromRows(rows pgx.Rows) (*model.ChannelAllocation, error) {
+	allocation := model.ChannelAllocation{}
+	var nullUpdatedAt sql.NullTime
+
+	err := rows.Scan(&allocation.ID,
+					&allocation.Product.ID,
+					&allocation.Channel,
+					&allocation.Type,
+					&allocation.Quantity,
+					&allocation.Percentage,
+					&allocation.CreatedAt,
+					&nullUpdatedAt,
+				)
+	if err != nil {
+		return nil, fmt.Errorf("FAILED to scan channel allocation from rows: %w", dbError(err))
+	}
+
+	allocation.UpdatedAt = w.pointerTime(nullUpdatedAt)
+	return &allocation, nil
+}
+
+// Helper function to scan all channel allocations from rows iterator
+func (w *WorkerRepository) scanChannelAllocationList(rows pgx.Rows) (*[]model.ChannelAllocation, error) {
+	list_allocation := []model.ChannelAllocation{}
+	for rows.Next() {
+		res_allocation, err := w.scanChannelAllocationFromRows(rows)
+		if err != nil {
+			return nil, err
+		}
+		list_allocation = append(list_allocation, *res_allocation)
+	}
+	return &list_allocation, nil
+}
+
+// About create or replace the allocation of a channel
+func (w* WorkerRepository) UpsertChannelAllocation(ctx context.Context,
+												tx service.Tx,
+												allocation *model.ChannelAllocation) (*model.ChannelAllocation, error){
+	w.logger.Info().
+			Ctx(ctx).
+			Str("func","UpsertChannelAllocation").Send()
+
+	// trace
+	ctx, span := w.tracerProvider.SpanCtx(ctx, "database.UpsertChannelAllocation", trace.SpanKindInternal)
+	defer span.End()
+
+	//Prepare
+	var id int
+
+	// Query Execute
+	query := `INSERT INTO inventory_channel ( 	fk_product_id,
+												channel,
+												type,
+												quantity,
+												percentage,
+												created_at)
+				VALUES($1, $2, $3, $4, $5, $6)
+				ON CONFLICT (fk_product_id, channel) DO UPDATE
+				SET type = EXCLUDED.type,
+					quantity = EXCLUDED.quantity,
+					percentage = EXCLUDED.percentage,
+					updated_at = EXCLUDED.created_at
+				RETURNING id`
+
+	row := pgxTx(tx).QueryRow(	ctx,
+						query,
+						allocation.Product.ID,
+						allocation.Channel,
+						allocation.Type,
+						allocation.Quantity,
+						allocation.Percentage,
+						allocation.CreatedAt)
+
+	if err := row.Scan(&id); err != nil {
+		span.RecordError(err)
+        span.SetStatus(codes.Error, err.Error())
+		w.logger.Error().
+				Ctx(ctx).
+				Err(err).Send()
+		return nil, fmt.Errorf("FAILED to upsert inventory_channel: %w", dbError(err))
+	}
+
+	// Set PK
+	allocation.ID = id
+
+	return allocation , nil
+}
+
+// About delete the allocation of a channel
+func (w* WorkerRepository) DeleteChannelAllocation(ctx context.Context,
+												tx service.Tx,
+												allocation *model.ChannelAllocation) (int64, error){
+	w.logger.Info().
+			Ctx(ctx).
+			Str("func","DeleteChannelAllocation").Send()
+
+	// trace
+	ctx, span := w.tracerProvider.SpanCtx(ctx, "database.DeleteChannelAllocation", trace.SpanKindInternal)
+	defer span.End()
+
+	// Query Execute
+	query := `DELETE FROM inventory_channel
+				WHERE fk_product_id = $1
+				and channel = $2`
+
+	row, err := pgxTx(tx).Exec(ctx,
+						query,
+						allocation.Product.ID,
+						allocation.Channel)
+	if err != nil {
+		span.RecordError(err)
+        span.SetStatus(codes.Error, err.Error())
+		w.logger.Error().
+				Ctx(ctx).
+				Err(err).Send()
+		return 0, fmt.Errorf("FAILED to delete inventory_channel: %w", dbError(err))
+	}
+
+	return row.RowsAffected(), nil
+}
+
+// About list the channel allocations of a product
+func (w *WorkerRepository) ListChannelAllocation(ctx context.Context,
+												product *model.Product) (*[]model.ChannelAllocation, error){
+	w.logger.Info().
+			Ctx(ctx).
+			Str("func","ListChannelAllocation").Send()
+
+	// Trace
+	ctx, span := w.tracerProvider.SpanCtx(ctx, "database.ListChannelAllocation", trace.SpanKindInternal)
+	defer span.End()
+
+	// db connection
+	conn, err := w.acquireRead(ctx)
+	if err != nil {
+		span.RecordError(err)
+        span.SetStatus(codes.Error, err.Error())
+		w.logger.Error().
+			  	 Ctx(ctx).
+				 Err(err).Send()
+		return nil, fmt.Errorf("FAILED to acquire connection: %w", dbError(err))
+	}
+	defer w.DatabasePG.Release(conn)
+
+	// Query and Execute
+	query := `SELECT id,
+					 fk_product_id,
+					 channel,
+					 type,
+					 quantity,
+					 percentage,
+					 created_at,
+					 updated_at
+				FROM inventory_channel
+				WHERE fk_product_id = $1
+				order by channel`
+
+	rows, err := conn.Query(ctx,
+							query,
+							product.ID)
+	if err != nil {
+		span.RecordError(err)
+        span.SetStatus(codes.Error, err.Error())
+		w.logger.Error().
+				Ctx(ctx).
+				Err(err).Send()
+		return nil, fmt.Errorf("FAILED to query inventory_channel: %w", dbError(err))
+	}
+	defer rows.Close()
+
+	list_allocation, err := w.scanChannelAllocationList(rows)
+	if err != nil {
+		span.RecordError(err)
+        span.SetStatus(codes.Error, err.Error())
+		w.logger.Error().
+				Ctx(ctx).
+				Err(err).Send()
+		return nil, err
+	}
+
+	return list_allocation, nil
+}
+
+// About list the channel allocations of a product locking them until the end of the transaction
+func (w *WorkerRepository) ListChannelAllocationForUpdate(ctx context.Context,
+														tx service.Tx,
+														product *model.Product) (*[]model.ChannelAllocation, error){
+	w.logger.Info().
+			Ctx(ctx).
+			Str("func","ListChannelAllocationForUpdate").Send()
+
+	// Trace
+	ctx, span := w.tracerProvider.SpanCtx(ctx, "database.ListChannelAllocationForUpdate", trace.SpanKindInternal)
+	defer span.End()
+
+	// Query and Execute
+	query := `SELECT id,
+					 fk_product_id,
+					 channel,
+					 type,
+					 quantity,
+					 percentage,
+					 created_at,
+					 updated_at
+				FROM inventory_channel
+				WHERE fk_product_id = $1
+				order by channel
+				FOR UPDATE`
+
+	rows, err := pgxTx(tx).Query(ctx,
+						query,
+						product.ID)
+	if err != nil {
+		span.RecordError(err)
+        span.SetStatus(codes.Error, err.Error())
+		w.logger.Error().
+				Ctx(ctx).
+				Err(err).Send()
+		return nil, fmt.Errorf("FAILED to query inventory_channel: %w", dbError(err))
+	}
+	defer rows.Close()
+
+	list_allocation, err := w.scanChannelAllocationList(rows)
+	if err != nil {
+		span.RecordError(err)
+        span.SetStatus(codes.Error, err.Error())
+		w.logger.Error().
+				Ctx(ctx).
+				Err(err).Send()
+		return nil, err
+	}
+
+	return list_allocation, nil
+}
+
+// About add a delta to the pool of a fixed channel allocation, the pool can not become negative
+func (w* WorkerRepository) UpdateChannelAllocationQuantity(ctx context.Context,
+															tx service.Tx,
+															allocation *model.ChannelAllocation,
+															delta int) (int64, error){
+	w.logger.Info().
+			Ctx(ctx).
+			Str("func","UpdateChannelAllocationQuantity").Send()
+
+	// trace
+	ctx, span := w.tracerProvider.SpanCtx(ctx, "database.UpdateChannelAllocationQuantity", trace.SpanKindInternal)
+	defer span.End()
+
+	// Query Execute
+	query := `UPDATE inventory_channel
+				SET quantity = quantity + $2,
+					updated_at = $3
+				WHERE id = $1
+				and quantity + $2 >= 0`
+
+	row, err := pgxTx(tx).Exec(ctx,
+						query,
+						allocation.ID,
+						delta,
+						allocation.UpdatedAt)
+	if err != nil {
+		span.RecordError(err)
+        span.SetStatus(codes.Error, err.Error())
+		w.logger.Error().
+				Ctx(ctx).
+				Err(err).Send()
+		return 0, fmt.Errorf("FAILED to update inventory_channel: %w", dbError(err))
+	}
+
+	return row.RowsAffected(), nil
+}
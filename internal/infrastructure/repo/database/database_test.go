@@ -0,0 +1,564 @@
+package database
+
+import (
+	"os"
+	"fmt"
+	"net"
+	"sort"
+	"sync"
+	"time"
+	"errors"
+	"context"
+	"testing"
+	"os/exec"
+	"path/filepath"
+
+	"github.com/rs/zerolog"
+	"github.com/jackc/pgx/v5"
+	"go.opentelemetry.io/otel/trace/noop"
+
+	"github.com/go-inventory/internal/domain/model"
+	"github.com/go-inventory/internal/domain/service"
+	"github.com/go-inventory/shared/erro"
+	"github.com/go-inventory/shared/security"
+
+	go_core_db_pg "github.com/eliezerraj/go-core/v2/database/postgre"
+	go_core_otel_trace "github.com/eliezerraj/go-core/v2/otel/trace"
+)
+
+// The integration tests run against a postgres started by TestMain in a temporary directory, with the initdb and
+// pg_ctl found in the PATH or in the usual directories of the packages (/usr/lib/postgresql/<version>/bin...). They
+// are skipped when postgres is not installed. TEST_DB_HOST overrides it with a running postgres (TEST_DB_PORT,
+// TEST_DB_NAME, TEST_DB_USER and TEST_DB_PASS complete it), the database must be dedicated to the tests: the
+// migrations are applied and every table is truncated before each test. The user must not be superuser nor have
+// BYPASSRLS, otherwise the row level security is not checked
+//
+//	docker run -d -p 5432:5432 -e POSTGRES_PASSWORD=postgres postgres:16
+//	psql -h localhost -U postgres -c "CREATE ROLE inventory LOGIN PASSWORD 'inventory'" -c "CREATE DATABASE inventory_test OWNER inventory"
+//	TEST_DB_HOST=localhost TEST_DB_NAME=inventory_test TEST_DB_USER=inventory TEST_DB_PASS=inventory go test ./internal/infrastructure/repo/database/
+
+// tables of the schema, truncated before each test
+const truncateTables = `TRUNCATE product, inventory, inventory_time_series, inventory_adjustment, inventory_channel, rma, rma_event,
+						outbox, order_reservation, consumed_event, webhook, webhook_delivery, api_key, api_key_usage, audit_log
+						RESTART IDENTITY CASCADE`
+
+var (
+	testDBOnce	sync.Once
+	testDB		*go_core_db_pg.DatabasePGServer
+	testDBErr	error
+	localDBErr	error
+)
+
+// directories of the binaries of the postgres packages, when they are not in the PATH
+var postgresDirs = []string{"/usr/lib/postgresql/*/bin", "/usr/pgsql-*/bin", "/usr/local/pgsql/bin", "/opt/homebrew/opt/postgresql*/bin"}
+
+// TestMain starts the local postgres of the integration tests and stops it at the end
+func TestMain(m *testing.M) {
+	var stop func()
+	stop, localDBErr = startLocalPostgres()
+
+	code := m.Run()
+
+	if testDB != nil {
+		testDB.CloseConnection()
+	}
+	if stop != nil {
+		stop()
+	}
+	os.Exit(code)
+}
+
+// Helper function to find a binary of postgres, the newest version of the packages when not in the PATH
+func postgresBinary(name string) (string, error) {
+	if path, err := exec.LookPath(name); err == nil {
+		return path, nil
+	}
+
+	var found []string
+	for _, dir := range postgresDirs {
+		matches, _ := filepath.Glob(filepath.Join(dir, name))
+		found = append(found, matches...)
+	}
+	if len(found) == 0 {
+		return "", fmt.Errorf("%s not found, install postgres or set TEST_DB_HOST", name)
+	}
+	sort.Slice(found, func(i, j int) bool {
+		if len(found[i]) != len(found[j]) {
+			return len(found[i]) < len(found[j])
+		}
+		return found[i] < found[j]
+	})
+	return found[len(found) - 1], nil
+}
+
+// Helper function to get a free tcp port of the loopback
+func freePort() (int, error) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return 0, err
+	}
+	defer listener.Close()
+	return listener.Addr().(*net.TCPAddr).Port, nil
+}
+
+// Helper function to start a postgres in a temporary directory, with the role and the database of the tests.
+// The role is not superuser, so the row level security is checked. Nothing is started when TEST_DB_HOST is set
+func startLocalPostgres() (func(), error) {
+	if os.Getenv("TEST_DB_HOST") != "" {
+		return nil, nil
+	}
+
+	initdb, err := postgresBinary("initdb")
+	if err != nil {
+		return nil, err
+	}
+	pgCtl, err := postgresBinary("pg_ctl")
+	if err != nil {
+		return nil, err
+	}
+	if os.Geteuid() == 0 {
+		return nil, errors.New("postgres does not run as root, run the tests as another user or set TEST_DB_HOST")
+	}
+
+	port, err := freePort()
+	if err != nil {
+		return nil, fmt.Errorf("FAILED to get a free port: %w", err)
+	}
+
+	dir, err := os.MkdirTemp("", "inventory-pg-")
+	if err != nil {
+		return nil, err
+	}
+	data := filepath.Join(dir, "data")
+
+	output, err := exec.Command(initdb, "-D", data, "-U", "postgres", "--auth=trust", "--encoding=UTF8", "--no-sync").CombinedOutput()
+	if err != nil {
+		os.RemoveAll(dir)
+		return nil, fmt.Errorf("FAILED to initdb: %w: %s", err, output)
+	}
+
+	// the socket goes in the temporary directory too, the default one may not be writable
+	options := fmt.Sprintf("-p %d -c listen_addresses=127.0.0.1 -k %s -F", port, dir)
+	output, err = exec.Command(pgCtl, "-D", data, "-l", filepath.Join(dir, "postgres.log"), "-o", options, "-w", "start").CombinedOutput()
+	if err != nil {
+		os.RemoveAll(dir)
+		return nil, fmt.Errorf("FAILED to start postgres: %w: %s", err, output)
+	}
+	stop := func() {
+		exec.Command(pgCtl, "-D", data, "-m", "immediate", "-w", "stop").Run()
+		os.RemoveAll(dir)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	conn, err := pgx.Connect(ctx, fmt.Sprintf("postgres://postgres@127.0.0.1:%d/postgres?sslmode=disable", port))
+	if err != nil {
+		stop()
+		return nil, fmt.Errorf("FAILED to connect the local postgres: %w", err)
+	}
+	defer conn.Close(ctx)
+
+	for _, statement := range []string{	"CREATE ROLE inventory LOGIN PASSWORD 'inventory'",
+										"CREATE DATABASE inventory_test OWNER inventory"} {
+		if _, err := conn.Exec(ctx, statement); err != nil {
+			stop()
+			return nil, fmt.Errorf("FAILED to %s: %w", statement, err)
+		}
+	}
+
+	os.Setenv("TEST_DB_HOST", "127.0.0.1")
+	os.Setenv("TEST_DB_PORT", fmt.Sprint(port))
+	os.Setenv("TEST_DB_NAME", "inventory_test")
+	os.Setenv("TEST_DB_USER", "inventory")
+	os.Setenv("TEST_DB_PASS", "inventory")
+
+	return stop, nil
+}
+
+// Helper function to get a env var with default
+func testEnv(key string, defaultVal string) string {
+	if val := os.Getenv(key); val != "" {
+		return val
+	}
+	return defaultVal
+}
+
+// Helper function to connect the test database once and apply the pending migrations
+func connectTestDB(t *testing.T) *go_core_db_pg.DatabasePGServer {
+	t.Helper()
+
+	host := os.Getenv("TEST_DB_HOST")
+	if host == "" {
+		t.Skipf("skipping the postgres integration tests, local postgres not started: %v", localDBErr)
+	}
+
+	testDBOnce.Do(func() {
+		logger := zerolog.Nop()
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		var databasePG go_core_db_pg.DatabasePGServer
+		databasePG, testDBErr = databasePG.NewDatabasePG(ctx, go_core_db_pg.DatabaseConfig{
+			Host:				host,
+			Port:				testEnv("TEST_DB_PORT", "5432"),
+			DatabaseName:		testEnv("TEST_DB_NAME", "inventory_test"),
+			User:				testEnv("TEST_DB_USER", "inventory"),
+			Password:			testEnv("TEST_DB_PASS", "inventory"),
+			DBMaxConnection:	20,
+		}, &logger)
+		if testDBErr != nil {
+			return
+		}
+		testDB = &databasePG
+
+		migrator, err := NewMigrator(testDB, &model.MigrationConfig{LockTimeout: 10000}, &logger)
+		if err != nil {
+			testDBErr = err
+			return
+		}
+		_, testDBErr = migrator.Up(ctx)
+	})
+
+	if testDB == nil {
+		t.Skipf("postgres not available: %v", testDBErr)
+	}
+	if testDBErr != nil {
+		t.Fatalf("FAILED to prepare the test database: %v", testDBErr)
+	}
+	return testDB
+}
+
+// repository over a empty test database
+func newTestRepository(t *testing.T) *WorkerRepository {
+	t.Helper()
+
+	databasePG := connectTestDB(t)
+	if _, err := databasePG.GetConnection().Exec(context.Background(), truncateTables); err != nil {
+		t.Fatalf("FAILED to truncate the tables: %v", err)
+	}
+
+	logger := zerolog.Nop()
+	tracerProvider := go_core_otel_trace.TracerProvider{Tracer: noop.NewTracerProvider().Tracer("test")}
+	return NewWorkerRepository(databasePG, nil, nil, &logger, &tracerProvider)
+}
+
+// Helper function to skip the tests of the row level security when the user bypasses it
+func requireRowLevelSecurity(t *testing.T, repo *WorkerRepository) {
+	t.Helper()
+
+	var bypass bool
+	err := repo.DatabasePG.GetConnection().QueryRow(context.Background(),
+		`SELECT rolsuper OR rolbypassrls FROM pg_roles WHERE rolname = current_user`).Scan(&bypass)
+	if err != nil {
+		t.Fatalf("FAILED to read the role: %v", err)
+	}
+	if bypass {
+		t.Skip("the test user bypasses the row level security")
+	}
+}
+
+// Helper function to run a function within a transaction, committing when it succeeds
+func withTx(t *testing.T, repo *WorkerRepository, ctx context.Context, fn func(tx service.Tx) error) error {
+	t.Helper()
+
+	tx, err := repo.StartTx(ctx)
+	if err != nil {
+		t.Fatalf("FAILED to start transaction: %v", err)
+	}
+	if err := fn(tx); err != nil {
+		tx.Rollback(ctx)
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+// Helper function to create a product with its inventory rows, one for each available informed
+func addTestProduct(t *testing.T, repo *WorkerRepository, ctx context.Context, sku string, available ...int) *model.Product {
+	t.Helper()
+
+	product := model.Product{Sku: sku, Type: "BOOK", Name: "product " + sku, Status: model.ProductInStock, CreatedAt: time.Now()}
+	err := withTx(t, repo, ctx, func(tx service.Tx) error {
+		if _, err := repo.AddProduct(ctx, tx, &product); err != nil {
+			return err
+		}
+		for _, quantity := range available {
+			inventory := model.Inventory{Product: product, Available: quantity, CreatedAt: product.CreatedAt}
+			if _, err := repo.AddInventory(ctx, tx, &inventory); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("FAILED to add product %s: %v", sku, err)
+	}
+	return &product
+}
+
+// Helper function to read the available and the version of every inventory row of a product, by id
+func inventoryRows(t *testing.T, repo *WorkerRepository, ctx context.Context, product *model.Product) [][2]int {
+	t.Helper()
+
+	conn, err := repo.acquire(ctx)
+	if err != nil {
+		t.Fatalf("FAILED to acquire: %v", err)
+	}
+	defer repo.DatabasePG.Release(conn)
+
+	rows, err := conn.Query(ctx, `SELECT available, version FROM inventory WHERE fk_product_id = $1 ORDER BY id`, product.ID)
+	if err != nil {
+		t.Fatalf("FAILED to query inventory: %v", err)
+	}
+	defer rows.Close()
+
+	list := [][2]int{}
+	for rows.Next() {
+		var available, version int
+		if err := rows.Scan(&available, &version); err != nil {
+			t.Fatalf("FAILED to scan inventory: %v", err)
+		}
+		list = append(list, [2]int{available, version})
+	}
+	return list
+}
+
+func TestIntegrationProduct(t *testing.T) {
+	repo := newTestRepository(t)
+	ctx := security.WithTenant(context.Background(), "acme")
+	addTestProduct(t, repo, ctx, "sku-1", 100)
+
+	tests := []struct {
+		name		string
+		sku			string
+		wantErr		error
+	}{
+		{name: "existing", sku: "sku-1"},
+		{name: "missing", sku: "sku-9", wantErr: erro.ErrNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			product, err := repo.GetProduct(ctx, &model.Product{Sku: tt.sku})
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("got error %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if product.Sku != tt.sku || product.Version != 1 {
+				t.Errorf("unexpected product: %+v", product)
+			}
+		})
+	}
+
+	t.Run("duplicate", func(t *testing.T) {
+		err := withTx(t, repo, ctx, func(tx service.Tx) error {
+			_, err := repo.AddProduct(ctx, tx, &model.Product{Sku: "sku-1", Type: "BOOK", Name: "again", Status: model.ProductInStock, CreatedAt: time.Now()})
+			return err
+		})
+		if !errors.Is(err, erro.ErrDuplicateKey) {
+			t.Fatalf("got error %v, want %v", err, erro.ErrDuplicateKey)
+		}
+	})
+}
+
+func TestIntegrationRowLevelSecurity(t *testing.T) {
+	repo := newTestRepository(t)
+	requireRowLevelSecurity(t, repo)
+	owner := security.WithTenant(context.Background(), "acme")
+	product := addTestProduct(t, repo, owner, "sku-1", 100)
+	err := withTx(t, repo, owner, func(tx service.Tx) error {
+		_, err := repo.UpsertChannelAllocation(owner, tx, &model.ChannelAllocation{	Product: *product,
+																					Channel: "web",
+																					Type: model.AllocationFixed,
+																					Quantity: 10,
+																					CreatedAt: time.Now()})
+		return err
+	})
+	if err != nil {
+		t.Fatalf("FAILED to add channel allocation: %v", err)
+	}
+
+	tests := []struct {
+		name		string
+		tenant		string
+		wantErr		error
+	}{
+		{name: "owner", tenant: "acme"},
+		{name: "other tenant", tenant: "globex", wantErr: erro.ErrNotFound},
+		{name: "system tenant", tenant: security.SystemTenant},
+		{name: "without tenant", tenant: "", wantErr: erro.ErrNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := security.WithTenant(context.Background(), tt.tenant)
+
+			// the allocations are scoped by the tenant as well, not only through the product
+			list_allocation, err := repo.ListChannelAllocation(ctx, product)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			want := 1
+			if tt.wantErr != nil {
+				want = 0
+			}
+			if len(*list_allocation) != want {
+				t.Errorf("got %d channel allocations, want %d", len(*list_allocation), want)
+			}
+
+			_, err = repo.GetInventory(ctx, &model.Inventory{Product: model.Product{Sku: "sku-1"}})
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("got error %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+// a row locked by a transaction is skipped by the others, which update the next row or nothing, without waiting
+func TestIntegrationUpdateInventorySkipLocked(t *testing.T) {
+	repo := newTestRepository(t)
+	ctx := security.WithTenant(context.Background(), "acme")
+	product := addTestProduct(t, repo, ctx, "sku-1", 100, 100)
+
+	// every update must answer at once, a wait for the lock would hit the deadline
+	lockCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	update := func(tx service.Tx) int64 {
+		now := time.Now()
+		row, err := repo.UpdateInventory(lockCtx, tx, &model.Inventory{Product: *product, Available: -1, UpdatedAt: &now})
+		if err != nil {
+			t.Fatalf("FAILED to update inventory: %v", err)
+		}
+		return row
+	}
+
+	var txs []service.Tx
+	for _, want := range []int64{1, 1, 0} {
+		tx, err := repo.StartTx(lockCtx)
+		if err != nil {
+			t.Fatalf("FAILED to start transaction: %v", err)
+		}
+		txs = append(txs, tx)
+
+		if row := update(tx); row != want {
+			t.Errorf("transaction %d updated %d rows, want %d", len(txs), row, want)
+		}
+	}
+	for _, tx := range txs {
+		if err := tx.Commit(lockCtx); err != nil {
+			t.Fatalf("FAILED to commit: %v", err)
+		}
+	}
+
+	got := inventoryRows(t, repo, ctx, product)
+	want := [][2]int{{99, 2}, {99, 2}}
+	if len(got) != len(want) || got[0] != want[0] || got[1] != want[1] {
+		t.Errorf("got inventory rows %v, want %v", got, want)
+	}
+}
+
+// the relays running at the same time get disjoint batches of the outbox
+func TestIntegrationOutboxSkipLocked(t *testing.T) {
+	repo := newTestRepository(t)
+	ctx := security.WithTenant(context.Background(), "acme")
+
+	err := withTx(t, repo, ctx, func(tx service.Tx) error {
+		for range 5 {
+			event := model.OutboxEvent{	EventID: time.Now().Format(time.RFC3339Nano), Type: model.EventTypeProductCreated, Source: "test",
+										Data: []byte(`{}`), Status: model.OutboxPending, CreatedAt: time.Now().Add(-time.Second)}
+			if _, err := repo.AddOutboxEvent(ctx, tx, &event); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("FAILED to add outbox events: %v", err)
+	}
+
+	systemCtx := security.WithTenant(context.Background(), security.SystemTenant)
+	seen := map[int]bool{}
+	for _, want := range []int{2, 2, 1, 0} {
+		tx, err := repo.StartTx(systemCtx)
+		if err != nil {
+			t.Fatalf("FAILED to start transaction: %v", err)
+		}
+		defer tx.Rollback(systemCtx)
+
+		list_event, err := repo.ListOutboxPendingForUpdate(systemCtx, tx, 2)
+		if err != nil {
+			t.Fatalf("FAILED to list the outbox: %v", err)
+		}
+		if len(*list_event) != want {
+			t.Errorf("got a batch of %d events, want %d", len(*list_event), want)
+		}
+		for _, event := range *list_event {
+			if seen[event.ID] {
+				t.Errorf("event %d locked by two relays", event.ID)
+			}
+			seen[event.ID] = true
+		}
+	}
+}
+
+// the concurrent updates of the service on a sku never wait each other nor get lost: each one updates a row it locked
+// (one more version) or, when every row is locked, inserts a new row
+func TestIntegrationUpdateInventoryConcurrent(t *testing.T) {
+	repo := newTestRepository(t)
+	ctx := security.WithTenant(context.Background(), "acme")
+	product := addTestProduct(t, repo, ctx, "sku-1", 1000)
+
+	logger := zerolog.Nop()
+	appServer := model.AppServer{
+		Application:		&model.Application{Name: "go-inventory"},
+		Server:				&model.Server{CtxTimeout: 5, StreamBufferSize: 16},
+		InventoryConfig:	&model.InventoryConfig{},
+		AuditConfig:		&model.AuditConfig{SealInterval: 1000, BatchSize: 500},
+	}
+	tracerProvider := go_core_otel_trace.TracerProvider{Tracer: noop.NewTracerProvider().Tracer("test")}
+	workerService := service.NewWorkerService(&appServer, repo, nil, &logger, &tracerProvider)
+
+	const workers = 20
+	updateCtx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	var wg sync.WaitGroup
+	errs := make(chan error, workers)
+	for range workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := workerService.UpdateInventory(updateCtx, &model.Inventory{Product: model.Product{Sku: "sku-1"}, Available: -1, Sold: 1})
+			errs <- err
+		}()
+	}
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	rows := inventoryRows(t, repo, ctx, product)
+	applied := len(rows) - 1
+	for _, row := range rows {
+		applied += row[1] - 1
+	}
+	if applied != workers {
+		t.Errorf("got %d updates applied over %d rows, want %d", applied, len(rows), workers)
+	}
+}
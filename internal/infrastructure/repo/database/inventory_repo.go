@@ -3,21 +3,62 @@ package database
 import (
 	"context"
 	"fmt"
+	"errors"
 	"database/sql"
 
 	"github.com/jackc/pgx/v5"
 	
 	"github.com/go-inventory/shared/erro"
 	"github.com/go-inventory/internal/domain/model"
+	"github.com/go-inventory/internal/domain/service"
 
 	"go.opentelemetry.io/otel/trace"
 	"go.opentelemetry.io/otel/codes"
 )
 
+// Helper function to scan a inventory joined with its product from rows iterator
+func (w *WorkerRepository) scanProductInventoryFromRows(rows pgx.Rows) (*model.Inventory, error) {
+	res_product := model.Product{}
+	res_inventory := model.Inventory{}
+	var nullProductUpdatedAt sql.NullTime
+	var nullInventoryUpdatedAt sql.NullTime
+
+	err := rows.Scan(&res_product.ID, 
+					&res_product.Sku, 
+					&res_product.Type,
+					&res_product.Name,
+					&res_product.Status,
+					&res_product.LeadTime,
+					&res_product.Version,
+					&res_product.CreatedAt,
+					&nullProductUpdatedAt,
+					&res_inventory.ID, 
+					&res_inventory.Available, 
+					&res_inventory.Pending,
+					&res_inventory.Reserved, 
+					&res_inventory.Sold,
+					&res_inventory.Quarantine,
+					&res_inventory.Damaged,
+					&res_inventory.Hold,
+					&res_inventory.Version,
+					&res_inventory.CreatedAt,
+					&nullInventoryUpdatedAt,
+				)
+	if err != nil {
+		return nil, fmt.Errorf("FAILED to scan inventory from rows: %w", dbError(err))
+	}
+
+	res_product.UpdatedAt = w.pointerTime(nullProductUpdatedAt)
+	res_inventory.UpdatedAt = w.pointerTime(nullInventoryUpdatedAt)
+	res_inventory.Product = res_product
+
+	return &res_inventory, nil
+}
+
 //--------------------------------------
 // About create a Inventory
 func (w* WorkerRepository) AddInventory(ctx context.Context, 
-										tx pgx.Tx, 
+										tx service.Tx, 
 										inventory *model.Inventory) (*model.Inventory, error){
 	w.logger.Info().
 			Ctx(ctx).
@@ -36,16 +77,22 @@ func (w* WorkerRepository) AddInventory(ctx context.Context,
 										pending,
 										reserved,
 										sold,
+										quarantine,
+										damaged,
+										hold,
 										created_at) 
-				VALUES($1, $2, $3, $4, $5, $6) RETURNING id`
+				VALUES($1, $2, $3, $4, $5, $6, $7, $8, $9) RETURNING id`
 
-	row := tx.QueryRow(	ctx, 
+	row := pgxTx(tx).QueryRow(	ctx, 
 						query,
 						inventory.Product.ID,
 						inventory.Available, 
 						inventory.Pending,
 						inventory.Reserved,
 						inventory.Sold,
+						inventory.Quarantine,
+						inventory.Damaged,
+						inventory.Hold,
 						inventory.CreatedAt)
 						
 	if err := row.Scan(&id); err != nil {
@@ -54,7 +101,7 @@ func (w* WorkerRepository) AddInventory(ctx context.Context,
 		w.logger.Error().
 				Ctx(ctx).
 				Err(err).Send()
-		return nil, fmt.Errorf("FAILED to insert inventory: %w", err)
+		return nil, fmt.Errorf("FAILED to insert inventory: %w", dbError(err))
 	}
 
 	// Set PK
@@ -75,23 +122,17 @@ func (w *WorkerRepository) GetInventory(ctx context.Context,
 	defer span.End()
 
 	// db connection
-	conn, err := w.DatabasePG.Acquire(ctx)
+	conn, err := w.acquireRead(ctx)
 	if err != nil {
 		span.RecordError(err) 
         span.SetStatus(codes.Error, err.Error())
 		w.logger.Error().
 				Ctx(ctx).
 				Err(err).Send()
-		return nil, fmt.Errorf("FAILED to acquire connection: %w", err)
+		return nil, fmt.Errorf("FAILED to acquire connection: %w", dbError(err))
 	}
 	defer w.DatabasePG.Release(conn)
 
-	// Prepare
-	res_product := model.Product{}
-	res_inventory := model.Inventory{}
-	var nullProductUpdatedAt sql.NullTime
-	var nullInventoryUpdatedAt sql.NullTime
-
 	// Query and Execute
 	query := `SELECT p.id, 
 					 p.sku, 
@@ -99,6 +140,7 @@ func (w *WorkerRepository) GetInventory(ctx context.Context,
 					 p.name,
 					 p.status,
 					 p.lead_time,
+					 p.version,
 					 p.created_at, 
 					 p.updated_at,
 					 i.id,
@@ -106,12 +148,17 @@ func (w *WorkerRepository) GetInventory(ctx context.Context,
 					 i.pending,
 					 i.reserved,
 					 i.sold,
+					 i.quarantine,
+					 i.damaged,
+					 i.hold,
+					 i.version,
 					 i.created_at,
 					 i.updated_at
 				FROM product as p,
 					 inventory as i
 				WHERE sku =$1
-				and p.id = i.fk_product_id`
+				and p.id = i.fk_product_id
+				order by i.id`
 
 	rows, err := conn.Query(ctx, 
 							query, 
@@ -122,40 +169,176 @@ func (w *WorkerRepository) GetInventory(ctx context.Context,
 		w.logger.Error().
 				Ctx(ctx).
 				Err(err).Send()
-		return nil, fmt.Errorf("FAILED to query inventory: %w", err)
+		return nil, fmt.Errorf("FAILED to query inventory: %w", dbError(err))
 	}
 	defer rows.Close()
 
 	if rows.Next() {
-		err := rows.Scan(&res_product.ID, 
-						&res_product.Sku, 
-						&res_product.Type,
-						&res_product.Name,
-						&res_product.Status,
-						&res_product.LeadTime,
-						&res_product.CreatedAt,
-						&nullProductUpdatedAt,
-						&res_inventory.ID, 
-						&res_inventory.Available, 
-						&res_inventory.Pending,
-						&res_inventory.Reserved, 
-						&res_inventory.Sold,
-						&res_inventory.CreatedAt,
-						&nullInventoryUpdatedAt,
-					)
+		res_inventory, err := w.scanProductInventoryFromRows(rows)
+		if err != nil {
+			span.RecordError(err) 
+        	span.SetStatus(codes.Error, err.Error())
+			w.logger.Error().
+					Ctx(ctx).
+					Err(err).Send()
+			return nil, fmt.Errorf("FAILED to scan inventory row: %w", dbError(err))
+		}
+		return res_inventory, nil
+	}
+
+	w.logger.Warn().
+			Ctx(ctx).
+			Err(erro.ErrNotFound).Send()
+
+	return nil, erro.ErrNotFound
+}
+
+// About get the inventories of several products at once (batch loading), the first inventory of each sku
+func (w *WorkerRepository) ListInventoryBySku(ctx context.Context, 
+											skus []string) (*[]model.Inventory, error){
+	w.logger.Info().
+			Ctx(ctx).
+			Str("func","ListInventoryBySku").Send()
+			
+	// Trace
+	ctx, span := w.tracerProvider.SpanCtx(ctx, "database.ListInventoryBySku", trace.SpanKindInternal)
+	defer span.End()
+
+	// db connection
+	conn, err := w.acquireRead(ctx)
+	if err != nil {
+		span.RecordError(err) 
+        span.SetStatus(codes.Error, err.Error())
+		w.logger.Error().
+				Ctx(ctx).
+				Err(err).Send()
+		return nil, fmt.Errorf("FAILED to acquire connection: %w", dbError(err))
+	}
+	defer w.DatabasePG.Release(conn)
+
+	// Query and Execute
+	query := `SELECT DISTINCT ON (p.sku)
+					 p.id, 
+					 p.sku, 
+					 p.type,
+					 p.name,
+					 p.status,
+					 p.lead_time,
+					 p.version,
+					 p.created_at, 
+					 p.updated_at,
+					 i.id,
+					 i.available,
+					 i.pending,
+					 i.reserved,
+					 i.sold,
+					 i.quarantine,
+					 i.damaged,
+					 i.hold,
+					 i.version,
+					 i.created_at,
+					 i.updated_at
+				FROM product as p,
+					 inventory as i
+				WHERE p.sku = ANY($1)
+				and p.id = i.fk_product_id
+				order by p.sku, i.id`
+
+	rows, err := conn.Query(ctx, 
+							query, 
+							skus)
+	if err != nil {
+		span.RecordError(err) 
+        span.SetStatus(codes.Error, err.Error())		
+		w.logger.Error().
+				Ctx(ctx).
+				Err(err).Send()
+		return nil, fmt.Errorf("FAILED to query inventory: %w", dbError(err))
+	}
+	defer rows.Close()
+
+	list_inventory := []model.Inventory{}
+	for rows.Next() {
+		res_inventory, err := w.scanProductInventoryFromRows(rows)
 		if err != nil {
 			span.RecordError(err) 
         	span.SetStatus(codes.Error, err.Error())
 			w.logger.Error().
 					Ctx(ctx).
 					Err(err).Send()
-			return nil, fmt.Errorf("FAILED to scan inventory row: %w", err)
+			return nil, fmt.Errorf("FAILED to scan inventory row: %w", dbError(err))
 		}
+		list_inventory = append(list_inventory, *res_inventory)
+	}
+
+	return &list_inventory, nil
+}
+
+// About get a Inventory locking its row until the end of the transaction
+func (w *WorkerRepository) GetInventoryForUpdate(ctx context.Context, 
+												tx service.Tx,
+												inventory *model.Inventory) (*model.Inventory, error){
+	w.logger.Info().
+			Ctx(ctx).
+			Str("func","GetInventoryForUpdate").Send()
+			
+	// Trace
+	ctx, span := w.tracerProvider.SpanCtx(ctx, "database.GetInventoryForUpdate", trace.SpanKindInternal)
+	defer span.End()
+
+	// Query and Execute
+	query := `SELECT p.id, 
+					 p.sku, 
+					 p.type,
+					 p.name,
+					 p.status,
+					 p.lead_time,
+					 p.version,
+					 p.created_at, 
+					 p.updated_at,
+					 i.id,
+					 i.available,
+					 i.pending,
+					 i.reserved,
+					 i.sold,
+					 i.quarantine,
+					 i.damaged,
+					 i.hold,
+					 i.version,
+					 i.created_at,
+					 i.updated_at
+				FROM product as p,
+					 inventory as i
+				WHERE sku =$1
+				and p.id = i.fk_product_id
+				order by i.id
+				limit 1
+				FOR UPDATE OF i`
+
+	rows, err := pgxTx(tx).Query(ctx, 
+						query, 
+						inventory.Product.Sku)
+	if err != nil {
+		span.RecordError(err) 
+        span.SetStatus(codes.Error, err.Error())		
+		w.logger.Error().
+				Ctx(ctx).
+				Err(err).Send()
+		return nil, fmt.Errorf("FAILED to query inventory: %w", dbError(err))
+	}
+	defer rows.Close()
 
-		res_product.UpdatedAt = w.pointerTime(nullProductUpdatedAt)
-		res_inventory.UpdatedAt = w.pointerTime(nullInventoryUpdatedAt)
-		res_inventory.Product = res_product
-		return &res_inventory, nil
+	if rows.Next() {
+		res_inventory, err := w.scanProductInventoryFromRows(rows)
+		if err != nil {
+			span.RecordError(err) 
+        	span.SetStatus(codes.Error, err.Error())
+			w.logger.Error().
+					Ctx(ctx).
+					Err(err).Send()
+			return nil, fmt.Errorf("FAILED to scan inventory row: %w", dbError(err))
+		}
+		return res_inventory, nil
 	}
 
 	w.logger.Warn().
@@ -165,9 +348,117 @@ func (w *WorkerRepository) GetInventory(ctx context.Context,
 	return nil, erro.ErrNotFound
 }
 
+// About set the absolute available quantity of a Inventory row
+func (w* WorkerRepository) SetInventoryAvailable(ctx context.Context, 
+												tx service.Tx, 
+												inventory *model.Inventory) (int64, error){
+
+	w.logger.Info().
+			Ctx(ctx).
+			Str("func","SetInventoryAvailable").Send()
+
+	// trace
+	ctx, span := w.tracerProvider.SpanCtx(ctx, "database.SetInventoryAvailable", trace.SpanKindInternal)
+	defer span.End()
+
+	// Query Execute
+	query := `UPDATE inventory
+				SET available = $3,
+					updated_at = $2,
+					version = version + 1
+				WHERE id = $1
+				RETURNING version`
+
+	row := pgxTx(tx).QueryRow(	ctx, 
+						query,	
+						inventory.ID,
+						inventory.UpdatedAt,		
+						inventory.Available,
+					)
+
+	if err := row.Scan(&inventory.Version); err != nil {
+		// no row means nothing was updated
+		if errors.Is(err, pgx.ErrNoRows) {
+			return 0, nil
+		}
+		span.RecordError(err) 
+        span.SetStatus(codes.Error, err.Error())
+		w.logger.Error().
+				Ctx(ctx).
+				Err(err).Send()
+		return 0, fmt.Errorf("FAILED to set inventory available: %w", dbError(err))
+	}
+
+	return 1, nil
+}
+
+// Stock buckets that can be moved and their columns
+var bucketColumns = map[string]string{
+	model.BucketAvailable:	"available",
+	model.BucketQuarantine:	"quarantine",
+	model.BucketDamaged:	"damaged",
+	model.BucketHold:		"hold",
+	model.BucketSold:		"sold",
+	model.BucketReserved:	"reserved",
+}
+
+// About move a quantity between two stock buckets of a Inventory row, the source bucket can not become negative
+func (w* WorkerRepository) TransferInventory(ctx context.Context, 
+											tx service.Tx, 
+											inventory *model.Inventory,
+											transfer *model.InventoryTransfer) (int64, error){
+
+	w.logger.Info().
+			Ctx(ctx).
+			Str("func","TransferInventory").Send()
+
+	// trace
+	ctx, span := w.tracerProvider.SpanCtx(ctx, "database.TransferInventory", trace.SpanKindInternal)
+	defer span.End()
+
+	// the column names come only from the known buckets
+	from, okFrom := bucketColumns[transfer.From]
+	to, okTo := bucketColumns[transfer.To]
+	if !okFrom || !okTo {
+		return 0, erro.ErrBadRequest
+	}
+
+	// Query Execute
+	query := fmt.Sprintf(`UPDATE inventory
+							SET %[1]s = %[1]s - $3,
+								%[2]s = %[2]s + $3,
+								updated_at = $2,
+								version = version + 1
+							WHERE id = $1
+							and %[1]s >= $3
+							RETURNING version`, from, to)
+
+	row := pgxTx(tx).QueryRow(	ctx, 
+						query,	
+						inventory.ID,
+						inventory.UpdatedAt,		
+						transfer.Quantity,
+					)
+
+	if err := row.Scan(&inventory.Version); err != nil {
+		// no row means the source bucket has not enough stock
+		if errors.Is(err, pgx.ErrNoRows) {
+			return 0, nil
+		}
+		span.RecordError(err) 
+        span.SetStatus(codes.Error, err.Error())
+		w.logger.Error().
+				Ctx(ctx).
+				Err(err).Send()
+		return 0, fmt.Errorf("FAILED to transfer inventory: %w", dbError(err))
+	}
+
+	return 1, nil
+}
+
 // About update a Inventory
 func (w* WorkerRepository) UpdateInventory(ctx context.Context, 
-											tx pgx.Tx, 
+											tx service.Tx, 
 											inventory *model.Inventory) (int64, error){
 
 	w.logger.Info().
@@ -184,15 +475,17 @@ func (w* WorkerRepository) UpdateInventory(ctx context.Context,
 					reserved = reserved + $4,
 					pending = pending + $6,
 					sold = sold + $5,
-					updated_at = $2
+					updated_at = $2,
+					version = version + 1
 				WHERE id = (SELECT id 
 							FROM inventory
 							WHERE fk_product_id = $1
 							ORDER BY id
 							FOR UPDATE SKIP LOCKED 
-							LIMIT 1)`
+							LIMIT 1)
+				RETURNING version`
 
-	row, err := tx.Exec(ctx, 
+	row := pgxTx(tx).QueryRow(	ctx, 
 						query,	
 						inventory.Product.ID,
 						inventory.UpdatedAt,		
@@ -201,16 +494,73 @@ func (w* WorkerRepository) UpdateInventory(ctx context.Context,
 						inventory.Sold,
 						inventory.Pending,
 					)
-	if err != nil {
+
+	if err := row.Scan(&inventory.Version); err != nil {
+		// no row means nothing was updated
+		if errors.Is(err, pgx.ErrNoRows) {
+			return 0, nil
+		}
+		span.RecordError(err) 
+        span.SetStatus(codes.Error, err.Error())
+		w.logger.Error().
+				Ctx(ctx).
+				Err(err).Send()
+		return 0, fmt.Errorf("FAILED to update inventory: %w", dbError(err))
+	}
+
+	return 1, nil
+}
+
+// About update a Inventory only if the row still has the version informed (optimistic lock)
+func (w* WorkerRepository) UpdateInventoryIfMatch(ctx context.Context, 
+													tx service.Tx, 
+													inventory *model.Inventory) (int64, error){
+
+	w.logger.Info().
+			Ctx(ctx).
+			Str("func","UpdateInventoryIfMatch").Send()
+
+	// trace
+	ctx, span := w.tracerProvider.SpanCtx(ctx, "database.UpdateInventoryIfMatch", trace.SpanKindInternal)
+	defer span.End()
+
+	// Query Execute
+	query := `UPDATE inventory
+				SET available = available + $3,
+					reserved = reserved + $4,
+					pending = pending + $6,
+					sold = sold + $5,
+					updated_at = $2,
+					version = version + 1
+				WHERE id = $1
+				and version = $7
+				RETURNING version`
+
+	row := pgxTx(tx).QueryRow(	ctx, 
+						query,	
+						inventory.ID,
+						inventory.UpdatedAt,		
+						inventory.Available,
+						inventory.Reserved,
+						inventory.Sold,
+						inventory.Pending,
+						inventory.Version,
+					)
+
+	if err := row.Scan(&inventory.Version); err != nil {
+		// no row means nothing was updated
+		if errors.Is(err, pgx.ErrNoRows) {
+			return 0, nil
+		}
 		span.RecordError(err) 
         span.SetStatus(codes.Error, err.Error())
 		w.logger.Error().
 				Ctx(ctx).
 				Err(err).Send()
-		return 0, fmt.Errorf("FAILED to update inventory: %w", err)
+		return 0, fmt.Errorf("FAILED to update inventory: %w", dbError(err))
 	}
 
-	return row.RowsAffected(), nil
+	return 1, nil
 }
 
 // About get a product
@@ -227,14 +577,14 @@ func (w *WorkerRepository) ListInventory(ctx context.Context,
 	defer span.End()
 
 	// db connection
-	conn, err := w.DatabasePG.Acquire(ctx)
+	conn, err := w.acquireRead(ctx)
 	if err != nil {
 		span.RecordError(err) 
         span.SetStatus(codes.Error, err.Error())
 		w.logger.Error().
 			  	 Ctx(ctx).
 				 Err(err).Send()
-		return nil, fmt.Errorf("FAILED to acquire connection: %w", err)
+		return nil, fmt.Errorf("FAILED to acquire connection: %w", dbError(err))
 	}
 	defer w.DatabasePG.Release(conn)
 
@@ -245,6 +595,7 @@ func (w *WorkerRepository) ListInventory(ctx context.Context,
 					 p.name,
 					 p.status,
 					 p.lead_time,
+					 p.version,
 					 p.created_at, 
 					 p.updated_at,
 					 i.id,
@@ -252,6 +603,10 @@ func (w *WorkerRepository) ListInventory(ctx context.Context,
 					 i.pending,
 					 i.reserved,
 					 i.sold,
+					 i.quarantine,
+					 i.damaged,
+					 i.hold,
+					 i.version,
 					 i.created_at,
 					 i.updated_at
 				FROM product as p,
@@ -272,49 +627,24 @@ func (w *WorkerRepository) ListInventory(ctx context.Context,
 		w.logger.Error().
 				Ctx(ctx).
 				Err(err).Send()
-		return nil, fmt.Errorf("FAILED to query inventory: %w", err)
+		return nil, fmt.Errorf("FAILED to query inventory: %w", dbError(err))
 	}
 	defer rows.Close()
 
 	list_inventory := []model.Inventory{}
 
-	var nullProductUpdatedAt sql.NullTime
-	var nullInventoryUpdatedAt sql.NullTime
-
 	for rows.Next() {
-		res_product := model.Product{}
-		res_inventory := model.Inventory{}
-
-		err := rows.Scan(&res_product.ID, 
-						&res_product.Sku, 
-						&res_product.Type,
-						&res_product.Name,
-						&res_product.Status,
-						&res_product.LeadTime,
-						&res_product.CreatedAt,
-						&nullProductUpdatedAt,
-						&res_inventory.ID, 
-						&res_inventory.Available, 
-						&res_inventory.Pending,
-						&res_inventory.Reserved, 
-						&res_inventory.Sold,
-						&res_inventory.CreatedAt,
-						&nullInventoryUpdatedAt,
-					)
+		res_inventory, err := w.scanProductInventoryFromRows(rows)
 		if err != nil {
 			span.RecordError(err) 
         	span.SetStatus(codes.Error, err.Error())
 			w.logger.Error().
 					Ctx(ctx).
 					Err(err).Send()
-			return nil, fmt.Errorf("FAILED to scan inventory row: %w", err)
+			return nil, fmt.Errorf("FAILED to scan inventory row: %w", dbError(err))
 		}
 
-		res_product.UpdatedAt = w.pointerTime(nullProductUpdatedAt)
-		res_inventory.UpdatedAt = w.pointerTime(nullInventoryUpdatedAt)
-		res_inventory.Product = res_product
-
-		list_inventory = append(list_inventory, res_inventory)
+		list_inventory = append(list_inventory, *res_inventory)
 	}
 	
 	if len(list_inventory) > 0 {
@@ -0,0 +1,504 @@
+package database
+
+import (
+	"fmt"
+	"sort"
+	"time"
+	"embed"
+	"regexp"
+	"context"
+	"strconv"
+	"strings"
+	"io/fs"
+	"path"
+	"crypto/sha256"
+	"encoding/hex"
+
+	"github.com/rs/zerolog"
+	"github.com/jackc/pgx/v5"
+
+	"github.com/go-inventory/internal/domain/model"
+
+	go_core_db_pg "github.com/eliezerraj/go-core/v2/database/postgre"
+)
+
+// versioned migrations of the schema, <version>_<name>.up.sql applies a version and <version>_<name>.down.sql reverts it
+//go:embed migrations/*.sql
+var migrationFiles embed.FS
+
+// key of the advisory lock of the migrations, only one process changes the schema at a time
+const migrationLock = 7403
+
+// version of a database created by hand before the migrations existed, recorded by Baseline
+const baselineVersion = 1
+
+// tables of the baseline version with a tenant_id and the row level security, a database adopted as the baseline
+// must have all of them
+var baselineTables = []string{"product", "inventory", "inventory_time_series", "inventory_channel", "inventory_adjustment",
+							"rma", "rma_event", "outbox", "order_reservation", "consumed_event", "webhook", "webhook_delivery",
+							"api_key", "audit_log"}
+
+// table of the versions applied, it has no tenant
+const migrationTable = `CREATE TABLE IF NOT EXISTS public.schema_migration (
+							version		INT 		NOT NULL,
+							name		VARCHAR(255) NOT NULL,
+							checksum	VARCHAR(64) NOT NULL,
+							applied_at	timestamptz NOT NULL,
+							CONSTRAINT schema_migration_pkey PRIMARY KEY (version)
+						)`
+
+var migrationFileName = regexp.MustCompile(`^(\d+)_(\w+)\.(up|down)\.sql$`)
+
+// migration is a version of the schema, the checksum of the up script detects a migration changed after applied
+type migration struct {
+	version		int
+	name		string
+	up			string
+	down		string
+	checksum	string
+}
+
+// appliedMigration is a row of schema_migration
+type appliedMigration struct {
+	version		int
+	name		string
+	checksum	string
+	appliedAt	time.Time
+}
+
+// querier is the pool or a transaction, the reads of the migrator run on both
+type querier interface {
+	QueryRow(context.Context, string, ...any) pgx.Row
+	Query(context.Context, string, ...any) (pgx.Rows, error)
+}
+
+// Migrator applies the migrations embedded in the binary. The migrations of a call run in a single transaction
+// holding the advisory lock, so a failure leaves the schema as it was and concurrent pods wait for each other
+type Migrator struct {
+	databasePG		*go_core_db_pg.DatabasePGServer
+	migrationConfig	*model.MigrationConfig
+	migrations		[]migration
+	logger			*zerolog.Logger
+}
+
+// Above new migrator
+func NewMigrator(databasePG *go_core_db_pg.DatabasePGServer,
+				migrationConfig *model.MigrationConfig,
+				appLogger *zerolog.Logger) (*Migrator, error){
+	logger := appLogger.With().
+						Str("package", "repo.database").
+						Logger()
+	logger.Info().
+			Str("func","NewMigrator").Send()
+
+	migrations, err := loadMigrations(migrationFiles, "migrations")
+	if err != nil {
+		return nil, err
+	}
+
+	return &Migrator{
+		databasePG: databasePG,
+		migrationConfig: migrationConfig,
+		migrations: migrations,
+		logger: &logger,
+	}, nil
+}
+
+// Helper function to read the migrations of a directory in the order of their versions. Every version has its up
+// and down scripts and the versions follow each other from 1
+func loadMigrations(fsys fs.FS, dir string) ([]migration, error) {
+	entries, err := fs.ReadDir(fsys, dir)
+	if err != nil {
+		return nil, fmt.Errorf("FAILED to read the migrations: %w", err)
+	}
+
+	byVersion := map[int]*migration{}
+	for _, entry := range entries {
+		match := migrationFileName.FindStringSubmatch(entry.Name())
+		if match == nil {
+			return nil, fmt.Errorf("invalid migration file %s: expected <version>_<name>.up.sql or .down.sql", entry.Name())
+		}
+
+		version, err := strconv.Atoi(match[1])
+		if err != nil || version <= 0 {
+			return nil, fmt.Errorf("invalid migration file %s: version must be greater than zero", entry.Name())
+		}
+
+		script, err := fs.ReadFile(fsys, path.Join(dir, entry.Name()))
+		if err != nil {
+			return nil, fmt.Errorf("FAILED to read the migration %s: %w", entry.Name(), err)
+		}
+
+		m, ok := byVersion[version]
+		if !ok {
+			m = &migration{version: version, name: match[2]}
+			byVersion[version] = m
+		}
+		if m.name != match[2] {
+			return nil, fmt.Errorf("invalid migration file %s: version %d is also named %s", entry.Name(), version, m.name)
+		}
+
+		if match[3] == "up" {
+			m.up = string(script)
+			sum := sha256.Sum256(script)
+			m.checksum = hex.EncodeToString(sum[:])
+		} else {
+			m.down = string(script)
+		}
+	}
+
+	migrations := make([]migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		if m.up == "" || m.down == "" {
+			return nil, fmt.Errorf("invalid migration %d_%s: both up and down scripts are required", m.version, m.name)
+		}
+		migrations = append(migrations, *m)
+	}
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].version < migrations[j].version })
+
+	for i, m := range migrations {
+		if m.version != i + 1 {
+			return nil, fmt.Errorf("invalid migration %d_%s: expected version %d", m.version, m.name, i + 1)
+		}
+	}
+
+	return migrations, nil
+}
+
+// About the version of the last migration embedded in the binary
+func (m *Migrator) Latest() int {
+	return len(m.migrations)
+}
+
+// Helper function to read the migrations applied. A database with the tables but no migration recorded was created
+// before the migrations, its version is unknown until it is adopted with Baseline
+func (m *Migrator) applied(ctx context.Context, q querier) (map[int]appliedMigration, error) {
+	var hasTable, hasSchema bool
+	err := q.QueryRow(ctx, `SELECT to_regclass('public.schema_migration') IS NOT NULL,
+									to_regclass('public.product') IS NOT NULL`).Scan(&hasTable, &hasSchema)
+	if err != nil {
+		return nil, fmt.Errorf("FAILED to read the schema: %w", dbError(err))
+	}
+
+	applied := map[int]appliedMigration{}
+	if hasTable {
+		if err := m.scanApplied(ctx, q, applied); err != nil {
+			return nil, err
+		}
+	}
+	if len(applied) == 0 && hasSchema {
+		return nil, fmt.Errorf("schema has the tables but no migration recorded, run migrate baseline to adopt it as the version %d", baselineVersion)
+	}
+
+	return applied, nil
+}
+
+// Helper function to scan the rows of schema_migration
+func (m *Migrator) scanApplied(ctx context.Context, q querier, applied map[int]appliedMigration) error {
+	rows, err := q.Query(ctx, `SELECT version, name, checksum, applied_at FROM schema_migration ORDER BY version`)
+	if err != nil {
+		return fmt.Errorf("FAILED to query schema_migration: %w", dbError(err))
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		a := appliedMigration{}
+		if err := rows.Scan(&a.version, &a.name, &a.checksum, &a.appliedAt); err != nil {
+			return fmt.Errorf("FAILED to scan schema_migration: %w", dbError(err))
+		}
+		applied[a.version] = a
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("FAILED to scan schema_migration: %w", dbError(err))
+	}
+
+	return nil
+}
+
+// Helper function to get the version of the schema, checking the applied migrations are the ones of the binary
+func (m *Migrator) version(applied map[int]appliedMigration) (int, error) {
+	version := 0
+	for v := range applied {
+		if v > version {
+			version = v
+		}
+	}
+
+	if version > m.Latest() {
+		return version, fmt.Errorf("schema version %d is newer than the version %d of the binary", version, m.Latest())
+	}
+	for v := 1; v <= version; v++ {
+		a, ok := applied[v]
+		if !ok {
+			return version, fmt.Errorf("schema version %d is missing the migration %d", version, v)
+		}
+		if a.checksum != m.migrations[v-1].checksum {
+			return version, fmt.Errorf("migration %d_%s differs from the one applied to the schema", v, m.migrations[v-1].name)
+		}
+	}
+
+	return version, nil
+}
+
+// Helper function to start the transaction of the migrations, it holds the advisory lock until it ends. The lock is
+// waited up to LockTimeout
+func (m *Migrator) startTx(ctx context.Context) (pgx.Tx, func(), error) {
+	tx, conn, err := m.databasePG.StartTx(ctx)
+	if err != nil {
+		return nil, nil, fmt.Errorf("FAILED to start the migration: %w", err)
+	}
+	end := func() {
+		tx.Rollback(ctx)
+		m.databasePG.ReleaseTx(conn)
+	}
+
+	lockCtx, cancel := context.WithTimeout(ctx, time.Duration(m.migrationConfig.LockTimeout) * time.Millisecond)
+	defer cancel()
+
+	_, err = tx.Exec(lockCtx, `SELECT pg_advisory_xact_lock($1)`, migrationLock)
+	if err != nil {
+		end()
+		return nil, nil, fmt.Errorf("FAILED to lock the migrations in %d ms: %w", m.migrationConfig.LockTimeout, dbError(err))
+	}
+
+	_, err = tx.Exec(ctx, migrationTable)
+	if err != nil {
+		end()
+		return nil, nil, fmt.Errorf("FAILED to create schema_migration: %w", dbError(err))
+	}
+
+	return tx, end, nil
+}
+
+// Helper function to record a migration applied
+func (m *Migrator) record(ctx context.Context, tx pgx.Tx, mig migration) error {
+	_, err := tx.Exec(ctx, `INSERT INTO schema_migration (version, name, checksum, applied_at) VALUES ($1, $2, $3, $4)`,
+						mig.version,
+						mig.name,
+						mig.checksum,
+						time.Now())
+	if err != nil {
+		return fmt.Errorf("FAILED to insert schema_migration: %w", dbError(err))
+	}
+	return nil
+}
+
+// About apply the pending migrations, it returns how many were applied
+func (m *Migrator) Up(ctx context.Context) (int, error) {
+	m.logger.Info().
+			Ctx(ctx).
+			Str("func","Up").Send()
+
+	tx, end, err := m.startTx(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer end()
+
+	applied, err := m.applied(ctx, tx)
+	if err != nil {
+		return 0, err
+	}
+	version, err := m.version(applied)
+	if err != nil {
+		return 0, err
+	}
+
+	for _, mig := range m.migrations[version:] {
+		m.logger.Info().
+				Ctx(ctx).
+				Msgf("applying migration %d_%s", mig.version, mig.name)
+
+		if _, err := tx.Exec(ctx, mig.up); err != nil {
+			return 0, fmt.Errorf("FAILED to apply migration %d_%s: %w", mig.version, mig.name, dbError(err))
+		}
+		if err := m.record(ctx, tx, mig); err != nil {
+			return 0, err
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return 0, fmt.Errorf("FAILED to commit the migrations: %w", dbError(err))
+	}
+
+	return m.Latest() - version, nil
+}
+
+// Helper function to list the tables of the baseline without a tenant_id or without the row level security enabled
+// and forced, a schema older than the baseline misses them
+func (m *Migrator) baselineMissing(ctx context.Context, q querier) ([]string, error) {
+	rows, err := q.Query(ctx, `SELECT t FROM unnest($1::text[]) AS t
+								WHERE NOT EXISTS (	SELECT 1
+													FROM pg_class c
+													JOIN pg_namespace n ON n.oid = c.relnamespace
+													JOIN pg_attribute a ON a.attrelid = c.oid
+													WHERE n.nspname = 'public'
+													AND c.relname = t
+													AND c.relrowsecurity
+													AND c.relforcerowsecurity
+													AND a.attname = 'tenant_id'
+													AND NOT a.attisdropped)
+								ORDER BY t`, baselineTables)
+	if err != nil {
+		return nil, fmt.Errorf("FAILED to read the schema: %w", dbError(err))
+	}
+	defer rows.Close()
+
+	missing := []string{}
+	for rows.Next() {
+		var table string
+		if err := rows.Scan(&table); err != nil {
+			return nil, fmt.Errorf("FAILED to read the schema: %w", dbError(err))
+		}
+		missing = append(missing, table)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("FAILED to read the schema: %w", dbError(err))
+	}
+
+	return missing, nil
+}
+
+// About adopt a database created before the migrations as the baseline version, after checking it has the tables
+// of the baseline with their tenant_id and row level security. The migrations after the baseline are left to Up
+func (m *Migrator) Baseline(ctx context.Context) error {
+	m.logger.Info().
+			Ctx(ctx).
+			Str("func","Baseline").Send()
+
+	tx, end, err := m.startTx(ctx)
+	if err != nil {
+		return err
+	}
+	defer end()
+
+	applied := map[int]appliedMigration{}
+	if err := m.scanApplied(ctx, tx, applied); err != nil {
+		return err
+	}
+	if len(applied) > 0 {
+		return fmt.Errorf("schema already has migrations recorded, nothing to adopt")
+	}
+
+	missing, err := m.baselineMissing(ctx, tx)
+	if err != nil {
+		return err
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("schema is not the version %d, without tenant_id or row level security: %s", baselineVersion, strings.Join(missing, ", "))
+	}
+
+	if err := m.record(ctx, tx, m.migrations[baselineVersion-1]); err != nil {
+		return err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("FAILED to commit the baseline: %w", dbError(err))
+	}
+
+	m.logger.Warn().
+			Ctx(ctx).
+			Msgf("schema created before the migrations, recorded as version %d", baselineVersion)
+
+	return nil
+}
+
+// About revert the last steps migrations applied, it returns how many were reverted
+func (m *Migrator) Down(ctx context.Context, steps int) (int, error) {
+	m.logger.Info().
+			Ctx(ctx).
+			Str("func","Down").Send()
+
+	tx, end, err := m.startTx(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer end()
+
+	applied, err := m.applied(ctx, tx)
+	if err != nil {
+		return 0, err
+	}
+	version, err := m.version(applied)
+	if err != nil {
+		return 0, err
+	}
+
+	reverted := 0
+	for ; reverted < steps && version > 0; version-- {
+		mig := m.migrations[version-1]
+		m.logger.Info().
+				Ctx(ctx).
+				Msgf("reverting migration %d_%s", mig.version, mig.name)
+
+		if _, err := tx.Exec(ctx, mig.down); err != nil {
+			return 0, fmt.Errorf("FAILED to revert migration %d_%s: %w", mig.version, mig.name, dbError(err))
+		}
+		if _, err := tx.Exec(ctx, `DELETE FROM schema_migration WHERE version = $1`, mig.version); err != nil {
+			return 0, fmt.Errorf("FAILED to delete schema_migration: %w", dbError(err))
+		}
+		reverted++
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return 0, fmt.Errorf("FAILED to commit the migrations: %w", dbError(err))
+	}
+
+	return reverted, nil
+}
+
+// About list the migrations of the binary and when they were applied, followed by the ones applied by a newer binary
+func (m *Migrator) Status(ctx context.Context) (*[]model.MigrationStatus, error) {
+	m.logger.Info().
+			Ctx(ctx).
+			Str("func","Status").Send()
+
+	applied, err := m.applied(ctx, m.databasePG.GetConnection())
+	if err != nil {
+		return nil, err
+	}
+
+	listStatus := []model.MigrationStatus{}
+	for _, mig := range m.migrations {
+		status := model.MigrationStatus{Version: mig.version, Name: mig.name}
+		if a, ok := applied[mig.version]; ok {
+			status.Applied = true
+			status.AppliedAt = &a.appliedAt
+		}
+		listStatus = append(listStatus, status)
+	}
+
+	unknown := []int{}
+	for v := range applied {
+		if v > m.Latest() {
+			unknown = append(unknown, v)
+		}
+	}
+	sort.Ints(unknown)
+	for _, v := range unknown {
+		a := applied[v]
+		listStatus = append(listStatus, model.MigrationStatus{Version: a.version, Name: a.name, Applied: true, AppliedAt: &a.appliedAt})
+	}
+
+	return &listStatus, nil
+}
+
+// About check the schema is the one the binary was built for, the service refuses to run on any other
+func (m *Migrator) CheckVersion(ctx context.Context) (int, error) {
+	m.logger.Info().
+			Ctx(ctx).
+			Str("func","CheckVersion").Send()
+
+	applied, err := m.applied(ctx, m.databasePG.GetConnection())
+	if err != nil {
+		return 0, err
+	}
+	version, err := m.version(applied)
+	if err != nil {
+		return version, err
+	}
+	if version < m.Latest() {
+		return version, fmt.Errorf("schema version %d is older than the version %d of the binary, run migrate up or set DB_AUTO_MIGRATE=true", version, m.Latest())
+	}
+
+	return version, nil
+}
@@ -0,0 +1,239 @@
+package database
+
+import (
+	"strings"
+	"context"
+	"testing"
+	"testing/fstest"
+
+	"github.com/rs/zerolog"
+
+	"github.com/go-inventory/internal/domain/model"
+)
+
+// Helper function to build a directory of migrations with the given files
+func migrationFS(names ...string) fstest.MapFS {
+	fsys := fstest.MapFS{}
+	for _, name := range names {
+		fsys["migrations/" + name] = &fstest.MapFile{Data: []byte("-- " + name)}
+	}
+	return fsys
+}
+
+func TestLoadMigrations(t *testing.T) {
+	tests := []struct {
+		name		string
+		fsys		fstest.MapFS
+		want		[]string
+		wantErr		string
+	}{
+		{name: "in the order of the versions", fsys: migrationFS(
+			"0002_channel.up.sql", "0002_channel.down.sql", "0001_init.up.sql", "0001_init.down.sql"),
+			want: []string{"init", "channel"}},
+		{name: "down missing", fsys: migrationFS("0001_init.up.sql"),
+			wantErr: "both up and down"},
+		{name: "gap in the versions", fsys: migrationFS(
+			"0001_init.up.sql", "0001_init.down.sql", "0003_drop.up.sql", "0003_drop.down.sql"),
+			wantErr: "expected version 2"},
+		{name: "not starting at 1", fsys: migrationFS("0002_init.up.sql", "0002_init.down.sql"),
+			wantErr: "expected version 1"},
+		{name: "version zero", fsys: migrationFS("0000_init.up.sql", "0000_init.down.sql"),
+			wantErr: "greater than zero"},
+		{name: "two names of a version", fsys: migrationFS("0001_init.up.sql", "0001_other.down.sql"),
+			wantErr: "also named"},
+		{name: "unexpected file", fsys: migrationFS("0001_init.up.sql", "0001_init.down.sql", "README.md"),
+			wantErr: "invalid migration file"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			migrations, err := loadMigrations(tt.fsys, "migrations")
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("got error %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if len(migrations) != len(tt.want) {
+				t.Fatalf("got %d migrations, want %d", len(migrations), len(tt.want))
+			}
+			for i, m := range migrations {
+				if m.version != i + 1 || m.name != tt.want[i] || m.up == "" || m.down == "" || m.checksum == "" {
+					t.Errorf("unexpected migration %d: %+v", i, m)
+				}
+			}
+		})
+	}
+}
+
+// the migrations embedded in the binary must load, a invalid file would stop every pod at boot
+func TestEmbeddedMigrations(t *testing.T) {
+	logger := zerolog.Nop()
+	migrator, err := NewMigrator(nil, &model.MigrationConfig{LockTimeout: 1000}, &logger)
+	if err != nil {
+		t.Fatalf("FAILED to load the embedded migrations: %v", err)
+	}
+	if migrator.Latest() < baselineVersion {
+		t.Fatalf("got latest version %d, want at least %d", migrator.Latest(), baselineVersion)
+	}
+}
+
+func TestMigratorVersion(t *testing.T) {
+	logger := zerolog.Nop()
+	migrator, err := NewMigrator(nil, &model.MigrationConfig{LockTimeout: 1000}, &logger)
+	if err != nil {
+		t.Fatalf("FAILED to load the embedded migrations: %v", err)
+	}
+
+	// every migration of the binary applied, with its checksum
+	current := func() map[int]appliedMigration {
+		applied := map[int]appliedMigration{}
+		for _, m := range migrator.migrations {
+			applied[m.version] = appliedMigration{version: m.version, name: m.name, checksum: m.checksum}
+		}
+		return applied
+	}
+	latest := migrator.Latest()
+
+	tests := []struct {
+		name		string
+		applied		func() map[int]appliedMigration
+		want		int
+		wantErr		string
+	}{
+		{name: "empty database", applied: func() map[int]appliedMigration { return map[int]appliedMigration{} }, want: 0},
+		{name: "current", applied: current, want: latest},
+		{name: "baseline", applied: func() map[int]appliedMigration {
+			baseline := migrator.migrations[baselineVersion-1]
+			return map[int]appliedMigration{baselineVersion: {version: baselineVersion, name: baseline.name, checksum: baseline.checksum}}
+		}, want: baselineVersion},
+		{name: "newer than the binary", applied: func() map[int]appliedMigration {
+			applied := current()
+			applied[latest + 1] = appliedMigration{version: latest + 1, name: "future", checksum: "x"}
+			return applied
+		}, want: latest + 1, wantErr: "newer than the version"},
+		{name: "migration changed after applied", applied: func() map[int]appliedMigration {
+			applied := current()
+			applied[1] = appliedMigration{version: 1, checksum: "changed"}
+			return applied
+		}, want: latest, wantErr: "differs"},
+		{name: "migration missing", applied: func() map[int]appliedMigration {
+			applied := current()
+			delete(applied, 1)
+			return applied
+		}, want: latest, wantErr: "missing the migration 1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			version, err := migrator.version(tt.applied())
+			if version != tt.want {
+				t.Errorf("got version %d, want %d", version, tt.want)
+			}
+			if tt.wantErr == "" && err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)) {
+				t.Fatalf("got error %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// reverts the last migration and applies it again, the schema ends as it started
+func TestIntegrationMigrations(t *testing.T) {
+	databasePG := connectTestDB(t)
+	ctx := context.Background()
+
+	logger := zerolog.Nop()
+	migrator, err := NewMigrator(databasePG, &model.MigrationConfig{LockTimeout: 10000}, &logger)
+	if err != nil {
+		t.Fatalf("FAILED to load the embedded migrations: %v", err)
+	}
+
+	if _, err := migrator.CheckVersion(ctx); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if applied, err := migrator.Up(ctx); err != nil || applied != 0 {
+		t.Fatalf("got %d applied (%v), want nothing to apply", applied, err)
+	}
+
+	reverted, err := migrator.Down(ctx, 1)
+	if err != nil || reverted != 1 {
+		t.Fatalf("got %d reverted (%v), want 1", reverted, err)
+	}
+	if version, err := migrator.CheckVersion(ctx); err == nil || version != migrator.Latest() - 1 {
+		t.Errorf("got version %d (%v), want %d refused", version, err, migrator.Latest() - 1)
+	}
+
+	listStatus, err := migrator.Status(ctx)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if last := (*listStatus)[len(*listStatus) - 1]; last.Applied {
+		t.Errorf("got last migration %+v applied, want pending", last)
+	}
+
+	applied, err := migrator.Up(ctx)
+	if err != nil || applied != 1 {
+		t.Fatalf("got %d applied (%v), want 1", applied, err)
+	}
+	if _, err := migrator.CheckVersion(ctx); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+// a schema without migration recorded is refused until it is adopted, and only when it has the baseline
+func TestIntegrationMigrationBaseline(t *testing.T) {
+	databasePG := connectTestDB(t)
+	ctx := context.Background()
+
+	logger := zerolog.Nop()
+	migrator, err := NewMigrator(databasePG, &model.MigrationConfig{LockTimeout: 10000}, &logger)
+	if err != nil {
+		t.Fatalf("FAILED to load the embedded migrations: %v", err)
+	}
+
+	if err := migrator.Baseline(ctx); err == nil || !strings.Contains(err.Error(), "already") {
+		t.Fatalf("got error %v, want the migrations already recorded", err)
+	}
+
+	// the schema of the latest version without its records, as a database created by hand
+	exec := func(statement string) {
+		t.Helper()
+		if _, err := databasePG.GetConnection().Exec(ctx, statement); err != nil {
+			t.Fatalf("FAILED to %s: %v", statement, err)
+		}
+	}
+	exec(`DELETE FROM schema_migration`)
+	defer exec(`ALTER TABLE public.inventory_channel ENABLE ROW LEVEL SECURITY`)
+
+	if _, err := migrator.CheckVersion(ctx); err == nil || !strings.Contains(err.Error(), "migrate baseline") {
+		t.Fatalf("got error %v, want the schema refused until the baseline", err)
+	}
+	if _, err := migrator.Up(ctx); err == nil {
+		t.Fatalf("got schema migrated, want it refused until the baseline")
+	}
+
+	exec(`ALTER TABLE public.inventory_channel DISABLE ROW LEVEL SECURITY`)
+	if err := migrator.Baseline(ctx); err == nil || !strings.Contains(err.Error(), "inventory_channel") {
+		t.Fatalf("got error %v, want inventory_channel without row level security", err)
+	}
+	exec(`ALTER TABLE public.inventory_channel ENABLE ROW LEVEL SECURITY`)
+
+	if err := migrator.Baseline(ctx); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if version, err := migrator.CheckVersion(ctx); err == nil || version != baselineVersion {
+		t.Errorf("got version %d (%v), want %d refused", version, err, baselineVersion)
+	}
+	if applied, err := migrator.Up(ctx); err != nil || applied != migrator.Latest() - baselineVersion {
+		t.Fatalf("got %d applied (%v), want %d", applied, err, migrator.Latest() - baselineVersion)
+	}
+	if _, err := migrator.CheckVersion(ctx); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
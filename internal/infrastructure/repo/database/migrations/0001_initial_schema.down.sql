@@ -0,0 +1,19 @@
+-- drops every table of the service, with its data
+
+DROP TABLE IF EXISTS public.audit_log;
+DROP FUNCTION IF EXISTS public.audit_log_immutable();
+DROP TABLE IF EXISTS public.api_key_usage;
+DROP TABLE IF EXISTS public.api_key;
+DROP TABLE IF EXISTS public.webhook_delivery;
+DROP TABLE IF EXISTS public.webhook;
+DROP TABLE IF EXISTS public.consumed_event;
+DROP TABLE IF EXISTS public.order_reservation;
+DROP TABLE IF EXISTS public.outbox;
+DROP TABLE IF EXISTS public.rma_event;
+DROP TABLE IF EXISTS public.rma;
+DROP TABLE IF EXISTS public.inventory_adjustment;
+DROP TABLE IF EXISTS public.inventory_channel;
+DROP TABLE IF EXISTS public.inventory_time_series;
+DROP TABLE IF EXISTS public.inventory;
+DROP TABLE IF EXISTS public.product;
+DROP FUNCTION IF EXISTS public.tenant_visible(VARCHAR);
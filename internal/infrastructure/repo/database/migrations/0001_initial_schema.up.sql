@@ -0,0 +1,288 @@
+-- tables of the service, with the row level security of the tenants
+
+CREATE TABLE public.product (
+    id 			BIGSERIAL 	NOT NULL,
+    sku 		VARCHAR(100)	NOT NULL,
+    type 		VARCHAR(100) NOT NULL,
+    name 		VARCHAR(100) NOT NULL,
+    status		VARCHAR(100) NOT NULL,
+    lead_time 		INT 	    NOT NULL DEFAULT 30,
+    version 		INT 	    NOT NULL DEFAULT 1,
+    tenant_id		VARCHAR(63)	NOT NULL DEFAULT NULLIF(current_setting('app.tenant_id', true), ''),
+    created_at	timestamptz NOT NULL,
+    updated_at	timestamptz NULL,
+    CONSTRAINT 	product_pkey PRIMARY KEY (id)
+);
+
+CREATE UNIQUE INDEX product_sku_tenant_unique_idx ON public.product USING btree (sku, tenant_id);
+
+CREATE TABLE public.inventory (
+    id 				BIGSERIAL	NOT NULL,
+    fk_product_id	BIGSERIAL	NOT NULL,
+    available		INT 		NOT null DEFAULT 0,
+    pending 	 	INT 		NOT NULL DEFAULT 0,
+    reserved	 	INT 		NOT NULL DEFAULT 0,
+    sold		 	INT 		NOT null DEFAULT 0,
+    quarantine	 	INT 		NOT NULL DEFAULT 0,
+    damaged		 	INT 		NOT NULL DEFAULT 0,
+    hold		 	INT 		NOT NULL DEFAULT 0,
+    lead_time 		INT 	    NOT NULL DEFAULT 30,
+    version 		INT 	    NOT NULL DEFAULT 1,
+    tenant_id		VARCHAR(63)	NOT NULL DEFAULT NULLIF(current_setting('app.tenant_id', true), ''),
+    created_at 		timestamptz 	NOT NULL,
+    updated_at 		timestamptz 	NULL,
+    CONSTRAINT inventory_pkey PRIMARY KEY (id)
+);
+
+CREATE TABLE public.inventory_time_series (
+    id 				BIGSERIAL	NOT NULL,
+    snapshot_date   timestamptz NOT NULL,
+    fk_product_id	BIGSERIAL	NOT NULL,
+    available		INT 		NOT null DEFAULT 0,
+    pending         INT 		NOT null DEFAULT 0,
+    sold		 	INT 		NOT null DEFAULT 0,
+    incoming		INT 		NOT null DEFAULT 0,
+    quarantine		INT 		NOT null DEFAULT 0,
+    damaged			INT 		NOT null DEFAULT 0,
+    hold			INT 		NOT null DEFAULT 0,
+    tenant_id		VARCHAR(63)	NOT NULL DEFAULT NULLIF(current_setting('app.tenant_id', true), ''),
+    created_at 		timestamptz 	NOT NULL,
+    updated_at 		timestamptz 	NULL,
+    CONSTRAINT id PRIMARY KEY (id)
+);
+
+ALTER TABLE public.inventory_time_series ADD CONSTRAINT inventory_fk_product_id_fkey 
+FOREIGN KEY (fk_product_id) REFERENCES public.product(id);
+
+CREATE TABLE public.inventory_channel (
+    id 				BIGSERIAL	NOT NULL,
+    fk_product_id	BIGINT		NOT NULL,
+    channel			VARCHAR(100) NOT NULL,
+    type			VARCHAR(100) NOT NULL,
+    quantity		INT 		NOT NULL DEFAULT 0,
+    percentage		INT 		NOT NULL DEFAULT 0,
+    tenant_id		VARCHAR(63)	NOT NULL DEFAULT NULLIF(current_setting('app.tenant_id', true), ''),
+    created_at 		timestamptz 	NOT NULL,
+    updated_at 		timestamptz 	NULL,
+    CONSTRAINT inventory_channel_pkey PRIMARY KEY (id),
+    CONSTRAINT inventory_channel_product_channel_key UNIQUE (fk_product_id, channel),
+    CONSTRAINT inventory_channel_fk_product_id_fkey FOREIGN KEY (fk_product_id) REFERENCES public.product(id)
+);
+
+CREATE TABLE public.inventory_adjustment (
+    id 				BIGSERIAL	NOT NULL,
+    fk_product_id	BIGINT		NOT NULL,
+    counted			INT 		NOT NULL,
+    previous		INT 		NOT NULL,
+    variance		INT 		NOT NULL,
+    status			VARCHAR(100) NOT NULL,
+    reason			VARCHAR(100) NOT NULL DEFAULT '',
+    tenant_id		VARCHAR(63)	NOT NULL DEFAULT NULLIF(current_setting('app.tenant_id', true), ''),
+    created_at 		timestamptz 	NOT NULL,
+    updated_at 		timestamptz 	NULL,
+    CONSTRAINT inventory_adjustment_pkey PRIMARY KEY (id)
+);
+
+ALTER TABLE public.inventory_adjustment ADD CONSTRAINT inventory_adjustment_fk_product_id_fkey 
+FOREIGN KEY (fk_product_id) REFERENCES public.product(id);
+
+CREATE TABLE public.rma (
+    id 				BIGSERIAL	NOT NULL,
+    order_ref		VARCHAR(100) NOT NULL,
+    fk_product_id	BIGINT		NOT NULL,
+    quantity		INT 		NOT NULL,
+    received		INT 		NOT NULL DEFAULT 0,
+    restock			INT 		NOT NULL DEFAULT 0,
+    damaged			INT 		NOT NULL DEFAULT 0,
+    status			VARCHAR(100) NOT NULL,
+    reason			VARCHAR(100) NOT NULL DEFAULT '',
+    tenant_id		VARCHAR(63)	NOT NULL DEFAULT NULLIF(current_setting('app.tenant_id', true), ''),
+    created_at 		timestamptz 	NOT NULL,
+    updated_at 		timestamptz 	NULL,
+    CONSTRAINT rma_pkey PRIMARY KEY (id)
+);
+
+ALTER TABLE public.rma ADD CONSTRAINT rma_fk_product_id_fkey 
+FOREIGN KEY (fk_product_id) REFERENCES public.product(id);
+
+CREATE TABLE public.rma_event (
+    id 				BIGSERIAL	NOT NULL,
+    fk_rma_id		BIGINT		NOT NULL,
+    step			VARCHAR(100) NOT NULL,
+    quantity		INT 		NOT NULL DEFAULT 0,
+    note			VARCHAR(100) NOT NULL DEFAULT '',
+    tenant_id		VARCHAR(63)	NOT NULL DEFAULT NULLIF(current_setting('app.tenant_id', true), ''),
+    created_at 		timestamptz 	NOT NULL,
+    CONSTRAINT rma_event_pkey PRIMARY KEY (id)
+);
+
+ALTER TABLE public.rma_event ADD CONSTRAINT rma_event_fk_rma_id_fkey 
+FOREIGN KEY (fk_rma_id) REFERENCES public.rma(id);
+
+CREATE TABLE public.outbox (
+    id 				BIGSERIAL	NOT NULL,
+    event_id		VARCHAR(100) NOT NULL,
+    type			VARCHAR(100) NOT NULL,
+    source			VARCHAR(100) NOT NULL,
+    subject			VARCHAR(100) NOT NULL DEFAULT '',
+    actor			VARCHAR(100) NOT NULL DEFAULT '',
+    data			JSONB 		NOT NULL,
+    status			VARCHAR(100) NOT NULL,
+    attempts		INT 		NOT NULL DEFAULT 0,
+    last_error		TEXT 		NULL,
+    next_attempt_at	timestamptz 	NOT NULL,
+    published_at	timestamptz 	NULL,
+    tenant_id		VARCHAR(63)	NOT NULL DEFAULT NULLIF(current_setting('app.tenant_id', true), ''),
+    created_at 		timestamptz 	NOT NULL,
+    CONSTRAINT outbox_pkey PRIMARY KEY (id)
+);
+
+CREATE INDEX outbox_pending_idx ON public.outbox USING btree (next_attempt_at, id) WHERE status = 'PENDING';
+
+CREATE TABLE public.order_reservation (
+    id 				SERIAL		NOT NULL,
+    order_id		VARCHAR(100) NOT NULL,
+    fk_product_id	INT 		NOT NULL,
+    quantity		INT 		NOT NULL,
+    status			VARCHAR(100) NOT NULL,
+    tenant_id		VARCHAR(63)	NOT NULL DEFAULT NULLIF(current_setting('app.tenant_id', true), ''),
+    created_at 		timestamptz 	NOT NULL,
+    updated_at 		timestamptz 	NULL,
+    CONSTRAINT order_reservation_pkey PRIMARY KEY (id),
+    CONSTRAINT order_reservation_order_product_key UNIQUE (order_id, fk_product_id)
+);
+
+ALTER TABLE public.order_reservation ADD CONSTRAINT order_reservation_fk_product_id_fkey
+FOREIGN KEY (fk_product_id) REFERENCES public.product(id);
+
+CREATE TABLE public.consumed_event (
+    event_id		VARCHAR(100) NOT NULL,
+    type			VARCHAR(100) NOT NULL,
+    order_id		VARCHAR(100) NOT NULL,
+    status			VARCHAR(100) NOT NULL,
+    error			VARCHAR(100) NOT NULL DEFAULT '',
+    tenant_id		VARCHAR(63)	NOT NULL DEFAULT NULLIF(current_setting('app.tenant_id', true), ''),
+    created_at 		timestamptz 	NOT NULL,
+    CONSTRAINT consumed_event_pkey PRIMARY KEY (tenant_id, event_id)
+);
+
+CREATE TABLE public.webhook (
+    id 				SERIAL		NOT NULL,
+    url				VARCHAR(2048) NOT NULL,
+    secret			VARCHAR(256) NOT NULL,
+    sku				VARCHAR(100) NULL,
+    product_type	VARCHAR(100) NULL,
+    threshold		INT 		NOT NULL DEFAULT 0,
+    active			BOOLEAN 	NOT NULL DEFAULT true,
+    tenant_id		VARCHAR(63)	NOT NULL DEFAULT NULLIF(current_setting('app.tenant_id', true), ''),
+    created_at 		timestamptz 	NOT NULL,
+    updated_at 		timestamptz 	NULL,
+    CONSTRAINT webhook_pkey PRIMARY KEY (id),
+    CONSTRAINT webhook_target_check CHECK ((sku IS NULL) <> (product_type IS NULL))
+);
+
+CREATE INDEX webhook_sku_idx ON public.webhook USING btree (sku) WHERE active = true;
+CREATE INDEX webhook_product_type_idx ON public.webhook USING btree (product_type) WHERE active = true;
+
+CREATE TABLE public.webhook_delivery (
+    id 				BIGSERIAL	NOT NULL,
+    fk_webhook_id	INT 		NOT NULL,
+    event_id		VARCHAR(100) NOT NULL,
+    type			VARCHAR(100) NOT NULL,
+    sku				VARCHAR(100) NOT NULL,
+    payload			JSONB 		NOT NULL,
+    status			VARCHAR(100) NOT NULL,
+    attempts		INT 		NOT NULL DEFAULT 0,
+    last_status_code INT 		NOT NULL DEFAULT 0,
+    last_error		TEXT 		NOT NULL DEFAULT '',
+    next_attempt_at	timestamptz 	NOT NULL,
+    delivered_at	timestamptz 	NULL,
+    tenant_id		VARCHAR(63)	NOT NULL DEFAULT NULLIF(current_setting('app.tenant_id', true), ''),
+    created_at 		timestamptz 	NOT NULL,
+    CONSTRAINT webhook_delivery_pkey PRIMARY KEY (id)
+);
+
+ALTER TABLE public.webhook_delivery ADD CONSTRAINT webhook_delivery_fk_webhook_id_fkey
+FOREIGN KEY (fk_webhook_id) REFERENCES public.webhook(id);
+
+CREATE INDEX webhook_delivery_pending_idx ON public.webhook_delivery USING btree (next_attempt_at, id) WHERE status = 'PENDING';
+CREATE INDEX webhook_delivery_log_idx ON public.webhook_delivery USING btree (fk_webhook_id, id);
+
+CREATE TABLE public.api_key (
+    id 				BIGSERIAL	NOT NULL,
+    name			VARCHAR(100) NOT NULL,
+    prefix			VARCHAR(100) NOT NULL,
+    key_hash		VARCHAR(100) NOT NULL,
+    scopes			TEXT[] 		NOT NULL,
+    sku_prefixes	TEXT[] 		NOT NULL DEFAULT '{}',
+    quota			INT 		NOT NULL DEFAULT 0,
+    quota_window	INT 		NOT NULL DEFAULT 60,
+    status			VARCHAR(100) NOT NULL,
+    expires_at		timestamptz 	NULL,
+    tenant_id		VARCHAR(63)	NOT NULL DEFAULT NULLIF(current_setting('app.tenant_id', true), ''),
+    created_at 		timestamptz 	NOT NULL,
+    updated_at 		timestamptz 	NULL,
+    CONSTRAINT api_key_pkey PRIMARY KEY (id)
+);
+
+CREATE UNIQUE INDEX api_key_hash_unique_idx ON public.api_key USING btree (key_hash);
+
+CREATE TABLE public.api_key_usage (
+    fk_api_key_id	BIGINT		NOT NULL,
+    window_start	timestamptz 	NOT NULL,
+    requests		INT 		NOT NULL DEFAULT 0,
+    CONSTRAINT api_key_usage_pkey PRIMARY KEY (fk_api_key_id)
+);
+
+ALTER TABLE public.api_key_usage ADD CONSTRAINT api_key_usage_fk_api_key_id_fkey
+FOREIGN KEY (fk_api_key_id) REFERENCES public.api_key(id);
+
+CREATE TABLE public.audit_log (
+    id 				BIGSERIAL	NOT NULL,
+    entity			VARCHAR(100) NOT NULL,
+    sku				VARCHAR(100) NOT NULL,
+    action			VARCHAR(100) NOT NULL,
+    actor			VARCHAR(255) NOT NULL DEFAULT '',
+    route			VARCHAR(255) NOT NULL DEFAULT '',
+    request_id		VARCHAR(100) NOT NULL DEFAULT '',
+    before			JSONB 		NULL,
+    after			JSONB 		NOT NULL,
+    seq				BIGINT 		NULL,
+    prev_hash		VARCHAR(64) NULL,
+    hash			VARCHAR(64) NULL,
+    tenant_id		VARCHAR(63)	NOT NULL DEFAULT NULLIF(current_setting('app.tenant_id', true), ''),
+    created_at 		timestamptz 	NOT NULL,
+    CONSTRAINT audit_log_pkey PRIMARY KEY (id)
+);
+
+CREATE UNIQUE INDEX audit_log_chain_idx ON public.audit_log USING btree (tenant_id, seq);
+CREATE INDEX audit_log_unsealed_idx ON public.audit_log USING btree (id) WHERE hash IS NULL;
+CREATE INDEX audit_log_sku_idx ON public.audit_log USING btree (sku, id);
+
+CREATE FUNCTION public.audit_log_immutable() RETURNS trigger LANGUAGE plpgsql AS
+$$ BEGIN
+    IF TG_OP = 'DELETE' OR OLD.hash IS NOT NULL THEN
+        RAISE EXCEPTION 'audit_log is append only';
+    END IF;
+    RETURN NEW;
+END $$;
+
+CREATE TRIGGER audit_log_immutable_trg BEFORE UPDATE OR DELETE ON public.audit_log
+FOR EACH ROW EXECUTE FUNCTION public.audit_log_immutable();
+
+-- row level security, the service sets app.tenant_id in each connection and transaction ('*' is the system tenant
+-- of the background workers). The user of the service must not be superuser nor have BYPASSRLS
+CREATE FUNCTION public.tenant_visible(tenant_id VARCHAR) RETURNS BOOLEAN LANGUAGE sql STABLE AS
+$$ SELECT tenant_id = current_setting('app.tenant_id', true) OR current_setting('app.tenant_id', true) = '*' $$;
+
+DO $$
+DECLARE t TEXT;
+BEGIN
+    FOREACH t IN ARRAY ARRAY['product', 'inventory', 'inventory_time_series', 'inventory_channel', 'inventory_adjustment', 'rma',
+                             'rma_event', 'outbox', 'order_reservation', 'consumed_event', 'webhook', 'webhook_delivery',
+                             'api_key', 'audit_log'] LOOP
+        EXECUTE format('ALTER TABLE public.%I ENABLE ROW LEVEL SECURITY', t);
+        EXECUTE format('ALTER TABLE public.%I FORCE ROW LEVEL SECURITY', t);
+        EXECUTE format('CREATE POLICY %I ON public.%I USING (public.tenant_visible(tenant_id))', t || '_tenant_policy', t);
+    END LOOP;
+END $$;
@@ -0,0 +1 @@
+ALTER TABLE public.inventory ADD COLUMN IF NOT EXISTS lead_time INT NOT NULL DEFAULT 30;
@@ -0,0 +1,3 @@
+-- the lead time is a attribute of the product, the column of the inventory was never read nor written
+
+ALTER TABLE public.inventory DROP COLUMN IF EXISTS lead_time;
@@ -0,0 +1,207 @@
+package database
+
+import (
+	"fmt"
+	"context"
+	"database/sql"
+
+	"github.com/go-inventory/shared/erro"
+	"github.com/go-inventory/internal/domain/model"
+	"github.com/go-inventory/internal/domain/service"
+
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/codes"
+)
+
+// About record a order event as consumed, zero rows means the event was already consumed
+func (w *WorkerRepository) AddConsumedEvent(ctx context.Context,
+											tx service.Tx,
+											event *model.ConsumedEvent) (int64, error){
+	w.logger.Info().
+			Ctx(ctx).
+			Str("func","AddConsumedEvent").Send()
+
+	// trace
+	ctx, span := w.tracerProvider.SpanCtx(ctx, "database.AddConsumedEvent", trace.SpanKindInternal)
+	defer span.End()
+
+	// Query Execute
+	query := `INSERT INTO consumed_event (	event_id,
+											type,
+											order_id,
+											status,
+											error,
+											created_at)
+				VALUES($1, $2, $3, $4, $5, $6)
+				ON CONFLICT (event_id) DO NOTHING`
+
+	row, err := pgxTx(tx).Exec(ctx,
+						query,
+						event.EventID,
+						event.Type,
+						event.OrderID,
+						event.Status,
+						event.Error,
+						event.CreatedAt)
+	if err != nil {
+		span.RecordError(err)
+        span.SetStatus(codes.Error, err.Error())
+		w.logger.Error().
+				Ctx(ctx).
+				Err(err).Send()
+		return 0, fmt.Errorf("FAILED to insert consumed_event: %w", dbError(err))
+	}
+
+	return row.RowsAffected(), nil
+}
+
+// About get the reservation of a item of a order locking its row until the end of the transaction
+func (w *WorkerRepository) GetOrderReservationForUpdate(ctx context.Context,
+														tx service.Tx,
+														reservation *model.OrderReservation) (*model.OrderReservation, error){
+	w.logger.Info().
+			Ctx(ctx).
+			Str("func","GetOrderReservationForUpdate").Send()
+
+	// Trace
+	ctx, span := w.tracerProvider.SpanCtx(ctx, "database.GetOrderReservationForUpdate", trace.SpanKindInternal)
+	defer span.End()
+
+	// Query and Execute
+	query := `SELECT r.id,
+					 r.order_id,
+					 p.id,
+					 p.sku,
+					 r.quantity,
+					 r.status,
+					 r.created_at,
+					 r.updated_at
+				FROM order_reservation as r,
+					 product as p
+				WHERE r.order_id = $1
+				and r.fk_product_id = $2
+				and p.id = r.fk_product_id
+				FOR UPDATE OF r`
+
+	rows, err := pgxTx(tx).Query(ctx,
+						query,
+						reservation.OrderID,
+						reservation.Product.ID)
+	if err != nil {
+		span.RecordError(err)
+        span.SetStatus(codes.Error, err.Error())
+		w.logger.Error().
+				Ctx(ctx).
+				Err(err).Send()
+		return nil, fmt.Errorf("FAILED to query order_reservation: %w", dbError(err))
+	}
+	defer rows.Close()
+
+	if rows.Next() {
+		res_reservation := model.OrderReservation{}
+		var nullUpdatedAt sql.NullTime
+
+		err := rows.Scan(&res_reservation.ID,
+						&res_reservation.OrderID,
+						&res_reservation.Product.ID,
+						&res_reservation.Product.Sku,
+						&res_reservation.Quantity,
+						&res_reservation.Status,
+						&res_reservation.CreatedAt,
+						&nullUpdatedAt,
+					)
+		if err != nil {
+			span.RecordError(err)
+        	span.SetStatus(codes.Error, err.Error())
+			w.logger.Error().
+					Ctx(ctx).
+					Err(err).Send()
+			return nil, fmt.Errorf("FAILED to scan order_reservation row: %w", dbError(err))
+		}
+		res_reservation.UpdatedAt = w.pointerTime(nullUpdatedAt)
+		return &res_reservation, nil
+	}
+
+	return nil, erro.ErrNotFound
+}
+
+// About add the reservation of a item of a order
+func (w *WorkerRepository) AddOrderReservation(ctx context.Context,
+												tx service.Tx,
+												reservation *model.OrderReservation) (*model.OrderReservation, error){
+	w.logger.Info().
+			Ctx(ctx).
+			Str("func","AddOrderReservation").Send()
+
+	// trace
+	ctx, span := w.tracerProvider.SpanCtx(ctx, "database.AddOrderReservation", trace.SpanKindInternal)
+	defer span.End()
+
+	//Prepare
+	var id int
+
+	// Query Execute
+	query := `INSERT INTO order_reservation (	order_id,
+												fk_product_id,
+												quantity,
+												status,
+												created_at)
+				VALUES($1, $2, $3, $4, $5) RETURNING id`
+
+	row := pgxTx(tx).QueryRow(	ctx,
+						query,
+						reservation.OrderID,
+						reservation.Product.ID,
+						reservation.Quantity,
+						reservation.Status,
+						reservation.CreatedAt)
+
+	if err := row.Scan(&id); err != nil {
+		span.RecordError(err)
+        span.SetStatus(codes.Error, err.Error())
+		w.logger.Error().
+				Ctx(ctx).
+				Err(err).Send()
+		return nil, fmt.Errorf("FAILED to insert order_reservation: %w", dbError(err))
+	}
+
+	// Set PK
+	reservation.ID = id
+
+	return reservation, nil
+}
+
+// About update the status of the reservation of a item of a order
+func (w *WorkerRepository) UpdateOrderReservation(ctx context.Context,
+												tx service.Tx,
+												reservation *model.OrderReservation) (int64, error){
+	w.logger.Info().
+			Ctx(ctx).
+			Str("func","UpdateOrderReservation").Send()
+
+	// trace
+	ctx, span := w.tracerProvider.SpanCtx(ctx, "database.UpdateOrderReservation", trace.SpanKindInternal)
+	defer span.End()
+
+	// Query Execute
+	query := `UPDATE order_reservation
+				SET status = $2,
+					updated_at = $3
+				WHERE id = $1`
+
+	row, err := pgxTx(tx).Exec(ctx,
+						query,
+						reservation.ID,
+						reservation.Status,
+						reservation.UpdatedAt)
+	if err != nil {
+		span.RecordError(err)
+        span.SetStatus(codes.Error, err.Error())
+		w.logger.Error().
+				Ctx(ctx).
+				Err(err).Send()
+		return 0, fmt.Errorf("FAILED to update order_reservation: %w", dbError(err))
+	}
+
+	return row.RowsAffected(), nil
+}
@@ -0,0 +1,184 @@
+package database
+
+import (
+	"fmt"
+	"context"
+
+	"github.com/go-inventory/internal/domain/model"
+	"github.com/go-inventory/internal/domain/service"
+
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/codes"
+)
+
+// About add a event to the outbox, in the transaction of the change it describes
+func (w *WorkerRepository) AddOutboxEvent(ctx context.Context,
+										tx service.Tx,
+										event *model.OutboxEvent) (*model.OutboxEvent, error){
+	w.logger.Info().
+			Ctx(ctx).
+			Str("func","AddOutboxEvent").Send()
+
+	// trace
+	ctx, span := w.tracerProvider.SpanCtx(ctx, "database.AddOutboxEvent", trace.SpanKindInternal)
+	defer span.End()
+
+	//Prepare
+	var id int
+
+	// Query Execute
+	query := `INSERT INTO outbox (	event_id,
+									type,
+									source,
+									subject,
+									actor,
+									data,
+									status,
+									attempts,
+									next_attempt_at,
+									created_at)
+				VALUES($1, $2, $3, $4, $5, $6, $7, 0, $8, $8) RETURNING id`
+
+	row := pgxTx(tx).QueryRow(	ctx,
+						query,
+						event.EventID,
+						event.Type,
+						event.Source,
+						event.Subject,
+						event.Actor,
+						event.Data,
+						event.Status,
+						event.CreatedAt)
+
+	if err := row.Scan(&id); err != nil {
+		span.RecordError(err)
+        span.SetStatus(codes.Error, err.Error())
+		w.logger.Error().
+				Ctx(ctx).
+				Err(err).Send()
+		return nil, fmt.Errorf("FAILED to insert outbox: %w", dbError(err))
+	}
+
+	// Set PK
+	event.ID = id
+
+	return event, nil
+}
+
+// About list the events ready to be published locking them, the events locked by other pods are skipped
+func (w *WorkerRepository) ListOutboxPendingForUpdate(ctx context.Context,
+													tx service.Tx,
+													limit int) (*[]model.OutboxEvent, error){
+	w.logger.Debug().
+			Ctx(ctx).
+			Str("func","ListOutboxPendingForUpdate").Send()
+
+	// trace
+	ctx, span := w.tracerProvider.SpanCtx(ctx, "database.ListOutboxPendingForUpdate", trace.SpanKindInternal)
+	defer span.End()
+
+	// Query and Execute
+	query := `SELECT id,
+					event_id,
+					type,
+					source,
+					subject,
+					actor,
+					tenant_id,
+					data,
+					status,
+					attempts,
+					next_attempt_at,
+					created_at
+				FROM outbox
+				WHERE status = $1
+				and next_attempt_at <= now()
+				order by id
+				limit $2
+				FOR UPDATE SKIP LOCKED`
+
+	rows, err := pgxTx(tx).Query(ctx,
+						query,
+						model.OutboxPending,
+						limit)
+	if err != nil {
+		span.RecordError(err)
+        span.SetStatus(codes.Error, err.Error())
+		w.logger.Error().
+				Ctx(ctx).
+				Err(err).Send()
+		return nil, fmt.Errorf("FAILED to query outbox: %w", dbError(err))
+	}
+	defer rows.Close()
+
+	list_event := []model.OutboxEvent{}
+	for rows.Next() {
+		event := model.OutboxEvent{}
+		err := rows.Scan(
+						&event.ID,
+						&event.EventID,
+						&event.Type,
+						&event.Source,
+						&event.Subject,
+						&event.Actor,
+						&event.Tenant,
+						&event.Data,
+						&event.Status,
+						&event.Attempts,
+						&event.NextAttemptAt,
+						&event.CreatedAt,
+					)
+		if err != nil {
+			span.RecordError(err)
+        	span.SetStatus(codes.Error, err.Error())
+			w.logger.Error().
+					Ctx(ctx).
+					Err(err).Send()
+			return nil, fmt.Errorf("FAILED to scan outbox row: %w", dbError(err))
+		}
+		list_event = append(list_event, event)
+	}
+
+	return &list_event, nil
+}
+
+// About update the delivery of a event (status, attempts, next attempt)
+func (w *WorkerRepository) UpdateOutboxEvent(ctx context.Context,
+											tx service.Tx,
+											event *model.OutboxEvent) (int64, error){
+	w.logger.Info().
+			Ctx(ctx).
+			Str("func","UpdateOutboxEvent").Send()
+
+	// trace
+	ctx, span := w.tracerProvider.SpanCtx(ctx, "database.UpdateOutboxEvent", trace.SpanKindInternal)
+	defer span.End()
+
+	// Query Execute
+	query := `UPDATE outbox
+				SET status = $2,
+					attempts = $3,
+					last_error = $4,
+					next_attempt_at = $5,
+					published_at = $6
+				WHERE id = $1`
+
+	row, err := pgxTx(tx).Exec(ctx,
+						query,
+						event.ID,
+						event.Status,
+						event.Attempts,
+						event.LastError,
+						event.NextAttemptAt,
+						event.PublishedAt)
+	if err != nil {
+		span.RecordError(err)
+        span.SetStatus(codes.Error, err.Error())
+		w.logger.Error().
+				Ctx(ctx).
+				Err(err).Send()
+		return 0, fmt.Errorf("FAILED to update outbox: %w", dbError(err))
+	}
+
+	return row.RowsAffected(), nil
+}
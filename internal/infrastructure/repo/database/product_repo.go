@@ -3,15 +3,18 @@ package database
 import (
 	"context"
 	"fmt"
+	"errors"
 	"strings"
 	"database/sql"
 	"time"
 
 	"github.com/rs/zerolog"
 	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
 
 	"github.com/go-inventory/shared/erro"
 	"github.com/go-inventory/internal/domain/model"
+	"github.com/go-inventory/internal/domain/service"
 	"go.opentelemetry.io/otel/trace"
 	"go.opentelemetry.io/otel/codes"
 
@@ -23,10 +26,13 @@ type WorkerRepository struct {
 	DatabasePG 		*go_core_db_pg.DatabasePGServer
 	logger			*zerolog.Logger
 	tracerProvider 	*go_core_otel_trace.TracerProvider
+	replica			*replicaRouter
 }
 
-// Above new worker
+// Above new worker, a nil replica sends every read to the primary
 func NewWorkerRepository(databasePG *go_core_db_pg.DatabasePGServer,
+						replicaPG *go_core_db_pg.DatabasePGServer,
+						replicaConfig *model.ReplicaConfig,
 						appLogger *zerolog.Logger,
 						tracerProvider *go_core_otel_trace.TracerProvider) *WorkerRepository{
 	logger := appLogger.With().
@@ -39,6 +45,7 @@ func NewWorkerRepository(databasePG *go_core_db_pg.DatabasePGServer,
 		DatabasePG: databasePG,
 		logger: &logger,
 		tracerProvider: tracerProvider,
+		replica: newReplicaRouter(replicaPG, replicaConfig),
 	}
 }
 
@@ -50,6 +57,69 @@ func (w *WorkerRepository) pointerTime(nt sql.NullTime) *time.Time {
 	return nil
 }
 
+// Helper function to convert a database error into a typed error using the SQLSTATE code
+func dbError(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	// already typed
+	var typed *erro.Error
+	if errors.As(err, &typed) {
+		return err
+	}
+
+	if errors.Is(err, context.DeadlineExceeded) || errors.Is(err, context.Canceled) {
+		return erro.Wrap(err, erro.KindTimeout, erro.ErrTimeout.Code, erro.ErrTimeout.Message)
+	}
+
+	if errors.Is(err, pgx.ErrNoRows) {
+		return erro.Wrap(err, erro.KindNotFound, erro.ErrNotFound.Code, erro.ErrNotFound.Message)
+	}
+
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		details := map[string]interface{}{"sqlstate": pgErr.Code}
+		if pgErr.ConstraintName != "" {
+			details["constraint"] = pgErr.ConstraintName
+		}
+
+		var sentinel *erro.Error
+		switch {
+		case pgErr.Code == "23505": // unique_violation
+			sentinel = erro.ErrDuplicateKey
+		case pgErr.Code == "23503": // foreign_key_violation
+			sentinel = erro.ErrForeignKey
+		case strings.HasPrefix(pgErr.Code, "23"), // integrity constraint violation
+			 strings.HasPrefix(pgErr.Code, "22"): // data exception
+			sentinel = erro.ErrConstraint
+		case pgErr.Code == "40001", // serialization_failure
+			 pgErr.Code == "40P01", // deadlock_detected
+			 pgErr.Code == "55P03": // lock_not_available
+			sentinel = erro.ErrConcurrency.AsRetryable()
+		case pgErr.Code == "57014": // query_canceled (statement timeout)
+			sentinel = erro.ErrTimeout.AsRetryable()
+		case strings.HasPrefix(pgErr.Code, "08"), // connection exception
+			 strings.HasPrefix(pgErr.Code, "53"), // insufficient resources
+			 strings.HasPrefix(pgErr.Code, "57P"): // operator intervention
+			sentinel = erro.ErrUnavailable.AsRetryable()
+		default:
+			return erro.Wrap(err, erro.KindInternal, "DATABASE_ERROR", "database error").WithDetails(details)
+		}
+
+		typed := sentinel.WithDetails(details)
+		typed.Err = err
+		return typed
+	}
+
+	// failures before reaching the database (dial, pool closed)
+	if pgconn.SafeToRetry(err) || pgconn.Timeout(err) {
+		return erro.Wrap(err, erro.KindUnavailable, erro.ErrUnavailable.Code, erro.ErrUnavailable.Message).AsRetryable()
+	}
+
+	return err
+}
+
 // Helper function to scan product from rows iterator
 func (w *WorkerRepository) scanProductFromRows(rows pgx.Rows) (*model.Product, error) {
 	product := model.Product{}
@@ -61,11 +131,12 @@ func (w *WorkerRepository) scanProductFromRows(rows pgx.Rows) (*model.Product, e
 					&product.Name,
 					&product.Status,
 					&product.LeadTime,
+					&product.Version,
 					&product.CreatedAt,
 					&nullUpdatedAt,
 				)
 	if err != nil {
-		return nil, fmt.Errorf("FAILED to scan product from rows: %w", err)
+		return nil, fmt.Errorf("FAILED to scan product from rows: %w", dbError(err))
 	}
 	
 	product.UpdatedAt = w.pointerTime(nullUpdatedAt)
@@ -94,9 +165,20 @@ func (w *WorkerRepository) Stat(ctx context.Context) (go_core_db_pg.PoolStats){
 	return resPoolStats
 }
 
+// Above get the counters of the acquires of the pool, read at each interval by the load shedder
+func (w *WorkerRepository) AcquireStat() (int64, int64, time.Duration) {
+	stats := w.DatabasePG.Stat()
+	return stats.AcquireCount(), stats.CanceledAcquireCount(), stats.AcquireDuration()
+}
+
+// Above check the primary answers
+func (w *WorkerRepository) Ping(ctx context.Context) error {
+	return w.DatabasePG.Ping()
+}
+
 // About create a product
 func (w* WorkerRepository) AddProduct(ctx context.Context, 
-									tx pgx.Tx, 
+									tx service.Tx, 
 									product *model.Product) (*model.Product, error){
 	w.logger.Info().
 			Ctx(ctx).
@@ -118,7 +200,7 @@ func (w* WorkerRepository) AddProduct(ctx context.Context,
 									created_at) 
 				VALUES($1, $2, $3, $4, $5, $6) RETURNING id`
 
-	row := tx.QueryRow(	ctx, 
+	row := pgxTx(tx).QueryRow(	ctx, 
 						query,
 						product.Sku,
 						product.Type, 
@@ -130,7 +212,8 @@ func (w* WorkerRepository) AddProduct(ctx context.Context,
 	if err := row.Scan(&id); err != nil {
 		span.RecordError(err) 
         span.SetStatus(codes.Error, err.Error())
-		if strings.Contains(err.Error(), "duplicate key value violates") {
+		err = dbError(err)
+		if errors.Is(err, erro.ErrDuplicateKey) {
     		w.logger.Warn().
 					 Ctx(ctx).
 					 Err(err).Send()
@@ -160,14 +243,14 @@ func (w *WorkerRepository) GetProduct(ctx context.Context,
 	defer span.End()
 
 	// db connection
-	conn, err := w.DatabasePG.Acquire(ctx)
+	conn, err := w.acquireRead(ctx)
 	if err != nil {
 		span.RecordError(err) 
         span.SetStatus(codes.Error, err.Error())
 		w.logger.Error().
 			  	 Ctx(ctx).
 				 Err(err).Send()
-		return nil, fmt.Errorf("FAILED to acquire connection: %w", err)
+		return nil, fmt.Errorf("FAILED to acquire connection: %w", dbError(err))
 	}
 	defer w.DatabasePG.Release(conn)
 
@@ -178,6 +261,7 @@ func (w *WorkerRepository) GetProduct(ctx context.Context,
 					name,
 					status,
 					lead_time,
+					version,
 					created_at, 
 					updated_at
 				FROM product 
@@ -192,7 +276,66 @@ func (w *WorkerRepository) GetProduct(ctx context.Context,
 		w.logger.Error().
 				Ctx(ctx).
 				Err(err).Send()
-		return nil, fmt.Errorf("FAILED to query product: %w", err)
+		return nil, fmt.Errorf("FAILED to query product: %w", dbError(err))
+	}
+	defer rows.Close()
+
+	if rows.Next() {
+		res_product, err := w.scanProductFromRows(rows)
+		if err != nil {
+			span.RecordError(err) 
+        	span.SetStatus(codes.Error, err.Error())
+			w.logger.Error().
+					Ctx(ctx).
+					Err(err).Send()
+			return nil, fmt.Errorf("FAILED to scanProductFromRows product: %w", dbError(err))
+		}
+		return res_product, nil
+	}
+
+	w.logger.Warn().
+			Ctx(ctx).
+			Err(erro.ErrNotFound).Send()
+
+	return nil, erro.ErrNotFound
+}
+
+// About get a product locking its row until the end of the transaction
+func (w *WorkerRepository) GetProductForUpdate(ctx context.Context,
+												tx service.Tx,
+												product *model.Product) (*model.Product, error){
+	w.logger.Info().
+			Ctx(ctx).
+			Str("func","GetProductForUpdate").Send()
+
+	// Trace
+	ctx, span := w.tracerProvider.SpanCtx(ctx, "database.GetProductForUpdate", trace.SpanKindInternal)
+	defer span.End()
+
+	// Query and Execute
+	query := `SELECT id, 
+					sku, 
+					type,
+					name,
+					status,
+					lead_time,
+					version,
+					created_at, 
+					updated_at
+				FROM product 
+				WHERE sku =$1
+				FOR UPDATE`
+
+	rows, err := pgxTx(tx).Query(ctx, 
+						query, 
+						product.Sku)
+	if err != nil {
+		span.RecordError(err) 
+        span.SetStatus(codes.Error, err.Error())
+		w.logger.Error().
+				Ctx(ctx).
+				Err(err).Send()
+		return nil, fmt.Errorf("FAILED to query product: %w", dbError(err))
 	}
 	defer rows.Close()
 
@@ -204,7 +347,7 @@ func (w *WorkerRepository) GetProduct(ctx context.Context,
 			w.logger.Error().
 					Ctx(ctx).
 					Err(err).Send()
-			return nil, fmt.Errorf("FAILED to scanProductFromRows product: %w", err)
+			return nil, fmt.Errorf("FAILED to scanProductFromRows product: %w", dbError(err))
 		}
 		return res_product, nil
 	}
@@ -228,14 +371,14 @@ func (w *WorkerRepository) GetProductId(ctx context.Context,
 	defer span.End()
 
 	// db connection
-	conn, err := w.DatabasePG.Acquire(ctx)
+	conn, err := w.acquireRead(ctx)
 	if err != nil {
 		span.RecordError(err) 
         span.SetStatus(codes.Error, err.Error())
 		w.logger.Error().
 				Ctx(ctx).
 				Err(err).Send()
-		return nil, fmt.Errorf("FAILED to acquire connection: %w", err)
+		return nil, fmt.Errorf("FAILED to acquire connection: %w", dbError(err))
 	}
 	defer w.DatabasePG.Release(conn)
 
@@ -246,6 +389,7 @@ func (w *WorkerRepository) GetProductId(ctx context.Context,
 					name,
 					status,
 					lead_time,
+					version,
 					created_at, 
 					updated_at
 				FROM product 
@@ -260,7 +404,7 @@ func (w *WorkerRepository) GetProductId(ctx context.Context,
 		w.logger.Error().
 				Ctx(ctx).
 				Err(err).Send()
-		return nil, fmt.Errorf("FAILED to query product by id: %w", err)
+		return nil, fmt.Errorf("FAILED to query product by id: %w", dbError(err))
 	}
 	defer rows.Close()
 
@@ -272,7 +416,7 @@ func (w *WorkerRepository) GetProductId(ctx context.Context,
 			w.logger.Error().
 					Ctx(ctx).
 					Err(err).Send()
-			return nil, fmt.Errorf("FAILED to scanProductFromRows product: %w", err)
+			return nil, fmt.Errorf("FAILED to scanProductFromRows product: %w", dbError(err))
 		}
 		return res_product, nil
 	}
@@ -283,3 +427,54 @@ func (w *WorkerRepository) GetProductId(ctx context.Context,
 
 	return nil, erro.ErrNotFound
 }
+
+// About update a product, when a version is informed the update only happens if it still matches (optimistic lock)
+func (w* WorkerRepository) UpdateProduct(ctx context.Context, 
+										tx service.Tx, 
+										product *model.Product) (int64, error){
+	w.logger.Info().
+			Ctx(ctx).
+			Str("func","UpdateProduct").Send()
+
+	// trace
+	ctx, span := w.tracerProvider.SpanCtx(ctx, "database.UpdateProduct", trace.SpanKindInternal)
+	defer span.End()
+
+	// Query Execute
+	query := `UPDATE product
+				SET type = $2,
+					name = $3,
+					status = $4,
+					lead_time = $5,
+					updated_at = $6,
+					version = version + 1
+				WHERE id = $1
+				and ($7 = 0 or version = $7)
+				RETURNING version`
+
+	row := pgxTx(tx).QueryRow(	ctx, 
+						query,	
+						product.ID,
+						product.Type,
+						product.Name,
+						product.Status,
+						product.LeadTime,
+						product.UpdatedAt,
+						product.Version,
+					)
+
+	if err := row.Scan(&product.Version); err != nil {
+		// no row means the version informed did not match
+		if errors.Is(err, pgx.ErrNoRows) {
+			return 0, nil
+		}
+		span.RecordError(err) 
+        span.SetStatus(codes.Error, err.Error())
+		w.logger.Error().
+				Ctx(ctx).
+				Err(err).Send()
+		return 0, fmt.Errorf("FAILED to update product: %w", dbError(err))
+	}
+
+	return 1, nil
+}
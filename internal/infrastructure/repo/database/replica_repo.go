@@ -0,0 +1,172 @@
+package database
+
+import (
+	"sync"
+	"time"
+	"context"
+	"sync/atomic"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/go-inventory/internal/domain/model"
+	"github.com/go-inventory/shared/security"
+
+	go_core_db_pg "github.com/eliezerraj/go-core/v2/database/postgre"
+)
+
+// lag of the replica in milliseconds. It is zero when the replica replayed everything it received, since a idle
+// primary does not move the replay timestamp, and when the server is not a standby
+const replicaLagQuery = `SELECT COALESCE(CASE WHEN pg_last_wal_receive_lsn() = pg_last_wal_replay_lsn() THEN 0
+									ELSE EXTRACT(EPOCH FROM now() - pg_last_xact_replay_timestamp()) * 1000
+								END, 0)::bigint`
+
+// replicaRouter sends the reads to the replica while it answers with a lag under the tolerance, except the reads
+// of a caller (tenant and subject) that changed something in the last moments, they read the primary
+type replicaRouter struct {
+	databasePG		*go_core_db_pg.DatabasePGServer
+	maxLag			time.Duration
+	checkInterval	time.Duration
+	readYourWrites	time.Duration
+	healthy			atomic.Bool
+	mu				sync.Mutex
+	writes			map[string]time.Time
+}
+
+// Helper function to create the router of the replica, nil when there is no replica
+func newReplicaRouter(databasePG *go_core_db_pg.DatabasePGServer, replicaConfig *model.ReplicaConfig) *replicaRouter {
+	if databasePG == nil || replicaConfig == nil {
+		return nil
+	}
+
+	return &replicaRouter{
+		databasePG: databasePG,
+		maxLag: time.Duration(replicaConfig.MaxLag) * time.Millisecond,
+		checkInterval: time.Duration(replicaConfig.CheckInterval) * time.Millisecond,
+		readYourWrites: time.Duration(replicaConfig.ReadYourWrites) * time.Millisecond,
+		writes: make(map[string]time.Time),
+	}
+}
+
+// Helper function to get the caller of the context, the writes and reads of the same caller are paired by it
+func writerOf(ctx context.Context) string {
+	return security.TenantFrom(ctx) + " " + security.SubjectFrom(ctx)
+}
+
+// Helper function to record a change of the caller, its reads go to the primary until the replica catches up
+func (r *replicaRouter) markWrite(ctx context.Context) {
+	if r == nil || r.readYourWrites <= 0 {
+		return
+	}
+
+	r.mu.Lock()
+	r.writes[writerOf(ctx)] = time.Now()
+	r.mu.Unlock()
+}
+
+// Helper function to tell whether a read can go to the replica
+func (r *replicaRouter) usable(ctx context.Context) bool {
+	if r == nil || !r.healthy.Load() {
+		return false
+	}
+
+	r.mu.Lock()
+	last, ok := r.writes[writerOf(ctx)]
+	r.mu.Unlock()
+
+	return !ok || time.Since(last) >= r.readYourWrites
+}
+
+// Helper function to forget the changes older than the read your writes window
+func (r *replicaRouter) prune(now time.Time) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for writer, last := range r.writes {
+		if now.Sub(last) >= r.readYourWrites {
+			delete(r.writes, writer)
+		}
+	}
+}
+
+// Helper function to acquire a connection for a read bound to the tenant of the context, from the replica when
+// it can serve the caller, else from the primary. A replica failing is left out until the next check passes. The
+// connection is released like any other, it goes back to its own pool
+func (w *WorkerRepository) acquireRead(ctx context.Context) (*pgxpool.Conn, error) {
+	if !w.replica.usable(ctx) {
+		return w.acquire(ctx)
+	}
+
+	conn, err := w.acquireFrom(ctx, w.replica.databasePG)
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, err
+		}
+		w.replica.healthy.Store(false)
+		w.logger.Warn().
+				Ctx(ctx).
+				Err(err).
+				Msg("replica FAILED, reads go to the primary")
+		return w.acquire(ctx)
+	}
+
+	return conn, nil
+}
+
+// About check the replica at each interval until the context is done, the first check runs at once
+func (w *WorkerRepository) StartReplicaMonitor(ctx context.Context) {
+	if w.replica == nil {
+		return
+	}
+
+	w.logger.Info().
+			Ctx(ctx).
+			Str("func","StartReplicaMonitor").Send()
+
+	ticker := time.NewTicker(w.replica.checkInterval)
+	defer ticker.Stop()
+
+	for {
+		w.checkReplica(ctx)
+		w.replica.prune(time.Now())
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Helper function to measure the lag of the replica, it serves the reads while it answers under the tolerance
+func (w *WorkerRepository) checkReplica(ctx context.Context) {
+	checkCtx, cancel := context.WithTimeout(ctx, w.replica.checkInterval)
+	defer cancel()
+
+	var lag int64
+	conn, err := w.replica.databasePG.Acquire(checkCtx)
+	if err == nil {
+		err = conn.QueryRow(checkCtx, replicaLagQuery).Scan(&lag)
+		w.replica.databasePG.Release(conn)
+	}
+	if ctx.Err() != nil {
+		return
+	}
+
+	healthy := err == nil && time.Duration(lag) * time.Millisecond <= w.replica.maxLag
+	if w.replica.healthy.Swap(healthy) == healthy {
+		return
+	}
+
+	if healthy {
+		w.logger.Info().
+				Ctx(ctx).
+				Int64("lag_ms", lag).
+				Msg("replica available, reads go to the replica")
+		return
+	}
+	w.logger.Warn().
+			Ctx(ctx).
+			Err(err).
+			Int64("lag_ms", lag).
+			Msg("replica unavailable or lagging, reads go to the primary")
+}
@@ -0,0 +1,385 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"database/sql"
+
+	"github.com/jackc/pgx/v5"
+
+	"github.com/go-inventory/shared/erro"
+	"github.com/go-inventory/internal/domain/model"
+	"github.com/go-inventory/internal/domain/service"
+
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/codes"
+)
+
+// Helper function to scan a rma joined with its product from rows iterator
+func (w *WorkerRepository) scanRmaFromRows(rows pgx.Rows) (*model.Rma, error) {
+	rma := model.Rma{}
+	var nullUpdatedAt sql.NullTime
+
+	err := rows.Scan(&rma.ID,
+					&rma.OrderRef,
+					&rma.Product.ID,
+					&rma.Product.Sku,
+					&rma.Quantity,
+					&rma.Received,
+					&rma.Restock,
+					&rma.Damaged,
+					&rma.Status,
+					&rma.Reason,
+					&rma.CreatedAt,
+					&nullUpdatedAt,
+				)
+	if err != nil {
+		return nil, fmt.Errorf("FAILED to scan rma from rows: %w", dbError(err))
+	}
+
+	rma.UpdatedAt = w.pointerTime(nullUpdatedAt)
+	return &rma, nil
+}
+
+// About create a rma (return merchandise authorization)
+func (w* WorkerRepository) AddRma(ctx context.Context,
+								tx service.Tx,
+								rma *model.Rma) (*model.Rma, error){
+	w.logger.Info().
+			Ctx(ctx).
+			Str("func","AddRma").Send()
+
+	// trace
+	ctx, span := w.tracerProvider.SpanCtx(ctx, "database.AddRma", trace.SpanKindInternal)
+	defer span.End()
+
+	//Prepare
+	var id int
+
+	// Query Execute
+	query := `INSERT INTO rma ( order_ref,
+								fk_product_id,
+								quantity,
+								status,
+								reason,
+								created_at)
+				VALUES($1, $2, $3, $4, $5, $6) RETURNING id`
+
+	row := pgxTx(tx).QueryRow(	ctx,
+						query,
+						rma.OrderRef,
+						rma.Product.ID,
+						rma.Quantity,
+						rma.Status,
+						rma.Reason,
+						rma.CreatedAt)
+
+	if err := row.Scan(&id); err != nil {
+		span.RecordError(err)
+        span.SetStatus(codes.Error, err.Error())
+		w.logger.Error().
+				Ctx(ctx).
+				Err(err).Send()
+		return nil, fmt.Errorf("FAILED to insert rma: %w", dbError(err))
+	}
+
+	// Set PK
+	rma.ID = id
+
+	return rma , nil
+}
+
+// About get a rma
+func (w *WorkerRepository) GetRma(ctx context.Context,
+								rma *model.Rma) (*model.Rma, error){
+	w.logger.Info().
+			Ctx(ctx).
+			Str("func","GetRma").Send()
+
+	// Trace
+	ctx, span := w.tracerProvider.SpanCtx(ctx, "database.GetRma", trace.SpanKindInternal)
+	defer span.End()
+
+	// db connection
+	conn, err := w.acquireRead(ctx)
+	if err != nil {
+		span.RecordError(err)
+        span.SetStatus(codes.Error, err.Error())
+		w.logger.Error().
+				Ctx(ctx).
+				Err(err).Send()
+		return nil, fmt.Errorf("FAILED to acquire connection: %w", dbError(err))
+	}
+	defer w.DatabasePG.Release(conn)
+
+	// Query and Execute
+	query := `SELECT r.id,
+					 r.order_ref,
+					 p.id,
+					 p.sku,
+					 r.quantity,
+					 r.received,
+					 r.restock,
+					 r.damaged,
+					 r.status,
+					 r.reason,
+					 r.created_at,
+					 r.updated_at
+				FROM rma as r,
+					 product as p
+				WHERE r.id = $1
+				and p.id = r.fk_product_id`
+
+	rows, err := conn.Query(ctx,
+							query,
+							rma.ID)
+	if err != nil {
+		span.RecordError(err)
+        span.SetStatus(codes.Error, err.Error())
+		w.logger.Error().
+				Ctx(ctx).
+				Err(err).Send()
+		return nil, fmt.Errorf("FAILED to query rma: %w", dbError(err))
+	}
+	defer rows.Close()
+
+	if rows.Next() {
+		res_rma, err := w.scanRmaFromRows(rows)
+		if err != nil {
+			span.RecordError(err)
+        	span.SetStatus(codes.Error, err.Error())
+			w.logger.Error().
+					Ctx(ctx).
+					Err(err).Send()
+			return nil, fmt.Errorf("FAILED to scanRmaFromRows rma: %w", dbError(err))
+		}
+		return res_rma, nil
+	}
+
+	w.logger.Warn().
+			Ctx(ctx).
+			Err(erro.ErrNotFound).Send()
+
+	return nil, erro.ErrNotFound
+}
+
+// About get a rma locking its row until the end of the transaction
+func (w *WorkerRepository) GetRmaForUpdate(ctx context.Context,
+											tx service.Tx,
+											rma *model.Rma) (*model.Rma, error){
+	w.logger.Info().
+			Ctx(ctx).
+			Str("func","GetRmaForUpdate").Send()
+
+	// Trace
+	ctx, span := w.tracerProvider.SpanCtx(ctx, "database.GetRmaForUpdate", trace.SpanKindInternal)
+	defer span.End()
+
+	// Query and Execute
+	query := `SELECT r.id,
+					 r.order_ref,
+					 p.id,
+					 p.sku,
+					 r.quantity,
+					 r.received,
+					 r.restock,
+					 r.damaged,
+					 r.status,
+					 r.reason,
+					 r.created_at,
+					 r.updated_at
+				FROM rma as r,
+					 product as p
+				WHERE r.id = $1
+				and p.id = r.fk_product_id
+				FOR UPDATE OF r`
+
+	rows, err := pgxTx(tx).Query(ctx,
+						query,
+						rma.ID)
+	if err != nil {
+		span.RecordError(err)
+        span.SetStatus(codes.Error, err.Error())
+		w.logger.Error().
+				Ctx(ctx).
+				Err(err).Send()
+		return nil, fmt.Errorf("FAILED to query rma: %w", dbError(err))
+	}
+	defer rows.Close()
+
+	if rows.Next() {
+		res_rma, err := w.scanRmaFromRows(rows)
+		if err != nil {
+			span.RecordError(err)
+        	span.SetStatus(codes.Error, err.Error())
+			w.logger.Error().
+					Ctx(ctx).
+					Err(err).Send()
+			return nil, fmt.Errorf("FAILED to scanRmaFromRows rma: %w", dbError(err))
+		}
+		return res_rma, nil
+	}
+
+	w.logger.Warn().
+			Ctx(ctx).
+			Err(erro.ErrNotFound).Send()
+
+	return nil, erro.ErrNotFound
+}
+
+// About update the quantities and status of a rma
+func (w* WorkerRepository) UpdateRma(ctx context.Context,
+									tx service.Tx,
+									rma *model.Rma) (int64, error){
+	w.logger.Info().
+			Ctx(ctx).
+			Str("func","UpdateRma").Send()
+
+	// trace
+	ctx, span := w.tracerProvider.SpanCtx(ctx, "database.UpdateRma", trace.SpanKindInternal)
+	defer span.End()
+
+	// Query Execute
+	query := `UPDATE rma
+				SET received = $2,
+					restock = $3,
+					damaged = $4,
+					status = $5,
+					updated_at = $6
+				WHERE id = $1`
+
+	row, err := pgxTx(tx).Exec(ctx,
+						query,
+						rma.ID,
+						rma.Received,
+						rma.Restock,
+						rma.Damaged,
+						rma.Status,
+						rma.UpdatedAt,
+					)
+	if err != nil {
+		span.RecordError(err)
+        span.SetStatus(codes.Error, err.Error())
+		w.logger.Error().
+				Ctx(ctx).
+				Err(err).Send()
+		return 0, fmt.Errorf("FAILED to update rma: %w", dbError(err))
+	}
+
+	return row.RowsAffected(), nil
+}
+
+// About record a step of a rma
+func (w* WorkerRepository) AddRmaEvent(ctx context.Context,
+										tx service.Tx,
+										rma *model.Rma,
+										rmaEvent *model.RmaEvent) (*model.RmaEvent, error){
+	w.logger.Info().
+			Ctx(ctx).
+			Str("func","AddRmaEvent").Send()
+
+	// trace
+	ctx, span := w.tracerProvider.SpanCtx(ctx, "database.AddRmaEvent", trace.SpanKindInternal)
+	defer span.End()
+
+	//Prepare
+	var id int
+
+	// Query Execute
+	query := `INSERT INTO rma_event ( 	fk_rma_id,
+										step,
+										quantity,
+										note,
+										created_at)
+				VALUES($1, $2, $3, $4, $5) RETURNING id`
+
+	row := pgxTx(tx).QueryRow(	ctx,
+						query,
+						rma.ID,
+						rmaEvent.Step,
+						rmaEvent.Quantity,
+						rmaEvent.Note,
+						rmaEvent.CreatedAt)
+
+	if err := row.Scan(&id); err != nil {
+		span.RecordError(err)
+        span.SetStatus(codes.Error, err.Error())
+		w.logger.Error().
+				Ctx(ctx).
+				Err(err).Send()
+		return nil, fmt.Errorf("FAILED to insert rma_event: %w", dbError(err))
+	}
+
+	// Set PK
+	rmaEvent.ID = id
+
+	return rmaEvent , nil
+}
+
+// About list the steps of a rma
+func (w *WorkerRepository) ListRmaEvent(ctx context.Context,
+										rma *model.Rma) (*[]model.RmaEvent, error){
+	w.logger.Info().
+			Ctx(ctx).
+			Str("func","ListRmaEvent").Send()
+
+	// Trace
+	ctx, span := w.tracerProvider.SpanCtx(ctx, "database.ListRmaEvent", trace.SpanKindInternal)
+	defer span.End()
+
+	// db connection
+	conn, err := w.acquireRead(ctx)
+	if err != nil {
+		span.RecordError(err)
+        span.SetStatus(codes.Error, err.Error())
+		w.logger.Error().
+			  	 Ctx(ctx).
+				 Err(err).Send()
+		return nil, fmt.Errorf("FAILED to acquire connection: %w", dbError(err))
+	}
+	defer w.DatabasePG.Release(conn)
+
+	// Query and Execute
+	query := `SELECT id,
+					 step,
+					 quantity,
+					 note,
+					 created_at
+				FROM rma_event
+				WHERE fk_rma_id = $1
+				order by id asc`
+
+	rows, err := conn.Query(ctx,
+							query,
+							rma.ID)
+	if err != nil {
+		span.RecordError(err)
+        span.SetStatus(codes.Error, err.Error())
+		w.logger.Error().
+				Ctx(ctx).
+				Err(err).Send()
+		return nil, fmt.Errorf("FAILED to query rma_event: %w", dbError(err))
+	}
+	defer rows.Close()
+
+	list_event := []model.RmaEvent{}
+	for rows.Next() {
+		res_event := model.RmaEvent{}
+		err := rows.Scan(&res_event.ID,
+						&res_event.Step,
+						&res_event.Quantity,
+						&res_event.Note,
+						&res_event.CreatedAt)
+		if err != nil {
+			span.RecordError(err)
+        	span.SetStatus(codes.Error, err.Error())
+			w.logger.Error().
+					Ctx(ctx).
+					Err(err).Send()
+			return nil, fmt.Errorf("FAILED to scan rma_event row: %w", dbError(err))
+		}
+		list_event = append(list_event, res_event)
+	}
+
+	return &list_event, nil
+}
@@ -7,6 +7,7 @@ import (
 	"github.com/jackc/pgx/v5"
 
 	"github.com/go-inventory/internal/domain/model"
+	"github.com/go-inventory/internal/domain/service"
 
 	"go.opentelemetry.io/otel/trace"
 	"go.opentelemetry.io/otel/codes"
@@ -26,10 +27,13 @@ func (w *WorkerRepository) scanInventoryFromRows(rows pgx.Rows) (*model.Inventor
 					&inventory.Sold,
 					&inventory.Pending,
 					&inventory.Incoming,
+					&inventory.Quarantine,
+					&inventory.Damaged,
+					&inventory.Hold,
 					&product.LeadTime,
 				)
 	if err != nil {
-		return nil, fmt.Errorf("FAILED to scan inventory from rows: %w", err)
+		return nil, fmt.Errorf("FAILED to scan inventory from rows: %w", dbError(err))
 	}
 	// Set product
 	inventory.Product = product
@@ -39,7 +43,7 @@ func (w *WorkerRepository) scanInventoryFromRows(rows pgx.Rows) (*model.Inventor
 
 // AddInventoryTimeSeries inserts a new inventory record into the inventory_time_series table and returns the inserted inventory with its ID.
 func (w* WorkerRepository) AddInventoryTimeSeries(ctx context.Context, 
-												tx pgx.Tx, 
+												tx service.Tx, 
 												inventory *model.Inventory) (*model.Inventory, error){
 	w.logger.Info().
 			Ctx(ctx).
@@ -59,10 +63,13 @@ func (w* WorkerRepository) AddInventoryTimeSeries(ctx context.Context,
 													pending,
 													sold,
 													incoming,
+													quarantine,
+													damaged,
+													hold,
 													created_at) 
-				VALUES($1, $2, $3, $4, $5, $6, $7) RETURNING id`
+				VALUES($1, $2, $3, $4, $5, $6, $7, $8, $9, $10) RETURNING id`
 
-	row := tx.QueryRow(	ctx, 
+	row := pgxTx(tx).QueryRow(	ctx, 
 						query,
 						inventory.CreatedAt,
 						inventory.Product.ID,
@@ -70,6 +77,9 @@ func (w* WorkerRepository) AddInventoryTimeSeries(ctx context.Context,
 						inventory.Pending,
 						inventory.Sold,
 						inventory.Incoming,
+						inventory.Quarantine,
+						inventory.Damaged,
+						inventory.Hold,
 						inventory.CreatedAt)
 						
 	if err := row.Scan(&id); err != nil {
@@ -78,7 +88,7 @@ func (w* WorkerRepository) AddInventoryTimeSeries(ctx context.Context,
 		w.logger.Error().
 				Ctx(ctx).
 				Err(err).Send()
-		return nil, fmt.Errorf("FAILED to insert inventory_time_series: %w", err)
+		return nil, fmt.Errorf("FAILED to insert inventory_time_series: %w", dbError(err))
 	}
 
 	// Set PK
@@ -101,14 +111,14 @@ func (w *WorkerRepository) GetInventoryTimeSeries(	ctx context.Context,
 	defer span.End()
 
 	// db connection
-	conn, err := w.DatabasePG.Acquire(ctx)
+	conn, err := w.acquireRead(ctx)
 	if err != nil {
 		span.RecordError(err) 
         span.SetStatus(codes.Error, err.Error())
 		w.logger.Error().
 			  	 Ctx(ctx).
 				 Err(err).Send()
-		return nil, fmt.Errorf("FAILED to acquire connection: %w", err)
+		return nil, fmt.Errorf("FAILED to acquire connection: %w", dbError(err))
 	}
 	defer w.DatabasePG.Release(conn)
 
@@ -121,6 +131,9 @@ func (w *WorkerRepository) GetInventoryTimeSeries(	ctx context.Context,
 										its.sold,
 										its.pending,
 										its.incoming,
+										its.quarantine,
+										its.damaged,
+										its.hold,
 										pr.lead_time
 								from 	inventory_time_series its,
 										product pr
@@ -141,7 +154,7 @@ func (w *WorkerRepository) GetInventoryTimeSeries(	ctx context.Context,
 		w.logger.Error().
 				Ctx(ctx).
 				Err(err).Send()
-		return nil, fmt.Errorf("FAILED to query inventory_time_series: %w", err)
+		return nil, fmt.Errorf("FAILED to query inventory_time_series: %w", dbError(err))
 	}
 	defer rows.Close()
 
@@ -154,7 +167,100 @@ func (w *WorkerRepository) GetInventoryTimeSeries(	ctx context.Context,
 			w.logger.Error().
 					Ctx(ctx).
 					Err(err).Send()
-			return nil, fmt.Errorf("FAILED to scanInventoryFromRows inventory_time_series: %w", err)
+			return nil, fmt.Errorf("FAILED to scanInventoryFromRows inventory_time_series: %w", dbError(err))
+		}
+		list_inventory = append(list_inventory, *res_inventory)
+	}
+
+	return &list_inventory, nil
+}
+
+// About get the time series of several products in one query (batch loading), a window of snapshots per product
+func (w *WorkerRepository) ListInventoryTimeSeriesBySku(ctx context.Context, 
+														windowsize int,
+														offset int,
+														skus []string) (*[]model.Inventory, error){
+	w.logger.Info().
+			Ctx(ctx).
+			Str("func","ListInventoryTimeSeriesBySku").Send()
+
+	// Trace
+	ctx, span := w.tracerProvider.SpanCtx(ctx, "database.ListInventoryTimeSeriesBySku", trace.SpanKindInternal)
+	defer span.End()
+
+	// db connection
+	conn, err := w.acquireRead(ctx)
+	if err != nil {
+		span.RecordError(err) 
+        span.SetStatus(codes.Error, err.Error())
+		w.logger.Error().
+			  	 Ctx(ctx).
+				 Err(err).Send()
+		return nil, fmt.Errorf("FAILED to acquire connection: %w", dbError(err))
+	}
+	defer w.DatabasePG.Release(conn)
+
+	// Query and Execute
+	// the snapshots are numbered per product from the newest, so the window is applied to each product
+	query := `select 	ts.sku,
+						ts.id,
+						ts.fk_product_id, 
+						ts.snapshot_date, 
+						ts.available,
+						ts.sold,
+						ts.pending,
+						ts.incoming,
+						ts.quarantine,
+						ts.damaged,
+						ts.hold,
+						ts.lead_time
+				from ( select 	pr.sku,
+								its.id,
+								its.fk_product_id, 
+								its.snapshot_date, 
+								its.available,
+								its.sold,
+								its.pending,
+								its.incoming,
+								its.quarantine,
+								its.damaged,
+								its.hold,
+								pr.lead_time,
+								row_number() over (partition by its.fk_product_id order by its.snapshot_date desc) as rn
+						from 	inventory_time_series its,
+								product pr
+						where pr.sku = ANY($1)
+						and its.fk_product_id = pr.id
+						and its.sold > 0 ) ts
+				where ts.rn > $3
+				and ts.rn <= $2 + $3
+				order by ts.sku, ts.snapshot_date asc;`
+
+	rows, err := conn.Query(ctx, 
+							query, 
+							skus, 
+							windowsize,
+							offset)
+	if err != nil {
+		span.RecordError(err) 
+        span.SetStatus(codes.Error, err.Error())
+		w.logger.Error().
+				Ctx(ctx).
+				Err(err).Send()
+		return nil, fmt.Errorf("FAILED to query inventory_time_series: %w", dbError(err))
+	}
+	defer rows.Close()
+
+	list_inventory := []model.Inventory{}
+	for rows.Next() {
+		res_inventory, err := w.scanInventoryFromRows(rows)
+		if err != nil {
+			span.RecordError(err) 
+        	span.SetStatus(codes.Error, err.Error())
+			w.logger.Error().
+					Ctx(ctx).
+					Err(err).Send()
+			return nil, fmt.Errorf("FAILED to scanInventoryFromRows inventory_time_series: %w", dbError(err))
 		}
 		list_inventory = append(list_inventory, *res_inventory)
 	}
@@ -0,0 +1,107 @@
+package database
+
+import (
+	"fmt"
+	"context"
+	"encoding/json"
+
+	"github.com/go-inventory/internal/domain/model"
+	"github.com/go-inventory/internal/domain/service"
+
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/codes"
+)
+
+// postgres channel of the inventory changes
+const inventoryChangedChannel = "inventory_changed"
+
+// About notify a inventory change, postgres only delivers the notification when the transaction commits
+func (w *WorkerRepository) NotifyInventoryChanged(ctx context.Context,
+												tx service.Tx,
+												event *model.InventoryEvent) error{
+	w.logger.Info().
+			Ctx(ctx).
+			Str("func","NotifyInventoryChanged").Send()
+
+	// trace
+	ctx, span := w.tracerProvider.SpanCtx(ctx, "database.NotifyInventoryChanged", trace.SpanKindInternal)
+	defer span.End()
+
+	payload, err := json.Marshal(event)
+	if err != nil {
+		span.RecordError(err)
+        span.SetStatus(codes.Error, err.Error())
+		return fmt.Errorf("FAILED to marshal inventory event: %w", err)
+	}
+
+	// Query Execute
+	_, err = pgxTx(tx).Exec(ctx, `SELECT pg_notify($1, $2)`, inventoryChangedChannel, string(payload))
+	if err != nil {
+		span.RecordError(err)
+        span.SetStatus(codes.Error, err.Error())
+		w.logger.Error().
+				Ctx(ctx).
+				Err(err).Send()
+		return fmt.Errorf("FAILED to notify inventory change: %w", dbError(err))
+	}
+
+	return nil
+}
+
+// About listen the inventory changes calling the handler for each notification, it blocks until
+// the context is done or the connection fails. The connection is taken from the pool for the whole time
+func (w *WorkerRepository) ListenInventoryChanged(ctx context.Context,
+												handler func(*model.InventoryEvent)) error{
+	w.logger.Info().
+			Ctx(ctx).
+			Str("func","ListenInventoryChanged").Send()
+
+	// db connection
+	conn, err := w.DatabasePG.Acquire(ctx)
+	if err != nil {
+		w.logger.Error().
+				Ctx(ctx).
+				Err(err).Send()
+		return fmt.Errorf("FAILED to acquire connection: %w", dbError(err))
+	}
+	defer func() {
+		// a connection still listening can not go back to the pool
+		if _, err := conn.Exec(context.Background(), `UNLISTEN *`); err != nil {
+			conn.Conn().Close(context.Background())
+		}
+		w.DatabasePG.Release(conn)
+	}()
+
+	_, err = conn.Exec(ctx, `LISTEN ` + inventoryChangedChannel)
+	if err != nil {
+		w.logger.Error().
+				Ctx(ctx).
+				Err(err).Send()
+		return fmt.Errorf("FAILED to listen %s: %w", inventoryChangedChannel, dbError(err))
+	}
+
+	for {
+		notification, err := conn.Conn().WaitForNotification(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			w.logger.Error().
+					Ctx(ctx).
+					Err(err).Send()
+			return fmt.Errorf("FAILED to wait notification: %w", dbError(err))
+		}
+
+		event := model.InventoryEvent{}
+		if err := json.Unmarshal([]byte(notification.Payload), &event); err != nil {
+			w.logger.Warn().
+					Ctx(ctx).
+					Err(err).
+					Str("payload", notification.Payload).
+					Msg("invalid inventory notification")
+			continue
+		}
+
+		handler(&event)
+	}
+}
@@ -0,0 +1,87 @@
+package database
+
+import (
+	"fmt"
+	"sync"
+	"context"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/go-inventory/internal/domain/service"
+	"github.com/go-inventory/shared/security"
+
+	go_core_db_pg "github.com/eliezerraj/go-core/v2/database/postgre"
+)
+
+// postgres setting read by the row level security policies and by the default of the tenant_id columns,
+// so the queries never filter the tenant themselves
+const tenantSetting = "app.tenant_id"
+
+// Helper function to acquire a connection bound to the tenant of the context. The setting lasts for the
+// session, every acquire replaces it, so a connection never keeps the tenant of its previous use
+func (w *WorkerRepository) acquire(ctx context.Context) (*pgxpool.Conn, error) {
+	return w.acquireFrom(ctx, w.DatabasePG)
+}
+
+// Helper function to acquire a connection of a pool (primary or replica) bound to the tenant of the context
+func (w *WorkerRepository) acquireFrom(ctx context.Context, databasePG *go_core_db_pg.DatabasePGServer) (*pgxpool.Conn, error) {
+	conn, err := databasePG.Acquire(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = conn.Exec(ctx, `SELECT set_config($1, $2, false)`, tenantSetting, security.TenantFrom(ctx))
+	if err != nil {
+		databasePG.Release(conn)
+		return nil, fmt.Errorf("FAILED to set tenant: %w", dbError(err))
+	}
+
+	return conn, nil
+}
+
+// pgTx is a transaction of the primary with its connection, the connection goes back to the pool once the
+// transaction ends
+type pgTx struct {
+	pgx.Tx
+	databasePG	*go_core_db_pg.DatabasePGServer
+	conn		*pgxpool.Conn
+	release		sync.Once
+}
+
+// About commit the transaction and release its connection
+func (t *pgTx) Commit(ctx context.Context) error {
+	defer t.release.Do(func() { t.databasePG.ReleaseTx(t.conn) })
+	return t.Tx.Commit(ctx)
+}
+
+// About rollback the transaction and release its connection
+func (t *pgTx) Rollback(ctx context.Context) error {
+	defer t.release.Do(func() { t.databasePG.ReleaseTx(t.conn) })
+	return t.Tx.Rollback(ctx)
+}
+
+// Helper function to get the pgx transaction of a transaction started by StartTx
+func pgxTx(tx service.Tx) pgx.Tx {
+	return tx.(*pgTx).Tx
+}
+
+// About start a transaction bound to the tenant of the context, the setting ends with the transaction. The caller
+// reads the primary for a while, so it reads its own writes
+func (w *WorkerRepository) StartTx(ctx context.Context) (service.Tx, error) {
+	w.replica.markWrite(ctx)
+
+	tx, conn, err := w.DatabasePG.StartTx(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = tx.Exec(ctx, `SELECT set_config($1, $2, true)`, tenantSetting, security.TenantFrom(ctx))
+	if err != nil {
+		tx.Rollback(ctx)
+		w.DatabasePG.ReleaseTx(conn)
+		return nil, fmt.Errorf("FAILED to set tenant: %w", dbError(err))
+	}
+
+	return &pgTx{Tx: tx, databasePG: w.DatabasePG, conn: conn}, nil
+}
@@ -0,0 +1,611 @@
+package database
+
+import (
+	"fmt"
+	"context"
+	"database/sql"
+
+	"github.com/jackc/pgx/v5"
+
+	"github.com/go-inventory/shared/erro"
+	"github.com/go-inventory/internal/domain/model"
+	"github.com/go-inventory/internal/domain/service"
+
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/codes"
+)
+
+// Helper function to scan a webhook from rows iterator
+func (w *WorkerRepository) scanWebhookFromRows(rows pgx.Rows) (*model.Webhook, error) {
+	webhook := model.Webhook{}
+	var nullUpdatedAt sql.NullTime
+
+	err := rows.Scan(&webhook.ID,
+					&webhook.Url,
+					&webhook.Secret,
+					&webhook.Sku,
+					&webhook.ProductType,
+					&webhook.Threshold,
+					&webhook.Active,
+					&webhook.CreatedAt,
+					&nullUpdatedAt,
+				)
+	if err != nil {
+		return nil, fmt.Errorf("FAILED to scan webhook from rows: %w", dbError(err))
+	}
+
+	webhook.UpdatedAt = w.pointerTime(nullUpdatedAt)
+	return &webhook, nil
+}
+
+// Helper function to scan a webhook delivery from rows iterator
+func (w *WorkerRepository) scanWebhookDeliveryFromRows(rows pgx.Rows) (*model.WebhookDelivery, error) {
+	delivery := model.WebhookDelivery{}
+	var nullDeliveredAt sql.NullTime
+
+	err := rows.Scan(&delivery.ID,
+					&delivery.WebhookID,
+					&delivery.EventID,
+					&delivery.Type,
+					&delivery.Sku,
+					&delivery.Payload,
+					&delivery.Status,
+					&delivery.Attempts,
+					&delivery.LastStatusCode,
+					&delivery.LastError,
+					&delivery.NextAttemptAt,
+					&nullDeliveredAt,
+					&delivery.CreatedAt,
+				)
+	if err != nil {
+		return nil, fmt.Errorf("FAILED to scan webhook delivery from rows: %w", dbError(err))
+	}
+
+	delivery.DeliveredAt = w.pointerTime(nullDeliveredAt)
+	return &delivery, nil
+}
+
+// About register a webhook
+func (w *WorkerRepository) AddWebhook(ctx context.Context,
+									tx service.Tx,
+									webhook *model.Webhook) (*model.Webhook, error){
+	w.logger.Info().
+			Ctx(ctx).
+			Str("func","AddWebhook").Send()
+
+	// trace
+	ctx, span := w.tracerProvider.SpanCtx(ctx, "database.AddWebhook", trace.SpanKindInternal)
+	defer span.End()
+
+	//Prepare
+	var id int
+
+	// Query Execute, the empty sku or product type is stored as null
+	query := `INSERT INTO webhook (	url,
+									secret,
+									sku,
+									product_type,
+									threshold,
+									active,
+									created_at)
+				VALUES($1, $2, nullif($3, ''), nullif($4, ''), $5, $6, $7) RETURNING id`
+
+	row := pgxTx(tx).QueryRow(	ctx,
+						query,
+						webhook.Url,
+						webhook.Secret,
+						webhook.Sku,
+						webhook.ProductType,
+						webhook.Threshold,
+						webhook.Active,
+						webhook.CreatedAt)
+
+	if err := row.Scan(&id); err != nil {
+		span.RecordError(err)
+        span.SetStatus(codes.Error, err.Error())
+		w.logger.Error().
+				Ctx(ctx).
+				Err(err).Send()
+		return nil, fmt.Errorf("FAILED to insert webhook: %w", dbError(err))
+	}
+
+	// Set PK
+	webhook.ID = id
+
+	return webhook, nil
+}
+
+// About get a webhook
+func (w *WorkerRepository) GetWebhook(ctx context.Context,
+									webhook *model.Webhook) (*model.Webhook, error){
+	w.logger.Info().
+			Ctx(ctx).
+			Str("func","GetWebhook").Send()
+
+	// Trace
+	ctx, span := w.tracerProvider.SpanCtx(ctx, "database.GetWebhook", trace.SpanKindInternal)
+	defer span.End()
+
+	// db connection
+	conn, err := w.acquire(ctx)
+	if err != nil {
+		span.RecordError(err)
+        span.SetStatus(codes.Error, err.Error())
+		w.logger.Error().
+				Ctx(ctx).
+				Err(err).Send()
+		return nil, fmt.Errorf("FAILED to acquire connection: %w", dbError(err))
+	}
+	defer w.DatabasePG.Release(conn)
+
+	// Query and Execute
+	query := `SELECT id,
+					 url,
+					 secret,
+					 coalesce(sku, ''),
+					 coalesce(product_type, ''),
+					 threshold,
+					 active,
+					 created_at,
+					 updated_at
+				FROM webhook
+				WHERE id = $1`
+
+	rows, err := conn.Query(ctx,
+							query,
+							webhook.ID)
+	if err != nil {
+		span.RecordError(err)
+        span.SetStatus(codes.Error, err.Error())
+		w.logger.Error().
+				Ctx(ctx).
+				Err(err).Send()
+		return nil, fmt.Errorf("FAILED to query webhook: %w", dbError(err))
+	}
+	defer rows.Close()
+
+	if rows.Next() {
+		res_webhook, err := w.scanWebhookFromRows(rows)
+		if err != nil {
+			span.RecordError(err)
+        	span.SetStatus(codes.Error, err.Error())
+			w.logger.Error().
+					Ctx(ctx).
+					Err(err).Send()
+			return nil, fmt.Errorf("FAILED to scanWebhookFromRows webhook: %w", dbError(err))
+		}
+		return res_webhook, nil
+	}
+
+	w.logger.Warn().
+			Ctx(ctx).
+			Err(erro.ErrNotFound).Send()
+
+	return nil, erro.ErrNotFound
+}
+
+// About deactivate a webhook, it is kept for the delivery log
+func (w *WorkerRepository) DeactivateWebhook(ctx context.Context,
+											tx service.Tx,
+											webhook *model.Webhook) (int64, error){
+	w.logger.Info().
+			Ctx(ctx).
+			Str("func","DeactivateWebhook").Send()
+
+	// trace
+	ctx, span := w.tracerProvider.SpanCtx(ctx, "database.DeactivateWebhook", trace.SpanKindInternal)
+	defer span.End()
+
+	// Query Execute
+	query := `UPDATE webhook
+				SET active = false,
+					updated_at = $2
+				WHERE id = $1
+				and active = true`
+
+	row, err := pgxTx(tx).Exec(ctx,
+						query,
+						webhook.ID,
+						webhook.UpdatedAt)
+	if err != nil {
+		span.RecordError(err)
+        span.SetStatus(codes.Error, err.Error())
+		w.logger.Error().
+				Ctx(ctx).
+				Err(err).Send()
+		return 0, fmt.Errorf("FAILED to update webhook: %w", dbError(err))
+	}
+
+	return row.RowsAffected(), nil
+}
+
+// About list the active webhooks of a sku, registered for the sku itself or for its product type
+func (w *WorkerRepository) ListWebhookMatch(ctx context.Context,
+											tx service.Tx,
+											product *model.Product) (*[]model.Webhook, error){
+	w.logger.Info().
+			Ctx(ctx).
+			Str("func","ListWebhookMatch").Send()
+
+	// trace
+	ctx, span := w.tracerProvider.SpanCtx(ctx, "database.ListWebhookMatch", trace.SpanKindInternal)
+	defer span.End()
+
+	// Query and Execute
+	query := `SELECT id,
+					 url,
+					 secret,
+					 coalesce(sku, ''),
+					 coalesce(product_type, ''),
+					 threshold,
+					 active,
+					 created_at,
+					 updated_at
+				FROM webhook
+				WHERE active = true
+				and (sku = $1 or product_type = $2)
+				order by id`
+
+	rows, err := pgxTx(tx).Query(ctx,
+						query,
+						product.Sku,
+						product.Type)
+	if err != nil {
+		span.RecordError(err)
+        span.SetStatus(codes.Error, err.Error())
+		w.logger.Error().
+				Ctx(ctx).
+				Err(err).Send()
+		return nil, fmt.Errorf("FAILED to query webhook: %w", dbError(err))
+	}
+	defer rows.Close()
+
+	list_webhook := []model.Webhook{}
+	for rows.Next() {
+		res_webhook, err := w.scanWebhookFromRows(rows)
+		if err != nil {
+			span.RecordError(err)
+        	span.SetStatus(codes.Error, err.Error())
+			w.logger.Error().
+					Ctx(ctx).
+					Err(err).Send()
+			return nil, err
+		}
+		list_webhook = append(list_webhook, *res_webhook)
+	}
+
+	return &list_webhook, nil
+}
+
+// About add a delivery of a alert, in the transaction of the change that raised it
+func (w *WorkerRepository) AddWebhookDelivery(ctx context.Context,
+											tx service.Tx,
+											delivery *model.WebhookDelivery) (*model.WebhookDelivery, error){
+	w.logger.Info().
+			Ctx(ctx).
+			Str("func","AddWebhookDelivery").Send()
+
+	// trace
+	ctx, span := w.tracerProvider.SpanCtx(ctx, "database.AddWebhookDelivery", trace.SpanKindInternal)
+	defer span.End()
+
+	//Prepare
+	var id int
+
+	// Query Execute
+	query := `INSERT INTO webhook_delivery (fk_webhook_id,
+											event_id,
+											type,
+											sku,
+											payload,
+											status,
+											attempts,
+											next_attempt_at,
+											created_at)
+				VALUES($1, $2, $3, $4, $5, $6, 0, $7, $7) RETURNING id`
+
+	row := pgxTx(tx).QueryRow(	ctx,
+						query,
+						delivery.WebhookID,
+						delivery.EventID,
+						delivery.Type,
+						delivery.Sku,
+						delivery.Payload,
+						delivery.Status,
+						delivery.CreatedAt)
+
+	if err := row.Scan(&id); err != nil {
+		span.RecordError(err)
+        span.SetStatus(codes.Error, err.Error())
+		w.logger.Error().
+				Ctx(ctx).
+				Err(err).Send()
+		return nil, fmt.Errorf("FAILED to insert webhook_delivery: %w", dbError(err))
+	}
+
+	// Set PK
+	delivery.ID = id
+
+	return delivery, nil
+}
+
+// About list the deliveries ready to be posted with their webhook locking them, the deliveries locked by
+// other pods are skipped. The deliveries of a deactivated webhook are not posted
+func (w *WorkerRepository) ListWebhookDeliveryPendingForUpdate(ctx context.Context,
+																tx service.Tx,
+																limit int) (*[]model.WebhookDelivery, error){
+	w.logger.Debug().
+			Ctx(ctx).
+			Str("func","ListWebhookDeliveryPendingForUpdate").Send()
+
+	// trace
+	ctx, span := w.tracerProvider.SpanCtx(ctx, "database.ListWebhookDeliveryPendingForUpdate", trace.SpanKindInternal)
+	defer span.End()
+
+	// Query and Execute
+	query := `SELECT d.id,
+					 d.fk_webhook_id,
+					 d.event_id,
+					 d.type,
+					 d.sku,
+					 d.payload,
+					 d.status,
+					 d.attempts,
+					 d.last_status_code,
+					 d.last_error,
+					 d.next_attempt_at,
+					 d.delivered_at,
+					 d.created_at,
+					 w.url,
+					 w.secret
+				FROM webhook_delivery as d,
+					 webhook as w
+				WHERE d.status = $1
+				and d.next_attempt_at <= now()
+				and w.id = d.fk_webhook_id
+				and w.active = true
+				order by d.id
+				limit $2
+				FOR UPDATE OF d SKIP LOCKED`
+
+	rows, err := pgxTx(tx).Query(ctx,
+						query,
+						model.DeliveryPending,
+						limit)
+	if err != nil {
+		span.RecordError(err)
+        span.SetStatus(codes.Error, err.Error())
+		w.logger.Error().
+				Ctx(ctx).
+				Err(err).Send()
+		return nil, fmt.Errorf("FAILED to query webhook_delivery: %w", dbError(err))
+	}
+	defer rows.Close()
+
+	list_delivery := []model.WebhookDelivery{}
+	for rows.Next() {
+		delivery := model.WebhookDelivery{}
+		var nullDeliveredAt sql.NullTime
+
+		err := rows.Scan(
+						&delivery.ID,
+						&delivery.WebhookID,
+						&delivery.EventID,
+						&delivery.Type,
+						&delivery.Sku,
+						&delivery.Payload,
+						&delivery.Status,
+						&delivery.Attempts,
+						&delivery.LastStatusCode,
+						&delivery.LastError,
+						&delivery.NextAttemptAt,
+						&nullDeliveredAt,
+						&delivery.CreatedAt,
+						&delivery.Webhook.Url,
+						&delivery.Webhook.Secret,
+					)
+		if err != nil {
+			span.RecordError(err)
+        	span.SetStatus(codes.Error, err.Error())
+			w.logger.Error().
+					Ctx(ctx).
+					Err(err).Send()
+			return nil, fmt.Errorf("FAILED to scan webhook_delivery row: %w", dbError(err))
+		}
+		delivery.DeliveredAt = w.pointerTime(nullDeliveredAt)
+		delivery.Webhook.ID = delivery.WebhookID
+		list_delivery = append(list_delivery, delivery)
+	}
+
+	return &list_delivery, nil
+}
+
+// About update the attempt of a delivery (status, attempts, next attempt)
+func (w *WorkerRepository) UpdateWebhookDelivery(ctx context.Context,
+												tx service.Tx,
+												delivery *model.WebhookDelivery) (int64, error){
+	w.logger.Info().
+			Ctx(ctx).
+			Str("func","UpdateWebhookDelivery").Send()
+
+	// trace
+	ctx, span := w.tracerProvider.SpanCtx(ctx, "database.UpdateWebhookDelivery", trace.SpanKindInternal)
+	defer span.End()
+
+	// Query Execute
+	query := `UPDATE webhook_delivery
+				SET status = $2,
+					attempts = $3,
+					last_status_code = $4,
+					last_error = $5,
+					next_attempt_at = $6,
+					delivered_at = $7
+				WHERE id = $1`
+
+	row, err := pgxTx(tx).Exec(ctx,
+						query,
+						delivery.ID,
+						delivery.Status,
+						delivery.Attempts,
+						delivery.LastStatusCode,
+						delivery.LastError,
+						delivery.NextAttemptAt,
+						delivery.DeliveredAt)
+	if err != nil {
+		span.RecordError(err)
+        span.SetStatus(codes.Error, err.Error())
+		w.logger.Error().
+				Ctx(ctx).
+				Err(err).Send()
+		return 0, fmt.Errorf("FAILED to update webhook_delivery: %w", dbError(err))
+	}
+
+	return row.RowsAffected(), nil
+}
+
+// About move a dead delivery back to pending, the attempts start again
+func (w *WorkerRepository) RetryWebhookDelivery(ctx context.Context,
+												tx service.Tx,
+												delivery *model.WebhookDelivery) (*model.WebhookDelivery, error){
+	w.logger.Info().
+			Ctx(ctx).
+			Str("func","RetryWebhookDelivery").Send()
+
+	// trace
+	ctx, span := w.tracerProvider.SpanCtx(ctx, "database.RetryWebhookDelivery", trace.SpanKindInternal)
+	defer span.End()
+
+	// Query Execute
+	query := `UPDATE webhook_delivery
+				SET status = $2,
+					attempts = 0,
+					next_attempt_at = now()
+				WHERE id = $1
+				and status = $3
+				RETURNING id,
+						fk_webhook_id,
+						event_id,
+						type,
+						sku,
+						payload,
+						status,
+						attempts,
+						last_status_code,
+						last_error,
+						next_attempt_at,
+						delivered_at,
+						created_at`
+
+	rows, err := pgxTx(tx).Query(ctx,
+						query,
+						delivery.ID,
+						model.DeliveryPending,
+						model.DeliveryDead)
+	if err != nil {
+		span.RecordError(err)
+        span.SetStatus(codes.Error, err.Error())
+		w.logger.Error().
+				Ctx(ctx).
+				Err(err).Send()
+		return nil, fmt.Errorf("FAILED to update webhook_delivery: %w", dbError(err))
+	}
+	defer rows.Close()
+
+	if rows.Next() {
+		res_delivery, err := w.scanWebhookDeliveryFromRows(rows)
+		if err != nil {
+			span.RecordError(err)
+        	span.SetStatus(codes.Error, err.Error())
+			w.logger.Error().
+					Ctx(ctx).
+					Err(err).Send()
+			return nil, err
+		}
+		return res_delivery, nil
+	}
+
+	w.logger.Warn().
+			Ctx(ctx).
+			Err(erro.ErrNotFound).Send()
+
+	return nil, erro.ErrNotFound
+}
+
+// About list the deliveries of a webhook, newest first, optionally filtered by status
+func (w *WorkerRepository) ListWebhookDelivery(ctx context.Context,
+											limit int,
+											offset int,
+											delivery *model.WebhookDelivery) (*[]model.WebhookDelivery, error){
+	w.logger.Info().
+			Ctx(ctx).
+			Str("func","ListWebhookDelivery").Send()
+
+	// Trace
+	ctx, span := w.tracerProvider.SpanCtx(ctx, "database.ListWebhookDelivery", trace.SpanKindInternal)
+	defer span.End()
+
+	// db connection
+	conn, err := w.acquireRead(ctx)
+	if err != nil {
+		span.RecordError(err)
+        span.SetStatus(codes.Error, err.Error())
+		w.logger.Error().
+				Ctx(ctx).
+				Err(err).Send()
+		return nil, fmt.Errorf("FAILED to acquire connection: %w", dbError(err))
+	}
+	defer w.DatabasePG.Release(conn)
+
+	// Query and Execute
+	query := `SELECT id,
+					 fk_webhook_id,
+					 event_id,
+					 type,
+					 sku,
+					 payload,
+					 status,
+					 attempts,
+					 last_status_code,
+					 last_error,
+					 next_attempt_at,
+					 delivered_at,
+					 created_at
+				FROM webhook_delivery
+				WHERE fk_webhook_id = $1
+				and ($2 = '' or status = $2)
+				order by id desc
+				limit $3
+				offset $4`
+
+	rows, err := conn.Query(ctx,
+							query,
+							delivery.WebhookID,
+							delivery.Status,
+							limit,
+							offset)
+	if err != nil {
+		span.RecordError(err)
+        span.SetStatus(codes.Error, err.Error())
+		w.logger.Error().
+				Ctx(ctx).
+				Err(err).Send()
+		return nil, fmt.Errorf("FAILED to query webhook_delivery: %w", dbError(err))
+	}
+	defer rows.Close()
+
+	list_delivery := []model.WebhookDelivery{}
+	for rows.Next() {
+		res_delivery, err := w.scanWebhookDeliveryFromRows(rows)
+		if err != nil {
+			span.RecordError(err)
+        	span.SetStatus(codes.Error, err.Error())
+			w.logger.Error().
+					Ctx(ctx).
+					Err(err).Send()
+			return nil, err
+		}
+		list_delivery = append(list_delivery, *res_delivery)
+	}
+
+	return &list_delivery, nil
+}
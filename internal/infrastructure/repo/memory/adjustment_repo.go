@@ -0,0 +1,101 @@
+package memory
+
+import (
+	"slices"
+	"context"
+
+	"github.com/go-inventory/internal/domain/model"
+	"github.com/go-inventory/internal/domain/service"
+	"github.com/go-inventory/shared/erro"
+)
+
+// Helper function to join a adjustment with the id and sku of its product
+func withProduct(ctx context.Context, s *state, product model.Product) (model.Product, bool) {
+	rw, ok := s.products.get(product.ID)
+	if !ok || !visible(ctx, rw.tenant) {
+		return model.Product{}, false
+	}
+	return model.Product{ID: rw.value.ID, Sku: rw.value.Sku}, true
+}
+
+// About create a inventory adjustment
+func (r *MemoryRepository) AddInventoryAdjustment(ctx context.Context,
+												tx service.Tx,
+												adjustment *model.InventoryAdjustment) (*model.InventoryAdjustment, error){
+	s := txState(tx)
+
+	tenant, err := tenantOf(ctx, "inventory_adjustment")
+	if err != nil {
+		return nil, err
+	}
+
+	adjustment.ID = r.nextID("inventory_adjustment")
+	res_adjustment := *adjustment
+	res_adjustment.Product = model.Product{ID: adjustment.Product.ID}
+	res_adjustment.UpdatedAt = nil
+	s.adjustments.put(adjustment.ID, tenant, res_adjustment)
+
+	return adjustment, nil
+}
+
+// About get a inventory adjustment by id within a transaction
+func (r *MemoryRepository) GetInventoryAdjustmentForUpdate(ctx context.Context,
+														tx service.Tx,
+														adjustment *model.InventoryAdjustment) (*model.InventoryAdjustment, error){
+	s := txState(tx)
+
+	rw, ok := s.adjustments.get(adjustment.ID)
+	if !ok || !visible(ctx, rw.tenant) {
+		return nil, erro.ErrNotFound
+	}
+	res_adjustment := rw.value
+	if res_adjustment.Product, ok = withProduct(ctx, s, res_adjustment.Product); !ok {
+		return nil, erro.ErrNotFound
+	}
+
+	return &res_adjustment, nil
+}
+
+// About update the status of a inventory adjustment
+func (r *MemoryRepository) UpdateInventoryAdjustmentStatus(ctx context.Context,
+															tx service.Tx,
+															adjustment *model.InventoryAdjustment) (int64, error){
+	s := txState(tx)
+
+	rw, ok := s.adjustments.get(adjustment.ID)
+	if !ok || !visible(ctx, rw.tenant) {
+		return 0, nil
+	}
+	res_adjustment := rw.value
+	res_adjustment.Status = adjustment.Status
+	res_adjustment.Previous = adjustment.Previous
+	res_adjustment.UpdatedAt = adjustment.UpdatedAt
+	s.adjustments.put(adjustment.ID, rw.tenant, res_adjustment)
+
+	return 1, nil
+}
+
+// About list the inventory adjustments of a product, the newest first
+func (r *MemoryRepository) ListInventoryAdjustment(ctx context.Context,
+													limit int,
+													offset int,
+													adjustment *model.InventoryAdjustment) (*[]model.InventoryAdjustment, error){
+	s := r.read()
+
+	adjustments := []model.InventoryAdjustment{}
+	for _, rw := range scan(ctx, &s.adjustments, func(_ int, a model.InventoryAdjustment) bool {
+		return adjustment.Status == "" || a.Status == adjustment.Status
+	}) {
+		product, ok := withProduct(ctx, s, rw.value.Product)
+		if !ok || product.Sku != adjustment.Product.Sku {
+			continue
+		}
+		res_adjustment := rw.value
+		res_adjustment.Product = product
+		adjustments = append(adjustments, res_adjustment)
+	}
+	slices.SortFunc(adjustments, func(a, b model.InventoryAdjustment) int { return b.ID - a.ID })
+
+	res_adjustments := page(adjustments, limit, offset)
+	return &res_adjustments, nil
+}
@@ -0,0 +1,150 @@
+package memory
+
+import (
+	"fmt"
+	"time"
+	"slices"
+	"context"
+
+	"github.com/go-inventory/internal/domain/model"
+	"github.com/go-inventory/internal/domain/service"
+	"github.com/go-inventory/shared/erro"
+)
+
+// Helper function to get a copy of a api key with its tenant, so the caller can not change the stored one
+func copyApiKey(rw row[model.ApiKey]) *model.ApiKey {
+	apiKey := rw.value
+	apiKey.Scopes = slices.Clone(apiKey.Scopes)
+	apiKey.SkuPrefixes = slices.Clone(apiKey.SkuPrefixes)
+	apiKey.Tenant = rw.tenant
+	return &apiKey
+}
+
+// Helper function to tell whether a hash is taken by another key, the hashes are unique across the tenants
+func hashTaken(s *state, id int, keyHash string) bool {
+	for _, rw := range s.apiKeys.rows {
+		if rw.value.ID != id && rw.value.KeyHash == keyHash {
+			return true
+		}
+	}
+	return false
+}
+
+// About issue a api key, only its hash is stored
+func (r *MemoryRepository) AddApiKey(ctx context.Context,
+									tx service.Tx,
+									apiKey *model.ApiKey) (*model.ApiKey, error){
+	s := txState(tx)
+
+	tenant, err := tenantOf(ctx, "api_key")
+	if err != nil {
+		return nil, err
+	}
+	if hashTaken(s, 0, apiKey.KeyHash) {
+		return nil, fmt.Errorf("FAILED to insert api_key: %w", erro.ErrDuplicateKey)
+	}
+
+	apiKey.ID = r.nextID("api_key")
+	s.apiKeys.put(apiKey.ID, tenant, model.ApiKey{
+		ID: apiKey.ID,
+		Name: apiKey.Name,
+		Prefix: apiKey.Prefix,
+		KeyHash: apiKey.KeyHash,
+		Scopes: slices.Clone(apiKey.Scopes),
+		SkuPrefixes: slices.Clone(apiKey.SkuPrefixes),
+		Quota: apiKey.Quota,
+		QuotaWindow: apiKey.QuotaWindow,
+		Status: apiKey.Status,
+		ExpiresAt: apiKey.ExpiresAt,
+		CreatedAt: apiKey.CreatedAt,
+	})
+
+	return apiKey, nil
+}
+
+// About get a api key by id
+func (r *MemoryRepository) GetApiKey(ctx context.Context,
+									apiKey *model.ApiKey) (*model.ApiKey, error){
+	rw, ok := r.read().apiKeys.get(apiKey.ID)
+	if !ok || !visible(ctx, rw.tenant) {
+		return nil, erro.ErrNotFound
+	}
+	return copyApiKey(rw), nil
+}
+
+// About get a api key by the hash of the key
+func (r *MemoryRepository) GetApiKeyByHash(ctx context.Context,
+											keyHash string) (*model.ApiKey, error){
+	rows := scan(ctx, &r.read().apiKeys, func(_ int, k model.ApiKey) bool { return k.KeyHash == keyHash })
+	if len(rows) == 0 {
+		return nil, erro.ErrNotFound
+	}
+	return copyApiKey(rows[0]), nil
+}
+
+// Helper function to change a active api key
+func updateApiKey(ctx context.Context, s *state, id int, change func(*model.ApiKey)) int64 {
+	rw, ok := s.apiKeys.get(id)
+	if !ok || !visible(ctx, rw.tenant) || rw.value.Status != model.ApiKeyActive {
+		return 0
+	}
+	apiKey := rw.value
+	change(&apiKey)
+	s.apiKeys.put(id, rw.tenant, apiKey)
+	return 1
+}
+
+// About replace the key of a active api key
+func (r *MemoryRepository) RotateApiKey(ctx context.Context,
+										tx service.Tx,
+										apiKey *model.ApiKey) (int64, error){
+	s := txState(tx)
+
+	if hashTaken(s, apiKey.ID, apiKey.KeyHash) {
+		return 0, fmt.Errorf("FAILED to update api_key: %w", erro.ErrDuplicateKey)
+	}
+
+	return updateApiKey(ctx, s, apiKey.ID, func(k *model.ApiKey) {
+		k.Prefix = apiKey.Prefix
+		k.KeyHash = apiKey.KeyHash
+		k.UpdatedAt = apiKey.UpdatedAt
+	}), nil
+}
+
+// About revoke a api key, it is kept for the audit
+func (r *MemoryRepository) RevokeApiKey(ctx context.Context,
+										tx service.Tx,
+										apiKey *model.ApiKey) (int64, error){
+	return updateApiKey(ctx, txState(tx), apiKey.ID, func(k *model.ApiKey) {
+		k.Status = model.ApiKeyRevoked
+		k.UpdatedAt = apiKey.UpdatedAt
+	}), nil
+}
+
+// About count a request of a api key in its window, returning the requests of the window. A newer window starts
+// the count again, a older one is counted in the current window
+func (r *MemoryRepository) AddApiKeyUsage(ctx context.Context,
+										tx service.Tx,
+										apiKey *model.ApiKey,
+										windowStart time.Time) (int, error){
+	s := txState(tx)
+
+	usage := model.ApiKeyUsage{WindowStart: windowStart, Requests: 1}
+	if rw, ok := s.apiKeyUsage.get(apiKey.ID); ok && !windowStart.After(rw.value.WindowStart) {
+		usage = model.ApiKeyUsage{WindowStart: rw.value.WindowStart, Requests: rw.value.Requests + 1}
+	}
+	s.apiKeyUsage.put(apiKey.ID, "", usage)
+
+	return usage.Requests, nil
+}
+
+// About get the usage of a api key, zero requests when it was not used in the window
+func (r *MemoryRepository) GetApiKeyUsage(ctx context.Context,
+										apiKey *model.ApiKey,
+										windowStart time.Time) (*model.ApiKeyUsage, error){
+	usage := model.ApiKeyUsage{WindowStart: windowStart}
+	if rw, ok := r.read().apiKeyUsage.get(apiKey.ID); ok && rw.value.WindowStart.Equal(windowStart) {
+		usage.Requests = rw.value.Requests
+	}
+	return &usage, nil
+}
@@ -0,0 +1,140 @@
+package memory
+
+import (
+	"fmt"
+	"slices"
+	"context"
+
+	"github.com/go-inventory/internal/domain/model"
+	"github.com/go-inventory/internal/domain/service"
+	"github.com/go-inventory/shared/erro"
+)
+
+// Helper function to list the audit records of a table with their tenant
+func listAudit(ctx context.Context, s *state, keep func(model.AuditRecord) bool) []model.AuditRecord {
+	records := []model.AuditRecord{}
+	for _, rw := range scan(ctx, &s.audit, func(_ int, a model.AuditRecord) bool { return keep(a) }) {
+		record := rw.value
+		record.Tenant = rw.tenant
+		records = append(records, record)
+	}
+	return records
+}
+
+// About record a change, the sealer chains it later
+func (r *MemoryRepository) AddAuditRecord(ctx context.Context,
+										tx service.Tx,
+										record *model.AuditRecord) (*model.AuditRecord, error){
+	s := txState(tx)
+
+	tenant, err := tenantOf(ctx, "audit_log")
+	if err != nil {
+		return nil, err
+	}
+
+	record.ID = r.nextID("audit_log")
+	s.audit.put(record.ID, tenant, model.AuditRecord{
+		ID: record.ID,
+		Entity: record.Entity,
+		Sku: record.Sku,
+		Action: record.Action,
+		Actor: record.Actor,
+		Route: record.Route,
+		RequestID: record.RequestID,
+		Before: slices.Clone(record.Before),
+		After: slices.Clone(record.After),
+		CreatedAt: record.CreatedAt,
+	})
+
+	return record, nil
+}
+
+// About list the audit records matching the filters, the newest first
+func (r *MemoryRepository) ListAuditRecord(ctx context.Context,
+										limit int,
+										offset int,
+										record *model.AuditRecord) (*[]model.AuditRecord, error){
+	records := listAudit(ctx, r.read(), func(a model.AuditRecord) bool {
+		return (record.Entity == "" || a.Entity == record.Entity) &&
+			(record.Sku == "" || a.Sku == record.Sku) &&
+			(record.Actor == "" || a.Actor == record.Actor) &&
+			(record.RequestID == "" || a.RequestID == record.RequestID) &&
+			(record.From == nil || !a.CreatedAt.Before(*record.From)) &&
+			(record.To == nil || a.CreatedAt.Before(*record.To))
+	})
+	slices.SortFunc(records, func(a, b model.AuditRecord) int { return b.ID - a.ID })
+
+	res_records := page(records, limit, offset)
+	return &res_records, nil
+}
+
+// About list the sealed records after a position of the chain, in the order of the chain
+func (r *MemoryRepository) ListAuditChain(ctx context.Context,
+										afterSeq int64,
+										limit int) (*[]model.AuditRecord, error){
+	records := listAudit(ctx, r.read(), func(a model.AuditRecord) bool { return a.Hash != "" && a.Seq > afterSeq })
+	slices.SortFunc(records, func(a, b model.AuditRecord) int { return int(a.Seq - b.Seq) })
+
+	res_records := page(records, limit, 0)
+	return &res_records, nil
+}
+
+// About count the records of the tenant of the context not sealed yet
+func (r *MemoryRepository) CountAuditUnsealed(ctx context.Context) (int, error){
+	return len(scan(ctx, &r.read().audit, func(_ int, a model.AuditRecord) bool { return a.Hash == "" })), nil
+}
+
+// About take the lock of the sealer, the transaction already runs alone
+func (r *MemoryRepository) TryAuditSealLock(ctx context.Context, tx service.Tx) (bool, error){
+	return true, nil
+}
+
+// About list the records not sealed yet, in the order they were written
+func (r *MemoryRepository) ListAuditUnsealedForUpdate(ctx context.Context,
+													tx service.Tx,
+													limit int) (*[]model.AuditRecord, error){
+	records := listAudit(ctx, txState(tx), func(a model.AuditRecord) bool { return a.Hash == "" })
+	slices.SortFunc(records, func(a, b model.AuditRecord) int { return a.ID - b.ID })
+
+	res_records := page(records, limit, 0)
+	return &res_records, nil
+}
+
+// About get the last sealed record of a tenant, the head of its chain. A tenant without records has a empty head
+func (r *MemoryRepository) GetAuditHead(ctx context.Context,
+										tx service.Tx,
+										tenant string) (int64, string, error){
+	var seq int64
+	var hash string
+	for _, rw := range scan(ctx, &txState(tx).audit, nil) {
+		if rw.tenant == tenant && rw.value.Hash != "" && rw.value.Seq > seq {
+			seq, hash = rw.value.Seq, rw.value.Hash
+		}
+	}
+	return seq, hash, nil
+}
+
+// About seal a record with its position in the chain of its tenant, a sealed record is never changed
+func (r *MemoryRepository) SealAuditRecord(ctx context.Context,
+										tx service.Tx,
+										record *model.AuditRecord) (int64, error){
+	s := txState(tx)
+
+	rw, ok := s.audit.get(record.ID)
+	if !ok || !visible(ctx, rw.tenant) || rw.value.Hash != "" {
+		return 0, nil
+	}
+	for _, other := range s.audit.rows {
+		if other.tenant == rw.tenant && other.value.Hash != "" && other.value.Seq == record.Seq {
+			return 0, fmt.Errorf("FAILED to seal audit_log: %w", erro.ErrDuplicateKey)
+		}
+	}
+
+	sealed := rw.value
+	sealed.Seq = record.Seq
+	sealed.PrevHash = record.PrevHash
+	sealed.Hash = record.Hash
+	s.audit.put(record.ID, rw.tenant, sealed)
+
+	return 1, nil
+}
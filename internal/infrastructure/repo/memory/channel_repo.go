@@ -0,0 +1,112 @@
+package memory
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"context"
+
+	"github.com/go-inventory/internal/domain/model"
+	"github.com/go-inventory/internal/domain/service"
+	"github.com/go-inventory/shared/erro"
+)
+
+// Helper function to list the channel allocations of a product by channel
+func listChannel(ctx context.Context, s *state, product *model.Product) *[]model.ChannelAllocation {
+	allocations := []model.ChannelAllocation{}
+	for _, rw := range scan(ctx, &s.channels, func(_ int, a model.ChannelAllocation) bool { return a.Product.ID == product.ID }) {
+		allocations = append(allocations, rw.value)
+	}
+	slices.SortFunc(allocations, func(a, b model.ChannelAllocation) int { return strings.Compare(a.Channel, b.Channel) })
+	return &allocations
+}
+
+// About create or replace the allocation of a channel of a product
+func (r *MemoryRepository) UpsertChannelAllocation(ctx context.Context,
+												tx service.Tx,
+												allocation *model.ChannelAllocation) (*model.ChannelAllocation, error){
+	s := txState(tx)
+
+	tenant, err := tenantOf(ctx, "inventory_channel")
+	if err != nil {
+		return nil, err
+	}
+
+	for id, rw := range s.channels.rows {
+		if rw.value.Product.ID == allocation.Product.ID && rw.value.Channel == allocation.Channel {
+			if !visible(ctx, rw.tenant) {
+				return nil, fmt.Errorf("FAILED to upsert inventory_channel: %w", erro.ErrConstraint)
+			}
+			res_allocation := rw.value
+			res_allocation.Type = allocation.Type
+			res_allocation.Quantity = allocation.Quantity
+			res_allocation.Percentage = allocation.Percentage
+			updatedAt := allocation.CreatedAt
+			res_allocation.UpdatedAt = &updatedAt
+			s.channels.put(id, rw.tenant, res_allocation)
+
+			allocation.ID = id
+			return allocation, nil
+		}
+	}
+
+	allocation.ID = r.nextID("inventory_channel")
+	s.channels.put(allocation.ID, tenant, model.ChannelAllocation{
+		ID: allocation.ID,
+		Product: model.Product{ID: allocation.Product.ID},
+		Channel: allocation.Channel,
+		Type: allocation.Type,
+		Quantity: allocation.Quantity,
+		Percentage: allocation.Percentage,
+		CreatedAt: allocation.CreatedAt,
+	})
+
+	return allocation, nil
+}
+
+// About delete the allocation of a channel of a product
+func (r *MemoryRepository) DeleteChannelAllocation(ctx context.Context,
+												tx service.Tx,
+												allocation *model.ChannelAllocation) (int64, error){
+	s := txState(tx)
+
+	for id, rw := range s.channels.rows {
+		if rw.value.Product.ID == allocation.Product.ID && rw.value.Channel == allocation.Channel && visible(ctx, rw.tenant) {
+			s.channels.remove(id)
+			return 1, nil
+		}
+	}
+	return 0, nil
+}
+
+// About list the channel allocations of a product
+func (r *MemoryRepository) ListChannelAllocation(ctx context.Context,
+												product *model.Product) (*[]model.ChannelAllocation, error){
+	return listChannel(ctx, r.read(), product), nil
+}
+
+// About list the channel allocations of a product within a transaction
+func (r *MemoryRepository) ListChannelAllocationForUpdate(ctx context.Context,
+														tx service.Tx,
+														product *model.Product) (*[]model.ChannelAllocation, error){
+	return listChannel(ctx, txState(tx), product), nil
+}
+
+// About add a delta to the quantity of a allocation, the quantity can not become negative
+func (r *MemoryRepository) UpdateChannelAllocationQuantity(ctx context.Context,
+															tx service.Tx,
+															allocation *model.ChannelAllocation,
+															delta int) (int64, error){
+	s := txState(tx)
+
+	rw, ok := s.channels.get(allocation.ID)
+	if !ok || !visible(ctx, rw.tenant) || rw.value.Quantity + delta < 0 {
+		return 0, nil
+	}
+	res_allocation := rw.value
+	res_allocation.Quantity += delta
+	res_allocation.UpdatedAt = allocation.UpdatedAt
+	s.channels.put(allocation.ID, rw.tenant, res_allocation)
+
+	return 1, nil
+}
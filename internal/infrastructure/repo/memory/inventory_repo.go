@@ -0,0 +1,218 @@
+package memory
+
+import (
+	"slices"
+	"strings"
+	"context"
+
+	"github.com/go-inventory/internal/domain/model"
+	"github.com/go-inventory/internal/domain/service"
+	"github.com/go-inventory/shared/erro"
+)
+
+// Helper function to list the inventories seen by the tenant of the context joined with their products, keeping
+// the products accepted by the filter, in the order of the inventory ids
+func joinInventory(ctx context.Context, s *state, keep func(model.Product) bool) []model.Inventory {
+	rows := scan(ctx, &s.inventories, nil)
+	inventories := make([]model.Inventory, 0, len(rows))
+	for _, rw := range rows {
+		product, ok := s.products.get(rw.value.Product.ID)
+		if !ok || !visible(ctx, product.tenant) || !keep(product.value) {
+			continue
+		}
+		inventory := rw.value
+		inventory.Product = product.value
+		inventories = append(inventories, inventory)
+	}
+	slices.SortFunc(inventories, func(a, b model.Inventory) int { return a.ID - b.ID })
+	return inventories
+}
+
+// Helper function to get the first inventory of a sku
+func firstInventory(ctx context.Context, s *state, sku string) (*model.Inventory, error) {
+	inventories := joinInventory(ctx, s, func(p model.Product) bool { return p.Sku == sku })
+	if len(inventories) == 0 {
+		return nil, erro.ErrNotFound
+	}
+	return &inventories[0], nil
+}
+
+// Helper function to get the column of a stock bucket
+func bucketOf(inventory *model.Inventory, bucket string) *int {
+	switch bucket {
+	case model.BucketAvailable:
+		return &inventory.Available
+	case model.BucketQuarantine:
+		return &inventory.Quarantine
+	case model.BucketDamaged:
+		return &inventory.Damaged
+	case model.BucketHold:
+		return &inventory.Hold
+	case model.BucketSold:
+		return &inventory.Sold
+	case model.BucketReserved:
+		return &inventory.Reserved
+	}
+	return nil
+}
+
+// About create a inventory
+func (r *MemoryRepository) AddInventory(ctx context.Context,
+										tx service.Tx,
+										inventory *model.Inventory) (*model.Inventory, error){
+	s := txState(tx)
+
+	tenant, err := tenantOf(ctx, "inventory")
+	if err != nil {
+		return nil, err
+	}
+
+	inventory.ID = r.nextID("inventory")
+	s.inventories.put(inventory.ID, tenant, model.Inventory{
+		ID: inventory.ID,
+		Product: model.Product{ID: inventory.Product.ID},
+		Available: inventory.Available,
+		Pending: inventory.Pending,
+		Reserved: inventory.Reserved,
+		Sold: inventory.Sold,
+		Quarantine: inventory.Quarantine,
+		Damaged: inventory.Damaged,
+		Hold: inventory.Hold,
+		Version: 1,
+		CreatedAt: inventory.CreatedAt,
+	})
+
+	return inventory, nil
+}
+
+// About get a inventory by the sku of its product
+func (r *MemoryRepository) GetInventory(ctx context.Context,
+										inventory *model.Inventory) (*model.Inventory, error){
+	return firstInventory(ctx, r.read(), inventory.Product.Sku)
+}
+
+// About get the inventory of each sku of a list, the skus without inventory are left out
+func (r *MemoryRepository) ListInventoryBySku(ctx context.Context,
+											skus []string) (*[]model.Inventory, error){
+	inventories := joinInventory(ctx, r.read(), func(p model.Product) bool { return slices.Contains(skus, p.Sku) })
+
+	res_inventory := []model.Inventory{}
+	seen := map[string]bool{}
+	for _, inventory := range inventories {
+		if !seen[inventory.Product.Sku] {
+			seen[inventory.Product.Sku] = true
+			res_inventory = append(res_inventory, inventory)
+		}
+	}
+	slices.SortStableFunc(res_inventory, func(a, b model.Inventory) int { return strings.Compare(a.Product.Sku, b.Product.Sku) })
+
+	return &res_inventory, nil
+}
+
+// About get a inventory by the sku of its product within a transaction
+func (r *MemoryRepository) GetInventoryForUpdate(ctx context.Context,
+												tx service.Tx,
+												inventory *model.Inventory) (*model.Inventory, error){
+	return firstInventory(ctx, txState(tx), inventory.Product.Sku)
+}
+
+// Helper function to change a inventory by id, the change tells whether the row can be changed
+func updateInventory(ctx context.Context, s *state, inventory *model.Inventory, id int, change func(*model.Inventory) bool) int64 {
+	rw, ok := s.inventories.get(id)
+	if !ok || !visible(ctx, rw.tenant) {
+		return 0
+	}
+
+	res_inventory := rw.value
+	if !change(&res_inventory) {
+		return 0
+	}
+	res_inventory.UpdatedAt = inventory.UpdatedAt
+	res_inventory.Version++
+	s.inventories.put(id, rw.tenant, res_inventory)
+
+	inventory.Version = res_inventory.Version
+	return 1
+}
+
+// About set the available of a inventory
+func (r *MemoryRepository) SetInventoryAvailable(ctx context.Context,
+												tx service.Tx,
+												inventory *model.Inventory) (int64, error){
+	return updateInventory(ctx, txState(tx), inventory, inventory.ID, func(i *model.Inventory) bool {
+		i.Available = inventory.Available
+		return true
+	}), nil
+}
+
+// About move a quantity between two stock buckets of a inventory, the source bucket can not become negative
+func (r *MemoryRepository) TransferInventory(ctx context.Context,
+											tx service.Tx,
+											inventory *model.Inventory,
+											transfer *model.InventoryTransfer) (int64, error){
+	probe := model.Inventory{}
+	if bucketOf(&probe, transfer.From) == nil || bucketOf(&probe, transfer.To) == nil {
+		return 0, erro.ErrBadRequest
+	}
+
+	return updateInventory(ctx, txState(tx), inventory, inventory.ID, func(i *model.Inventory) bool {
+		from, to := bucketOf(i, transfer.From), bucketOf(i, transfer.To)
+		if *from < transfer.Quantity {
+			return false
+		}
+		*from -= transfer.Quantity
+		*to += transfer.Quantity
+		return true
+	}), nil
+}
+
+// Helper function to add the deltas of a change to a inventory
+func addDeltas(inventory *model.Inventory) func(*model.Inventory) bool {
+	return func(i *model.Inventory) bool {
+		i.Available += inventory.Available
+		i.Reserved += inventory.Reserved
+		i.Sold += inventory.Sold
+		i.Pending += inventory.Pending
+		return true
+	}
+}
+
+// About add the deltas to the first inventory of a product
+func (r *MemoryRepository) UpdateInventory(ctx context.Context,
+											tx service.Tx,
+											inventory *model.Inventory) (int64, error){
+	s := txState(tx)
+
+	rows := scan(ctx, &s.inventories, func(_ int, i model.Inventory) bool { return i.Product.ID == inventory.Product.ID })
+	if len(rows) == 0 {
+		return 0, nil
+	}
+	first := slices.MinFunc(rows, func(a, b row[model.Inventory]) int { return a.value.ID - b.value.ID })
+
+	return updateInventory(ctx, s, inventory, first.value.ID, addDeltas(inventory)), nil
+}
+
+// About add the deltas to a inventory when its version matches (optimistic lock)
+func (r *MemoryRepository) UpdateInventoryIfMatch(ctx context.Context,
+												tx service.Tx,
+												inventory *model.Inventory) (int64, error){
+	version := inventory.Version
+	return updateInventory(ctx, txState(tx), inventory, inventory.ID, func(i *model.Inventory) bool {
+		return i.Version == version && addDeltas(inventory)(i)
+	}), nil
+}
+
+// About list the inventories of the skus containing a text, by sku
+func (r *MemoryRepository) ListInventory(ctx context.Context,
+										limit int,
+										offset int,
+										inventory *model.Inventory) (*[]model.Inventory, error){
+	inventories := joinInventory(ctx, r.read(), func(p model.Product) bool { return strings.Contains(p.Sku, inventory.Product.Sku) })
+	slices.SortStableFunc(inventories, func(a, b model.Inventory) int { return strings.Compare(a.Product.Sku, b.Product.Sku) })
+
+	res_inventory := page(inventories, limit, offset)
+	if len(res_inventory) == 0 {
+		return nil, erro.ErrNotFound
+	}
+	return &res_inventory, nil
+}
@@ -0,0 +1,255 @@
+package memory
+
+import (
+	"fmt"
+	"sync"
+	"time"
+	"errors"
+	"context"
+	"sync/atomic"
+
+	"github.com/rs/zerolog"
+
+	"github.com/go-inventory/internal/domain/model"
+	"github.com/go-inventory/internal/domain/service"
+	"github.com/go-inventory/shared/erro"
+	"github.com/go-inventory/shared/security"
+
+	go_core_db_pg "github.com/eliezerraj/go-core/v2/database/postgre"
+)
+
+// ending a transaction already ended
+var errTxClosed = errors.New("tx is closed")
+
+// row of a table with the tenant that owns it
+type row[T any] struct {
+	tenant	string
+	value	T
+}
+
+// table is copied on write, a transaction shares the maps of the committed state until it changes them
+type table[K comparable, T any] struct {
+	rows	map[K]row[T]
+	owned	bool
+}
+
+// Helper function to get a row by its key, whatever the tenant
+func (t *table[K, T]) get(key K) (row[T], bool) {
+	r, ok := t.rows[key]
+	return r, ok
+}
+
+// Helper function to write a row, the map is copied on the first write of the transaction
+func (t *table[K, T]) put(key K, tenant string, value T) {
+	t.own()
+	t.rows[key] = row[T]{tenant: tenant, value: value}
+}
+
+// Helper function to delete a row
+func (t *table[K, T]) remove(key K) {
+	t.own()
+	delete(t.rows, key)
+}
+
+// Helper function to copy the map before the first write of the transaction
+func (t *table[K, T]) own() {
+	if t.owned {
+		return
+	}
+	rows := make(map[K]row[T], len(t.rows) + 1)
+	for key, r := range t.rows {
+		rows[key] = r
+	}
+	t.rows = rows
+	t.owned = true
+}
+
+// Helper function to list the rows of a table seen by the tenant of the context, keeping the ones accepted by
+// the filter (nil keeps all). The order is the one of the map, the caller sorts
+func scan[K comparable, T any](ctx context.Context, t *table[K, T], keep func(K, T) bool) []row[T] {
+	var rows []row[T]
+	for key, r := range t.rows {
+		if visible(ctx, r.tenant) && (keep == nil || keep(key, r.value)) {
+			rows = append(rows, r)
+		}
+	}
+	return rows
+}
+
+// Helper function to tell whether a row of a tenant is seen by the tenant of the context, like the row level
+// security policies (the system tenant sees every tenant)
+func visible(ctx context.Context, tenant string) bool {
+	current := security.TenantFrom(ctx)
+	return current != "" && (tenant == current || current == security.SystemTenant)
+}
+
+// Helper function to get the tenant of a new row, like the default of the tenant_id columns
+func tenantOf(ctx context.Context, what string) (string, error) {
+	tenant := security.TenantFrom(ctx)
+	if tenant == "" {
+		return "", fmt.Errorf("FAILED to insert %s: %w", what, erro.ErrConstraint)
+	}
+	return tenant, nil
+}
+
+// Helper function to page a sorted list
+func page[T any](items []T, limit int, offset int) []T {
+	if offset >= len(items) {
+		return []T{}
+	}
+	items = items[offset:]
+	if limit >= 0 && limit < len(items) {
+		items = items[:limit]
+	}
+	return items
+}
+
+// state is the content of every table, a committed state is never changed, a transaction works on a copy
+type state struct {
+	products		table[int, model.Product]
+	inventories		table[int, model.Inventory]
+	timeSeries		table[int, model.Inventory]
+	adjustments		table[int, model.InventoryAdjustment]
+	channels		table[int, model.ChannelAllocation]
+	rmas			table[int, model.Rma]
+	rmaEvents		table[int, rmaEventRow]
+	outbox			table[int, model.OutboxEvent]
+	consumed		table[string, model.ConsumedEvent]
+	reservations	table[int, model.OrderReservation]
+	webhooks		table[int, model.Webhook]
+	deliveries		table[int, model.WebhookDelivery]
+	apiKeys			table[int, model.ApiKey]
+	apiKeyUsage		table[int, model.ApiKeyUsage]
+	audit			table[int, model.AuditRecord]
+}
+
+// Helper function to copy a state for a transaction, the maps are shared until written
+func (s *state) clone() *state {
+	c := *s
+	c.products.owned = false
+	c.inventories.owned = false
+	c.timeSeries.owned = false
+	c.adjustments.owned = false
+	c.channels.owned = false
+	c.rmas.owned = false
+	c.rmaEvents.owned = false
+	c.outbox.owned = false
+	c.consumed.owned = false
+	c.reservations.owned = false
+	c.webhooks.owned = false
+	c.deliveries.owned = false
+	c.apiKeys.owned = false
+	c.apiKeyUsage.owned = false
+	c.audit.owned = false
+	return &c
+}
+
+// MemoryRepository keeps the tables in memory, for the development and the tests without a postgres. The
+// transactions run one at a time: a transaction works on a copy of the committed state and replaces it on the
+// commit, so a rollback just drops the copy. The reads outside a transaction see the last committed state. The
+// ids are never reused, even when the transaction that took them rolls back, like the postgres sequences
+type MemoryRepository struct {
+	logger		*zerolog.Logger
+	committed	atomic.Pointer[state]
+	writer		chan struct{}
+	mu			sync.Mutex
+	sequences	map[string]int
+	listeners	map[*listener]struct{}
+}
+
+// About create a empty in memory repository
+func NewMemoryRepository(appLogger *zerolog.Logger) *MemoryRepository {
+	logger := appLogger.With().
+						Str("package", "repo.memory").
+						Logger()
+	logger.Info().
+			Str("func","NewMemoryRepository").Send()
+
+	r := &MemoryRepository{
+		logger: &logger,
+		writer: make(chan struct{}, 1),
+		sequences: make(map[string]int),
+		listeners: make(map[*listener]struct{}),
+	}
+	r.committed.Store(&state{})
+	return r
+}
+
+// Helper function to take the next id of a table
+func (r *MemoryRepository) nextID(name string) int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.sequences[name]++
+	return r.sequences[name]
+}
+
+// Helper function to get the last committed state, for the reads outside a transaction
+func (r *MemoryRepository) read() *state {
+	return r.committed.Load()
+}
+
+// memoryTx is a transaction of the repository, it holds the writer until it ends
+type memoryTx struct {
+	repo		*MemoryRepository
+	state		*state
+	payloads	[][]byte
+	done		bool
+}
+
+// Helper function to get the state of a transaction started by StartTx
+func txState(tx service.Tx) *state {
+	return tx.(*memoryTx).state
+}
+
+// About start a transaction, it waits for the running one to end
+func (r *MemoryRepository) StartTx(ctx context.Context) (service.Tx, error) {
+	select {
+	case r.writer <- struct{}{}:
+	case <-ctx.Done():
+		return nil, fmt.Errorf("FAILED to start transaction: %w", erro.Wrap(ctx.Err(), erro.KindTimeout, erro.ErrTimeout.Code, erro.ErrTimeout.Message))
+	}
+
+	return &memoryTx{repo: r, state: r.read().clone()}, nil
+}
+
+// About commit the transaction, its notifications are delivered in the order of the commits
+func (t *memoryTx) Commit(ctx context.Context) error {
+	if t.done {
+		return errTxClosed
+	}
+	t.done = true
+
+	t.repo.committed.Store(t.state)
+	t.repo.deliver(t.payloads)
+	<-t.repo.writer
+
+	return nil
+}
+
+// About rollback the transaction, dropping its changes
+func (t *memoryTx) Rollback(ctx context.Context) error {
+	if t.done {
+		return errTxClosed
+	}
+	t.done = true
+
+	<-t.repo.writer
+
+	return nil
+}
+
+// Above get the stats of the pool, there is no pool
+func (r *MemoryRepository) Stat(ctx context.Context) (go_core_db_pg.PoolStats){
+	return go_core_db_pg.PoolStats{}
+}
+
+// Above get the counters of the acquires, there is no pool so the load shedder never sees a wait
+func (r *MemoryRepository) AcquireStat() (int64, int64, time.Duration) {
+	return 0, 0, 0
+}
+
+// Above check the repository answers, it always does
+func (r *MemoryRepository) Ping(ctx context.Context) error {
+	return nil
+}
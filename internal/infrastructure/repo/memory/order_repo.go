@@ -0,0 +1,98 @@
+package memory
+
+import (
+	"fmt"
+	"context"
+
+	"github.com/go-inventory/internal/domain/model"
+	"github.com/go-inventory/internal/domain/service"
+	"github.com/go-inventory/shared/erro"
+)
+
+// About record a consumed order event, zero rows when the event was already consumed by the tenant
+func (r *MemoryRepository) AddConsumedEvent(ctx context.Context,
+											tx service.Tx,
+											event *model.ConsumedEvent) (int64, error){
+	s := txState(tx)
+
+	tenant, err := tenantOf(ctx, "consumed_event")
+	if err != nil {
+		return 0, err
+	}
+
+	key := tenant + " " + event.EventID
+	if _, ok := s.consumed.get(key); ok {
+		return 0, nil
+	}
+	s.consumed.put(key, tenant, *event)
+
+	return 1, nil
+}
+
+// About get the reservation of a product by a order within a transaction
+func (r *MemoryRepository) GetOrderReservationForUpdate(ctx context.Context,
+														tx service.Tx,
+														reservation *model.OrderReservation) (*model.OrderReservation, error){
+	s := txState(tx)
+
+	for _, rw := range scan(ctx, &s.reservations, func(_ int, o model.OrderReservation) bool {
+		return o.OrderID == reservation.OrderID && o.Product.ID == reservation.Product.ID
+	}) {
+		res_reservation := rw.value
+		product, ok := withProduct(ctx, s, res_reservation.Product)
+		if !ok {
+			break
+		}
+		res_reservation.Product = product
+		return &res_reservation, nil
+	}
+
+	return nil, erro.ErrNotFound
+}
+
+// About create the reservation of a product by a order, a order reserves a product once
+func (r *MemoryRepository) AddOrderReservation(ctx context.Context,
+												tx service.Tx,
+												reservation *model.OrderReservation) (*model.OrderReservation, error){
+	s := txState(tx)
+
+	tenant, err := tenantOf(ctx, "order_reservation")
+	if err != nil {
+		return nil, err
+	}
+	for _, rw := range s.reservations.rows {
+		if rw.value.OrderID == reservation.OrderID && rw.value.Product.ID == reservation.Product.ID {
+			return nil, fmt.Errorf("FAILED to insert order_reservation: %w", erro.ErrDuplicateKey)
+		}
+	}
+
+	reservation.ID = r.nextID("order_reservation")
+	s.reservations.put(reservation.ID, tenant, model.OrderReservation{
+		ID: reservation.ID,
+		OrderID: reservation.OrderID,
+		Product: model.Product{ID: reservation.Product.ID},
+		Quantity: reservation.Quantity,
+		Status: reservation.Status,
+		CreatedAt: reservation.CreatedAt,
+	})
+
+	return reservation, nil
+}
+
+// About update the status of a reservation
+func (r *MemoryRepository) UpdateOrderReservation(ctx context.Context,
+												tx service.Tx,
+												reservation *model.OrderReservation) (int64, error){
+	s := txState(tx)
+
+	rw, ok := s.reservations.get(reservation.ID)
+	if !ok || !visible(ctx, rw.tenant) {
+		return 0, nil
+	}
+	res_reservation := rw.value
+	res_reservation.Status = reservation.Status
+	res_reservation.UpdatedAt = reservation.UpdatedAt
+	s.reservations.put(reservation.ID, rw.tenant, res_reservation)
+
+	return 1, nil
+}
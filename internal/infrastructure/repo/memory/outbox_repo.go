@@ -0,0 +1,81 @@
+package memory
+
+import (
+	"time"
+	"slices"
+	"context"
+
+	"github.com/go-inventory/internal/domain/model"
+	"github.com/go-inventory/internal/domain/service"
+)
+
+// About create a outbox event, it is due at once
+func (r *MemoryRepository) AddOutboxEvent(ctx context.Context,
+										tx service.Tx,
+										event *model.OutboxEvent) (*model.OutboxEvent, error){
+	s := txState(tx)
+
+	tenant, err := tenantOf(ctx, "outbox")
+	if err != nil {
+		return nil, err
+	}
+
+	event.ID = r.nextID("outbox")
+	s.outbox.put(event.ID, tenant, model.OutboxEvent{
+		ID: event.ID,
+		EventID: event.EventID,
+		Type: event.Type,
+		Source: event.Source,
+		Subject: event.Subject,
+		Actor: event.Actor,
+		Tenant: tenant,
+		Data: slices.Clone(event.Data),
+		Status: event.Status,
+		NextAttemptAt: event.CreatedAt,
+		CreatedAt: event.CreatedAt,
+	})
+
+	return event, nil
+}
+
+// About list the pending outbox events that are due within a transaction, the oldest first
+func (r *MemoryRepository) ListOutboxPendingForUpdate(ctx context.Context,
+													tx service.Tx,
+													limit int) (*[]model.OutboxEvent, error){
+	now := time.Now()
+
+	events := []model.OutboxEvent{}
+	for _, rw := range scan(ctx, &txState(tx).outbox, func(_ int, e model.OutboxEvent) bool {
+		return e.Status == model.OutboxPending && !e.NextAttemptAt.After(now)
+	}) {
+		event := rw.value
+		event.LastError = ""
+		event.PublishedAt = nil
+		events = append(events, event)
+	}
+	slices.SortFunc(events, func(a, b model.OutboxEvent) int { return a.ID - b.ID })
+
+	res_events := page(events, limit, 0)
+	return &res_events, nil
+}
+
+// About update the delivery state of a outbox event
+func (r *MemoryRepository) UpdateOutboxEvent(ctx context.Context,
+											tx service.Tx,
+											event *model.OutboxEvent) (int64, error){
+	s := txState(tx)
+
+	rw, ok := s.outbox.get(event.ID)
+	if !ok || !visible(ctx, rw.tenant) {
+		return 0, nil
+	}
+	res_event := rw.value
+	res_event.Status = event.Status
+	res_event.Attempts = event.Attempts
+	res_event.LastError = event.LastError
+	res_event.NextAttemptAt = event.NextAttemptAt
+	res_event.PublishedAt = event.PublishedAt
+	s.outbox.put(event.ID, rw.tenant, res_event)
+
+	return 1, nil
+}
@@ -0,0 +1,111 @@
+package memory
+
+import (
+	"fmt"
+	"slices"
+	"context"
+
+	"github.com/go-inventory/internal/domain/model"
+	"github.com/go-inventory/internal/domain/service"
+	"github.com/go-inventory/shared/erro"
+)
+
+// Helper function to get the first product of a sku seen by the tenant of the context
+func findProduct(ctx context.Context, s *state, sku string) (model.Product, bool) {
+	rows := scan(ctx, &s.products, func(_ int, p model.Product) bool { return p.Sku == sku })
+	if len(rows) == 0 {
+		return model.Product{}, false
+	}
+	first := slices.MinFunc(rows, func(a, b row[model.Product]) int { return a.value.ID - b.value.ID })
+	return first.value, true
+}
+
+// About create a product, the sku is unique per tenant
+func (r *MemoryRepository) AddProduct(ctx context.Context,
+									tx service.Tx,
+									product *model.Product) (*model.Product, error){
+	s := txState(tx)
+
+	tenant, err := tenantOf(ctx, "product")
+	if err != nil {
+		return nil, err
+	}
+	for _, rw := range s.products.rows {
+		if rw.tenant == tenant && rw.value.Sku == product.Sku {
+			r.logger.Warn().
+					Ctx(ctx).
+					Err(erro.ErrDuplicateKey).Send()
+			return nil, fmt.Errorf("FAILED to insert product: %w", erro.ErrDuplicateKey)
+		}
+	}
+
+	product.ID = r.nextID("product")
+	s.products.put(product.ID, tenant, model.Product{
+		ID: product.ID,
+		Sku: product.Sku,
+		Type: product.Type,
+		Name: product.Name,
+		Status: product.Status,
+		LeadTime: product.LeadTime,
+		Version: 1,
+		CreatedAt: product.CreatedAt,
+	})
+
+	return product, nil
+}
+
+// About get a product by sku
+func (r *MemoryRepository) GetProduct(ctx context.Context,
+									product *model.Product) (*model.Product, error){
+	res_product, ok := findProduct(ctx, r.read(), product.Sku)
+	if !ok {
+		return nil, erro.ErrNotFound
+	}
+	return &res_product, nil
+}
+
+// About get a product by ID
+func (r *MemoryRepository) GetProductId(ctx context.Context,
+										product *model.Product) (*model.Product, error){
+	rw, ok := r.read().products.get(product.ID)
+	if !ok || !visible(ctx, rw.tenant) {
+		return nil, erro.ErrNotFound
+	}
+	res_product := rw.value
+	return &res_product, nil
+}
+
+// About get a product in a transaction, the transaction already runs alone so nothing is locked
+func (r *MemoryRepository) GetProductForUpdate(ctx context.Context,
+												tx service.Tx,
+												product *model.Product) (*model.Product, error){
+	res_product, ok := findProduct(ctx, txState(tx), product.Sku)
+	if !ok {
+		return nil, erro.ErrNotFound
+	}
+	return &res_product, nil
+}
+
+// About update a product, when the version is informed it must match (optimistic lock)
+func (r *MemoryRepository) UpdateProduct(ctx context.Context,
+										tx service.Tx,
+										product *model.Product) (int64, error){
+	s := txState(tx)
+
+	rw, ok := s.products.get(product.ID)
+	if !ok || !visible(ctx, rw.tenant) || (product.Version != 0 && rw.value.Version != product.Version) {
+		return 0, nil
+	}
+
+	res_product := rw.value
+	res_product.Type = product.Type
+	res_product.Name = product.Name
+	res_product.Status = product.Status
+	res_product.LeadTime = product.LeadTime
+	res_product.UpdatedAt = product.UpdatedAt
+	res_product.Version++
+	s.products.put(product.ID, rw.tenant, res_product)
+
+	product.Version = res_product.Version
+	return 1, nil
+}
@@ -0,0 +1,118 @@
+package memory
+
+import (
+	"slices"
+	"context"
+
+	"github.com/go-inventory/internal/domain/model"
+	"github.com/go-inventory/internal/domain/service"
+	"github.com/go-inventory/shared/erro"
+)
+
+// rmaEventRow is a step of a rma with the rma it belongs to
+type rmaEventRow struct {
+	rmaID	int
+	event	model.RmaEvent
+}
+
+// Helper function to get a rma with the id and sku of its product
+func getRma(ctx context.Context, s *state, id int) (*model.Rma, error) {
+	rw, ok := s.rmas.get(id)
+	if !ok || !visible(ctx, rw.tenant) {
+		return nil, erro.ErrNotFound
+	}
+	res_rma := rw.value
+	if res_rma.Product, ok = withProduct(ctx, s, res_rma.Product); !ok {
+		return nil, erro.ErrNotFound
+	}
+	return &res_rma, nil
+}
+
+// About create a rma
+func (r *MemoryRepository) AddRma(ctx context.Context,
+								tx service.Tx,
+								rma *model.Rma) (*model.Rma, error){
+	s := txState(tx)
+
+	tenant, err := tenantOf(ctx, "rma")
+	if err != nil {
+		return nil, err
+	}
+
+	rma.ID = r.nextID("rma")
+	s.rmas.put(rma.ID, tenant, model.Rma{
+		ID: rma.ID,
+		OrderRef: rma.OrderRef,
+		Product: model.Product{ID: rma.Product.ID},
+		Quantity: rma.Quantity,
+		Status: rma.Status,
+		Reason: rma.Reason,
+		CreatedAt: rma.CreatedAt,
+	})
+
+	return rma, nil
+}
+
+// About get a rma by id
+func (r *MemoryRepository) GetRma(ctx context.Context,
+								rma *model.Rma) (*model.Rma, error){
+	return getRma(ctx, r.read(), rma.ID)
+}
+
+// About get a rma by id within a transaction
+func (r *MemoryRepository) GetRmaForUpdate(ctx context.Context,
+										tx service.Tx,
+										rma *model.Rma) (*model.Rma, error){
+	return getRma(ctx, txState(tx), rma.ID)
+}
+
+// About update the quantities and the status of a rma
+func (r *MemoryRepository) UpdateRma(ctx context.Context,
+									tx service.Tx,
+									rma *model.Rma) (int64, error){
+	s := txState(tx)
+
+	rw, ok := s.rmas.get(rma.ID)
+	if !ok || !visible(ctx, rw.tenant) {
+		return 0, nil
+	}
+	res_rma := rw.value
+	res_rma.Received = rma.Received
+	res_rma.Restock = rma.Restock
+	res_rma.Damaged = rma.Damaged
+	res_rma.Status = rma.Status
+	res_rma.UpdatedAt = rma.UpdatedAt
+	s.rmas.put(rma.ID, rw.tenant, res_rma)
+
+	return 1, nil
+}
+
+// About create a event of a rma
+func (r *MemoryRepository) AddRmaEvent(ctx context.Context,
+										tx service.Tx,
+										rma *model.Rma,
+										rmaEvent *model.RmaEvent) (*model.RmaEvent, error){
+	s := txState(tx)
+
+	tenant, err := tenantOf(ctx, "rma_event")
+	if err != nil {
+		return nil, err
+	}
+
+	rmaEvent.ID = r.nextID("rma_event")
+	s.rmaEvents.put(rmaEvent.ID, tenant, rmaEventRow{rmaID: rma.ID, event: *rmaEvent})
+
+	return rmaEvent, nil
+}
+
+// About list the events of a rma, the oldest first
+func (r *MemoryRepository) ListRmaEvent(ctx context.Context,
+										rma *model.Rma) (*[]model.RmaEvent, error){
+	list_event := []model.RmaEvent{}
+	for _, rw := range scan(ctx, &r.read().rmaEvents, func(_ int, e rmaEventRow) bool { return e.rmaID == rma.ID }) {
+		list_event = append(list_event, rw.value.event)
+	}
+	slices.SortFunc(list_event, func(a, b model.RmaEvent) int { return a.ID - b.ID })
+
+	return &list_event, nil
+}
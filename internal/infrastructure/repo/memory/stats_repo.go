@@ -0,0 +1,88 @@
+package memory
+
+import (
+	"slices"
+	"context"
+
+	"github.com/go-inventory/internal/domain/model"
+	"github.com/go-inventory/internal/domain/service"
+)
+
+// About create a snapshot of a inventory, the date of the snapshot is its creation
+func (r *MemoryRepository) AddInventoryTimeSeries(ctx context.Context,
+												tx service.Tx,
+												inventory *model.Inventory) (*model.Inventory, error){
+	s := txState(tx)
+
+	tenant, err := tenantOf(ctx, "inventory_time_series")
+	if err != nil {
+		return nil, err
+	}
+
+	inventory.ID = r.nextID("inventory_time_series")
+	s.timeSeries.put(inventory.ID, tenant, model.Inventory{
+		ID: inventory.ID,
+		Product: model.Product{ID: inventory.Product.ID},
+		Available: inventory.Available,
+		Pending: inventory.Pending,
+		Sold: inventory.Sold,
+		Incoming: inventory.Incoming,
+		Quarantine: inventory.Quarantine,
+		Damaged: inventory.Damaged,
+		Hold: inventory.Hold,
+		CreatedAt: inventory.CreatedAt,
+	})
+
+	return inventory, nil
+}
+
+// Helper function to get a window of the latest snapshots of a sku with sales, from the oldest to the newest
+func timeSeries(ctx context.Context, s *state, windowsize int, offset int, sku string) []model.Inventory {
+	var series []model.Inventory
+	for _, rw := range scan(ctx, &s.timeSeries, func(_ int, i model.Inventory) bool { return i.Sold > 0 }) {
+		product, ok := s.products.get(rw.value.Product.ID)
+		if !ok || !visible(ctx, product.tenant) || product.value.Sku != sku {
+			continue
+		}
+		snapshot := rw.value
+		snapshot.Product = model.Product{
+			ID: product.value.ID,
+			Sku: product.value.Sku,
+			LeadTime: product.value.LeadTime,
+		}
+		series = append(series, snapshot)
+	}
+	slices.SortStableFunc(series, func(a, b model.Inventory) int { return b.CreatedAt.Compare(a.CreatedAt) })
+
+	res_series := slices.Clone(page(series, windowsize, offset))
+	slices.Reverse(res_series)
+
+	return res_series
+}
+
+// About get a window of the latest snapshots of a sku with sales, from the oldest to the newest
+func (r *MemoryRepository) GetInventoryTimeSeries(ctx context.Context,
+												windowsize int,
+												offset int,
+												inventory *model.Inventory) (*[]model.Inventory, error){
+	res_series := timeSeries(ctx, r.read(), windowsize, offset, inventory.Product.Sku)
+	return &res_series, nil
+}
+
+// About get the window of snapshots of each sku of a list, in the order of the skus
+func (r *MemoryRepository) ListInventoryTimeSeriesBySku(ctx context.Context,
+														windowsize int,
+														offset int,
+														skus []string) (*[]model.Inventory, error){
+	s := r.read()
+
+	sorted := slices.Clone(skus)
+	slices.Sort(sorted)
+
+	res_series := []model.Inventory{}
+	for _, sku := range slices.Compact(sorted) {
+		res_series = append(res_series, timeSeries(ctx, s, windowsize, offset, sku)...)
+	}
+
+	return &res_series, nil
+}
@@ -0,0 +1,98 @@
+package memory
+
+import (
+	"fmt"
+	"context"
+	"encoding/json"
+
+	"github.com/go-inventory/internal/domain/model"
+	"github.com/go-inventory/internal/domain/service"
+)
+
+// size of the queue of a listener, a commit waits while the queue of a listener is full
+const listenerBuffer = 1024
+
+// listener is a call of ListenInventoryChanged
+type listener struct {
+	payloads	chan []byte
+	done		chan struct{}
+}
+
+// About notify a inventory change, the notification is only delivered when the transaction commits
+func (r *MemoryRepository) NotifyInventoryChanged(ctx context.Context,
+												tx service.Tx,
+												event *model.InventoryEvent) error{
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("FAILED to marshal inventory event: %w", err)
+	}
+
+	memTx := tx.(*memoryTx)
+	memTx.payloads = append(memTx.payloads, payload)
+
+	return nil
+}
+
+// About listen the inventory changes calling the handler for each notification, it blocks until the context is done
+func (r *MemoryRepository) ListenInventoryChanged(ctx context.Context,
+												handler func(*model.InventoryEvent)) error{
+	r.logger.Info().
+			Ctx(ctx).
+			Str("func","ListenInventoryChanged").Send()
+
+	l := &listener{
+		payloads: make(chan []byte, listenerBuffer),
+		done: make(chan struct{}),
+	}
+
+	r.mu.Lock()
+	r.listeners[l] = struct{}{}
+	r.mu.Unlock()
+
+	defer func() {
+		r.mu.Lock()
+		delete(r.listeners, l)
+		r.mu.Unlock()
+		close(l.done)
+	}()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case payload := <-l.payloads:
+			event := model.InventoryEvent{}
+			if err := json.Unmarshal(payload, &event); err != nil {
+				r.logger.Warn().
+						Ctx(ctx).
+						Err(err).
+						Msg("invalid inventory notification")
+				continue
+			}
+			handler(&event)
+		}
+	}
+}
+
+// Helper function to deliver the notifications of a commit to every listener
+func (r *MemoryRepository) deliver(payloads [][]byte) {
+	if len(payloads) == 0 {
+		return
+	}
+
+	r.mu.Lock()
+	listeners := make([]*listener, 0, len(r.listeners))
+	for l := range r.listeners {
+		listeners = append(listeners, l)
+	}
+	r.mu.Unlock()
+
+	for _, l := range listeners {
+		for _, payload := range payloads {
+			select {
+			case l.payloads <- payload:
+			case <-l.done:
+			}
+		}
+	}
+}
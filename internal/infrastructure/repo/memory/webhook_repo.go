@@ -0,0 +1,199 @@
+package memory
+
+import (
+	"fmt"
+	"time"
+	"slices"
+	"context"
+
+	"github.com/go-inventory/internal/domain/model"
+	"github.com/go-inventory/internal/domain/service"
+	"github.com/go-inventory/shared/erro"
+)
+
+// About create a webhook, it targets either a sku or a product type
+func (r *MemoryRepository) AddWebhook(ctx context.Context,
+									tx service.Tx,
+									webhook *model.Webhook) (*model.Webhook, error){
+	s := txState(tx)
+
+	tenant, err := tenantOf(ctx, "webhook")
+	if err != nil {
+		return nil, err
+	}
+	if (webhook.Sku == "") == (webhook.ProductType == "") {
+		return nil, fmt.Errorf("FAILED to insert webhook: %w", erro.ErrConstraint)
+	}
+
+	webhook.ID = r.nextID("webhook")
+	s.webhooks.put(webhook.ID, tenant, model.Webhook{
+		ID: webhook.ID,
+		Url: webhook.Url,
+		Secret: webhook.Secret,
+		Sku: webhook.Sku,
+		ProductType: webhook.ProductType,
+		Threshold: webhook.Threshold,
+		Active: webhook.Active,
+		CreatedAt: webhook.CreatedAt,
+	})
+
+	return webhook, nil
+}
+
+// About get a webhook by id
+func (r *MemoryRepository) GetWebhook(ctx context.Context,
+									webhook *model.Webhook) (*model.Webhook, error){
+	rw, ok := r.read().webhooks.get(webhook.ID)
+	if !ok || !visible(ctx, rw.tenant) {
+		return nil, erro.ErrNotFound
+	}
+	res_webhook := rw.value
+	return &res_webhook, nil
+}
+
+// About deactivate a webhook, zero rows when it is already inactive
+func (r *MemoryRepository) DeactivateWebhook(ctx context.Context,
+											tx service.Tx,
+											webhook *model.Webhook) (int64, error){
+	s := txState(tx)
+
+	rw, ok := s.webhooks.get(webhook.ID)
+	if !ok || !visible(ctx, rw.tenant) || !rw.value.Active {
+		return 0, nil
+	}
+	res_webhook := rw.value
+	res_webhook.Active = false
+	res_webhook.UpdatedAt = webhook.UpdatedAt
+	s.webhooks.put(webhook.ID, rw.tenant, res_webhook)
+
+	return 1, nil
+}
+
+// About list the active webhooks of a sku, registered for the sku itself or for its product type
+func (r *MemoryRepository) ListWebhookMatch(ctx context.Context,
+											tx service.Tx,
+											product *model.Product) (*[]model.Webhook, error){
+	list_webhook := []model.Webhook{}
+	for _, rw := range scan(ctx, &txState(tx).webhooks, func(_ int, w model.Webhook) bool {
+		return w.Active && ((w.Sku != "" && w.Sku == product.Sku) || (w.ProductType != "" && w.ProductType == product.Type))
+	}) {
+		list_webhook = append(list_webhook, rw.value)
+	}
+	slices.SortFunc(list_webhook, func(a, b model.Webhook) int { return a.ID - b.ID })
+
+	return &list_webhook, nil
+}
+
+// About create a delivery of a alert to a webhook, it is due at once
+func (r *MemoryRepository) AddWebhookDelivery(ctx context.Context,
+											tx service.Tx,
+											delivery *model.WebhookDelivery) (*model.WebhookDelivery, error){
+	s := txState(tx)
+
+	tenant, err := tenantOf(ctx, "webhook_delivery")
+	if err != nil {
+		return nil, err
+	}
+
+	delivery.ID = r.nextID("webhook_delivery")
+	s.deliveries.put(delivery.ID, tenant, model.WebhookDelivery{
+		ID: delivery.ID,
+		WebhookID: delivery.WebhookID,
+		EventID: delivery.EventID,
+		Type: delivery.Type,
+		Sku: delivery.Sku,
+		Payload: slices.Clone(delivery.Payload),
+		Status: delivery.Status,
+		NextAttemptAt: delivery.CreatedAt,
+		CreatedAt: delivery.CreatedAt,
+	})
+
+	return delivery, nil
+}
+
+// About list the pending deliveries that are due to active webhooks within a transaction, the oldest first
+func (r *MemoryRepository) ListWebhookDeliveryPendingForUpdate(ctx context.Context,
+															tx service.Tx,
+															limit int) (*[]model.WebhookDelivery, error){
+	s := txState(tx)
+	now := time.Now()
+
+	deliveries := []model.WebhookDelivery{}
+	for _, rw := range scan(ctx, &s.deliveries, func(_ int, d model.WebhookDelivery) bool {
+		return d.Status == model.DeliveryPending && !d.NextAttemptAt.After(now)
+	}) {
+		webhook, ok := s.webhooks.get(rw.value.WebhookID)
+		if !ok || !visible(ctx, webhook.tenant) || !webhook.value.Active {
+			continue
+		}
+		delivery := rw.value
+		delivery.Webhook = model.Webhook{
+			ID: webhook.value.ID,
+			Url: webhook.value.Url,
+			Secret: webhook.value.Secret,
+		}
+		deliveries = append(deliveries, delivery)
+	}
+	slices.SortFunc(deliveries, func(a, b model.WebhookDelivery) int { return a.ID - b.ID })
+
+	res_deliveries := page(deliveries, limit, 0)
+	return &res_deliveries, nil
+}
+
+// About update the state of a delivery after a attempt
+func (r *MemoryRepository) UpdateWebhookDelivery(ctx context.Context,
+												tx service.Tx,
+												delivery *model.WebhookDelivery) (int64, error){
+	s := txState(tx)
+
+	rw, ok := s.deliveries.get(delivery.ID)
+	if !ok || !visible(ctx, rw.tenant) {
+		return 0, nil
+	}
+	res_delivery := rw.value
+	res_delivery.Status = delivery.Status
+	res_delivery.Attempts = delivery.Attempts
+	res_delivery.LastStatusCode = delivery.LastStatusCode
+	res_delivery.LastError = delivery.LastError
+	res_delivery.NextAttemptAt = delivery.NextAttemptAt
+	res_delivery.DeliveredAt = delivery.DeliveredAt
+	s.deliveries.put(delivery.ID, rw.tenant, res_delivery)
+
+	return 1, nil
+}
+
+// About move a dead delivery back to pending, the attempts start again
+func (r *MemoryRepository) RetryWebhookDelivery(ctx context.Context,
+												tx service.Tx,
+												delivery *model.WebhookDelivery) (*model.WebhookDelivery, error){
+	s := txState(tx)
+
+	rw, ok := s.deliveries.get(delivery.ID)
+	if !ok || !visible(ctx, rw.tenant) || rw.value.Status != model.DeliveryDead {
+		return nil, erro.ErrNotFound
+	}
+	res_delivery := rw.value
+	res_delivery.Status = model.DeliveryPending
+	res_delivery.Attempts = 0
+	res_delivery.NextAttemptAt = time.Now()
+	s.deliveries.put(delivery.ID, rw.tenant, res_delivery)
+
+	return &res_delivery, nil
+}
+
+// About list the deliveries of a webhook, the newest first
+func (r *MemoryRepository) ListWebhookDelivery(ctx context.Context,
+											limit int,
+											offset int,
+											delivery *model.WebhookDelivery) (*[]model.WebhookDelivery, error){
+	deliveries := []model.WebhookDelivery{}
+	for _, rw := range scan(ctx, &r.read().deliveries, func(_ int, d model.WebhookDelivery) bool {
+		return d.WebhookID == delivery.WebhookID && (delivery.Status == "" || d.Status == delivery.Status)
+	}) {
+		deliveries = append(deliveries, rw.value)
+	}
+	slices.SortFunc(deliveries, func(a, b model.WebhookDelivery) int { return b.ID - a.ID })
+
+	res_deliveries := page(deliveries, limit, offset)
+	return &res_deliveries, nil
+}
@@ -0,0 +1,253 @@
+package server
+
+import (
+	"net"
+	"time"
+	"strconv"
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/rs/zerolog"
+	"github.com/google/uuid"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/health"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/reflection"
+	grpc_codes "google.golang.org/grpc/codes"
+	grpc_status "google.golang.org/grpc/status"
+	grpc_health "google.golang.org/grpc/health/grpc_health_v1"
+	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
+
+	go_core_midleware "github.com/eliezerraj/go-core/v2/middleware"
+
+	"github.com/go-inventory/internal/domain/model"
+	"github.com/go-inventory/shared/security"
+	"github.com/go-inventory/internal/infrastructure/middleware"
+	inventoryv1 "github.com/go-inventory/api/proto/inventory/v1"
+	app_grpc_routers "github.com/go-inventory/internal/infrastructure/adapter/grpc"
+)
+
+// level required by each method of the inventory service, the methods of the other services (health,
+// reflection) are public
+var methodScope = map[string]string{
+	inventoryv1.InventoryService_AddProduct_FullMethodName:			security.ScopeWrite,
+	inventoryv1.InventoryService_UpdateProduct_FullMethodName:		security.ScopeWrite,
+	inventoryv1.InventoryService_UpdateInventory_FullMethodName:	security.ScopeWrite,
+	inventoryv1.InventoryService_TransferInventory_FullMethodName:	security.ScopeWrite,
+	inventoryv1.InventoryService_GetProduct_FullMethodName:			security.ScopeRead,
+	inventoryv1.InventoryService_GetInventory_FullMethodName:		security.ScopeRead,
+	inventoryv1.InventoryService_ListInventory_FullMethodName:		security.ScopeRead,
+}
+
+type GrpcAppServer struct {
+	appServer		*model.AppServer
+	appGrpcRouters	*app_grpc_routers.GrpcRouters
+	authenticator	*middleware.Authenticator
+	tenantResolver	*middleware.TenantResolver
+	rateLimiter		*middleware.RateLimiter
+	loadShedder		*middleware.LoadShedder
+	logger			*zerolog.Logger
+	server			*grpc.Server
+	health			*health.Server
+}
+
+// About create new grpc server, with the otel handler, health service and reflection
+func NewGrpcAppServer(	appServer *model.AppServer,
+						appGrpcRouters *app_grpc_routers.GrpcRouters,
+						authenticator *middleware.Authenticator,
+						tenantResolver *middleware.TenantResolver,
+						rateLimiter *middleware.RateLimiter,
+						loadShedder *middleware.LoadShedder,
+						appLogger *zerolog.Logger) *GrpcAppServer {
+
+	logger := appLogger.With().
+						Str("package", "infrastructure.server.grpc").
+						Logger()
+
+	logger.Info().
+			Str("func","NewGrpcAppServer").Send()
+
+	g := &GrpcAppServer{
+		appServer: appServer,
+		appGrpcRouters: appGrpcRouters,
+		authenticator: authenticator,
+		tenantResolver: tenantResolver,
+		rateLimiter: rateLimiter,
+		loadShedder: loadShedder,
+		logger: &logger,
+		health: health.NewServer(),
+	}
+
+	g.server = grpc.NewServer(
+		grpc.StatsHandler(otelgrpc.NewServerHandler()),
+		grpc.ChainUnaryInterceptor(g.requestIDInterceptor, g.recoveryInterceptor, g.shedInterceptor, g.authInterceptor, g.limitInterceptor),
+	)
+
+	inventoryv1.RegisterInventoryServiceServer(g.server, appGrpcRouters)
+	grpc_health.RegisterHealthServer(g.server, g.health)
+	reflection.Register(g.server)
+
+	return g
+}
+
+// Helper function to set the request id in the context, as the http middleware does
+func (g *GrpcAppServer) requestIDInterceptor(ctx context.Context,
+											req interface{},
+											info *grpc.UnaryServerInfo,
+											handler grpc.UnaryHandler) (interface{}, error) {
+	requestID := uuid.New().String()
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if values := md.Get("x-request-id"); len(values) > 0 && values[0] != "" {
+			requestID = values[0]
+		}
+	}
+
+	grpc.SetHeader(ctx, metadata.Pairs("x-request-id", requestID))
+	ctx = context.WithValue(ctx, go_core_midleware.RequestIDKey, requestID)
+
+	return handler(ctx, req)
+}
+
+// Helper function to recover from handler panics
+func (g *GrpcAppServer) recoveryInterceptor(ctx context.Context,
+											req interface{},
+											info *grpc.UnaryServerInfo,
+											handler grpc.UnaryHandler) (resp interface{}, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			g.logger.Error().
+					Ctx(ctx).
+					Interface("panic", r).
+					Str("method", info.FullMethod).
+					Msg("Handler panic recovered")
+			err = grpc_status.Error(grpc_codes.Internal, "internal server error")
+		}
+	}()
+	return handler(ctx, req)
+}
+
+// Helper function to authorize the calls and resolve their tenant, a method of the inventory service without level requires admin
+func (g *GrpcAppServer) authInterceptor(ctx context.Context,
+										req interface{},
+										info *grpc.UnaryServerInfo,
+										handler grpc.UnaryHandler) (interface{}, error) {
+	level, ok := methodScope[info.FullMethod]
+	if !ok {
+		if !strings.HasPrefix(info.FullMethod, "/" + inventoryv1.InventoryService_ServiceDesc.ServiceName + "/") {
+			return handler(ctx, req)
+		}
+		level = security.ScopeAdmin
+	}
+
+	ctx, err := g.authenticator.AuthorizeMetadata(ctx, level)
+	if err != nil {
+		return nil, g.appGrpcRouters.ErrorHandler(err)
+	}
+
+	ctx, err = g.tenantResolver.ResolveMetadata(ctx)
+	if err != nil {
+		return nil, g.appGrpcRouters.ErrorHandler(err)
+	}
+
+	// the route is recorded in the audit of the changes
+	ctx = security.WithRoute(ctx, info.FullMethod)
+
+	return handler(ctx, req)
+}
+
+// Helper function to apply the bucket of the address of the peer and the load shedding to the calls of the
+// inventory service, before the authentication so a flood of invalid credentials does not reach the database
+func (g *GrpcAppServer) shedInterceptor(ctx context.Context,
+										req interface{},
+										info *grpc.UnaryServerInfo,
+										handler grpc.UnaryHandler) (interface{}, error) {
+	if !strings.HasPrefix(info.FullMethod, "/" + inventoryv1.InventoryService_ServiceDesc.ServiceName + "/") {
+		return handler(ctx, req)
+	}
+
+	if err := g.rateLimiter.AllowMetadataAddress(ctx, info.FullMethod); err != nil {
+		return nil, g.appGrpcRouters.ErrorHandler(err)
+	}
+
+	release, err := g.loadShedder.Acquire(ctx, info.FullMethod)
+	if err != nil {
+		return nil, g.appGrpcRouters.ErrorHandler(err)
+	}
+	defer release()
+
+	return handler(ctx, req)
+}
+
+// Helper function to apply the rate limit to the calls of the inventory service, after the authentication so
+// the client is the subject of the credential
+func (g *GrpcAppServer) limitInterceptor(ctx context.Context,
+										req interface{},
+										info *grpc.UnaryServerInfo,
+										handler grpc.UnaryHandler) (interface{}, error) {
+	if !strings.HasPrefix(info.FullMethod, "/" + inventoryv1.InventoryService_ServiceDesc.ServiceName + "/") {
+		return handler(ctx, req)
+	}
+
+	if err := g.rateLimiter.AllowMetadata(ctx, info.FullMethod); err != nil {
+		return nil, g.appGrpcRouters.ErrorHandler(err)
+	}
+
+	return handler(ctx, req)
+}
+
+// About start grpc server in background, a failure is sent to serverErrors
+func (g *GrpcAppServer) StartGrpcAppServer(ctx context.Context, serverErrors chan<- error) error {
+	g.logger.Info().
+			Ctx(ctx).
+			Str("func","StartGrpcAppServer").Send()
+
+	listener, err := net.Listen("tcp", ":" + strconv.Itoa(g.appServer.Server.GrpcPort))
+	if err != nil {
+		return fmt.Errorf("FAILED to listen grpc port: %w", err)
+	}
+
+	g.health.SetServingStatus("", grpc_health.HealthCheckResponse_SERVING)
+	g.health.SetServingStatus(inventoryv1.InventoryService_ServiceDesc.ServiceName, grpc_health.HealthCheckResponse_SERVING)
+
+	g.logger.Info().
+			Ctx(ctx).
+			Str("Grpc Port", strconv.Itoa(g.appServer.Server.GrpcPort)).Send()
+
+	go func() {
+		err := g.server.Serve(listener)
+		if err != nil && err != grpc.ErrServerStopped {
+			g.logger.Error().
+				Err(err).
+				Msg("Grpc server error")
+			serverErrors <- err
+		}
+	}()
+
+	return nil
+}
+
+// About stop grpc server, in flight calls have until the timeout to finish
+func (g *GrpcAppServer) StopGrpcAppServer(ctx context.Context, timeout time.Duration) {
+	g.logger.Info().
+			Ctx(ctx).
+			Str("func","StopGrpcAppServer").Send()
+
+	g.health.Shutdown()
+
+	stopped := make(chan struct{})
+	go func() {
+		g.server.GracefulStop()
+		close(stopped)
+	}()
+
+	select {
+	case <-stopped:
+	case <-time.After(timeout):
+		g.logger.Warn().
+				Ctx(ctx).
+				Msg("Grpc graceful stop timeout, forcing stop")
+		g.server.Stop()
+	}
+}
@@ -0,0 +1,295 @@
+package server
+
+import (
+	"os"
+	"flag"
+	"time"
+	"bytes"
+	"strings"
+	"testing"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"encoding/json"
+
+	"github.com/rs/zerolog"
+	"github.com/gorilla/mux"
+	"github.com/golang-jwt/jwt/v5"
+	"go.opentelemetry.io/otel/trace/noop"
+
+	"github.com/go-inventory/internal/domain/model"
+	"github.com/go-inventory/internal/domain/service"
+	"github.com/go-inventory/internal/infrastructure/middleware"
+	"github.com/go-inventory/internal/infrastructure/repo/memory"
+	app_http_routers "github.com/go-inventory/internal/infrastructure/adapter/http"
+
+	go_core_otel_trace "github.com/eliezerraj/go-core/v2/otel/trace"
+)
+
+// go test ./internal/infrastructure/server -update rewrites the golden files with the current responses
+var update = flag.Bool("update", false, "update the golden files")
+
+// fields that change on every run, the golden files keep a placeholder
+var volatileFields = map[string]bool{
+	"created_at":	true,
+	"update_at":	true,
+}
+
+// router of the application over a empty memory repository, a nil authenticator leaves the routes open
+func newTestRouter(t *testing.T, authenticator *middleware.Authenticator) *mux.Router {
+	t.Helper()
+
+	logger := zerolog.Nop()
+	appServer := model.AppServer{
+		Application:		&model.Application{Name: "go-inventory"},
+		Server:				&model.Server{CtxTimeout: 5, StreamBufferSize: 16},
+		InventoryConfig:	&model.InventoryConfig{},
+		AuditConfig:		&model.AuditConfig{SealInterval: 1000, BatchSize: 500},
+		TenantConfig:		&model.TenantConfig{Header: "X-Tenant-ID", Default: "default"},
+	}
+	tracerProvider := go_core_otel_trace.TracerProvider{Tracer: noop.NewTracerProvider().Tracer("test")}
+
+	workerService := service.NewWorkerService(&appServer, memory.NewMemoryRepository(&logger), nil, &logger, &tracerProvider)
+	tenantResolver := middleware.NewTenantResolver(appServer.TenantConfig, &logger)
+
+	httpAppServer := NewHttpAppServer(&appServer, authenticator, tenantResolver, nil, nil, &logger)
+	return httpAppServer.setupRoutes(app_http_routers.NewHttpRouters(&appServer, workerService, &logger, &tracerProvider))
+}
+
+// Helper function to replace the volatile fields of a json document
+func normalize(v interface{}) interface{} {
+	switch value := v.(type) {
+	case map[string]interface{}:
+		for key, field := range value {
+			if volatileFields[key] && field != nil {
+				value[key] = "<" + key + ">"
+			} else {
+				value[key] = normalize(field)
+			}
+		}
+	case []interface{}:
+		for i := range value {
+			value[i] = normalize(value[i])
+		}
+	}
+	return v
+}
+
+// Helper function to compare a json response with its golden file
+func assertGolden(t *testing.T, name string, body []byte) {
+	t.Helper()
+
+	var document interface{}
+	if err := json.Unmarshal(body, &document); err != nil {
+		t.Fatalf("response is not json: %v: %s", err, body)
+	}
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(normalize(document)); err != nil {
+		t.Fatalf("FAILED to marshal the response: %v", err)
+	}
+	got := buf.Bytes()
+
+	path := filepath.Join("testdata", name + ".golden")
+	if *update {
+		if err := os.WriteFile(path, got, 0o644); err != nil {
+			t.Fatalf("FAILED to write %s: %v", path, err)
+		}
+		return
+	}
+
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("FAILED to read %s (run with -update to create it): %v", path, err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("response differs from %s\ngot:\n%s\nwant:\n%s", path, got, want)
+	}
+}
+
+// the cases run in order on the same router, each one sees the changes of the previous ones
+func TestRoutes(t *testing.T) {
+	appRouter := newTestRouter(t, nil)
+
+	tests := []struct {
+		name		string
+		method		string
+		path		string
+		header		map[string]string
+		body		string
+		wantStatus	int
+		wantETag	string
+		golden		string
+	}{
+		{name: "add product", method: http.MethodPost, path: "/product",
+			body: `{"sku":"sku-1","type":"BOOK","name":"Go in Action","status":"IN-STOCK","lead_time":3}`,
+			wantStatus: http.StatusOK, golden: "add_product"},
+		{name: "add product duplicate", method: http.MethodPost, path: "/product",
+			body: `{"sku":"sku-1","type":"BOOK","name":"Go in Action","status":"IN-STOCK"}`,
+			wantStatus: http.StatusConflict, golden: "add_product_duplicate"},
+		{name: "add product invalid", method: http.MethodPost, path: "/product",
+			body: `{"sku":"sku 2","type":"BOOK","status":"SOLD"}`,
+			wantStatus: http.StatusBadRequest, golden: "add_product_invalid"},
+		{name: "add product unknown field", method: http.MethodPost, path: "/product",
+			body: `{"sku":"sku-2","price":10}`,
+			wantStatus: http.StatusBadRequest, golden: "add_product_unknown_field"},
+		{name: "add product with server field", method: http.MethodPost, path: "/product",
+			body: `{"sku":"sku-3","type":"BOOK","name":"Go in Action","status":"IN-STOCK","version":7}`,
+			wantStatus: http.StatusBadRequest},
+		{name: "get product", method: http.MethodGet, path: "/product/sku-1",
+			wantStatus: http.StatusOK, wantETag: `"1"`, golden: "get_product"},
+		{name: "get product of other tenant", method: http.MethodGet, path: "/product/sku-1",
+			header: map[string]string{"X-Tenant-ID": "globex"},
+			wantStatus: http.StatusNotFound, golden: "get_product_not_found"},
+		{name: "get product as problem", method: http.MethodGet, path: "/product/sku-9",
+			header: map[string]string{"Accept": "application/problem+json"},
+			wantStatus: http.StatusNotFound, golden: "get_product_problem"},
+		{name: "invalid tenant", method: http.MethodGet, path: "/product/sku-1",
+			header: map[string]string{"X-Tenant-ID": "Not A Tenant"},
+			wantStatus: http.StatusBadRequest},
+		{name: "get inventory", method: http.MethodGet, path: "/inventory/product/sku-1",
+			wantStatus: http.StatusOK, wantETag: `"1"`, golden: "get_inventory"},
+		{name: "update inventory", method: http.MethodPut, path: "/inventory/product/sku-1",
+			body: `{"available":-5,"sold":5}`,
+			wantStatus: http.StatusOK, wantETag: `"2"`, golden: "update_inventory"},
+		{name: "update inventory without delta", method: http.MethodPut, path: "/inventory/product/sku-1",
+			body: `{}`,
+			wantStatus: http.StatusBadRequest, golden: "update_inventory_invalid"},
+		{name: "update inventory with version in the body", method: http.MethodPut, path: "/inventory/product/sku-1",
+			body: `{"available":-1,"version":1}`,
+			wantStatus: http.StatusBadRequest},
+		{name: "update inventory with server bucket", method: http.MethodPut, path: "/inventory/product/sku-1",
+			body: `{"available":-1,"quarantine":5}`,
+			wantStatus: http.StatusBadRequest},
+		{name: "update inventory weak version", method: http.MethodPut, path: "/inventory/product/sku-1",
+			header: map[string]string{"If-Match": `W/"2"`},
+			body: `{"available":-1}`,
+			wantStatus: http.StatusPreconditionFailed},
+		{name: "update inventory stale version", method: http.MethodPut, path: "/inventory/product/sku-1",
+			header: map[string]string{"If-Match": `"1"`},
+			body: `{"available":-1}`,
+			wantStatus: http.StatusPreconditionFailed, golden: "update_inventory_stale"},
+		{name: "update inventory current version", method: http.MethodPut, path: "/inventory/product/sku-1",
+			header: map[string]string{"If-Match": `"2"`},
+			body: `{"available":-1}`,
+			wantStatus: http.StatusOK, wantETag: `"3"`},
+		{name: "quarantine", method: http.MethodPut, path: "/inventory/product/sku-1/quarantine",
+			body: `{"quantity":10}`,
+			wantStatus: http.StatusOK, wantETag: `"4"`, golden: "quarantine_inventory"},
+		{name: "release without source", method: http.MethodPut, path: "/inventory/product/sku-1/release",
+			body: `{"quantity":10}`,
+			wantStatus: http.StatusBadRequest},
+		{name: "hold more than available", method: http.MethodPut, path: "/inventory/product/sku-1/hold",
+			body: `{"quantity":100000}`,
+			wantStatus: http.StatusConflict, golden: "hold_insufficient_stock"},
+		{name: "list inventory", method: http.MethodGet, path: "/inventory/list/product?sku=sku",
+			wantStatus: http.StatusOK, golden: "list_inventory"},
+		{name: "list inventory without sku", method: http.MethodGet, path: "/inventory/list/product",
+			wantStatus: http.StatusBadRequest},
+		{name: "add webhook to the metadata address", method: http.MethodPost, path: "/webhook",
+			body: `{"url":"http://169.254.169.254/latest/meta-data","sku":"sku-1","threshold":10}`,
+			wantStatus: http.StatusBadRequest},
+		{name: "add webhook to localhost", method: http.MethodPost, path: "/webhook",
+			body: `{"url":"http://localhost:8080/hook","sku":"sku-1","threshold":10}`,
+			wantStatus: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.path, strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			req.Header.Set("X-Request-Id", "test-request")
+			for key, value := range tt.header {
+				req.Header.Set(key, value)
+			}
+
+			rec := httptest.NewRecorder()
+			appRouter.ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("got status %d, want %d: %s", rec.Code, tt.wantStatus, rec.Body.String())
+			}
+			if tt.wantETag != "" && rec.Header().Get("ETag") != tt.wantETag {
+				t.Errorf("got ETag %s, want %s", rec.Header().Get("ETag"), tt.wantETag)
+			}
+			if tt.golden != "" {
+				assertGolden(t, tt.golden, rec.Body.Bytes())
+			}
+		})
+	}
+}
+
+// Helper function to sign a token with the secret of the test, the tenant_id claim only when informed
+func signToken(t *testing.T, secret string, scope string, tenant string) string {
+	t.Helper()
+
+	claims := jwt.MapClaims{
+		"sub": "tester",
+		"scope": scope,
+		"exp": time.Now().Add(time.Minute).Unix(),
+	}
+	if tenant != "" {
+		claims["tenant_id"] = tenant
+	}
+
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(secret))
+	if err != nil {
+		t.Fatalf("FAILED to sign the token: %v", err)
+	}
+	return token
+}
+
+// a credential without tenant is bound to the default tenant, only a admin chooses the tenant by the header
+func TestTenantOfCredential(t *testing.T) {
+	logger := zerolog.Nop()
+	secret := "test-secret-with-at-least-32-bytes!"
+
+	authenticator, err := middleware.NewAuthenticator(&model.AuthConfig{	Enabled: true,
+																			HmacSecret: secret,
+																			ScopesRead: []string{"inventory.read"},
+																			ScopesWrite: []string{"inventory.write"},
+																			ScopesAdmin: []string{"inventory.admin"}}, nil, &logger)
+	if err != nil {
+		t.Fatalf("FAILED to create the authenticator: %v", err)
+	}
+	appRouter := newTestRouter(t, authenticator)
+
+	tests := []struct {
+		name		string
+		token		string
+		tenant		string
+		wantStatus	int
+	}{
+		{name: "without tenant claim", token: signToken(t, secret, "inventory.read", ""),
+			wantStatus: http.StatusNotFound},
+		{name: "without tenant claim and the default header", token: signToken(t, secret, "inventory.read", ""),
+			tenant: "default", wantStatus: http.StatusNotFound},
+		{name: "without tenant claim and a foreign header", token: signToken(t, secret, "inventory.write", ""),
+			tenant: "globex", wantStatus: http.StatusForbidden},
+		{name: "tenant claim and a foreign header", token: signToken(t, secret, "inventory.read", "acme"),
+			tenant: "globex", wantStatus: http.StatusForbidden},
+		{name: "tenant claim and the same header", token: signToken(t, secret, "inventory.read", "acme"),
+			tenant: "acme", wantStatus: http.StatusNotFound},
+		{name: "admin without tenant claim and a header", token: signToken(t, secret, "inventory.admin", ""),
+			tenant: "globex", wantStatus: http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/product/sku-1", nil)
+			req.Header.Set("Authorization", "Bearer " + tt.token)
+			if tt.tenant != "" {
+				req.Header.Set("X-Tenant-ID", tt.tenant)
+			}
+
+			rec := httptest.NewRecorder()
+			appRouter.ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("got status %d, want %d: %s", rec.Code, tt.wantStatus, rec.Body.String())
+			}
+		})
+	}
+}
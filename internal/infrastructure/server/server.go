@@ -15,6 +15,9 @@ import(
 	go_core_midleware "github.com/eliezerraj/go-core/v2/middleware"
 
 	"github.com/go-inventory/internal/domain/model"
+	"github.com/go-inventory/shared/erro"
+	"github.com/go-inventory/shared/security"
+	"github.com/go-inventory/internal/infrastructure/middleware"
 	app_http_routers "github.com/go-inventory/internal/infrastructure/adapter/http"
 
 	"github.com/prometheus/client_golang/prometheus/promhttp"
@@ -34,11 +37,22 @@ const (
 	routeHeader      = "/header"
 	routeContext     = "/context"
 	routeInfo        = "/info"
+	routeOpenAPI     = "/openapi.json"
+	routeDocs        = "/docs"
 	routeProduct     = "/product"
 	routeProductID   = "/productId"
 	routeInventory   = "/inventory/product"
 	routeInventoryTimeSeries  = "/inventory/timeseries/product"
 	routeListInventory  = "/inventory/list/product"	
+	routeCycleCount		= "/inventory/count/product"
+	routeAdjustment		= "/inventory/adjustment"
+	routeRma			= "/rma"
+	routeChannel		= "/inventory/channel/product"
+	routeGraphql		= "/graphql"
+	routeInventoryStream	= "/inventory/stream"
+	routeWebhook		= "/webhook"
+	routeApiKey			= "/apikey"
+	routeAudit			= "/audit"
 )
 
 // ExcludedFromTracing routes that should not create spans
@@ -47,19 +61,35 @@ var ExcludedFromTracing = map[string]bool{
 	routeLive:     true,
 	routeHeader:   true,
 	routeContext:  true,
+	routeOpenAPI:  true,
+	routeDocs:     true,
+	routeInventoryStream: true,
 	"/metrics":    true,
 }
 
+// ExcludedFromShedding long lived routes, they would hold a slot of the concurrency limit for hours
+var ExcludedFromShedding = map[string]bool{
+	routeInventoryStream: true,
+}
+
 type HttpAppServer struct {
 	appServer		*model.AppServer
+	authenticator	*middleware.Authenticator
+	tenantResolver	*middleware.TenantResolver
+	rateLimiter		*middleware.RateLimiter
+	loadShedder		*middleware.LoadShedder
 	logger			*zerolog.Logger
 	tpsMetric		metric.Int64Counter
 	latencyMetric	metric.Float64Histogram
 	statusMetric    metric.Int64Counter
 }
 
-// About create new http server
+// About create new http server, a nil authenticator leaves the routes open and a nil limiter or shedder lets every request pass
 func NewHttpAppServer(	appServer *model.AppServer,
+						authenticator *middleware.Authenticator,
+						tenantResolver *middleware.TenantResolver,
+						rateLimiter *middleware.RateLimiter,
+						loadShedder *middleware.LoadShedder,
 						appLogger *zerolog.Logger) HttpAppServer {
 
 	logger := appLogger.With().
@@ -71,13 +101,18 @@ func NewHttpAppServer(	appServer *model.AppServer,
 
 	return HttpAppServer{
 		appServer: appServer,
+		authenticator: authenticator,
+		tenantResolver: tenantResolver,
+		rateLimiter: rateLimiter,
+		loadShedder: loadShedder,
 		logger: &logger,
 	}
 }
 
-// About start http server
+// About start http server, and the grpc server (when its port is informed) sharing the same lifecycle
 func (h *HttpAppServer) StartHttpAppServer(	ctx context.Context, 
-											appHttpRouters app_http_routers.HttpRouters) {
+											appHttpRouters app_http_routers.HttpRouters,
+											appGrpcServer *GrpcAppServer) {
 	h.logger.Info().
 			Ctx(ctx).
 			Str("func","StartHttpAppServer").Send()
@@ -101,12 +136,15 @@ func (h *HttpAppServer) StartHttpAppServer(	ctx context.Context,
 		IdleTimeout:  time.Duration(h.appServer.Server.IdleTimeout) * time.Second, 
 	}
 
+	// the open streams never finish by themselves, they are closed when the shutdown starts
+	srv.RegisterOnShutdown(appHttpRouters.CloseStreams)
+
 	h.logger.Info().
 			Ctx(ctx).
 			Str("Service Port", strconv.Itoa(h.appServer.Server.Port)).Send()
 
 	// start server in goroutine
-	serverErrors := make(chan error, 1)
+	serverErrors := make(chan error, 2)
 	go func() {
 		err := srv.ListenAndServe()
 		if err != nil && err != http.ErrServerClosed {
@@ -117,6 +155,17 @@ func (h *HttpAppServer) StartHttpAppServer(	ctx context.Context,
 		}
 	}()
 
+	// start grpc server
+	if appGrpcServer != nil && h.appServer.Server.GrpcPort > 0 {
+		if err := appGrpcServer.StartGrpcAppServer(ctx, serverErrors); err != nil {
+			h.logger.Error().
+				Err(err).
+				Msg("Grpc server not started")
+			serverErrors <- err
+		}
+		defer appGrpcServer.StopGrpcAppServer(ctx, 30*time.Second)
+	}
+
 	// Get SIGNALS and handle shutdown
 	ch := make(chan os.Signal, 1)
 	signal.Notify(ch, syscall.SIGHUP, syscall.SIGINT, syscall.SIGTERM)
@@ -194,6 +243,13 @@ func (h *HttpAppServer) setupMetrics(ctx context.Context) error {
     }
     h.statusMetric = statusMetric
 
+	if err := h.rateLimiter.RegisterMetrics(meter); err != nil {
+		return err
+	}
+	if err := h.loadShedder.RegisterMetrics(meter); err != nil {
+		return err
+	}
+
 	return nil
 }
 
@@ -234,31 +290,184 @@ func (h *HttpAppServer) setupRoutes(appHttpRouters app_http_routers.HttpRouters)
 	info := appRouter.Methods(http.MethodGet, http.MethodOptions).Subrouter()
 	info.HandleFunc(routeInfo, appHttpRouters.Info)
 
+	openAPI := appRouter.Methods(http.MethodGet, http.MethodOptions).Subrouter()
+	openAPI.HandleFunc(routeOpenAPI, appHttpRouters.OpenAPI)
+
+	docs := appRouter.Methods(http.MethodGet, http.MethodOptions).Subrouter()
+	docs.HandleFunc(routeDocs, appHttpRouters.Docs)
+
+	// the business routes require a token granting the level of the operation
+	read := h.withScope(appHttpRouters, security.ScopeRead)
+	write := h.withScope(appHttpRouters, security.ScopeWrite)
+	admin := h.withScope(appHttpRouters, security.ScopeAdmin)
+
 	// Register business logic routes with metrics middleware
 	add := appRouter.Methods(http.MethodPost, http.MethodOptions).Subrouter()
-	add.HandleFunc(routeProduct, h.withMetrics(appMiddleWare.MiddleWareErrorHandler(appHttpRouters.AddProduct)))
+	add.HandleFunc(routeProduct, h.withMetrics(appHttpRouters.MiddleWareErrorHandler(write(appHttpRouters.AddProduct))))
+
+	putProduct := appRouter.Methods(http.MethodPut, http.MethodOptions).Subrouter()
+	putProduct.HandleFunc(routeProduct+"/{id}", h.withMetrics(appHttpRouters.MiddleWareErrorHandler(write(appHttpRouters.UpdateProduct))))
 
 	get := appRouter.Methods(http.MethodGet, http.MethodOptions).Subrouter()
-	get.HandleFunc(routeProduct+"/{id}", h.withMetrics(appMiddleWare.MiddleWareErrorHandler(appHttpRouters.GetProduct)))
+	get.HandleFunc(routeProduct+"/{id}", h.withMetrics(appHttpRouters.MiddleWareErrorHandler(read(appHttpRouters.GetProduct))))
 
 	getId := appRouter.Methods(http.MethodGet, http.MethodOptions).Subrouter()
-	getId.HandleFunc(routeProductID+"/{id}", h.withMetrics(appMiddleWare.MiddleWareErrorHandler(appHttpRouters.GetProductId)))
+	getId.HandleFunc(routeProductID+"/{id}", h.withMetrics(appHttpRouters.MiddleWareErrorHandler(read(appHttpRouters.GetProductId))))
 
 	getInv := appRouter.Methods(http.MethodGet, http.MethodOptions).Subrouter()
-	getInv.HandleFunc(routeInventory+"/{id}", h.withMetrics(appMiddleWare.MiddleWareErrorHandler(appHttpRouters.GetInventory)))
+	getInv.HandleFunc(routeInventory+"/{id}", h.withMetrics(appHttpRouters.MiddleWareErrorHandler(read(appHttpRouters.GetInventory))))
 
 	put := appRouter.Methods(http.MethodPut, http.MethodOptions).Subrouter()
-	put.HandleFunc(routeInventory+"/{id}", h.withMetrics(appMiddleWare.MiddleWareErrorHandler(appHttpRouters.UpdateInventory)))
+	put.HandleFunc(routeInventory+"/{id}", h.withMetrics(appHttpRouters.MiddleWareErrorHandler(write(appHttpRouters.UpdateInventory))))
 
 	ts := appRouter.Methods(http.MethodGet, http.MethodOptions).Subrouter()
-	ts.HandleFunc(routeInventoryTimeSeries, h.withMetrics(appMiddleWare.MiddleWareErrorHandler(appHttpRouters.GetInventoryTimeSeries)))
+	ts.HandleFunc(routeInventoryTimeSeries, h.withMetrics(appHttpRouters.MiddleWareErrorHandler(read(appHttpRouters.GetInventoryTimeSeries))))
 
 	tsList := appRouter.Methods(http.MethodGet, http.MethodOptions).Subrouter()
-	tsList.HandleFunc(routeListInventory, h.withMetrics(appMiddleWare.MiddleWareErrorHandler(appHttpRouters.ListInventory)))	
+	tsList.HandleFunc(routeListInventory, h.withMetrics(appHttpRouters.MiddleWareErrorHandler(read(appHttpRouters.ListInventory))))	
+
+	quarantine := appRouter.Methods(http.MethodPut, http.MethodOptions).Subrouter()
+	quarantine.HandleFunc(routeInventory+"/{id}/quarantine", h.withMetrics(appHttpRouters.MiddleWareErrorHandler(write(appHttpRouters.QuarantineInventory))))
+
+	damaged := appRouter.Methods(http.MethodPut, http.MethodOptions).Subrouter()
+	damaged.HandleFunc(routeInventory+"/{id}/damaged", h.withMetrics(appHttpRouters.MiddleWareErrorHandler(write(appHttpRouters.DamageInventory))))
+
+	hold := appRouter.Methods(http.MethodPut, http.MethodOptions).Subrouter()
+	hold.HandleFunc(routeInventory+"/{id}/hold", h.withMetrics(appHttpRouters.MiddleWareErrorHandler(write(appHttpRouters.HoldInventory))))
+
+	release := appRouter.Methods(http.MethodPut, http.MethodOptions).Subrouter()
+	release.HandleFunc(routeInventory+"/{id}/release", h.withMetrics(appHttpRouters.MiddleWareErrorHandler(write(appHttpRouters.ReleaseInventory))))
+
+	count := appRouter.Methods(http.MethodPost, http.MethodOptions).Subrouter()
+	count.HandleFunc(routeCycleCount+"/{id}", h.withMetrics(appHttpRouters.MiddleWareErrorHandler(write(appHttpRouters.CycleCount))))
+
+	approve := appRouter.Methods(http.MethodPut, http.MethodOptions).Subrouter()
+	approve.HandleFunc(routeAdjustment+"/{id}/approve", h.withMetrics(appHttpRouters.MiddleWareErrorHandler(admin(appHttpRouters.ApproveAdjustment))))
+
+	reject := appRouter.Methods(http.MethodPut, http.MethodOptions).Subrouter()
+	reject.HandleFunc(routeAdjustment+"/{id}/reject", h.withMetrics(appHttpRouters.MiddleWareErrorHandler(admin(appHttpRouters.RejectAdjustment))))
+
+	listAdjustment := appRouter.Methods(http.MethodGet, http.MethodOptions).Subrouter()
+	listAdjustment.HandleFunc(routeAdjustment+"/product/{id}", h.withMetrics(appHttpRouters.MiddleWareErrorHandler(read(appHttpRouters.ListInventoryAdjustment))))
 	
+	addRma := appRouter.Methods(http.MethodPost, http.MethodOptions).Subrouter()
+	addRma.HandleFunc(routeRma, h.withMetrics(appHttpRouters.MiddleWareErrorHandler(write(appHttpRouters.CreateRma))))
+
+	getRma := appRouter.Methods(http.MethodGet, http.MethodOptions).Subrouter()
+	getRma.HandleFunc(routeRma+"/{id}", h.withMetrics(appHttpRouters.MiddleWareErrorHandler(read(appHttpRouters.GetRma))))
+
+	receiveRma := appRouter.Methods(http.MethodPut, http.MethodOptions).Subrouter()
+	receiveRma.HandleFunc(routeRma+"/{id}/receive", h.withMetrics(appHttpRouters.MiddleWareErrorHandler(write(appHttpRouters.ReceiveRma))))
+
+	inspectRma := appRouter.Methods(http.MethodPut, http.MethodOptions).Subrouter()
+	inspectRma.HandleFunc(routeRma+"/{id}/inspect", h.withMetrics(appHttpRouters.MiddleWareErrorHandler(write(appHttpRouters.InspectRma))))
+
+	restockRma := appRouter.Methods(http.MethodPut, http.MethodOptions).Subrouter()
+	restockRma.HandleFunc(routeRma+"/{id}/restock", h.withMetrics(appHttpRouters.MiddleWareErrorHandler(write(appHttpRouters.RestockRma))))
+
+	putChannel := appRouter.Methods(http.MethodPut, http.MethodOptions).Subrouter()
+	putChannel.HandleFunc(routeChannel+"/{id}", h.withMetrics(appHttpRouters.MiddleWareErrorHandler(admin(appHttpRouters.SetChannelAllocation))))
+
+	listChannel := appRouter.Methods(http.MethodGet, http.MethodOptions).Subrouter()
+	listChannel.HandleFunc(routeChannel+"/{id}", h.withMetrics(appHttpRouters.MiddleWareErrorHandler(read(appHttpRouters.ListChannelAllocation))))
+
+	deleteChannel := appRouter.Methods(http.MethodDelete, http.MethodOptions).Subrouter()
+	deleteChannel.HandleFunc(routeChannel+"/{id}/{channel}", h.withMetrics(appHttpRouters.MiddleWareErrorHandler(admin(appHttpRouters.DeleteChannelAllocation))))
+
+	graphql := appRouter.Methods(http.MethodPost, http.MethodOptions).Subrouter()
+	graphql.HandleFunc(routeGraphql, h.withMetrics(appHttpRouters.MiddleWareErrorHandler(read(appHttpRouters.Graphql))))
+
+	addWebhook := appRouter.Methods(http.MethodPost, http.MethodOptions).Subrouter()
+	addWebhook.HandleFunc(routeWebhook, h.withMetrics(appHttpRouters.MiddleWareErrorHandler(admin(appHttpRouters.AddWebhook))))
+
+	getWebhook := appRouter.Methods(http.MethodGet, http.MethodOptions).Subrouter()
+	getWebhook.HandleFunc(routeWebhook+"/{id}", h.withMetrics(appHttpRouters.MiddleWareErrorHandler(admin(appHttpRouters.GetWebhook))))
+
+	deleteWebhook := appRouter.Methods(http.MethodDelete, http.MethodOptions).Subrouter()
+	deleteWebhook.HandleFunc(routeWebhook+"/{id}", h.withMetrics(appHttpRouters.MiddleWareErrorHandler(admin(appHttpRouters.DeleteWebhook))))
+
+	listWebhookDelivery := appRouter.Methods(http.MethodGet, http.MethodOptions).Subrouter()
+	listWebhookDelivery.HandleFunc(routeWebhook+"/{id}/delivery", h.withMetrics(appHttpRouters.MiddleWareErrorHandler(admin(appHttpRouters.ListWebhookDelivery))))
+
+	retryWebhookDelivery := appRouter.Methods(http.MethodPut, http.MethodOptions).Subrouter()
+	retryWebhookDelivery.HandleFunc(routeWebhook+"/delivery/{id}/retry", h.withMetrics(appHttpRouters.MiddleWareErrorHandler(admin(appHttpRouters.RetryWebhookDelivery))))
+
+	issueApiKey := appRouter.Methods(http.MethodPost, http.MethodOptions).Subrouter()
+	issueApiKey.HandleFunc(routeApiKey, h.withMetrics(appHttpRouters.MiddleWareErrorHandler(admin(appHttpRouters.IssueApiKey))))
+
+	getApiKey := appRouter.Methods(http.MethodGet, http.MethodOptions).Subrouter()
+	getApiKey.HandleFunc(routeApiKey+"/{id}", h.withMetrics(appHttpRouters.MiddleWareErrorHandler(admin(appHttpRouters.GetApiKey))))
+
+	rotateApiKey := appRouter.Methods(http.MethodPut, http.MethodOptions).Subrouter()
+	rotateApiKey.HandleFunc(routeApiKey+"/{id}/rotate", h.withMetrics(appHttpRouters.MiddleWareErrorHandler(admin(appHttpRouters.RotateApiKey))))
+
+	revokeApiKey := appRouter.Methods(http.MethodDelete, http.MethodOptions).Subrouter()
+	revokeApiKey.HandleFunc(routeApiKey+"/{id}", h.withMetrics(appHttpRouters.MiddleWareErrorHandler(admin(appHttpRouters.RevokeApiKey))))
+
+	listAudit := appRouter.Methods(http.MethodGet, http.MethodOptions).Subrouter()
+	listAudit.HandleFunc(routeAudit, h.withMetrics(appHttpRouters.MiddleWareErrorHandler(admin(appHttpRouters.ListAuditRecord))))
+
+	verifyAudit := appRouter.Methods(http.MethodGet, http.MethodOptions).Subrouter()
+	verifyAudit.HandleFunc(routeAudit+"/verify", h.withMetrics(appHttpRouters.MiddleWareErrorHandler(admin(appHttpRouters.VerifyAudit))))
+
+	// long lived, without metrics of latency and span, the writer must support flush and hijack
+	inventoryStream := appRouter.Methods(http.MethodGet, http.MethodOptions).Subrouter()
+	inventoryStream.HandleFunc(routeInventoryStream, appHttpRouters.MiddleWareErrorHandler(read(appHttpRouters.InventoryStream)))
+
 	return appRouter
 }
 
+// Helper function to wrap handler with the authorization of a level, the principal and the tenant go in the request context.
+// The bucket of the ip and the load shedding come before the authentication, which reads the database for a api key,
+// so a flood of missing or invalid credentials is rejected without taking a connection. The bucket of the subject
+// of the credential comes after
+func (h *HttpAppServer) withScope(appHttpRouters app_http_routers.HttpRouters, level string) func(next func(http.ResponseWriter, *http.Request) error) func(http.ResponseWriter, *http.Request) error {
+	return func(next func(http.ResponseWriter, *http.Request) error) func(http.ResponseWriter, *http.Request) error {
+		return func(w http.ResponseWriter, r *http.Request) error {
+			route := r.URL.Path
+			if current := mux.CurrentRoute(r); current != nil {
+				if pathTemplate, err := current.GetPathTemplate(); err == nil {
+					route = pathTemplate
+				}
+			}
+
+			if err := h.rateLimiter.AllowRequestAddress(r, route); err != nil {
+				return appHttpRouters.ErrorHandler(go_core_midleware.GetRequestID(r.Context()), err)
+			}
+
+			if !ExcludedFromShedding[r.URL.Path] {
+				release, err := h.loadShedder.Acquire(r.Context(), route)
+				if err != nil {
+					return appHttpRouters.ErrorHandler(go_core_midleware.GetRequestID(r.Context()), err)
+				}
+				defer release()
+			}
+
+			authReq, err := h.authenticator.AuthorizeRequest(r, level)
+			if err != nil {
+				if erro.KindOf(err) == erro.KindUnauthorized {
+					w.Header().Set("WWW-Authenticate", `Bearer realm="` + h.appServer.Application.Name + `"`)
+				}
+				return appHttpRouters.ErrorHandler(go_core_midleware.GetRequestID(r.Context()), err)
+			}
+
+			// the tenant depends on the credential, so it is resolved after the authentication
+			authReq, err = h.tenantResolver.ResolveRequest(authReq)
+			if err != nil {
+				return appHttpRouters.ErrorHandler(go_core_midleware.GetRequestID(r.Context()), err)
+			}
+
+			// the route is recorded in the audit of the changes
+			authReq = authReq.WithContext(security.WithRoute(authReq.Context(), r.Method + " " + route))
+
+			if err := h.rateLimiter.AllowRequest(authReq, route); err != nil {
+				return appHttpRouters.ErrorHandler(go_core_midleware.GetRequestID(r.Context()), err)
+			}
+			return next(w, authReq)
+		}
+	}
+}
+
 // Response recorder to capture status code
 type statusRecorder struct {
     http.ResponseWriter
@@ -0,0 +1,187 @@
+package server
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"net/http"
+	"net/http/httptest"
+	"encoding/json"
+
+	"github.com/rs/zerolog"
+	"github.com/gorilla/mux"
+
+	"github.com/go-inventory/api"
+	"github.com/go-inventory/shared/erro"
+	"github.com/go-inventory/shared/security"
+	"github.com/go-inventory/internal/domain/model"
+	"github.com/go-inventory/internal/infrastructure/middleware"
+	app_http_routers "github.com/go-inventory/internal/infrastructure/adapter/http"
+)
+
+// registered routes, path template and methods
+func registeredRoutes(t *testing.T) map[string]map[string]bool {
+	logger := zerolog.Nop()
+	appServer := model.AppServer{	Application: &model.Application{Name: "go-inventory"},
+									Server: &model.Server{CtxTimeout: 1}}
+
+	httpAppServer := NewHttpAppServer(&appServer, nil, nil, nil, nil, &logger)
+	appRouter := httpAppServer.setupRoutes(app_http_routers.NewHttpRouters(&appServer, nil, &logger, nil))
+
+	routes := map[string]map[string]bool{}
+	err := appRouter.Walk(func(route *mux.Route, router *mux.Router, ancestors []*mux.Route) error {
+		path, err := route.GetPathTemplate()
+		if err != nil {
+			// subrouters only match the methods
+			return nil
+		}
+
+		methods, _ := route.GetMethods()
+		for _, ancestor := range ancestors {
+			ancestorMethods, err := ancestor.GetMethods()
+			if err == nil {
+				methods = append(methods, ancestorMethods...)
+			}
+		}
+		if len(methods) == 0 {
+			methods = []string{http.MethodGet}
+		}
+
+		if routes[path] == nil {
+			routes[path] = map[string]bool{}
+		}
+		for _, method := range methods {
+			if method != http.MethodOptions {
+				routes[path][strings.ToLower(method)] = true
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("FAILED to walk the routes: %v", err)
+	}
+
+	return routes
+}
+
+// documented routes, path and methods
+func documentedRoutes(t *testing.T) map[string]map[string]json.RawMessage {
+	spec := struct {
+		OpenAPI	string										`json:"openapi"`
+		Paths	map[string]map[string]json.RawMessage		`json:"paths"`
+	}{}
+
+	err := json.Unmarshal(api.OpenAPI, &spec)
+	if err != nil {
+		t.Fatalf("FAILED to unmarshal openapi.json: %v", err)
+	}
+	if !strings.HasPrefix(spec.OpenAPI, "3.") {
+		t.Fatalf("openapi version must be 3.x, got %q", spec.OpenAPI)
+	}
+
+	return spec.Paths
+}
+
+func TestOpenAPIDocumentsEveryRoute(t *testing.T) {
+	routes := registeredRoutes(t)
+	paths := documentedRoutes(t)
+
+	for path, methods := range routes {
+		for method := range methods {
+			if _, ok := paths[path][method]; !ok {
+				t.Errorf("route %s %s is registered but missing in api/openapi.json", strings.ToUpper(method), path)
+			}
+		}
+	}
+
+	for path, operations := range paths {
+		for method := range operations {
+			if method == "parameters" {
+				continue
+			}
+			if !routes[path][method] {
+				t.Errorf("route %s %s is documented in api/openapi.json but not registered", strings.ToUpper(method), path)
+			}
+		}
+	}
+}
+
+// api key verifier that rejects every key, counting the lookups
+type rejectingApiKeys struct {
+	lookups		int
+}
+
+func (r *rejectingApiKeys) AuthenticateApiKey(ctx context.Context, key string) (*security.Principal, error) {
+	r.lookups++
+	return nil, erro.ErrUnauthorized
+}
+
+// a flood of invalid api keys is limited by the bucket of the ip, before the authentication looks the keys up
+func TestRateLimitBeforeAuthentication(t *testing.T) {
+	logger := zerolog.Nop()
+	appServer := model.AppServer{	Application: &model.Application{Name: "go-inventory"},
+									Server: &model.Server{CtxTimeout: 1}}
+
+	apiKeys := &rejectingApiKeys{}
+	authenticator, err := middleware.NewAuthenticator(&model.AuthConfig{ApiKeyEnabled: true, ApiKeyHeader: "X-Api-Key"}, apiKeys, &logger)
+	if err != nil {
+		t.Fatalf("FAILED to create the authenticator: %v", err)
+	}
+	rateLimiter := middleware.NewRateLimiter(&model.RateLimitConfig{Rate: 0.001, Burst: 2, IpRate: 0.001, IpBurst: 2}, &logger)
+
+	httpAppServer := NewHttpAppServer(&appServer, authenticator, nil, rateLimiter, nil, &logger)
+	appRouter := httpAppServer.setupRoutes(app_http_routers.NewHttpRouters(&appServer, nil, &logger, nil))
+
+	for i, want := range []int{http.StatusUnauthorized, http.StatusUnauthorized, http.StatusTooManyRequests, http.StatusTooManyRequests} {
+		req := httptest.NewRequest(http.MethodGet, "/product/sku-1", nil)
+		req.Header.Set("X-Api-Key", "invalid")
+		rec := httptest.NewRecorder()
+		appRouter.ServeHTTP(rec, req)
+
+		if rec.Code != want {
+			t.Errorf("request %d: got status %d, want %d", i + 1, rec.Code, want)
+		}
+	}
+	if apiKeys.lookups != 2 {
+		t.Errorf("got %d api key lookups, want 2", apiKeys.lookups)
+	}
+}
+
+// behind a trusted proxy the bucket is the one of the address the proxy appended, a client changing the
+// addresses before it still shares its bucket
+func TestRateLimitSpoofedForwardedFor(t *testing.T) {
+	logger := zerolog.Nop()
+	appServer := model.AppServer{	Application: &model.Application{Name: "go-inventory"},
+									Server: &model.Server{CtxTimeout: 1}}
+
+	authenticator, err := middleware.NewAuthenticator(&model.AuthConfig{ApiKeyEnabled: true, ApiKeyHeader: "X-Api-Key"}, &rejectingApiKeys{}, &logger)
+	if err != nil {
+		t.Fatalf("FAILED to create the authenticator: %v", err)
+	}
+	rateLimiter := middleware.NewRateLimiter(&model.RateLimitConfig{	Rate: 0.001, Burst: 2, IpRate: 0.001, IpBurst: 2,
+																		TrustForwarded: true}, &logger)
+
+	httpAppServer := NewHttpAppServer(&appServer, authenticator, nil, rateLimiter, nil, &logger)
+	appRouter := httpAppServer.setupRoutes(app_http_routers.NewHttpRouters(&appServer, nil, &logger, nil))
+
+	for i, tt := range []struct {
+		forwarded	string
+		want		int
+	}{
+		{forwarded: "10.0.0.1, 203.0.113.7", want: http.StatusUnauthorized},
+		{forwarded: "10.0.0.2, 203.0.113.7", want: http.StatusUnauthorized},
+		{forwarded: "10.0.0.3, 203.0.113.7", want: http.StatusTooManyRequests},
+		{forwarded: "203.0.113.7", want: http.StatusTooManyRequests},
+		{forwarded: "10.0.0.4, 198.51.100.9", want: http.StatusUnauthorized},
+	} {
+		req := httptest.NewRequest(http.MethodGet, "/product/sku-1", nil)
+		req.Header.Set("X-Api-Key", "invalid")
+		req.Header.Set("X-Forwarded-For", tt.forwarded)
+		rec := httptest.NewRecorder()
+		appRouter.ServeHTTP(rec, req)
+
+		if rec.Code != tt.want {
+			t.Errorf("request %d (%s): got status %d, want %d", i + 1, tt.forwarded, rec.Code, tt.want)
+		}
+	}
+}
@@ -7,15 +7,153 @@ import (
 	"errors"
 )
 
+// Kind is the class of a error, each kind is mapped to a single http status
+type Kind string
+
+const (
+	KindInvalid			Kind = "INVALID"
+	KindNotFound		Kind = "NOT_FOUND"
+	KindConflict		Kind = "CONFLICT"
+	KindPrecondition	Kind = "PRECONDITION_FAILED"
+	KindUnauthorized	Kind = "UNAUTHORIZED"
+	KindForbidden		Kind = "FORBIDDEN"
+	KindRateLimited		Kind = "RATE_LIMITED"
+	KindTimeout			Kind = "TIMEOUT"
+	KindUnavailable		Kind = "UNAVAILABLE"
+	KindInternal		Kind = "INTERNAL"
+)
+
+// Violation is a invalid field of a request
+type Violation struct {
+	Field		string	`json:"field"`
+	Message		string	`json:"message"`
+}
+
+// Error is the typed error of the domain, Code is a stable machine readable identifier
+type Error struct {
+	Kind		Kind
+	Code		string
+	Message		string
+	Retryable	bool
+	Details		map[string]interface{}
+	Violations	[]Violation
+	Err			error
+}
+
+func (e *Error) Error() string {
+	if e.Err == nil {
+		return e.Message
+	}
+	if e.Message == "" {
+		return e.Err.Error()
+	}
+	return e.Message + ": " + e.Err.Error()
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// Is matches errors with the same code, so a error derived from a sentinel (WithDetails) still is the sentinel
+func (e *Error) Is(target error) bool {
+	t, ok := target.(*Error)
+	if !ok {
+		return false
+	}
+	return e.Code != "" && e.Code == t.Code
+}
+
+// About create a typed error
+func New(kind Kind, code string, message string) *Error {
+	return &Error{Kind: kind, Code: code, Message: message}
+}
+
+// About wrap a cause into a typed error
+func Wrap(err error, kind Kind, code string, message string) *Error {
+	return &Error{Kind: kind, Code: code, Message: message, Err: err}
+}
+
+// About mark a error as retryable, returning a copy
+func (e *Error) AsRetryable() *Error {
+	c := *e
+	c.Retryable = true
+	return &c
+}
+
+// About add details to a error, returning a copy so the sentinels are never changed
+func (e *Error) WithDetails(details map[string]interface{}) *Error {
+	c := *e
+	c.Details = make(map[string]interface{}, len(e.Details) + len(details))
+	for k, v := range e.Details {
+		c.Details[k] = v
+	}
+	for k, v := range details {
+		c.Details[k] = v
+	}
+	return &c
+}
+
+// About add field violations to a error, returning a copy
+func (e *Error) WithViolations(violations ...Violation) *Error {
+	c := *e
+	c.Violations = append(append([]Violation{}, e.Violations...), violations...)
+	return &c
+}
+
+// message answered for internal errors, their cause is only logged
+const internalMessage = "internal server error"
+
+// About get the message that can be sent to a client, without the wrapped cause
+func (e *Error) PublicMessage() string {
+	if e.Kind == KindInternal || e.Message == "" {
+		return internalMessage
+	}
+	return e.Message
+}
+
+// About find the typed error in a chain, untyped errors are internal
+func As(err error) *Error {
+	var e *Error
+	if errors.As(err, &e) {
+		return e
+	}
+	return &Error{Kind: KindInternal, Code: "INTERNAL_ERROR", Message: "internal error", Err: err}
+}
+
+// About get the kind of a error
+func KindOf(err error) Kind {
+	return As(err).Kind
+}
+
+// About know whether a operation could succeed if retried
+func IsRetryable(err error) bool {
+	return As(err).Retryable
+}
+
 var (
-	ErrNotFound 		= errors.New("item not found")
-	ErrBadRequest 		= errors.New("check parameters")
-	ErrUpdate			= errors.New("update unsuccessful")
-	ErrInsert 			= errors.New("insert data error")
-	ErrUnmarshal 		= errors.New("unmarshal json error")
-	ErrUnauthorized 	= errors.New("not authorized")
-	ErrServer		 	= errors.New("server identified error")
-	ErrHTTPForbiden		= errors.New("forbiden request")
-	ErrTimeout			= errors.New("timeout: context deadline exceeded")
-	ErrHealthCheck		= errors.New("health check services required FAILED")
+	ErrNotFound 		= New(KindNotFound, "NOT_FOUND", "item not found")
+	ErrBadRequest 		= New(KindInvalid, "BAD_REQUEST", "check parameters")
+	ErrValidation 		= New(KindInvalid, "VALIDATION_FAILED", "check parameters: validation failed")
+	ErrUpdate			= New(KindInternal, "UPDATE_FAILED", "update unsuccessful")
+	ErrInsert 			= New(KindInternal, "INSERT_FAILED", "insert data error")
+	ErrUnmarshal 		= New(KindInvalid, "UNMARSHAL_FAILED", "unmarshal json error")
+	ErrUnauthorized 	= New(KindUnauthorized, "UNAUTHORIZED", "not authorized")
+	ErrServer		 	= New(KindInternal, "SERVER_ERROR", "server identified error")
+	ErrHTTPForbiden		= New(KindForbidden, "FORBIDDEN", "forbiden request")
+	ErrTimeout			= New(KindTimeout, "TIMEOUT", "timeout: context deadline exceeded")
+	ErrHealthCheck		= New(KindUnavailable, "HEALTH_CHECK_FAILED", "health check services required FAILED")
+	ErrPreconditionFailed = New(KindPrecondition, "VERSION_MISMATCH", "precondition failed: version does not match")
+	ErrInvalidStatus	= New(KindConflict, "INVALID_STATUS", "invalid status for this operation")
+	ErrInsufficientStock = New(KindConflict, "INSUFFICIENT_STOCK", "insufficient stock for this operation")
+	ErrDuplicateKey		= New(KindConflict, "DUPLICATE_KEY", "duplicate key")
+	ErrForeignKey		= New(KindInvalid, "FOREIGN_KEY_VIOLATION", "referenced item does not exist")
+	ErrConstraint		= New(KindInvalid, "CONSTRAINT_VIOLATION", "data violates a constraint")
+	ErrConcurrency		= New(KindConflict, "CONCURRENT_UPDATE", "concurrent update, try again")
+	ErrUnavailable		= New(KindUnavailable, "DATABASE_UNAVAILABLE", "database unavailable")
+	ErrQuotaExceeded	= New(KindRateLimited, "QUOTA_EXCEEDED", "quota of requests exceeded").AsRetryable()
+	ErrSkuForbidden		= New(KindForbidden, "SKU_FORBIDDEN", "sku not allowed for this caller")
+	ErrTenantRequired	= New(KindInvalid, "TENANT_REQUIRED", "tenant is required")
+	ErrTenantForbidden	= New(KindForbidden, "TENANT_FORBIDDEN", "tenant not allowed for this caller")
+	ErrRateLimited		= New(KindRateLimited, "RATE_LIMITED", "too many requests").AsRetryable()
+	ErrOverloaded		= New(KindUnavailable, "OVERLOADED", "server overloaded, try again").AsRetryable()
 )
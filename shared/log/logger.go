@@ -6,6 +6,7 @@ package log
 import(
 	"github.com/rs/zerolog"
 	"go.opentelemetry.io/otel/trace"
+	"github.com/go-inventory/shared/security"
 	go_core_midleware "github.com/eliezerraj/go-core/v2/middleware"
 )
 
@@ -19,6 +20,18 @@ func (h TraceHook) Run(e *zerolog.Event, level zerolog.Level, msg string) {
         e = e.Str(string(go_core_midleware.RequestIDKey), requestID)
     } 
 
+	// the authenticated caller
+	subject := security.SubjectFrom(ctx)
+	if subject != "" {
+		e.Str("subject", subject)
+	}
+
+	// the tenant of the request
+	tenant := security.TenantFrom(ctx)
+	if tenant != "" {
+		e.Str("tenant", tenant)
+	}
+
 	spanCtx := trace.SpanContextFromContext(ctx)
 	if spanCtx.IsValid() {
 		// Add the OTel Trace ID and Span ID fields to the log event
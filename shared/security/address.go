@@ -0,0 +1,45 @@
+//---------------------------------------
+// Component is charge of tell the addresses the service may call on behalf of a tenant
+//---------------------------------------
+package security
+
+import (
+	"net/netip"
+)
+
+// special purpose ranges not covered by the netip helpers, they reach the deployment or
+// embed a ipv4 address that could be internal
+var deniedPrefixes = []netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/8"),			// this network
+	netip.MustParsePrefix("100.64.0.0/10"),		// carrier-grade nat, shared address space
+	netip.MustParsePrefix("192.0.0.0/24"),		// ietf protocol assignments
+	netip.MustParsePrefix("198.18.0.0/15"),		// benchmarking
+	netip.MustParsePrefix("240.0.0.0/4"),		// reserved and limited broadcast
+	netip.MustParsePrefix("64:ff9b::/96"),		// nat64 well-known prefix
+	netip.MustParsePrefix("64:ff9b:1::/48"),	// nat64 local-use prefix
+	netip.MustParsePrefix("2002::/16"),			// 6to4
+}
+
+// About tell whether a address is public, the loopback, private, link-local (cloud metadata),
+// multicast, unspecified and the denied special purpose addresses are internal to the deployment
+// and never called for a tenant
+func PublicAddress(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	if !addr.IsValid() ||
+		addr.IsLoopback() ||
+		addr.IsPrivate() ||
+		addr.IsLinkLocalUnicast() ||
+		addr.IsLinkLocalMulticast() ||
+		addr.IsInterfaceLocalMulticast() ||
+		addr.IsMulticast() ||
+		addr.IsUnspecified() {
+		return false
+	}
+
+	for _, prefix := range deniedPrefixes {
+		if prefix.Contains(addr) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,51 @@
+package security
+
+import (
+	"net/netip"
+	"testing"
+)
+
+func TestPublicAddress(t *testing.T) {
+	tests := []struct {
+		name	string
+		addr	string
+		want	bool
+	}{
+		{name: "public ipv4", addr: "93.184.216.34", want: true},
+		{name: "public ipv6", addr: "2606:2800:220:1:248:1893:25c8:1946", want: true},
+		{name: "public ipv4 mapped", addr: "::ffff:93.184.216.34", want: true},
+		{name: "loopback", addr: "127.0.0.1", want: false},
+		{name: "loopback ipv6", addr: "::1", want: false},
+		{name: "private", addr: "10.1.2.3", want: false},
+		{name: "private ipv4 mapped", addr: "::ffff:192.168.0.1", want: false},
+		{name: "unique local ipv6", addr: "fd00::1", want: false},
+		{name: "cloud metadata", addr: "169.254.169.254", want: false},
+		{name: "link local ipv6", addr: "fe80::1", want: false},
+		{name: "multicast", addr: "224.0.0.1", want: false},
+		{name: "unspecified", addr: "0.0.0.0", want: false},
+		{name: "this network", addr: "0.1.2.3", want: false},
+		{name: "carrier-grade nat", addr: "100.64.0.1", want: false},
+		{name: "carrier-grade nat upper bound", addr: "100.127.255.254", want: false},
+		{name: "above carrier-grade nat", addr: "100.128.0.1", want: true},
+		{name: "ietf protocol assignments", addr: "192.0.0.8", want: false},
+		{name: "benchmarking", addr: "198.18.0.1", want: false},
+		{name: "benchmarking upper bound", addr: "198.19.255.254", want: false},
+		{name: "reserved", addr: "240.0.0.1", want: false},
+		{name: "limited broadcast", addr: "255.255.255.255", want: false},
+		{name: "nat64", addr: "64:ff9b::a00:1", want: false},
+		{name: "nat64 local use", addr: "64:ff9b:1::a00:1", want: false},
+		{name: "6to4", addr: "2002:a00:1::1", want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := PublicAddress(netip.MustParseAddr(tt.addr)); got != tt.want {
+				t.Errorf("PublicAddress(%s) = %v, want %v", tt.addr, got, tt.want)
+			}
+		})
+	}
+
+	if PublicAddress(netip.Addr{}) {
+		t.Errorf("PublicAddress of a invalid address = true, want false")
+	}
+}
@@ -0,0 +1,95 @@
+//---------------------------------------
+// Component is charge of carry the caller authenticated along the context
+//---------------------------------------
+package security
+
+import (
+	"slices"
+	"strings"
+	"context"
+)
+
+// Access levels of the routes, each level includes the ones below it
+const (
+	ScopeRead	= "read"
+	ScopeWrite	= "write"
+	ScopeAdmin	= "admin"
+)
+
+// rank of each level
+var levelRank = map[string]int{
+	ScopeRead:	1,
+	ScopeWrite:	2,
+	ScopeAdmin:	3,
+}
+
+type contextKey string
+const principalKey contextKey = "principal"
+
+// Principal is the caller of a request, as asserted by its token
+type Principal struct {
+	Subject		string		`json:"subject"`
+	Issuer		string		`json:"issuer,omitempty"`
+	TokenID		string		`json:"token_id,omitempty"`
+	Scopes		[]string	`json:"scopes,omitempty"`
+	Level		string		`json:"level,omitempty"`
+	SkuPrefixes	[]string	`json:"sku_prefixes,omitempty"`
+	Tenant		string		`json:"tenant,omitempty"`
+}
+
+// About know whether the principal has a scope
+func (p *Principal) HasScope(scope string) bool {
+	return slices.Contains(p.Scopes, scope)
+}
+
+// About know whether the level of the principal includes the level required
+func (p *Principal) Allows(level string) bool {
+	required, ok := levelRank[level]
+	return ok && levelRank[p.Level] >= required
+}
+
+// About know whether the principal can access a sku, any sku when it has no prefixes
+func (p *Principal) AllowsSku(sku string) bool {
+	if len(p.SkuPrefixes) == 0 {
+		return true
+	}
+	for _, prefix := range p.SkuPrefixes {
+		if strings.HasPrefix(sku, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// About get the highest level of a list, empty when none is a level
+func HighestLevel(levels []string) string {
+	highest := ""
+	for _, level := range levels {
+		if levelRank[level] > levelRank[highest] {
+			highest = level
+		}
+	}
+	return highest
+}
+
+// About put the principal in the context
+func WithPrincipal(ctx context.Context, principal *Principal) context.Context {
+	return context.WithValue(ctx, principalKey, principal)
+}
+
+// About get the principal of the context, nil when the request was not authenticated
+func PrincipalFrom(ctx context.Context) *Principal {
+	if ctx == nil {
+		return nil
+	}
+	principal, _ := ctx.Value(principalKey).(*Principal)
+	return principal
+}
+
+// About get the subject of the context, empty when the request was not authenticated
+func SubjectFrom(ctx context.Context) string {
+	if principal := PrincipalFrom(ctx); principal != nil {
+		return principal.Subject
+	}
+	return ""
+}
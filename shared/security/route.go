@@ -0,0 +1,24 @@
+//---------------------------------------
+// Component is charge of carry the route of the request along the context
+//---------------------------------------
+package security
+
+import (
+	"context"
+)
+
+const routeKey contextKey = "route"
+
+// About put the route of the request in the context (method and path template in http, full method in grpc)
+func WithRoute(ctx context.Context, route string) context.Context {
+	return context.WithValue(ctx, routeKey, route)
+}
+
+// About get the route of the context, empty for the background workers
+func RouteFrom(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+	route, _ := ctx.Value(routeKey).(string)
+	return route
+}
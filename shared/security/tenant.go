@@ -0,0 +1,28 @@
+//---------------------------------------
+// Component is charge of carry the tenant of the request along the context
+//---------------------------------------
+package security
+
+import (
+	"context"
+)
+
+// SystemTenant is the tenant of the background workers (outbox relay, webhook dispatcher, api key lookup),
+// it sees the rows of every tenant. It never matches the pattern of a tenant, so no caller can claim it
+const SystemTenant = "*"
+
+const tenantKey contextKey = "tenant"
+
+// About put the tenant in the context
+func WithTenant(ctx context.Context, tenant string) context.Context {
+	return context.WithValue(ctx, tenantKey, tenant)
+}
+
+// About get the tenant of the context, empty when it was not resolved (the database then returns no row)
+func TenantFrom(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+	tenant, _ := ctx.Value(tenantKey).(string)
+	return tenant
+}
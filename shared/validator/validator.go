@@ -0,0 +1,87 @@
+//---------------------------------------
+// Component is charge of validate the request payloads
+//---------------------------------------
+package validator
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/go-inventory/shared/erro"
+)
+
+// Sku allows letters, digits, dot, underscore and hyphen, starting with a letter or digit
+var SkuPattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]*$`)
+
+// Tenant allows lowercase letters, digits, underscore and hyphen, starting with a letter or digit
+var TenantPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]*$`)
+
+// Validator collects all the violations of a payload so they are returned at once
+type Validator struct {
+	violations []erro.Violation
+}
+
+// Rule checks a payload and records its violations
+type Rule func(v *Validator)
+
+// About validate a payload with its rules
+func Validate(rules ...Rule) error {
+	v := &Validator{}
+	for _, rule := range rules {
+		rule(v)
+	}
+	return v.Err()
+}
+
+// About record a violation whenever the condition is false
+func (v *Validator) Check(ok bool, field string, message string) *Validator {
+	if !ok {
+		v.violations = append(v.violations, erro.Violation{Field: field, Message: message})
+	}
+	return v
+}
+
+// About require a non blank value
+func (v *Validator) Required(field string, value string) *Validator {
+	return v.Check(strings.TrimSpace(value) != "", field, "is required")
+}
+
+// About limit the length of a value (in characters, as VARCHAR)
+func (v *Validator) MaxLength(field string, value string, max int) *Validator {
+	return v.Check(utf8.RuneCountInString(value) <= max, field, fmt.Sprintf("must have at most %d characters", max))
+}
+
+// About check a value matches a pattern, a empty value is not checked
+func (v *Validator) Pattern(field string, value string, pattern *regexp.Regexp) *Validator {
+	return v.Check(value == "" || pattern.MatchString(value), field, fmt.Sprintf("must match %s", pattern.String()))
+}
+
+// About check a value is one of the values allowed, a empty value is not checked
+func (v *Validator) OneOf(field string, value string, values ...string) *Validator {
+	for _, allowed := range values {
+		if value == allowed {
+			return v
+		}
+	}
+	return v.Check(value == "", field, fmt.Sprintf("must be one of %s", strings.Join(values, ", ")))
+}
+
+// About check a value is not lower than the min
+func (v *Validator) Min(field string, value int, min int) *Validator {
+	return v.Check(value >= min, field, fmt.Sprintf("must be greater than or equal to %d", min))
+}
+
+// About check a value is between min and max
+func (v *Validator) Range(field string, value int, min int, max int) *Validator {
+	return v.Check(value >= min && value <= max, field, fmt.Sprintf("must be between %d and %d", min, max))
+}
+
+// About get the violations as a typed error, nil when the payload is valid
+func (v *Validator) Err() error {
+	if len(v.violations) == 0 {
+		return nil
+	}
+	return erro.ErrValidation.WithViolations(v.violations...)
+}